@@ -0,0 +1,222 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/appsign"
+	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Маршруты входа (/auth/...) вызываются приложениями и проверяются так же, как gRPC:
+// подпись v2 (см. lib/appsign) и ограничение частоты на приложение и адрес клиента.
+// Подпись передается в заголовках X-App-Id, X-Signature-Timestamp (RFC3339),
+// X-Signature-Nonce и X-Signature. Вместо имени gRPC метода подписываются
+// HTTP метод и путь ("POST /auth/mfa/verify"), вместо protobuf — тело запроса;
+// заголовки appsign.SignedHeaders (DPoP, X-Scope, X-Remember-Me, ...) подписываются так же, как в gRPC.
+// В режиме local подпись не проверяется.
+const (
+	appIDHeader        = "X-App-Id"
+	timestampHeader    = "X-Signature-Timestamp"
+	nonceHeader        = "X-Signature-Nonce"
+	signatureHeader    = "X-Signature"
+	appKey             = "app_id"
+	maxSignedBodyBytes = 1 << 20
+)
+
+var (
+	errInvalidSignature = errors.New("invalid request signature")
+	errReplayedNonce    = errors.New("request nonce has already been used")
+	errNonceStore       = errors.New("failed to save nonce")
+	errAppMismatch      = errors.New("app_id does not match the signing app")
+)
+
+// appStore источник приложений и их секретов
+type appStore interface {
+	GetCachedApp(ctx context.Context, appID uint32) (models.App, error)
+}
+
+// nonceStore хранилище одноразовых nonce подписи
+type nonceStore interface {
+	SaveNonce(ctx context.Context, scope, nonce string, ttl time.Duration) (bool, error)
+}
+
+// rateLimiter хранилище token bucket
+type rateLimiter interface {
+	AllowRequest(ctx context.Context, key string, limit models.RateLimit) (models.RateLimitResult, error)
+}
+
+// rateLimitRule bucket, из которого запрос должен взять токен
+type rateLimitRule struct {
+	key   string
+	limit models.RateLimit
+}
+
+// AppAuth настройки проверки приложений на маршрутах входа
+type AppAuth struct {
+	Mode      string
+	HMAC      config.HMACConfig
+	RateLimit config.RateLimitConfig
+	Apps      appStore
+	Nonces    nonceStore
+	Limiter   rateLimiter
+}
+
+// appAuth проверяет подпись приложения и сохраняет его id в контексте запроса
+func (r *Routes) appAuth(c *gin.Context) {
+	if r.apps.Mode == config.LocalMode {
+		c.Next()
+		return
+	}
+
+	appID, err := r.apps.verify(c)
+	if err != nil {
+		switch {
+		case errors.Is(err, errNonceStore):
+			r.log.Error("failed to save nonce", logger.Error(err))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "failed to verify request"})
+		case errors.Is(err, errReplayedNonce):
+			r.log.Warn("replayed request rejected", slog.String("path", c.Request.URL.Path))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": errReplayedNonce.Error()})
+		default:
+			r.log.Warn("invalid app signature", slog.String("path", c.Request.URL.Path), logger.Error(err))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": errInvalidSignature.Error()})
+		}
+		return
+	}
+
+	c.Set(appKey, appID)
+	c.Next()
+}
+
+// verify проверяет подпись запроса и запоминает nonce.
+// Nonce сохраняется только для запросов с верной подписью.
+func (a *AppAuth) verify(c *gin.Context) (uint32, error) {
+	const op = opAdmin + "AppAuth.verify"
+
+	appID, err := strconv.ParseUint(c.GetHeader(appIDHeader), 10, 32)
+	if err != nil || appID == 0 {
+		return 0, errInvalidSignature
+	}
+	ts, err := appsign.CheckTimestamp(c.GetHeader(timestampHeader), a.HMAC)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	nonce := c.GetHeader(nonceHeader)
+	if err := appsign.ValidateNonce(nonce); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSignedBodyBytes))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	app, err := a.Apps.GetCachedApp(c.Request.Context(), uint32(appID))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	method := c.Request.Method + " " + c.Request.URL.Path
+	headers := appsign.CanonicalHeaders(c.Request.Header.Values)
+	data := appsign.Payload(ts, appID, method, nonce, headers, appsign.BodyHash(body))
+	if !appsign.Match(app, data, c.GetHeader(signatureHeader)) {
+		return 0, errInvalidSignature
+	}
+
+	scope := "hmac:" + strconv.FormatUint(appID, 10)
+	fresh, err := a.Nonces.SaveNonce(c.Request.Context(), scope, nonce, appsign.NonceTTL(a.HMAC))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w: %w", op, errNonceStore, err)
+	}
+	if !fresh {
+		return 0, errReplayedNonce
+	}
+	return uint32(appID), nil
+}
+
+// rateLimitIP ограничивает частоту запросов к маршруту входа для адреса клиента.
+// Проверяется до подписи, чтобы перебор подписей тоже ограничивался.
+func (r *Routes) rateLimitIP(c *gin.Context) {
+	method := c.Request.Method + " " + c.FullPath()
+	_, perIP := r.apps.RateLimit.MethodLimits(method)
+	r.limit(c, method, rateLimitRule{fmt.Sprintf("ip:%s:%s", c.ClientIP(), method), models.RateLimit(perIP)})
+}
+
+// rateLimitApp ограничивает частоту запросов к маршруту входа для приложения.
+// Проверяется после подписи, чтобы чужой app_id не расходовал токены приложения.
+func (r *Routes) rateLimitApp(c *gin.Context) {
+	appID, ok := appFromContext(c)
+	if !ok {
+		c.Next()
+		return
+	}
+	method := c.Request.Method + " " + c.FullPath()
+	perApp, _ := r.apps.RateLimit.MethodLimits(method)
+	r.limit(c, method, rateLimitRule{fmt.Sprintf("app:%d:%s", appID, method), models.RateLimit(perApp)})
+}
+
+// limit берет токен из bucket rule. При ошибке хранилища запрос пропускается.
+func (r *Routes) limit(c *gin.Context, method string, rule rateLimitRule) {
+	if !r.apps.RateLimit.IsEnabled() {
+		c.Next()
+		return
+	}
+
+	result, err := r.apps.Limiter.AllowRequest(c.Request.Context(), rule.key, rule.limit)
+	if err != nil {
+		r.log.Warn("rate limiter unavailable", slog.String("key", rule.key), logger.Error(err))
+		c.Next()
+		return
+	}
+
+	if !result.Allowed {
+		r.log.Warn("rate limit exceeded",
+			slog.String("method", method),
+			slog.String("key", rule.key),
+			slog.Duration("retry_after", result.RetryAfter),
+		)
+		seconds := int64(math.Ceil(result.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+		return
+	}
+
+	c.Next()
+}
+
+// appFromContext возвращает id приложения, подписавшего запрос
+func appFromContext(c *gin.Context) (uint32, bool) {
+	value, ok := c.Get(appKey)
+	if !ok {
+		return 0, false
+	}
+	appID, ok := value.(uint32)
+	return appID, ok
+}
+
+// requestAppID возвращает приложение, подписавшее запрос. app_id из тела запроса
+// допускается, только если совпадает с ним; без подписи (режим local) берется app_id из тела.
+func requestAppID(c *gin.Context, bodyAppID uint32) (uint32, bool) {
+	appID, ok := appFromContext(c)
+	if !ok {
+		return bodyAppID, true
+	}
+	if bodyAppID != 0 && bodyAppID != appID {
+		c.JSON(http.StatusForbidden, gin.H{"error": errAppMismatch.Error()})
+		return 0, false
+	}
+	return appID, true
+}
@@ -0,0 +1,284 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/appsign"
+	"github.com/Grino777/sso/internal/lib/ratelimit"
+	"github.com/Grino777/sso/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	testAppID     = 3
+	testAppSecret = "app-secret"
+	testLoginPath = "/auth/mfa/verify"
+)
+
+type testApps struct{}
+
+func (testApps) GetCachedApp(_ context.Context, appID uint32) (models.App, error) {
+	if appID != testAppID {
+		return models.App{}, storage.ErrAppNotFound
+	}
+	return models.App{ID: appID, Secret: testAppSecret}, nil
+}
+
+type testNonces struct {
+	seen map[string]bool
+	err  error
+}
+
+func (n *testNonces) SaveNonce(_ context.Context, scope, nonce string, _ time.Duration) (bool, error) {
+	if n.err != nil {
+		return false, n.err
+	}
+	if n.seen[scope+nonce] {
+		return false, nil
+	}
+	n.seen[scope+nonce] = true
+	return true, nil
+}
+
+// newTestAppRoutes регистрирует маршрут входа, который возвращает id приложения и тело запроса
+func newTestAppRoutes(t *testing.T, apps AppAuth) *gin.Engine {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	if err := engine.SetTrustedProxies(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if apps.Apps == nil {
+		apps.Apps = testApps{}
+	}
+	if apps.Nonces == nil {
+		apps.Nonces = &testNonces{seen: map[string]bool{}}
+	}
+	if apps.Limiter == nil {
+		apps.Limiter = ratelimit.NewMemoryLimiter()
+	}
+	if apps.HMAC.MaxAge == 0 {
+		apps.HMAC = config.HMACConfig{MaxAge: 2 * time.Minute, ClockSkew: 5 * time.Second}
+	}
+
+	r := &Routes{log: slog.New(slog.NewTextHandler(io.Discard, nil)), apps: &apps}
+	engine.POST(testLoginPath, r.rateLimitIP, r.appAuth, r.rateLimitApp, func(c *gin.Context) {
+		appID, _ := appFromContext(c)
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, "%d:%s", appID, body)
+	})
+	return engine
+}
+
+// signedRequest возвращает запрос к маршруту входа, подписанный по схеме v2
+// без подписываемых заголовков
+func signedRequest(secret, nonce, body string) *http.Request {
+	return signedRequestWith(secret, nonce, body, "dpop:\nx-login-method:\nx-permission:\nx-remember-me:\nx-scope:\nx-webauthn-assertion:")
+}
+
+// signedRequestWith возвращает запрос, подписанный с заголовками headers в каноническом виде
+func signedRequestWith(secret, nonce, body, headers string) *http.Request {
+	ts := time.Now().UTC()
+	payload := strings.Join([]string{
+		"v2",
+		ts.Format(time.RFC3339),
+		strconv.Itoa(testAppID),
+		"POST " + testLoginPath,
+		nonce,
+		headers,
+		appsign.BodyHash([]byte(body)),
+	}, "\n")
+
+	req := httptest.NewRequest(http.MethodPost, testLoginPath, strings.NewReader(body))
+	req.RemoteAddr = "198.51.100.10:40000"
+	req.Header.Set(appIDHeader, strconv.Itoa(testAppID))
+	req.Header.Set(timestampHeader, ts.Format(time.RFC3339))
+	req.Header.Set(nonceHeader, nonce)
+	req.Header.Set(signatureHeader, appsign.Compute(payload, secret))
+	return req
+}
+
+func serve(engine *gin.Engine, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestAppAuth__Signature(t *testing.T) {
+	engine := newTestAppRoutes(t, AppAuth{})
+
+	tampered := signedRequest(testAppSecret, "tampered-0123456789", `{"code":"123456"}`)
+	tampered.Body = io.NopCloser(strings.NewReader(`{"code":"654321"}`))
+
+	unknownApp := signedRequest(testAppSecret, "unknown-app-0123456789", `{}`)
+	unknownApp.Header.Set(appIDHeader, "4")
+
+	offlineHeaders := "dpop:\nx-login-method:\nx-permission:\nx-remember-me:true\nx-scope:openid offline_access\nx-webauthn-assertion:"
+	offline := signedRequestWith(testAppSecret, "offline-0123456789", `{"code":"123456"}`, offlineHeaders)
+	offline.Header.Set("X-Scope", " openid offline_access ")
+	offline.Header.Set("X-Remember-Me", "true")
+
+	unsignedScope := signedRequest(testAppSecret, "unsigned-scope-0123456789", `{}`)
+	unsignedScope.Header.Set("X-Scope", "offline_access")
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"signed request", signedRequest(testAppSecret, "signed-0123456789", `{"code":"123456"}`), http.StatusOK},
+		{"replayed nonce", signedRequest(testAppSecret, "signed-0123456789", `{"code":"123456"}`), http.StatusUnauthorized},
+		{"another secret", signedRequest("other-secret", "other-0123456789", `{}`), http.StatusUnauthorized},
+		{"tampered body", tampered, http.StatusUnauthorized},
+		{"signed headers", offline, http.StatusOK},
+		{"unsigned scope header", unsignedScope, http.StatusUnauthorized},
+		{"unknown app", unknownApp, http.StatusUnauthorized},
+		{"short nonce", signedRequest(testAppSecret, "short", `{}`), http.StatusUnauthorized},
+		{"unsigned request", httptest.NewRequest(http.MethodPost, testLoginPath, strings.NewReader(`{}`)), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(engine, tt.req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.want, w.Body)
+			}
+			if tt.want == http.StatusOK && w.Body.String() != `3:{"code":"123456"}` {
+				t.Fatalf("handler got %q", w.Body)
+			}
+		})
+	}
+}
+
+func TestAppAuth__NonceStoreUnavailable(t *testing.T) {
+	engine := newTestAppRoutes(t, AppAuth{Nonces: &testNonces{err: errors.New("connection refused")}})
+
+	w := serve(engine, signedRequest(testAppSecret, "unavailable-0123456789", `{}`))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestAppAuth__LocalMode(t *testing.T) {
+	engine := newTestAppRoutes(t, AppAuth{Mode: config.LocalMode})
+
+	w := serve(engine, httptest.NewRequest(http.MethodPost, testLoginPath, strings.NewReader(`{}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestAppAuth__RateLimit(t *testing.T) {
+	disabled := false
+	limits := config.RateLimitConfig{
+		PerApp: config.RateLimit{Rate: 100, Burst: 100},
+		PerIP:  config.RateLimit{Rate: 0.01, Burst: 1},
+	}
+
+	t.Run("forged forwarded for", func(t *testing.T) {
+		engine := newTestAppRoutes(t, AppAuth{RateLimit: limits})
+
+		if w := serve(engine, signedRequest(testAppSecret, "limit-0123456789-1", `{}`)); w.Code != http.StatusOK {
+			t.Fatalf("first request: status = %d", w.Code)
+		}
+
+		// Адрес в X-Forwarded-For от недоверенного клиента не создает новый bucket
+		req := signedRequest(testAppSecret, "limit-0123456789-2", `{}`)
+		req.Header.Set("X-Forwarded-For", "192.0.2.99")
+		w := serve(engine, req)
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("forged request: status = %d, want %d", w.Code, http.StatusTooManyRequests)
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Fatal("Retry-After header is not set")
+		}
+	})
+
+	t.Run("invalid signatures", func(t *testing.T) {
+		engine := newTestAppRoutes(t, AppAuth{RateLimit: limits})
+
+		// Запросы с неверной подписью расходуют лимит адреса клиента
+		if w := serve(engine, signedRequest("wrong-secret", "brute-0123456789-1", `{}`)); w.Code != http.StatusUnauthorized {
+			t.Fatalf("first request: status = %d, want %d", w.Code, http.StatusUnauthorized)
+		}
+		if w := serve(engine, signedRequest("wrong-secret", "brute-0123456789-2", `{}`)); w.Code != http.StatusTooManyRequests {
+			t.Fatalf("second request: status = %d, want %d", w.Code, http.StatusTooManyRequests)
+		}
+	})
+
+	t.Run("foreign app id", func(t *testing.T) {
+		limits := config.RateLimitConfig{
+			PerApp: config.RateLimit{Rate: 0.01, Burst: 1},
+			PerIP:  config.RateLimit{Rate: 100, Burst: 100},
+		}
+		engine := newTestAppRoutes(t, AppAuth{RateLimit: limits})
+
+		// Неподписанные запросы от имени приложения не расходуют его лимит
+		for i := range 3 {
+			nonce := "foreign-0123456789-" + strconv.Itoa(i)
+			if w := serve(engine, signedRequest("wrong-secret", nonce, `{}`)); w.Code != http.StatusUnauthorized {
+				t.Fatalf("forged request %d: status = %d, want %d", i+1, w.Code, http.StatusUnauthorized)
+			}
+		}
+		if w := serve(engine, signedRequest(testAppSecret, "foreign-0123456789-signed", `{}`)); w.Code != http.StatusOK {
+			t.Fatalf("signed request: status = %d", w.Code)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		limits := limits
+		limits.Enabled = &disabled
+		engine := newTestAppRoutes(t, AppAuth{RateLimit: limits})
+
+		for i := range 3 {
+			nonce := "disabled-0123456789-" + strconv.Itoa(i)
+			if w := serve(engine, signedRequest(testAppSecret, nonce, `{}`)); w.Code != http.StatusOK {
+				t.Fatalf("request %d: status = %d", i+1, w.Code)
+			}
+		}
+	})
+}
+
+func TestRequestAppID(t *testing.T) {
+	tests := []struct {
+		name   string
+		signed uint32 // 0 — запрос без подписи
+		body   uint32
+		want   uint32
+		wantOK bool
+	}{
+		{"signed app", 3, 0, 3, true},
+		{"same app in body", 3, 3, 3, true},
+		{"another app in body", 3, 4, 0, false},
+		{"local mode", 0, 4, 4, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			if tt.signed != 0 {
+				c.Set(appKey, tt.signed)
+			}
+
+			got, ok := requestAppID(c, tt.body)
+			if ok != tt.wantOK || got != tt.want {
+				t.Fatalf("requestAppID = %d, %v; want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+			if !ok && w.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
@@ -0,0 +1,413 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/jwt"
+	adminS "github.com/Grino777/sso/internal/services/admin"
+	"github.com/Grino777/sso/internal/services/auth"
+	"github.com/Grino777/sso/internal/services/mfa"
+	"github.com/Grino777/sso/internal/services/passkeys"
+	"github.com/Grino777/sso/internal/services/profile"
+	"github.com/Grino777/sso/internal/storage"
+	"github.com/Grino777/sso/internal/utils/certs"
+	"github.com/gin-gonic/gin"
+)
+
+// appService бизнес-логика управления приложениями и шифрованием их токенов
+type appService interface {
+	ListApps(ctx context.Context) ([]models.App, error)
+	GetApp(ctx context.Context, appID uint32) (models.App, error)
+	CreateApp(ctx context.Context, name, policy string) (models.App, error)
+	UpdateApp(ctx context.Context, appID uint32, update adminS.AppUpdate) (models.App, error)
+	RotateAppSecret(ctx context.Context, appID uint32, overlap time.Duration) (models.App, error)
+	DeleteApp(ctx context.Context, appID uint32) error
+
+	GetAppEncryptionKey(ctx context.Context, appID uint32) (models.EncryptionKey, error)
+	SetAppEncryptionKey(ctx context.Context, appID uint32, algorithm, publicKey string) (models.EncryptionKey, error)
+	DisableAppEncryption(ctx context.Context, appID uint32) error
+}
+
+type createAppRequest struct {
+	Name             string `json:"name"`
+	MembershipPolicy string `json:"membership_policy"`
+}
+
+type updateAppRequest struct {
+	Name             *string `json:"name"`
+	MembershipPolicy *string `json:"membership_policy"`
+	RequireMFA       *bool   `json:"require_mfa"`
+	// Сроки в формате time.Duration, например "5m"; "0s" возвращает глобальную настройку
+	AccessTokenTTL  *string `json:"access_token_ttl"`
+	RefreshTokenTTL *string `json:"refresh_token_ttl"`
+	IdleTimeout     *string `json:"idle_timeout"`
+	MaxSessionAge   *string `json:"max_session_age"`
+	OfflineAccess   *bool   `json:"offline_access"`
+	OfflineTokenTTL *string `json:"offline_token_ttl"`
+}
+
+type rotateSecretRequest struct {
+	// Период, в течение которого принимается старый секрет, например "24h"
+	Overlap *string `json:"overlap"`
+}
+
+type appResponse struct {
+	ID               uint32 `json:"id"`
+	Name             string `json:"name"`
+	MembershipPolicy string `json:"membership_policy"`
+	RequireMFA       bool   `json:"require_mfa"`
+	CreatedAt        string `json:"created_at,omitempty"`
+	// Переопределенные для приложения сроки, отсутствуют, если действует глобальная настройка
+	AccessTokenTTL  string `json:"access_token_ttl,omitempty"`
+	RefreshTokenTTL string `json:"refresh_token_ttl,omitempty"`
+	IdleTimeout     string `json:"idle_timeout,omitempty"`
+	MaxSessionAge   string `json:"max_session_age,omitempty"`
+	OfflineAccess   bool   `json:"offline_access"`
+	OfflineTokenTTL string `json:"offline_token_ttl,omitempty"`
+	// Возвращается только при создании приложения и ротации секрета
+	Secret                  string `json:"secret,omitempty"`
+	PreviousSecretExpiresAt string `json:"previous_secret_expires_at,omitempty"`
+}
+
+func newAppResponse(app models.App) appResponse {
+	resp := appResponse{
+		ID:               app.ID,
+		Name:             app.Name,
+		MembershipPolicy: app.Policy(),
+		RequireMFA:       app.RequireMFA,
+	}
+	if !app.CreatedAt.IsZero() {
+		resp.CreatedAt = app.CreatedAt.Format(time.RFC3339)
+	}
+	resp.AccessTokenTTL = formatLifetime(app.Lifetimes.AccessTTL)
+	resp.RefreshTokenTTL = formatLifetime(app.Lifetimes.RefreshTTL)
+	resp.IdleTimeout = formatLifetime(app.Lifetimes.IdleTimeout)
+	resp.MaxSessionAge = formatLifetime(app.Lifetimes.MaxAge)
+	resp.OfflineAccess = app.OfflineAccess
+	resp.OfflineTokenTTL = formatLifetime(app.Lifetimes.OfflineTTL)
+	if app.PreviousSecret != "" && time.Now().Before(app.PreviousSecretExpiresAt) {
+		resp.PreviousSecretExpiresAt = app.PreviousSecretExpiresAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// formatLifetime возвращает срок для ответа, пусто — срок не переопределен
+func formatLifetime(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+type encryptionKeyRequest struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+}
+
+type encryptionKeyResponse struct {
+	Kid       string `json:"kid"`
+	AppID     uint32 `json:"app_id"`
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+	CreatedAt string `json:"created_at"`
+}
+
+func newEncryptionKeyResponse(key models.EncryptionKey) encryptionKeyResponse {
+	return encryptionKeyResponse{
+		Kid:       key.ID,
+		AppID:     key.AppID,
+		Algorithm: key.Algorithm,
+		PublicKey: key.PublicKey,
+		CreatedAt: key.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (r *Routes) listApps(c *gin.Context) {
+	apps, err := r.appService.ListApps(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	resp := make([]appResponse, 0, len(apps))
+	for _, app := range apps {
+		resp = append(resp, newAppResponse(app))
+	}
+	c.JSON(http.StatusOK, gin.H{"apps": resp})
+}
+
+func (r *Routes) getApp(c *gin.Context) {
+	appID, ok := appIDParam(c)
+	if !ok {
+		return
+	}
+
+	app, err := r.appService.GetApp(c.Request.Context(), appID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newAppResponse(app))
+}
+
+func (r *Routes) createApp(c *gin.Context) {
+	var req createAppRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	app, err := r.appService.CreateApp(c.Request.Context(), req.Name, req.MembershipPolicy)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	resp := newAppResponse(app)
+	resp.Secret = app.Secret
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (r *Routes) updateApp(c *gin.Context) {
+	appID, ok := appIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req updateAppRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	update := adminS.AppUpdate{
+		Name:             req.Name,
+		MembershipPolicy: req.MembershipPolicy,
+		RequireMFA:       req.RequireMFA,
+		OfflineAccess:    req.OfflineAccess,
+	}
+	lifetimes := []struct {
+		name  string
+		value *string
+		dst   **time.Duration
+	}{
+		{"access_token_ttl", req.AccessTokenTTL, &update.AccessTokenTTL},
+		{"refresh_token_ttl", req.RefreshTokenTTL, &update.RefreshTokenTTL},
+		{"idle_timeout", req.IdleTimeout, &update.IdleTimeout},
+		{"max_session_age", req.MaxSessionAge, &update.MaxSessionAge},
+		{"offline_token_ttl", req.OfflineTokenTTL, &update.OfflineTokenTTL},
+	}
+	for _, field := range lifetimes {
+		if field.value == nil {
+			continue
+		}
+		d, err := time.ParseDuration(*field.value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + field.name + " duration"})
+			return
+		}
+		*field.dst = &d
+	}
+
+	app, err := r.appService.UpdateApp(c.Request.Context(), appID, update)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newAppResponse(app))
+}
+
+func (r *Routes) rotateAppSecret(c *gin.Context) {
+	appID, ok := appIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req rotateSecretRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+	}
+
+	overlap := adminS.DefaultSecretOverlap
+	if req.Overlap != nil {
+		var err error
+		if overlap, err = time.ParseDuration(*req.Overlap); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid overlap duration"})
+			return
+		}
+	}
+
+	app, err := r.appService.RotateAppSecret(c.Request.Context(), appID, overlap)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	resp := newAppResponse(app)
+	resp.Secret = app.Secret
+	c.JSON(http.StatusOK, resp)
+}
+
+func (r *Routes) deleteApp(c *gin.Context) {
+	appID, ok := appIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := r.appService.DeleteApp(c.Request.Context(), appID); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "app deleted"})
+}
+
+func (r *Routes) getAppEncryptionKey(c *gin.Context) {
+	appID, ok := appIDParam(c)
+	if !ok {
+		return
+	}
+
+	key, err := r.appService.GetAppEncryptionKey(c.Request.Context(), appID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newEncryptionKeyResponse(key))
+}
+
+func (r *Routes) setAppEncryptionKey(c *gin.Context) {
+	appID, ok := appIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req encryptionKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	key, err := r.appService.SetAppEncryptionKey(c.Request.Context(), appID, req.Algorithm, req.PublicKey)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newEncryptionKeyResponse(key))
+}
+
+func (r *Routes) disableAppEncryption(c *gin.Context) {
+	appID, ok := appIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := r.appService.DisableAppEncryption(c.Request.Context(), appID); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "token encryption disabled"})
+}
+
+// appIDParam получает id приложения из пути запроса
+func appIDParam(c *gin.Context) (uint32, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid app id"})
+		return 0, false
+	}
+	return uint32(id), true
+}
+
+// writeError преобразует ошибку бизнес-логики в HTTP ответ
+func writeError(c *gin.Context, err error) {
+	var valErr *models.ValidationError
+
+	switch {
+	case errors.As(err, &valErr):
+		body := gin.H{"error": valErr.Error()}
+		if len(valErr.Details) > 0 {
+			body["details"] = valErr.Details
+		}
+		c.JSON(http.StatusBadRequest, body)
+	case errors.Is(err, jwt.ErrInvalidEncryptionKey):
+		c.JSON(http.StatusBadRequest, gin.H{"error": jwt.ErrInvalidEncryptionKey.Error()})
+	case errors.Is(err, certs.ErrInvalidCertificate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": certs.ErrInvalidCertificate.Error()})
+	case errors.Is(err, storage.ErrCertificateExist):
+		c.JSON(http.StatusConflict, gin.H{"error": storage.ErrCertificateExist.Error()})
+	case errors.Is(err, storage.ErrCertificateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": storage.ErrCertificateNotFound.Error()})
+	case errors.Is(err, adminS.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": adminS.ErrInvalidRole.Error()})
+	case errors.Is(err, adminS.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": adminS.ErrForbidden.Error()})
+	case errors.Is(err, adminS.ErrSelfAction):
+		c.JSON(http.StatusConflict, gin.H{"error": adminS.ErrSelfAction.Error()})
+	case errors.Is(err, adminS.ErrBuiltinRole):
+		c.JSON(http.StatusConflict, gin.H{"error": adminS.ErrBuiltinRole.Error()})
+	case errors.Is(err, adminS.ErrBuiltinPermission):
+		c.JSON(http.StatusConflict, gin.H{"error": adminS.ErrBuiltinPermission.Error()})
+	case errors.Is(err, storage.ErrRoleExist):
+		c.JSON(http.StatusConflict, gin.H{"error": storage.ErrRoleExist.Error()})
+	case errors.Is(err, storage.ErrRoleInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": storage.ErrRoleInUse.Error()})
+	case errors.Is(err, storage.ErrRoleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": storage.ErrRoleNotFound.Error()})
+	case errors.Is(err, storage.ErrPermissionExist):
+		c.JSON(http.StatusConflict, gin.H{"error": storage.ErrPermissionExist.Error()})
+	case errors.Is(err, storage.ErrPermissionNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, storage.ErrMemberExist):
+		c.JSON(http.StatusConflict, gin.H{"error": storage.ErrMemberExist.Error()})
+	case errors.Is(err, storage.ErrMemberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": storage.ErrMemberNotFound.Error()})
+	case errors.Is(err, storage.ErrUserExist):
+		c.JSON(http.StatusConflict, gin.H{"error": storage.ErrUserExist.Error()})
+	case errors.Is(err, storage.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": storage.ErrUserNotFound.Error()})
+	case errors.Is(err, storage.ErrAppNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": storage.ErrAppNotFound.Error()})
+	case errors.Is(err, mfa.ErrInvalidMFACode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": mfa.ErrInvalidMFACode.Error()})
+	case errors.Is(err, mfa.ErrMFAAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": mfa.ErrMFAAlreadyEnabled.Error()})
+	case errors.Is(err, mfa.ErrMFANotEnrolled):
+		c.JSON(http.StatusConflict, gin.H{"error": mfa.ErrMFANotEnrolled.Error()})
+	case errors.Is(err, passkeys.ErrInvalidPasskey):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, passkeys.ErrPasskeyChallenge):
+		c.JSON(http.StatusBadRequest, gin.H{"error": passkeys.ErrPasskeyChallenge.Error()})
+	case errors.Is(err, passkeys.ErrTooManyPasskeys):
+		c.JSON(http.StatusConflict, gin.H{"error": passkeys.ErrTooManyPasskeys.Error()})
+	case errors.Is(err, auth.ErrPasskeysDisabled):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": auth.ErrPasskeysDisabled.Error()})
+	case errors.Is(err, storage.ErrCredentialExist):
+		c.JSON(http.StatusConflict, gin.H{"error": storage.ErrCredentialExist.Error()})
+	case errors.Is(err, storage.ErrCredentialNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": storage.ErrCredentialNotFound.Error()})
+	case errors.Is(err, profile.ErrInvalidVerificationToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": profile.ErrInvalidVerificationToken.Error()})
+	case errors.Is(err, profile.ErrEmailNotSet), errors.Is(err, profile.ErrEmailAlreadyVerified):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, profile.ErrEmailVerificationDisabled):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": profile.ErrEmailVerificationDisabled.Error()})
+	case errors.Is(err, storage.ErrEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": storage.ErrEmailTaken.Error()})
+	case errors.Is(err, storage.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": storage.ErrSessionNotFound.Error()})
+	case errors.Is(err, storage.ErrMFANotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": storage.ErrMFANotFound.Error()})
+	case errors.Is(err, storage.ErrEncryptionKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": storage.ErrEncryptionKeyNotFound.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+	}
+}
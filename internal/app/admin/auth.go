@@ -0,0 +1,196 @@
+package admin
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/jwt"
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "admin.principal"
+
+// bootstrapActor имя субъекта, аутентифицированного статическим токеном
+const bootstrapActor = "bootstrap-token"
+
+// Principal аутентифицированный вызывающий административного API
+type Principal struct {
+	UserID   uint64
+	Username string
+	RoleID   int
+	AppID    uint32
+	// Сеанс, которому выдан access токен, пусто для bootstrap токена
+	SessionID string
+}
+
+// userProvider возвращает актуальные данные пользователя и его сеансов
+type userProvider interface {
+	GetUser(ctx context.Context, userID uint64) (models.User, error)
+	GetUserSession(ctx context.Context, userID uint64, sessionID string) (models.Session, error)
+}
+
+// authenticator проверяет bearer токены административного API
+type authenticator struct {
+	keysStore      keysStore
+	users          userProvider
+	bootstrapToken string
+	// Приложение, токены которого принимаются, 0 — только bootstrap токен
+	appID uint32
+}
+
+// authenticate проверяет bearer токен административного API: access токен, выпущенный
+// приложению административного API (aud), либо статический bootstrap токен из env.
+func (a *authenticator) authenticate(c *gin.Context) (Principal, bool) {
+	raw, ok := bearerToken(c.GetHeader("Authorization"))
+	if !ok {
+		return Principal{}, false
+	}
+
+	if a.bootstrapToken != "" &&
+		subtle.ConstantTimeCompare([]byte(raw), []byte(a.bootstrapToken)) == 1 {
+		return Principal{Username: bootstrapActor, RoleID: models.RoleSuperAdmin}, true
+	}
+
+	if a.appID == 0 {
+		return Principal{}, false
+	}
+	return a.verifyAccessToken(c, raw, a.appID)
+}
+
+// authenticateAccount проверяет bearer токен маршрутов самообслуживания (/account/...):
+// принимается access токен пользователя, выпущенный любому приложению.
+// Bootstrap токен не принимается: за ним нет пользователя.
+func (a *authenticator) authenticateAccount(c *gin.Context) (Principal, bool) {
+	raw, ok := bearerToken(c.GetHeader("Authorization"))
+	if !ok {
+		return Principal{}, false
+	}
+	return a.verifyAccessToken(c, raw, 0)
+}
+
+// verifyAccessToken проверяет access токен этого SSO, подписанный ключом из JWKS
+// и выпущенный приложению appID (0 — любому приложению).
+// Токен, привязанный к ключу клиента (cnf), не принимается: API
+// не проверяет DPoP proof и клиентский сертификат.
+// Токен действует, пока не отозван его сеанс (выход, отзыв сеанса, смена пароля).
+// Роли в токене относятся к приложению, поэтому глобальная роль и статус
+// учетной записи берутся из БД.
+func (a *authenticator) verifyAccessToken(c *gin.Context, raw string, appID uint32) (Principal, bool) {
+	audience := ""
+	if appID != 0 {
+		audience = jwt.Audience(appID)
+	}
+
+	claims, err := jwt.ParseAccessToken(raw, audience, func(kid string) (*rsa.PublicKey, error) {
+		publicKey, err := a.keysStore.GetPublicKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		return publicKey.Key, nil
+	})
+	if err != nil || claims.Cnf != nil || claims.SessionID == "" {
+		return Principal{}, false
+	}
+	if appID != 0 && claims.AppID != appID {
+		return Principal{}, false
+	}
+
+	ctx := c.Request.Context()
+	if _, err := a.users.GetUserSession(ctx, claims.UserID, claims.SessionID); err != nil {
+		return Principal{}, false
+	}
+
+	user, err := a.users.GetUser(ctx, claims.UserID)
+	if err != nil || user.Disabled {
+		return Principal{}, false
+	}
+
+	return Principal{
+		UserID:    user.ID,
+		Username:  user.Username,
+		RoleID:    user.Role_id,
+		AppID:     claims.AppID,
+		SessionID: claims.SessionID,
+	}, true
+}
+
+// bearerToken извлекает токен из заголовка Authorization
+func bearerToken(header string) (string, bool) {
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// requireRole пропускает запрос, если вызывающий аутентифицирован и имеет роль не ниже role
+func (r *Routes) requireRole(role int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := r.auth.authenticate(c)
+		if !ok {
+			c.Header("WWW-Authenticate", `Bearer realm="sso-admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		c.Set(principalKey, principal)
+
+		if !models.HasRole(principal.RoleID, role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// requireAccount пропускает запрос пользователя с действующим access токеном любого приложения
+func (r *Routes) requireAccount(c *gin.Context) {
+	principal, ok := r.auth.authenticateAccount(c)
+	if !ok {
+		c.Header("WWW-Authenticate", `Bearer realm="sso-account"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	c.Set(principalKey, principal)
+	c.Next()
+}
+
+// audit записывает в журнал, кто и какой административный метод вызвал
+func (r *Routes) audit(c *gin.Context) {
+	c.Next()
+
+	actor := "anonymous"
+	principal, ok := principalFromContext(c)
+	if ok {
+		actor = principal.Username
+	}
+
+	r.log.Info("admin audit",
+		slog.String("actor", actor),
+		slog.Uint64("user_id", principal.UserID),
+		slog.String("role", models.RoleName(principal.RoleID)),
+		slog.String("method", c.Request.Method),
+		slog.String("route", c.FullPath()),
+		slog.String("path", c.Request.URL.Path),
+		slog.Int("status", c.Writer.Status()),
+		slog.String("client_ip", c.ClientIP()),
+	)
+}
+
+// principalFromContext возвращает вызывающего, сохраненного requireRole или requireAccount
+func principalFromContext(c *gin.Context) (Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return Principal{}, false
+	}
+	principal, ok := value.(Principal)
+	return principal, ok
+}
@@ -0,0 +1,155 @@
+package admin
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/jwt"
+	"github.com/Grino777/sso/internal/services/keys/manager"
+	keysModels "github.com/Grino777/sso/internal/services/keys/models"
+	"github.com/Grino777/sso/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+const testAdminAppID = 1
+
+type testKeys struct{ key *keysModels.PrivateKey }
+
+func (k testKeys) RotateKeys() (*manager.GenKeys, error) { return nil, errors.New("not implemented") }
+
+func (k testKeys) GetPublicKey(kid string) (*keysModels.PublicKey, error) {
+	if kid != k.key.ID {
+		return nil, errors.New("key not found")
+	}
+	return &keysModels.PublicKey{ID: kid, Key: &k.key.Key.PublicKey}, nil
+}
+
+type testUsers struct {
+	user     models.User
+	sessions map[string]bool
+}
+
+func (u testUsers) GetUser(_ context.Context, userID uint64) (models.User, error) {
+	if userID != u.user.ID {
+		return models.User{}, storage.ErrUserNotFound
+	}
+	return u.user, nil
+}
+
+func (u testUsers) GetUserSession(_ context.Context, userID uint64, sessionID string) (models.Session, error) {
+	if userID != u.user.ID || !u.sessions[sessionID] {
+		return models.Session{}, storage.ErrSessionNotFound
+	}
+	return models.Session{ID: sessionID, UserID: userID}, nil
+}
+
+// newTestAuthenticator возвращает authenticator с активным сеансом "active" пользователя user
+// и функцию выпуска его access токенов
+func newTestAuthenticator(t *testing.T, user models.User) (*authenticator, func(uint32, string, *models.Confirmation) string) {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := &keysModels.PrivateKey{ID: "k1", Key: rsaKey}
+
+	a := &authenticator{
+		keysStore:      testKeys{pk},
+		users:          testUsers{user: user, sessions: map[string]bool{"active": true}},
+		bootstrapToken: "bootstrap",
+		appID:          testAdminAppID,
+	}
+
+	token := func(appID uint32, sessionID string, cnf *models.Confirmation) string {
+		tok, err := jwt.NewAccessToken(user, models.App{ID: appID}, pk, time.Minute, sessionID, cnf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok.Token
+	}
+	return a, token
+}
+
+func bearerContext(token string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/users", nil)
+	c.Request.Header.Set("Authorization", "Bearer "+token)
+	return c
+}
+
+func TestAuthenticator__AccessToken(t *testing.T) {
+	user := models.User{ID: 7, Username: "alice", Role_id: models.RoleAdmin}
+	a, token := newTestAuthenticator(t, user)
+
+	tests := []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{"admin app token", token(testAdminAppID, "active", nil), true},
+		{"token of another app", token(2, "active", nil), false},
+		{"revoked session", token(testAdminAppID, "revoked", nil), false},
+		{"token without session", token(testAdminAppID, "", nil), false},
+		{"sender-constrained token", token(testAdminAppID, "active", &models.Confirmation{JKT: "jkt"}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, ok := a.authenticate(bearerContext(tt.token))
+			if ok != tt.want {
+				t.Fatalf("authenticated = %v, want %v", ok, tt.want)
+			}
+			if ok && (principal.UserID != user.ID || principal.SessionID != "active") {
+				t.Errorf("unexpected principal %+v", principal)
+			}
+		})
+	}
+
+	// Без app_id access токены не принимаются
+	a.appID = 0
+	if _, ok := a.authenticate(bearerContext(token(testAdminAppID, "active", nil))); ok {
+		t.Error("access token accepted without configured admin app")
+	}
+}
+
+func TestAuthenticator__AccountToken(t *testing.T) {
+	user := models.User{ID: 7, Username: "alice", Role_id: models.RoleUser}
+	a, token := newTestAuthenticator(t, user)
+
+	tests := []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{"admin app token", token(testAdminAppID, "active", nil), true},
+		{"token of another app", token(2, "active", nil), true},
+		{"revoked session", token(2, "revoked", nil), false},
+		{"token without session", token(2, "", nil), false},
+		{"sender-constrained token", token(2, "active", &models.Confirmation{JKT: "jkt"}), false},
+		{"bootstrap token", "bootstrap", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, ok := a.authenticateAccount(bearerContext(tt.token))
+			if ok != tt.want {
+				t.Fatalf("authenticated = %v, want %v", ok, tt.want)
+			}
+			if ok && principal.UserID != user.ID {
+				t.Errorf("unexpected principal %+v", principal)
+			}
+		})
+	}
+
+	// Маршруты самообслуживания не зависят от приложения административного API
+	a.appID = 0
+	if _, ok := a.authenticateAccount(bearerContext(token(2, "active", nil))); !ok {
+		t.Error("account token rejected without configured admin app")
+	}
+}
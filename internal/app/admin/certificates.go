@@ -0,0 +1,92 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/gin-gonic/gin"
+)
+
+// certificateService бизнес-логика клиентских сертификатов приложений
+type certificateService interface {
+	GetAppCertificates(ctx context.Context, appID uint32) ([]models.AppCertificate, error)
+	AddAppCertificate(ctx context.Context, appID uint32, certPEM string) (models.AppCertificate, error)
+	DeleteAppCertificate(ctx context.Context, appID uint32, thumbprint string) error
+}
+
+type certificateRequest struct {
+	Certificate string `json:"certificate"` // PEM
+}
+
+type certificateResponse struct {
+	AppID      uint32 `json:"app_id"`
+	Thumbprint string `json:"x5t#S256"`
+	Subject    string `json:"subject"`
+	NotAfter   string `json:"not_after"`
+	CreatedAt  string `json:"created_at"`
+}
+
+func newCertificateResponse(cert models.AppCertificate) certificateResponse {
+	return certificateResponse{
+		AppID:      cert.AppID,
+		Thumbprint: cert.Thumbprint,
+		Subject:    cert.Subject,
+		NotAfter:   cert.NotAfter.Format(time.RFC3339),
+		CreatedAt:  cert.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (r *Routes) getAppCertificates(c *gin.Context) {
+	appID, ok := appIDParam(c)
+	if !ok {
+		return
+	}
+
+	appCerts, err := r.certificateService.GetAppCertificates(c.Request.Context(), appID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	resp := make([]certificateResponse, 0, len(appCerts))
+	for _, cert := range appCerts {
+		resp = append(resp, newCertificateResponse(cert))
+	}
+	c.JSON(http.StatusOK, gin.H{"certificates": resp})
+}
+
+func (r *Routes) addAppCertificate(c *gin.Context) {
+	appID, ok := appIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req certificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	cert, err := r.certificateService.AddAppCertificate(c.Request.Context(), appID, req.Certificate)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, newCertificateResponse(cert))
+}
+
+func (r *Routes) deleteAppCertificate(c *gin.Context) {
+	appID, ok := appIDParam(c)
+	if !ok {
+		return
+	}
+
+	err := r.certificateService.DeleteAppCertificate(c.Request.Context(), appID, c.Param("thumbprint"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "certificate removed"})
+}
@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	adminS "github.com/Grino777/sso/internal/services/admin"
+	"github.com/gin-gonic/gin"
+)
+
+// lockoutService бизнес-логика блокировок входа
+type lockoutService interface {
+	GetUserLockout(ctx context.Context, username string) (models.LockoutStatus, error)
+	ClearUserLockout(ctx context.Context, actor adminS.Actor, username string) error
+	GetIPLockout(ctx context.Context, ip string) (models.LockoutStatus, error)
+	ClearIPLockout(ctx context.Context, actor adminS.Actor, ip string) error
+}
+
+type lockoutResponse struct {
+	Subject           string `json:"subject"`
+	Failures          int64  `json:"failures"`
+	Strikes           int64  `json:"strikes"`
+	Locked            bool   `json:"locked"`
+	LockedUntil       string `json:"locked_until,omitempty"`
+	RetryAfterSeconds int64  `json:"retry_after_seconds,omitempty"`
+}
+
+func newLockoutResponse(status models.LockoutStatus) lockoutResponse {
+	now := time.Now()
+	resp := lockoutResponse{
+		Subject:  status.Subject,
+		Failures: status.Failures,
+		Strikes:  status.Strikes,
+		Locked:   status.Locked(now),
+	}
+	if resp.Locked {
+		resp.LockedUntil = status.LockedUntil.Format(time.RFC3339)
+		resp.RetryAfterSeconds = int64(status.RetryAfter(now).Round(time.Second).Seconds())
+	}
+	return resp
+}
+
+func (r *Routes) getUserLockout(c *gin.Context) {
+	status, err := r.lockoutService.GetUserLockout(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newLockoutResponse(status))
+}
+
+func (r *Routes) clearUserLockout(c *gin.Context) {
+	if err := r.lockoutService.ClearUserLockout(c.Request.Context(), actor(c), c.Param("username")); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "lockout cleared"})
+}
+
+func (r *Routes) getIPLockout(c *gin.Context) {
+	status, err := r.lockoutService.GetIPLockout(c.Request.Context(), c.Param("ip"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newLockoutResponse(status))
+}
+
+func (r *Routes) clearIPLockout(c *gin.Context) {
+	if err := r.lockoutService.ClearIPLockout(c.Request.Context(), actor(c), c.Param("ip")); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "lockout cleared"})
+}
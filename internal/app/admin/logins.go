@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/webauthn"
+	"github.com/gin-gonic/gin"
+)
+
+// loginHistoryService бизнес-логика журнала входов пользователей
+type loginHistoryService interface {
+	ListUserLogins(ctx context.Context, userID uint64, limit int) ([]models.LoginRecord, error)
+}
+
+// accountService интерфейс бизнес-логики самообслуживания пользователя
+type accountService interface {
+	ListLogins(ctx context.Context, userID uint64, limit int) ([]models.LoginRecord, error)
+	ListSessions(ctx context.Context, userID uint64) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID uint64, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, userID uint64, current string) (int64, error)
+
+	MFAStatus(ctx context.Context, userID uint64) (models.UserMFA, error)
+	EnrollTOTP(ctx context.Context, userID uint64) (models.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID uint64, code string) ([]string, error)
+	DisableMFA(ctx context.Context, userID uint64, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint64, code string) ([]string, error)
+
+	ListPasskeys(ctx context.Context, userID uint64) ([]models.WebAuthnCredential, error)
+	BeginPasskeyRegistration(ctx context.Context, userID uint64) (webauthn.CreationOptions, error)
+	FinishPasskeyRegistration(ctx context.Context, userID uint64, name string, resp webauthn.RegistrationResponse) (models.WebAuthnCredential, error)
+	DeletePasskey(ctx context.Context, userID uint64, id uint64) error
+
+	GetProfile(ctx context.Context, userID uint64) (models.Profile, error)
+	UpdateProfile(ctx context.Context, userID uint64, update models.ProfileUpdate) (models.Profile, error)
+	SendEmailVerification(ctx context.Context, userID uint64) error
+	VerifyEmail(ctx context.Context, token string) (models.Profile, error)
+}
+
+type loginRecordResponse struct {
+	AppID     uint32 `json:"app_id"`
+	Username  string `json:"username"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent,omitempty"`
+	Success   bool   `json:"success"`
+	Reason    string `json:"reason,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+func newLoginsResponse(records []models.LoginRecord) gin.H {
+	resp := make([]loginRecordResponse, 0, len(records))
+	for _, record := range records {
+		resp = append(resp, loginRecordResponse{
+			AppID:     record.AppID,
+			Username:  record.Username,
+			IP:        record.IP,
+			UserAgent: record.UserAgent,
+			Success:   record.Success,
+			Reason:    record.Reason,
+			CreatedAt: record.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return gin.H{"logins": resp}
+}
+
+// listUserLogins возвращает историю входов пользователя для администратора
+func (r *Routes) listUserLogins(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+	limit, ok := limitQuery(c)
+	if !ok {
+		return
+	}
+
+	records, err := r.loginHistoryService.ListUserLogins(c.Request.Context(), userID, limit)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newLoginsResponse(records))
+}
+
+// listOwnLogins возвращает историю входов вызывающего пользователя
+func (r *Routes) listOwnLogins(c *gin.Context) {
+	limit, ok := limitQuery(c)
+	if !ok {
+		return
+	}
+
+	principal, _ := principalFromContext(c)
+	records, err := r.accountService.ListLogins(c.Request.Context(), principal.UserID, limit)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newLoginsResponse(records))
+}
+
+// limitQuery разбирает необязательный параметр limit
+func limitQuery(c *gin.Context) (int, bool) {
+	value := c.Query("limit")
+	if value == "" {
+		return 0, true
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameter limit"})
+		return 0, false
+	}
+	return limit, true
+}
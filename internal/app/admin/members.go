@@ -0,0 +1,137 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	adminS "github.com/Grino777/sso/internal/services/admin"
+	"github.com/gin-gonic/gin"
+)
+
+// memberService бизнес-логика членства пользователей в приложениях
+type memberService interface {
+	ListAppMembers(ctx context.Context, appID uint32) ([]models.AppMember, error)
+	AddAppMember(ctx context.Context, actor adminS.Actor, appID uint32, userID uint64) error
+	RemoveAppMember(ctx context.Context, actor adminS.Actor, appID uint32, userID uint64) error
+	SetAppMemberBlocked(ctx context.Context, actor adminS.Actor, appID uint32, userID uint64, blocked bool) error
+}
+
+type addMemberRequest struct {
+	UserID uint64 `json:"user_id"`
+}
+
+type memberResponse struct {
+	UserID    uint64 `json:"user_id"`
+	Username  string `json:"username"`
+	Blocked   bool   `json:"blocked"`
+	RoleID    int    `json:"role_id,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+func newMemberResponse(member models.AppMember) memberResponse {
+	resp := memberResponse{
+		UserID:   member.UserID,
+		Username: member.Username,
+		Blocked:  member.Blocked,
+		RoleID:   member.RoleID,
+	}
+	if !member.CreatedAt.IsZero() {
+		resp.CreatedAt = member.CreatedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+func (r *Routes) listAppMembers(c *gin.Context) {
+	appID, ok := appIDParam(c)
+	if !ok {
+		return
+	}
+
+	members, err := r.memberService.ListAppMembers(c.Request.Context(), appID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	resp := make([]memberResponse, 0, len(members))
+	for _, member := range members {
+		resp = append(resp, newMemberResponse(member))
+	}
+	c.JSON(http.StatusOK, gin.H{"members": resp})
+}
+
+func (r *Routes) addAppMember(c *gin.Context) {
+	appID, ok := appIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req addMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.UserID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := r.memberService.AddAppMember(c.Request.Context(), actor(c), appID, req.UserID); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "user added to app"})
+}
+
+func (r *Routes) removeAppMember(c *gin.Context) {
+	appID, userID, ok := appMemberParams(c)
+	if !ok {
+		return
+	}
+
+	if err := r.memberService.RemoveAppMember(c.Request.Context(), actor(c), appID, userID); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "user removed from app"})
+}
+
+func (r *Routes) blockAppMember(c *gin.Context) {
+	r.setAppMemberBlocked(c, true)
+}
+
+func (r *Routes) unblockAppMember(c *gin.Context) {
+	r.setAppMemberBlocked(c, false)
+}
+
+func (r *Routes) setAppMemberBlocked(c *gin.Context, blocked bool) {
+	appID, userID, ok := appMemberParams(c)
+	if !ok {
+		return
+	}
+
+	if err := r.memberService.SetAppMemberBlocked(c.Request.Context(), actor(c), appID, userID, blocked); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	message := "user unblocked in app"
+	if blocked {
+		message = "user blocked in app"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// appMemberParams получает id приложения и пользователя из пути /apps/:id/members/:user_id
+func appMemberParams(c *gin.Context) (uint32, uint64, bool) {
+	appID, ok := appIDParam(c)
+	if !ok {
+		return 0, 0, false
+	}
+
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil || userID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+	return appID, userID, true
+}
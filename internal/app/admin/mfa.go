@@ -0,0 +1,256 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/reqctx"
+	"github.com/Grino777/sso/internal/lib/webauthn"
+	adminS "github.com/Grino777/sso/internal/services/admin"
+	"github.com/Grino777/sso/internal/services/auth"
+	"github.com/Grino777/sso/internal/services/mfa"
+	"github.com/Grino777/sso/internal/services/passkeys"
+	"github.com/gin-gonic/gin"
+)
+
+// mfaAdminService бизнес-логика сброса второго фактора администратором
+type mfaAdminService interface {
+	ResetUserMFA(ctx context.Context, actor adminS.Actor, userID uint64) error
+}
+
+// authService интерфейс бизнес-логики входа, доступной по HTTP
+type authService interface {
+	CompleteMFALogin(ctx context.Context, challengeToken string, code string, appID uint32) (models.Tokens, error)
+	BeginPasskeyLogin(ctx context.Context, username string, appID uint32) (webauthn.RequestOptions, error)
+	PasskeyLogin(ctx context.Context, resp webauthn.AssertionResponse, appID uint32) (models.Tokens, error)
+	StartPasswordlessLogin(ctx context.Context, username string, appID uint32, method string) (time.Time, error)
+	LoginWithCode(ctx context.Context, username string, code string, appID uint32) (models.Tokens, error)
+	LoginWithLink(ctx context.Context, token string, appID uint32) (models.Tokens, error)
+	ChangePassword(ctx context.Context, userID uint64, current string, password string) error
+	StartPasswordReset(ctx context.Context, username string) (time.Time, error)
+	ResetPassword(ctx context.Context, token string, password string) error
+	ChangeExpiredPassword(ctx context.Context, token string, password string, appID uint32) error
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type verifyMFARequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	AppID          uint32 `json:"app_id"`
+}
+
+type mfaStatusResponse struct {
+	Enabled           bool   `json:"enabled"`
+	RecoveryCodesLeft int    `json:"recovery_codes_left"`
+	EnabledAt         string `json:"enabled_at,omitempty"`
+}
+
+type tokenResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+type tokensResponse struct {
+	AccessToken  tokenResponse `json:"access_token"`
+	RefreshToken tokenResponse `json:"refresh_token"`
+}
+
+func newTokensResponse(tokens models.Tokens) tokensResponse {
+	return tokensResponse{
+		AccessToken: tokenResponse{
+			Token:     tokens.AccessToken.Token,
+			ExpiresAt: tokens.AccessToken.Expire_at,
+		},
+		RefreshToken: tokenResponse{
+			Token:     tokens.RefreshToken.Token,
+			ExpiresAt: tokens.RefreshToken.Expire_at,
+		},
+	}
+}
+
+// verifyMFA завершает вход вторым фактором по токену из ошибки MFA_REQUIRED метода Login
+func (r *Routes) verifyMFA(c *gin.Context) {
+	var req verifyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	appID, ok := requestAppID(c, req.AppID)
+	if !ok {
+		return
+	}
+
+	tokens, err := r.authService.CompleteMFALogin(loginContext(c), req.ChallengeToken, req.Code, appID)
+	if err != nil {
+		writeLoginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newTokensResponse(tokens))
+}
+
+// loginContext передает в бизнес-логику данные HTTP запроса входа
+func loginContext(c *gin.Context) context.Context {
+	return reqctx.WithInfo(c.Request.Context(), reqctx.Info{
+		Method:     c.Request.Method,
+		URL:        "https://" + c.Request.Host + c.Request.URL.Path,
+		DPoP:       c.GetHeader("DPoP"),
+		ClientIP:   c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		DeviceName: models.NormalizeDeviceName(c.GetHeader("X-Device-Name")),
+		OfflineAccess: models.OfflineAccessRequested(
+			c.GetHeader("X-Scope"),
+			c.GetHeader("X-Remember-Me"),
+		),
+	})
+}
+
+// writeLoginError преобразует ошибку входа в HTTP ответ
+func writeLoginError(c *gin.Context, err error) {
+	var lockErr *auth.LockoutError
+	var mfaErr *auth.MFARequiredError
+
+	switch {
+	case errors.As(err, &mfaErr):
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":           mfaErr.Error(),
+			"challenge_token": mfaErr.ChallengeToken,
+			"expires_at":      mfaErr.ExpiresAt.Format(time.RFC3339),
+		})
+	case errors.Is(err, auth.ErrMFAEnrollmentRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": auth.ErrMFAEnrollmentRequired.Error()})
+	case errors.Is(err, passkeys.ErrInvalidPasskey), errors.Is(err, passkeys.ErrPasskeyChallenge):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": passkeys.ErrInvalidPasskey.Error()})
+	case errors.Is(err, auth.ErrInvalidLoginCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": auth.ErrInvalidLoginCode.Error()})
+	case errors.Is(err, auth.ErrPasswordlessDisabled):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": auth.ErrPasswordlessDisabled.Error()})
+	case errors.Is(err, auth.ErrInvalidResetToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": auth.ErrInvalidResetToken.Error()})
+	case errors.Is(err, auth.ErrInvalidChangeToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": auth.ErrInvalidChangeToken.Error()})
+	case errors.Is(err, auth.ErrPasswordResetDisabled):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": auth.ErrPasswordResetDisabled.Error()})
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		c.JSON(http.StatusForbidden, gin.H{"error": auth.ErrInvalidCredentials.Error()})
+	case errors.As(err, &lockErr):
+		seconds := int64(math.Ceil(lockErr.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": lockErr.Error()})
+	case errors.Is(err, auth.ErrInvalidMFAChallenge):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": auth.ErrInvalidMFAChallenge.Error()})
+	case errors.Is(err, mfa.ErrInvalidMFACode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": mfa.ErrInvalidMFACode.Error()})
+	case errors.Is(err, auth.ErrUserDisabled),
+		errors.Is(err, auth.ErrUserLocked),
+		errors.Is(err, auth.ErrAppAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		writeError(c, err)
+	}
+}
+
+// getOwnMFA возвращает состояние второго фактора вызывающего пользователя
+func (r *Routes) getOwnMFA(c *gin.Context) {
+	principal, _ := principalFromContext(c)
+	status, err := r.accountService.MFAStatus(c.Request.Context(), principal.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	resp := mfaStatusResponse{Enabled: status.Enabled}
+	if status.Enabled {
+		resp.RecoveryCodesLeft = status.RecoveryCodesLeft
+		resp.EnabledAt = status.EnabledAt.Format(time.RFC3339)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// enrollTOTP создает секрет TOTP. Секрет и otpauth:// URI возвращаются только в этом ответе.
+func (r *Routes) enrollTOTP(c *gin.Context) {
+	principal, _ := principalFromContext(c)
+	enrollment, err := r.accountService.EnrollTOTP(c.Request.Context(), principal.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"secret": enrollment.Secret, "uri": enrollment.URI})
+}
+
+// confirmTOTP включает MFA первым кодом из приложения-аутентификатора
+func (r *Routes) confirmTOTP(c *gin.Context) {
+	code, ok := mfaCode(c)
+	if !ok {
+		return
+	}
+
+	principal, _ := principalFromContext(c)
+	codes, err := r.accountService.ConfirmTOTP(c.Request.Context(), principal.UserID, code)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (r *Routes) regenerateRecoveryCodes(c *gin.Context) {
+	code, ok := mfaCode(c)
+	if !ok {
+		return
+	}
+
+	principal, _ := principalFromContext(c)
+	codes, err := r.accountService.RegenerateRecoveryCodes(c.Request.Context(), principal.UserID, code)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (r *Routes) disableOwnMFA(c *gin.Context) {
+	code, ok := mfaCode(c)
+	if !ok {
+		return
+	}
+
+	principal, _ := principalFromContext(c)
+	if err := r.accountService.DisableMFA(c.Request.Context(), principal.UserID, code); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "mfa disabled"})
+}
+
+// resetUserMFA отключает второй фактор пользователя администратором
+func (r *Routes) resetUserMFA(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := r.mfaAdminService.ResetUserMFA(c.Request.Context(), actor(c), userID); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "mfa reset"})
+}
+
+// mfaCode разбирает тело запроса с кодом TOTP или кодом восстановления
+func mfaCode(c *gin.Context) (string, bool) {
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return "", false
+	}
+	return req.Code, true
+}
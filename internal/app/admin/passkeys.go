@@ -0,0 +1,156 @@
+package admin
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/webauthn"
+	"github.com/gin-gonic/gin"
+)
+
+type passkeyLoginOptionsRequest struct {
+	Username string `json:"username"`
+	AppID    uint32 `json:"app_id"`
+}
+
+// passkeyLoginRequest ответ аутентификатора (AuthenticationResponseJSON) и приложение входа
+type passkeyLoginRequest struct {
+	webauthn.AssertionResponse
+	AppID uint32 `json:"app_id"`
+}
+
+type registerPasskeyRequest struct {
+	Name       string                        `json:"name"`
+	Credential webauthn.RegistrationResponse `json:"credential"`
+}
+
+type passkeyResponse struct {
+	ID                uint64   `json:"id"`
+	Name              string   `json:"name"`
+	CredentialID      string   `json:"credential_id"`
+	AAGUID            string   `json:"aaguid"`
+	AttestationFormat string   `json:"attestation_format"`
+	Transports        []string `json:"transports,omitempty"`
+	CreatedAt         string   `json:"created_at"`
+	LastUsedAt        string   `json:"last_used_at,omitempty"`
+}
+
+func newPasskeyResponse(cred models.WebAuthnCredential) passkeyResponse {
+	resp := passkeyResponse{
+		ID:                cred.ID,
+		Name:              cred.Name,
+		CredentialID:      webauthn.EncodeID(cred.CredentialID),
+		AAGUID:            hex.EncodeToString(cred.AAGUID),
+		AttestationFormat: cred.AttestationFormat,
+		Transports:        cred.Transports,
+		CreatedAt:         cred.CreatedAt.Format(time.RFC3339),
+	}
+	if !cred.LastUsedAt.IsZero() {
+		resp.LastUsedAt = cred.LastUsedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// passkeyLoginOptions начинает вход по passkey: возвращает параметры navigator.credentials.get
+func (r *Routes) passkeyLoginOptions(c *gin.Context) {
+	var req passkeyLoginOptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	appID, ok := requestAppID(c, req.AppID)
+	if !ok {
+		return
+	}
+
+	options, err := r.authService.BeginPasskeyLogin(c.Request.Context(), req.Username, appID)
+	if err != nil {
+		writeLoginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, options)
+}
+
+// passkeyLogin завершает вход по passkey ответом аутентификатора
+func (r *Routes) passkeyLogin(c *gin.Context) {
+	var req passkeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	appID, ok := requestAppID(c, req.AppID)
+	if !ok {
+		return
+	}
+
+	tokens, err := r.authService.PasskeyLogin(loginContext(c), req.AssertionResponse, appID)
+	if err != nil {
+		writeLoginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newTokensResponse(tokens))
+}
+
+// listOwnPasskeys возвращает passkey вызывающего пользователя
+func (r *Routes) listOwnPasskeys(c *gin.Context) {
+	principal, _ := principalFromContext(c)
+	creds, err := r.accountService.ListPasskeys(c.Request.Context(), principal.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	resp := make([]passkeyResponse, 0, len(creds))
+	for _, cred := range creds {
+		resp = append(resp, newPasskeyResponse(cred))
+	}
+	c.JSON(http.StatusOK, gin.H{"passkeys": resp})
+}
+
+// passkeyRegistrationOptions возвращает параметры navigator.credentials.create
+func (r *Routes) passkeyRegistrationOptions(c *gin.Context) {
+	principal, _ := principalFromContext(c)
+	options, err := r.accountService.BeginPasskeyRegistration(c.Request.Context(), principal.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, options)
+}
+
+// registerPasskey сохраняет passkey по ответу аутентификатора
+func (r *Routes) registerPasskey(c *gin.Context) {
+	var req registerPasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	principal, _ := principalFromContext(c)
+	cred, err := r.accountService.FinishPasskeyRegistration(c.Request.Context(), principal.UserID, req.Name, req.Credential)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, newPasskeyResponse(cred))
+}
+
+func (r *Routes) deleteOwnPasskey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid passkey id"})
+		return
+	}
+
+	principal, _ := principalFromContext(c)
+	if err := r.accountService.DeletePasskey(c.Request.Context(), principal.UserID, id); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "passkey deleted"})
+}
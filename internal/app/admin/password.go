@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Password        string `json:"password"`
+}
+
+type startPasswordResetRequest struct {
+	Username string `json:"username"`
+}
+
+type confirmPasswordResetRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type changeExpiredPasswordRequest struct {
+	ChangeToken string `json:"change_token"`
+	Password    string `json:"password"`
+	AppID       uint32 `json:"app_id"`
+}
+
+// changeOwnPassword меняет пароль вызывающего пользователя. Все refresh токены
+// пользователя отзываются, в том числе токен текущей сессии.
+func (r *Routes) changeOwnPassword(c *gin.Context) {
+	var req changePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	principal, _ := principalFromContext(c)
+	if err := r.authService.ChangePassword(c.Request.Context(), principal.UserID, req.CurrentPassword, req.Password); err != nil {
+		writeLoginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password changed"})
+}
+
+// startPasswordReset отправляет письмо со ссылкой для сброса пароля.
+// Ответ одинаков для существующих и несуществующих пользователей.
+func (r *Routes) startPasswordReset(c *gin.Context) {
+	var req startPasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	expiresAt, err := r.authService.StartPasswordReset(c.Request.Context(), req.Username)
+	if err != nil {
+		writeLoginError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "if the account exists, a password reset email has been sent",
+		"expires_at": expiresAt.Format(time.RFC3339),
+	})
+}
+
+// confirmPasswordReset устанавливает новый пароль по токену из письма
+func (r *Routes) confirmPasswordReset(c *gin.Context) {
+	var req confirmPasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := r.authService.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		writeLoginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password changed"})
+}
+
+// changeExpiredPassword устанавливает новый пароль по токену из ошибки PASSWORD_EXPIRED метода Login
+func (r *Routes) changeExpiredPassword(c *gin.Context) {
+	var req changeExpiredPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	appID, ok := requestAppID(c, req.AppID)
+	if !ok {
+		return
+	}
+
+	if err := r.authService.ChangeExpiredPassword(c.Request.Context(), req.ChangeToken, req.Password, appID); err != nil {
+		writeLoginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password changed"})
+}
@@ -0,0 +1,92 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type startPasswordlessRequest struct {
+	Username string `json:"username"`
+	AppID    uint32 `json:"app_id"`
+	Method   string `json:"method"`
+}
+
+type loginCodeRequest struct {
+	Username string `json:"username"`
+	AppID    uint32 `json:"app_id"`
+	Code     string `json:"code"`
+}
+
+type loginLinkRequest struct {
+	Token string `json:"token"`
+	AppID uint32 `json:"app_id"`
+}
+
+// startPasswordless отправляет письмо с кодом (email_code) или ссылкой (magic_link) для входа.
+// Ответ одинаков для существующих и несуществующих пользователей.
+func (r *Routes) startPasswordless(c *gin.Context) {
+	var req startPasswordlessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	appID, ok := requestAppID(c, req.AppID)
+	if !ok {
+		return
+	}
+
+	expiresAt, err := r.authService.StartPasswordlessLogin(loginContext(c), req.Username, appID, req.Method)
+	if err != nil {
+		writeLoginError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "if the account exists, a login email has been sent",
+		"expires_at": expiresAt.Format(time.RFC3339),
+	})
+}
+
+// loginWithCode завершает вход кодом из письма
+func (r *Routes) loginWithCode(c *gin.Context) {
+	var req loginCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	appID, ok := requestAppID(c, req.AppID)
+	if !ok {
+		return
+	}
+
+	tokens, err := r.authService.LoginWithCode(loginContext(c), req.Username, req.Code, appID)
+	if err != nil {
+		writeLoginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newTokensResponse(tokens))
+}
+
+// loginWithLink завершает вход токеном из ссылки в письме
+func (r *Routes) loginWithLink(c *gin.Context) {
+	var req loginLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	appID, ok := requestAppID(c, req.AppID)
+	if !ok {
+		return
+	}
+
+	tokens, err := r.authService.LoginWithLink(loginContext(c), req.Token, appID)
+	if err != nil {
+		writeLoginError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newTokensResponse(tokens))
+}
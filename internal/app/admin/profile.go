@@ -0,0 +1,104 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/gin-gonic/gin"
+)
+
+type updateProfileRequest struct {
+	Email       *string        `json:"email"`
+	DisplayName *string        `json:"display_name"`
+	Locale      *string        `json:"locale"`
+	Attributes  map[string]any `json:"attributes"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type profileResponse struct {
+	Email         string         `json:"email,omitempty"`
+	EmailVerified bool           `json:"email_verified"`
+	DisplayName   string         `json:"display_name,omitempty"`
+	Locale        string         `json:"locale,omitempty"`
+	Attributes    map[string]any `json:"attributes"`
+	UpdatedAt     string         `json:"updated_at,omitempty"`
+}
+
+func newProfileResponse(profile models.Profile) profileResponse {
+	resp := profileResponse{
+		Email:         profile.Email,
+		EmailVerified: profile.EmailVerified,
+		DisplayName:   profile.DisplayName,
+		Locale:        profile.Locale,
+		Attributes:    profile.Attributes,
+	}
+	if resp.Attributes == nil {
+		resp.Attributes = map[string]any{}
+	}
+	if !profile.UpdatedAt.IsZero() {
+		resp.UpdatedAt = profile.UpdatedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// getOwnProfile возвращает профиль вызывающего пользователя
+func (r *Routes) getOwnProfile(c *gin.Context) {
+	principal, _ := principalFromContext(c)
+	profile, err := r.accountService.GetProfile(c.Request.Context(), principal.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newProfileResponse(profile))
+}
+
+// updateOwnProfile изменяет переданные поля профиля. Новый email нужно подтвердить
+// по ссылке из письма, до этого по нему нельзя войти.
+func (r *Routes) updateOwnProfile(c *gin.Context) {
+	var req updateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	principal, _ := principalFromContext(c)
+	profile, err := r.accountService.UpdateProfile(c.Request.Context(), principal.UserID, models.ProfileUpdate{
+		Email:       req.Email,
+		DisplayName: req.DisplayName,
+		Locale:      req.Locale,
+		Attributes:  req.Attributes,
+	})
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newProfileResponse(profile))
+}
+
+func (r *Routes) sendEmailVerification(c *gin.Context) {
+	principal, _ := principalFromContext(c)
+	if err := r.accountService.SendEmailVerification(c.Request.Context(), principal.UserID); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "verification email sent"})
+}
+
+// verifyEmail подтверждает email по токену из письма, аутентификация не требуется
+func (r *Routes) verifyEmail(c *gin.Context) {
+	var req verifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if _, err := r.accountService.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
@@ -0,0 +1,266 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	adminS "github.com/Grino777/sso/internal/services/admin"
+	"github.com/gin-gonic/gin"
+)
+
+// roleService бизнес-логика ролей, разрешений и ролей пользователей в приложениях
+type roleService interface {
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	GetRole(ctx context.Context, roleID int) (models.Role, error)
+	CreateRole(ctx context.Context, name, description string, permissions []string) (models.Role, error)
+	SetRolePermissions(ctx context.Context, roleID int, permissions []string) (models.Role, error)
+	DeleteRole(ctx context.Context, roleID int) error
+	ListPermissions(ctx context.Context) ([]models.Permission, error)
+	CreatePermission(ctx context.Context, name, description string) (models.Permission, error)
+	DeletePermission(ctx context.Context, permissionID int) error
+	SetUserAppRole(ctx context.Context, actor adminS.Actor, userID uint64, appID uint32, roleID int) error
+	GetUserAccess(ctx context.Context, userID uint64, appID uint32) (models.Access, error)
+}
+
+type roleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type rolePermissionsRequest struct {
+	Permissions []string `json:"permissions"`
+}
+
+type permissionRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type userAppRoleRequest struct {
+	RoleID int `json:"role_id"`
+}
+
+type roleResponse struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Builtin     bool     `json:"builtin"`
+	Permissions []string `json:"permissions"`
+}
+
+func newRoleResponse(role models.Role) roleResponse {
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+	return roleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Builtin:     role.IsBuiltin(),
+		Permissions: permissions,
+	}
+}
+
+type permissionResponse struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (r *Routes) listRoles(c *gin.Context) {
+	roles, err := r.roleService.ListRoles(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	resp := make([]roleResponse, 0, len(roles))
+	for _, role := range roles {
+		resp = append(resp, newRoleResponse(role))
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": resp})
+}
+
+func (r *Routes) getRole(c *gin.Context) {
+	roleID, ok := intParam(c, "id", "invalid role id")
+	if !ok {
+		return
+	}
+
+	role, err := r.roleService.GetRole(c.Request.Context(), roleID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newRoleResponse(role))
+}
+
+func (r *Routes) createRole(c *gin.Context) {
+	var req roleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	role, err := r.roleService.CreateRole(c.Request.Context(), req.Name, req.Description, req.Permissions)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, newRoleResponse(role))
+}
+
+func (r *Routes) setRolePermissions(c *gin.Context) {
+	roleID, ok := intParam(c, "id", "invalid role id")
+	if !ok {
+		return
+	}
+
+	var req rolePermissionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	role, err := r.roleService.SetRolePermissions(c.Request.Context(), roleID, req.Permissions)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newRoleResponse(role))
+}
+
+func (r *Routes) deleteRole(c *gin.Context) {
+	roleID, ok := intParam(c, "id", "invalid role id")
+	if !ok {
+		return
+	}
+
+	if err := r.roleService.DeleteRole(c.Request.Context(), roleID); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "role deleted"})
+}
+
+func (r *Routes) listPermissions(c *gin.Context) {
+	permissions, err := r.roleService.ListPermissions(c.Request.Context())
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	resp := make([]permissionResponse, 0, len(permissions))
+	for _, permission := range permissions {
+		resp = append(resp, permissionResponse(permission))
+	}
+	c.JSON(http.StatusOK, gin.H{"permissions": resp})
+}
+
+func (r *Routes) createPermission(c *gin.Context) {
+	var req permissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	permission, err := r.roleService.CreatePermission(c.Request.Context(), req.Name, req.Description)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, permissionResponse(permission))
+}
+
+func (r *Routes) deletePermission(c *gin.Context) {
+	permissionID, ok := intParam(c, "id", "invalid permission id")
+	if !ok {
+		return
+	}
+
+	if err := r.roleService.DeletePermission(c.Request.Context(), permissionID); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "permission deleted"})
+}
+
+func (r *Routes) getUserAccess(c *gin.Context) {
+	userID, appID, ok := userAppParams(c)
+	if !ok {
+		return
+	}
+
+	access, err := r.roleService.GetUserAccess(c.Request.Context(), userID, appID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":     userID,
+		"app_id":      appID,
+		"roles":       access.Roles,
+		"permissions": access.Permissions,
+	})
+}
+
+func (r *Routes) setUserAppRole(c *gin.Context) {
+	userID, appID, ok := userAppParams(c)
+	if !ok {
+		return
+	}
+
+	var req userAppRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RoleID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	if err := r.roleService.SetUserAppRole(c.Request.Context(), actor(c), userID, appID, req.RoleID); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "app role assigned"})
+}
+
+func (r *Routes) removeUserAppRole(c *gin.Context) {
+	userID, appID, ok := userAppParams(c)
+	if !ok {
+		return
+	}
+
+	if err := r.roleService.SetUserAppRole(c.Request.Context(), actor(c), userID, appID, 0); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "app role removed"})
+}
+
+// userAppParams получает id пользователя и приложения из пути /users/:id/apps/:app_id
+func userAppParams(c *gin.Context) (uint64, uint32, bool) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return 0, 0, false
+	}
+
+	appID, err := strconv.ParseUint(c.Param("app_id"), 10, 32)
+	if err != nil || appID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid app id"})
+		return 0, 0, false
+	}
+	return userID, uint32(appID), true
+}
+
+// intParam получает положительный числовой параметр пути
+func intParam(c *gin.Context, name, message string) (int, bool) {
+	value, err := strconv.Atoi(c.Param(name))
+	if err != nil || value <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return 0, false
+	}
+	return value, true
+}
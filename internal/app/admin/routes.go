@@ -2,34 +2,101 @@ package admin
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 
+	"github.com/Grino777/sso/internal/domain/models"
 	"github.com/Grino777/sso/internal/services/keys/manager"
+	keysModels "github.com/Grino777/sso/internal/services/keys/models"
 	"github.com/gin-gonic/gin"
 )
 
 // keysStore интерфейс для работы с ключами
 type keysStore interface {
 	RotateKeys() (*manager.GenKeys, error)
+	GetPublicKey(kid string) (*keysModels.PublicKey, error)
 }
 
-// Route представляет маршрут для API
+// Route представляет маршрут для API.
+// role — минимальная роль вызывающего, 0 для публичных маршрутов.
+// app — маршрут входа, который вызывает приложение с подписью запроса (см. appauth.go).
+// account — маршрут самообслуживания, доступный пользователю любого приложения.
 type Route struct {
 	method  string
 	path    string
+	role    int
+	app     bool
+	account bool
 	handler gin.HandlerFunc
 }
 
+// adminService бизнес-логика административного API: интерфейсы, которые
+// маршруты администратора объявляют в своих файлах
+type adminService interface {
+	appService
+	certificateService
+	userService
+	sessionService
+	lockoutService
+	roleService
+	memberService
+	loginHistoryService
+	mfaAdminService
+	userProvider
+}
+
 // Routes представляет набор маршрутов для API
 type Routes struct {
-	keysStore keysStore
-	routes    []*Route
+	log                 *slog.Logger
+	keysStore           keysStore
+	appService          appService
+	certificateService  certificateService
+	userService         userService
+	sessionService      sessionService
+	lockoutService      lockoutService
+	roleService         roleService
+	memberService       memberService
+	loginHistoryService loginHistoryService
+	mfaAdminService     mfaAdminService
+	accountService      accountService
+	authService         authService
+	auth                *authenticator
+	apps                *AppAuth
+	routes              []*Route
 }
 
 // NewRoutes создает новый набор маршрутов
-func NewRoutes(keysStore keysStore) *Routes {
+func NewRoutes(
+	log *slog.Logger,
+	keysStore keysStore,
+	adminService adminService,
+	accountService accountService,
+	authService authService,
+	apps AppAuth,
+	bootstrapToken string,
+	appID uint32,
+) *Routes {
 	r := &Routes{
-		keysStore: keysStore,
+		log:                 log,
+		keysStore:           keysStore,
+		appService:          adminService,
+		certificateService:  adminService,
+		userService:         adminService,
+		sessionService:      adminService,
+		lockoutService:      adminService,
+		roleService:         adminService,
+		memberService:       adminService,
+		loginHistoryService: adminService,
+		mfaAdminService:     adminService,
+		accountService:      accountService,
+		authService:         authService,
+		auth: &authenticator{
+			keysStore:      keysStore,
+			users:          adminService,
+			bootstrapToken: bootstrapToken,
+			appID:          appID,
+		},
+		apps: &apps,
 	}
 	r.routes = r.initRoutes()
 	return r
@@ -38,7 +105,19 @@ func NewRoutes(keysStore keysStore) *Routes {
 // RegisterRoutes регистрирует маршруты в GIN-сервере
 func (r *Routes) RegisterRoutes(engine *gin.Engine) {
 	for _, route := range r.routes {
-		engine.Handle(route.method, route.path, route.handler)
+		if route.app {
+			engine.Handle(route.method, route.path, r.rateLimitIP, r.appAuth, r.rateLimitApp, route.handler)
+			continue
+		}
+		if route.account {
+			engine.Handle(route.method, route.path, r.audit, r.requireAccount, route.handler)
+			continue
+		}
+		if route.role == 0 {
+			engine.Handle(route.method, route.path, route.handler)
+			continue
+		}
+		engine.Handle(route.method, route.path, r.audit, r.requireRole(route.role), route.handler)
 	}
 }
 
@@ -50,11 +129,461 @@ func (r *Routes) initRoutes() []*Route {
 			path:    "/ping",
 			handler: r.ping,
 		},
+		{
+			method:  "POST",
+			path:    "/auth/mfa/verify",
+			app:     true,
+			handler: r.verifyMFA,
+		},
+		{
+			method:  "POST",
+			path:    "/auth/email/verify",
+			handler: r.verifyEmail,
+		},
+		{
+			method:  "POST",
+			path:    "/auth/passwordless",
+			app:     true,
+			handler: r.startPasswordless,
+		},
+		{
+			method:  "POST",
+			path:    "/auth/passwordless/code",
+			app:     true,
+			handler: r.loginWithCode,
+		},
+		{
+			method:  "POST",
+			path:    "/auth/passwordless/link",
+			app:     true,
+			handler: r.loginWithLink,
+		},
+		{
+			method:  "POST",
+			path:    "/auth/password/reset",
+			app:     true,
+			handler: r.startPasswordReset,
+		},
+		{
+			method:  "POST",
+			path:    "/auth/password/reset/confirm",
+			app:     true,
+			handler: r.confirmPasswordReset,
+		},
+		{
+			method:  "POST",
+			path:    "/auth/password/expired",
+			app:     true,
+			handler: r.changeExpiredPassword,
+		},
+		{
+			method:  "POST",
+			path:    "/auth/passkeys/options",
+			app:     true,
+			handler: r.passkeyLoginOptions,
+		},
+		{
+			method:  "POST",
+			path:    "/auth/passkeys/login",
+			app:     true,
+			handler: r.passkeyLogin,
+		},
 		{
 			method:  "POST",
 			path:    "/rotate-keys",
+			role:    models.RoleSuperAdmin,
 			handler: r.rotateKeys,
 		},
+		{
+			method:  "GET",
+			path:    "/apps",
+			role:    models.RoleAdmin,
+			handler: r.listApps,
+		},
+		{
+			method:  "POST",
+			path:    "/apps",
+			role:    models.RoleSuperAdmin,
+			handler: r.createApp,
+		},
+		{
+			method:  "GET",
+			path:    "/apps/:id",
+			role:    models.RoleAdmin,
+			handler: r.getApp,
+		},
+		{
+			method:  "PATCH",
+			path:    "/apps/:id",
+			role:    models.RoleSuperAdmin,
+			handler: r.updateApp,
+		},
+		{
+			method:  "DELETE",
+			path:    "/apps/:id",
+			role:    models.RoleSuperAdmin,
+			handler: r.deleteApp,
+		},
+		{
+			method:  "POST",
+			path:    "/apps/:id/rotate-secret",
+			role:    models.RoleSuperAdmin,
+			handler: r.rotateAppSecret,
+		},
+		{
+			method:  "GET",
+			path:    "/apps/:id/members",
+			role:    models.RoleAdmin,
+			handler: r.listAppMembers,
+		},
+		{
+			method:  "POST",
+			path:    "/apps/:id/members",
+			role:    models.RoleAdmin,
+			handler: r.addAppMember,
+		},
+		{
+			method:  "DELETE",
+			path:    "/apps/:id/members/:user_id",
+			role:    models.RoleAdmin,
+			handler: r.removeAppMember,
+		},
+		{
+			method:  "POST",
+			path:    "/apps/:id/members/:user_id/block",
+			role:    models.RoleAdmin,
+			handler: r.blockAppMember,
+		},
+		{
+			method:  "POST",
+			path:    "/apps/:id/members/:user_id/unblock",
+			role:    models.RoleAdmin,
+			handler: r.unblockAppMember,
+		},
+		{
+			method:  "GET",
+			path:    "/apps/:id/encryption-key",
+			role:    models.RoleAdmin,
+			handler: r.getAppEncryptionKey,
+		},
+		{
+			method:  "PUT",
+			path:    "/apps/:id/encryption-key",
+			role:    models.RoleSuperAdmin,
+			handler: r.setAppEncryptionKey,
+		},
+		{
+			method:  "DELETE",
+			path:    "/apps/:id/encryption-key",
+			role:    models.RoleSuperAdmin,
+			handler: r.disableAppEncryption,
+		},
+		{
+			method:  "GET",
+			path:    "/apps/:id/certificates",
+			role:    models.RoleAdmin,
+			handler: r.getAppCertificates,
+		},
+		{
+			method:  "POST",
+			path:    "/apps/:id/certificates",
+			role:    models.RoleSuperAdmin,
+			handler: r.addAppCertificate,
+		},
+		{
+			method:  "DELETE",
+			path:    "/apps/:id/certificates/:thumbprint",
+			role:    models.RoleSuperAdmin,
+			handler: r.deleteAppCertificate,
+		},
+		{
+			method:  "GET",
+			path:    "/users",
+			role:    models.RoleAdmin,
+			handler: r.listUsers,
+		},
+		{
+			method:  "POST",
+			path:    "/users",
+			role:    models.RoleAdmin,
+			handler: r.createUser,
+		},
+		{
+			method:  "GET",
+			path:    "/users/:id",
+			role:    models.RoleAdmin,
+			handler: r.getUser,
+		},
+		{
+			method:  "PATCH",
+			path:    "/users/:id",
+			role:    models.RoleAdmin,
+			handler: r.updateUser,
+		},
+		{
+			method:  "POST",
+			path:    "/users/:id/disable",
+			role:    models.RoleAdmin,
+			handler: r.disableUser,
+		},
+		{
+			method:  "POST",
+			path:    "/users/:id/enable",
+			role:    models.RoleAdmin,
+			handler: r.enableUser,
+		},
+		{
+			method:  "POST",
+			path:    "/users/:id/unlock",
+			role:    models.RoleAdmin,
+			handler: r.unlockUser,
+		},
+		{
+			method:  "POST",
+			path:    "/users/:id/reset-password",
+			role:    models.RoleAdmin,
+			handler: r.resetPassword,
+		},
+		{
+			method:  "DELETE",
+			path:    "/users/:id",
+			role:    models.RoleSuperAdmin,
+			handler: r.deleteUser,
+		},
+		{
+			method:  "DELETE",
+			path:    "/users/:id/mfa",
+			role:    models.RoleAdmin,
+			handler: r.resetUserMFA,
+		},
+		{
+			method:  "GET",
+			path:    "/users/:id/logins",
+			role:    models.RoleAdmin,
+			handler: r.listUserLogins,
+		},
+		{
+			method:  "GET",
+			path:    "/users/:id/sessions",
+			role:    models.RoleAdmin,
+			handler: r.listUserSessions,
+		},
+		{
+			method:  "DELETE",
+			path:    "/users/:id/sessions",
+			role:    models.RoleAdmin,
+			handler: r.revokeUserSessions,
+		},
+		{
+			method:  "DELETE",
+			path:    "/users/:id/sessions/:session_id",
+			role:    models.RoleAdmin,
+			handler: r.revokeUserSession,
+		},
+		{
+			method:  "GET",
+			path:    "/users/:id/offline-grants",
+			role:    models.RoleAdmin,
+			handler: r.listUserOfflineGrants,
+		},
+		{
+			method:  "DELETE",
+			path:    "/users/:id/offline-grants",
+			role:    models.RoleAdmin,
+			handler: r.revokeUserOfflineGrants,
+		},
+		{
+			method:  "GET",
+			path:    "/lockouts/users/:username",
+			role:    models.RoleAdmin,
+			handler: r.getUserLockout,
+		},
+		{
+			method:  "DELETE",
+			path:    "/lockouts/users/:username",
+			role:    models.RoleAdmin,
+			handler: r.clearUserLockout,
+		},
+		{
+			method:  "GET",
+			path:    "/lockouts/ips/:ip",
+			role:    models.RoleAdmin,
+			handler: r.getIPLockout,
+		},
+		{
+			method:  "DELETE",
+			path:    "/lockouts/ips/:ip",
+			role:    models.RoleAdmin,
+			handler: r.clearIPLockout,
+		},
+		{
+			method:  "GET",
+			path:    "/account/logins",
+			account: true,
+			handler: r.listOwnLogins,
+		},
+		{
+			method:  "GET",
+			path:    "/account/sessions",
+			account: true,
+			handler: r.listOwnSessions,
+		},
+		{
+			method:  "DELETE",
+			path:    "/account/sessions",
+			account: true,
+			handler: r.revokeOtherSessions,
+		},
+		{
+			method:  "DELETE",
+			path:    "/account/sessions/:id",
+			account: true,
+			handler: r.revokeOwnSession,
+		},
+		{
+			method:  "GET",
+			path:    "/account/mfa",
+			account: true,
+			handler: r.getOwnMFA,
+		},
+		{
+			method:  "POST",
+			path:    "/account/mfa/totp",
+			account: true,
+			handler: r.enrollTOTP,
+		},
+		{
+			method:  "POST",
+			path:    "/account/mfa/totp/confirm",
+			account: true,
+			handler: r.confirmTOTP,
+		},
+		{
+			method:  "POST",
+			path:    "/account/mfa/recovery-codes",
+			account: true,
+			handler: r.regenerateRecoveryCodes,
+		},
+		{
+			method:  "POST",
+			path:    "/account/mfa/disable",
+			account: true,
+			handler: r.disableOwnMFA,
+		},
+		{
+			method:  "GET",
+			path:    "/account/profile",
+			account: true,
+			handler: r.getOwnProfile,
+		},
+		{
+			method:  "PATCH",
+			path:    "/account/profile",
+			account: true,
+			handler: r.updateOwnProfile,
+		},
+		{
+			method:  "POST",
+			path:    "/account/profile/email/verification",
+			account: true,
+			handler: r.sendEmailVerification,
+		},
+		{
+			method:  "POST",
+			path:    "/account/password",
+			account: true,
+			handler: r.changeOwnPassword,
+		},
+		{
+			method:  "GET",
+			path:    "/account/passkeys",
+			account: true,
+			handler: r.listOwnPasskeys,
+		},
+		{
+			method:  "POST",
+			path:    "/account/passkeys/options",
+			account: true,
+			handler: r.passkeyRegistrationOptions,
+		},
+		{
+			method:  "POST",
+			path:    "/account/passkeys",
+			account: true,
+			handler: r.registerPasskey,
+		},
+		{
+			method:  "DELETE",
+			path:    "/account/passkeys/:id",
+			account: true,
+			handler: r.deleteOwnPasskey,
+		},
+		{
+			method:  "GET",
+			path:    "/roles",
+			role:    models.RoleAdmin,
+			handler: r.listRoles,
+		},
+		{
+			method:  "POST",
+			path:    "/roles",
+			role:    models.RoleSuperAdmin,
+			handler: r.createRole,
+		},
+		{
+			method:  "GET",
+			path:    "/roles/:id",
+			role:    models.RoleAdmin,
+			handler: r.getRole,
+		},
+		{
+			method:  "PUT",
+			path:    "/roles/:id/permissions",
+			role:    models.RoleSuperAdmin,
+			handler: r.setRolePermissions,
+		},
+		{
+			method:  "DELETE",
+			path:    "/roles/:id",
+			role:    models.RoleSuperAdmin,
+			handler: r.deleteRole,
+		},
+		{
+			method:  "GET",
+			path:    "/permissions",
+			role:    models.RoleAdmin,
+			handler: r.listPermissions,
+		},
+		{
+			method:  "POST",
+			path:    "/permissions",
+			role:    models.RoleSuperAdmin,
+			handler: r.createPermission,
+		},
+		{
+			method:  "DELETE",
+			path:    "/permissions/:id",
+			role:    models.RoleSuperAdmin,
+			handler: r.deletePermission,
+		},
+		{
+			method:  "GET",
+			path:    "/users/:id/apps/:app_id/access",
+			role:    models.RoleAdmin,
+			handler: r.getUserAccess,
+		},
+		{
+			method:  "PUT",
+			path:    "/users/:id/apps/:app_id/role",
+			role:    models.RoleAdmin,
+			handler: r.setUserAppRole,
+		},
+		{
+			method:  "DELETE",
+			path:    "/users/:id/apps/:app_id/role",
+			role:    models.RoleAdmin,
+			handler: r.removeUserAppRole,
+		},
 	}
 }
 
@@ -68,6 +597,7 @@ func (r *Routes) rotateKeys(c *gin.Context) {
 	if _, err := r.keysStore.RotateKeys(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("failed to rotate keys: %v", err)})
+		return
 	}
 	c.JSON(200, gin.H{"message": "keys rotated"})
 }
@@ -2,11 +2,11 @@ package admin
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
-	"path/filepath"
 	"time"
 
 	"github.com/Grino777/sso/internal/config"
@@ -16,11 +16,6 @@ import (
 
 const opAdmin = "app.admin."
 
-type Certificate struct {
-	certPath string
-	keyPath  string
-}
-
 type APIServer struct {
 	Logger *slog.Logger
 	Router *gin.Engine
@@ -29,9 +24,23 @@ type APIServer struct {
 	Config config.ApiServerConfig
 }
 
-// NewApiServer создает новый экземпляр APIServer
-func NewApiServer(log *slog.Logger, cfg config.ApiServerConfig, keysStore keysStore) *APIServer {
+// NewApiServer создает новый экземпляр APIServer.
+// Адрес клиента (c.ClientIP) берется из X-Forwarded-For только за доверенными прокси.
+func NewApiServer(
+	log *slog.Logger,
+	cfg config.ApiServerConfig,
+	apps AppAuth,
+	keysStore keysStore,
+	adminService adminService,
+	accountService accountService,
+	authService authService,
+) (*APIServer, error) {
+	const op = opAdmin + "NewApiServer"
+
 	engine := gin.New()
+	if err := engine.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("%s: invalid trusted proxies: %w", op, err)
+	}
 	engine.Use(gin.LoggerWithConfig(gin.LoggerConfig{
 		Formatter: func(param gin.LogFormatterParams) string {
 			log.Info("Gin request",
@@ -51,7 +60,14 @@ func NewApiServer(log *slog.Logger, cfg config.ApiServerConfig, keysStore keysSt
 		Handler: engine,
 	}
 
-	routes := NewRoutes(keysStore)
+	if cfg.BootstrapToken != "" {
+		log.Warn("admin api bootstrap token is enabled")
+	}
+	if cfg.AppID == 0 {
+		log.Warn("admin api app_id is not set, access tokens are rejected")
+	}
+
+	routes := NewRoutes(log, keysStore, adminService, accountService, authService, apps, cfg.BootstrapToken, cfg.AppID)
 	routes.RegisterRoutes(engine)
 
 	return &APIServer{
@@ -59,7 +75,7 @@ func NewApiServer(log *slog.Logger, cfg config.ApiServerConfig, keysStore keysSt
 		Router: engine,
 		Server: server,
 		Config: cfg,
-	}
+	}, nil
 }
 
 // Run запускает сервер
@@ -68,13 +84,15 @@ func (as *APIServer) Run(ctx context.Context) error {
 
 	errChan := make(chan error, 1)
 
-	cert, err := as.init()
+	tlsConfig, err := as.init()
 	if err != nil {
 		return err
 	}
+	as.Server.TLSConfig = tlsConfig
 
 	go func() {
-		if err := as.Server.ListenAndServeTLS(cert.certPath, cert.keyPath); err != nil && err != http.ErrServerClosed {
+		// Сертификат отдается через TLSConfig.GetCertificate, поэтому пути не передаются
+		if err := as.Server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
 			errChan <- fmt.Errorf("%s: failed to starting api server: %w", op, err)
 		}
 	}()
@@ -116,32 +134,28 @@ func (as *APIServer) Stop() error {
 	return nil
 }
 
-// init Проверяет существование директории и файлов сертификата
-// и создает сертификаты если их нет
-func (as *APIServer) init() (Certificate, error) {
+// init загружает сертификат сервера (пользовательский или самоподписанный из CertsDir)
+// и возвращает TLS конфигурацию, перечитывающую сертификат при его ротации
+func (as *APIServer) init() (*tls.Config, error) {
 	const op = opAdmin + "init"
 
-	var certObj Certificate
+	cfg := as.Config.TLS
 
-	if err := certs.CheckCertsFolder(as.Config.CertsDir); err != nil {
-		return certObj, fmt.Errorf("%s: failed to checking certificate folder: %w", op, err)
+	reloader, err := certs.NewServerReloader(
+		cfg.CertFile,
+		cfg.KeyFile,
+		cfg.CertsDir,
+		certs.Options{Hosts: cfg.Hosts, KeyType: cfg.KeyType, Validity: cfg.Validity},
+		cfg.ReloadInterval,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to load certificate: %w", op, err)
 	}
 
-	expired, err := certs.CheckCertificate(as.Config.CertsDir)
+	tlsConfig, err := certs.NewServerTLSConfig(reloader, "", tls.NoClientCert)
 	if err != nil {
-		return certObj, fmt.Errorf("%s: failed to checking certificate: %w", op, err)
-	}
-	if expired {
-		if err := certs.CreateCertsFiles(as.Config.CertsDir); err != nil {
-			return certObj, fmt.Errorf("%s: failed to creating certificate: %w", op, err)
-		}
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	certPath := filepath.Join(as.Config.CertsDir, "cert.pem")
-	keyPath := filepath.Join(as.Config.CertsDir, "key.pem")
-
-	certObj.certPath = certPath
-	certObj.keyPath = keyPath
-
-	return certObj, nil
+	return tlsConfig, nil
 }
@@ -0,0 +1,156 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	adminS "github.com/Grino777/sso/internal/services/admin"
+	"github.com/gin-gonic/gin"
+)
+
+// sessionService бизнес-логика управления сеансами пользователей
+type sessionService interface {
+	ListUserSessions(ctx context.Context, userID uint64, offlineOnly bool) ([]models.Session, error)
+	RevokeUserSession(ctx context.Context, actor adminS.Actor, userID uint64, sessionID string) error
+	RevokeUserSessions(ctx context.Context, actor adminS.Actor, userID uint64) (int64, error)
+	RevokeUserOfflineGrants(ctx context.Context, actor adminS.Actor, userID uint64) (int64, error)
+}
+
+type sessionResponse struct {
+	ID         string `json:"id"`
+	AppID      uint32 `json:"app_id"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"user_agent,omitempty"`
+	DeviceName string `json:"device_name,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Offline    bool   `json:"offline"`
+	// Сеанс, которому выдан access токен вызывающего
+	Current bool `json:"current,omitempty"`
+}
+
+func newSessionsResponse(sessions []models.Session, current string) gin.H {
+	resp := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, sessionResponse{
+			ID:         session.ID,
+			AppID:      session.AppID,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			DeviceName: session.DeviceName,
+			CreatedAt:  session.CreatedAt.Format(time.RFC3339),
+			LastUsedAt: session.LastUsedAt.Format(time.RFC3339),
+			ExpiresAt:  session.ExpiresAt.Format(time.RFC3339),
+			Offline:    session.Offline,
+			Current:    current != "" && session.ID == current,
+		})
+	}
+	return gin.H{"sessions": resp}
+}
+
+// listOwnSessions возвращает сеансы вызывающего пользователя
+func (r *Routes) listOwnSessions(c *gin.Context) {
+	principal, _ := principalFromContext(c)
+	sessions, err := r.accountService.ListSessions(c.Request.Context(), principal.UserID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newSessionsResponse(sessions, principal.SessionID))
+}
+
+// revokeOwnSession отзывает сеанс вызывающего пользователя
+func (r *Routes) revokeOwnSession(c *gin.Context) {
+	principal, _ := principalFromContext(c)
+	if err := r.accountService.RevokeSession(c.Request.Context(), principal.UserID, c.Param("id")); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
+
+// revokeOtherSessions отзывает все сеансы вызывающего пользователя, кроме текущего
+func (r *Routes) revokeOtherSessions(c *gin.Context) {
+	principal, _ := principalFromContext(c)
+	revoked, err := r.accountService.RevokeOtherSessions(c.Request.Context(), principal.UserID, principal.SessionID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "sessions revoked", "revoked": revoked})
+}
+
+// listUserSessions возвращает сеансы пользователя для администратора
+func (r *Routes) listUserSessions(c *gin.Context) {
+	r.writeUserSessions(c, false)
+}
+
+// listUserOfflineGrants возвращает offline сеансы пользователя
+func (r *Routes) listUserOfflineGrants(c *gin.Context) {
+	r.writeUserSessions(c, true)
+}
+
+func (r *Routes) writeUserSessions(c *gin.Context, offlineOnly bool) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := r.sessionService.ListUserSessions(c.Request.Context(), userID, offlineOnly)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	principal, _ := principalFromContext(c)
+	current := ""
+	if principal.UserID == userID {
+		current = principal.SessionID
+	}
+	c.JSON(http.StatusOK, newSessionsResponse(sessions, current))
+}
+
+func (r *Routes) revokeUserSession(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := r.sessionService.RevokeUserSession(c.Request.Context(), actor(c), userID, c.Param("session_id")); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked"})
+}
+
+func (r *Routes) revokeUserSessions(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := r.sessionService.RevokeUserSessions(c.Request.Context(), actor(c), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "sessions revoked", "revoked": revoked})
+}
+
+// revokeUserOfflineGrants отзывает offline сеансы пользователя, не затрагивая обычные
+func (r *Routes) revokeUserOfflineGrants(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := r.sessionService.RevokeUserOfflineGrants(c.Request.Context(), actor(c), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "offline grants revoked", "revoked": revoked})
+}
@@ -0,0 +1,295 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	adminS "github.com/Grino777/sso/internal/services/admin"
+	"github.com/gin-gonic/gin"
+)
+
+// userService бизнес-логика управления пользователями
+type userService interface {
+	ListUsers(ctx context.Context, filter models.UserFilter) ([]models.User, int, error)
+	GetUser(ctx context.Context, userID uint64) (models.User, error)
+	CreateUser(ctx context.Context, actor adminS.Actor, username, password string, roleID int) (models.User, error)
+	UpdateUser(ctx context.Context, actor adminS.Actor, userID uint64, update adminS.UserUpdate) (models.User, error)
+	SetUserDisabled(ctx context.Context, actor adminS.Actor, userID uint64, disabled bool) error
+	UnlockUser(ctx context.Context, actor adminS.Actor, userID uint64) error
+	ResetPassword(ctx context.Context, actor adminS.Actor, userID uint64, password string) (string, error)
+	DeleteUser(ctx context.Context, actor adminS.Actor, userID uint64) error
+}
+
+type createUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	RoleID   int    `json:"role_id"`
+}
+
+type updateUserRequest struct {
+	Username *string `json:"username"`
+	RoleID   *int    `json:"role_id"`
+}
+
+type resetPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+type userResponse struct {
+	ID          uint64 `json:"id"`
+	Username    string `json:"username"`
+	RoleID      int    `json:"role_id"`
+	Role        string `json:"role"`
+	Disabled    bool   `json:"disabled"`
+	LockedUntil string `json:"locked_until,omitempty"`
+	CreatedAt   string `json:"created_at,omitempty"`
+}
+
+func newUserResponse(user models.User) userResponse {
+	resp := userResponse{
+		ID:       user.ID,
+		Username: user.Username,
+		RoleID:   user.Role_id,
+		Role:     models.RoleName(user.Role_id),
+		Disabled: user.Disabled,
+	}
+	if user.IsLocked(time.Now()) {
+		resp.LockedUntil = user.LockedUntil.Format(time.RFC3339)
+	}
+	if !user.CreatedAt.IsZero() {
+		resp.CreatedAt = user.CreatedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+func (r *Routes) listUsers(c *gin.Context) {
+	filter, ok := userFilterQuery(c)
+	if !ok {
+		return
+	}
+
+	users, total, err := r.userService.ListUsers(c.Request.Context(), filter)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	resp := make([]userResponse, 0, len(users))
+	for _, user := range users {
+		resp = append(resp, newUserResponse(user))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"users":  resp,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
+func (r *Routes) getUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	user, err := r.userService.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+func (r *Routes) createUser(c *gin.Context) {
+	var req createUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	user, err := r.userService.CreateUser(c.Request.Context(), actor(c), req.Username, req.Password, req.RoleID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, newUserResponse(user))
+}
+
+func (r *Routes) updateUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req updateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	update := adminS.UserUpdate{Username: req.Username, RoleID: req.RoleID}
+	user, err := r.userService.UpdateUser(c.Request.Context(), actor(c), userID, update)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+func (r *Routes) disableUser(c *gin.Context) {
+	r.setUserDisabled(c, true)
+}
+
+func (r *Routes) enableUser(c *gin.Context) {
+	r.setUserDisabled(c, false)
+}
+
+func (r *Routes) setUserDisabled(c *gin.Context, disabled bool) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := r.userService.SetUserDisabled(c.Request.Context(), actor(c), userID, disabled); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	message := "user enabled"
+	if disabled {
+		message = "user disabled"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+func (r *Routes) unlockUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := r.userService.UnlockUser(c.Request.Context(), actor(c), userID); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "user unlocked"})
+}
+
+// resetPassword устанавливает переданный пароль или генерирует временный.
+// Сгенерированный пароль возвращается только в этом ответе.
+func (r *Routes) resetPassword(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req resetPasswordRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+			return
+		}
+	}
+
+	generated, err := r.userService.ResetPassword(c.Request.Context(), actor(c), userID, req.Password)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	resp := gin.H{"message": "password reset"}
+	if generated != "" {
+		resp["temporary_password"] = generated
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (r *Routes) deleteUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := r.userService.DeleteUser(c.Request.Context(), actor(c), userID); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "user deleted"})
+}
+
+// userIDParam получает id пользователя из пути запроса
+func userIDParam(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// userFilterQuery разбирает параметры выборки: limit, offset, username, role, disabled
+func userFilterQuery(c *gin.Context) (models.UserFilter, bool) {
+	filter := models.UserFilter{
+		Limit:    adminS.DefaultPageSize,
+		Username: c.Query("username"),
+	}
+
+	invalid := func(param string) (models.UserFilter, bool) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid query parameter " + param})
+		return filter, false
+	}
+
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return invalid("limit")
+		}
+		filter.Limit = min(limit, adminS.MaxPageSize)
+	}
+	if v := c.Query("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return invalid("offset")
+		}
+		filter.Offset = offset
+	}
+	if v := c.Query("role"); v != "" {
+		roleID, ok := parseRole(v)
+		if !ok {
+			return invalid("role")
+		}
+		filter.RoleID = roleID
+	}
+	if v := c.Query("disabled"); v != "" {
+		disabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return invalid("disabled")
+		}
+		filter.Disabled = &disabled
+	}
+
+	return filter, true
+}
+
+// parseRole принимает id или имя роли
+func parseRole(value string) (int, bool) {
+	for _, roleID := range []int{models.RoleUser, models.RoleAdmin, models.RoleSuperAdmin} {
+		if value == models.RoleName(roleID) || value == strconv.Itoa(roleID) {
+			return roleID, true
+		}
+	}
+	return 0, false
+}
+
+// actor возвращает администратора, выполняющего запрос
+func actor(c *gin.Context) adminS.Actor {
+	principal, _ := principalFromContext(c)
+	return adminS.Actor{
+		UserID:   principal.UserID,
+		Username: principal.Username,
+		RoleID:   principal.RoleID,
+	}
+}
@@ -9,9 +9,15 @@ import (
 	"github.com/Grino777/sso/internal/config"
 	storageI "github.com/Grino777/sso/internal/interfaces/storage"
 	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/lib/passhash"
+	"github.com/Grino777/sso/internal/lib/secretbox"
 	"github.com/Grino777/sso/internal/services/auth"
 	"github.com/Grino777/sso/internal/services/jwks"
 	"github.com/Grino777/sso/internal/services/keys/store"
+	"github.com/Grino777/sso/internal/services/mfa"
+	"github.com/Grino777/sso/internal/services/passkeys"
+	"github.com/Grino777/sso/internal/services/password"
+	"github.com/Grino777/sso/internal/services/profile"
 )
 
 const opApp = "app."
@@ -43,6 +49,9 @@ type Storages struct {
 type Internal struct {
 	errChan chan error
 	cancel  context.CancelFunc
+	logins  *auth.LoginRecorder
+	hasher  *passhash.Hasher
+	secrets *secretbox.Box
 }
 
 type SSOApp struct {
@@ -54,8 +63,12 @@ type SSOApp struct {
 }
 
 type GrpcServices struct {
-	jwksService *jwks.JwksService
-	authService *auth.AuthService
+	jwksService     *jwks.JwksService
+	authService     *auth.AuthService
+	mfaService      *mfa.MFAService
+	passkeyService  *passkeys.PasskeyService
+	profileService  *profile.ProfileService
+	passwordService *password.PasswordService
 }
 
 func (s *GrpcServices) Auth() *auth.AuthService {
@@ -87,11 +100,28 @@ func NewApp(
 		return nil, err
 	}
 
-	app.initDB()
+	if err := app.initHasher(); err != nil {
+		log.Error("failed to init password hasher", logger.Error(err))
+		return nil, err
+	}
+	if err := app.initSecrets(); err != nil {
+		log.Error("failed to init app secrets encryption", logger.Error(err))
+		return nil, err
+	}
+	if err := app.initDB(); err != nil {
+		log.Error("failed to init db", logger.Error(err))
+		return nil, err
+	}
 	app.initCache()
 	services := app.initServices(keysStore)
-	app.initGRPCApp(services, keysStore)
-	app.initApiServer(keysStore)
+	if err := app.initGRPCApp(services, keysStore); err != nil {
+		log.Error("failed to init grpc server", logger.Error(err))
+		return nil, err
+	}
+	if err := app.initApiServer(keysStore, services); err != nil {
+		log.Error("failed to init api server", logger.Error(err))
+		return nil, err
+	}
 
 	return app, nil
 }
@@ -107,6 +137,13 @@ func (a *SSOApp) Run(mainErrChan chan error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	a.internal.cancel = cancel
 
+	// Журнал входов запускается первым: Stop ждет его завершения
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.internal.logins.Run(ctx)
+	}()
+
 	if err := a.Storages.Db.Connect(ctx); err != nil {
 		log.Error("failed to connect database")
 		errChan <- err
@@ -157,6 +194,9 @@ func (a *SSOApp) Stop() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// Дописываем журнал входов до закрытия БД
+	a.internal.logins.Wait(ctx)
+
 	if err := db.Close(ctx); err != nil {
 		log.Error("failed to close db session", logger.Error(err))
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/Grino777/sso/internal/config"
 	grpcauth "github.com/Grino777/sso/internal/delivery/grpc/auth"
 	grpcjwks "github.com/Grino777/sso/internal/delivery/grpc/jwks"
+	"github.com/Grino777/sso/internal/lib/clientip"
 	"github.com/Grino777/sso/internal/lib/logger"
 	"github.com/Grino777/sso/internal/services/auth"
 	"github.com/Grino777/sso/internal/services/jwks"
@@ -44,7 +45,8 @@ func NewGrpcApp(
 	log *slog.Logger,
 	services Services,
 	cfg *config.Config,
-) *GRPCApp {
+) (*GRPCApp, error) {
+	const op = opGrpc + "NewGrpcApp"
 
 	logger := log.With(slog.String("port", fmt.Sprint(cfg.GRPC.Port)))
 
@@ -60,11 +62,39 @@ func NewGrpcApp(
 		}),
 	}
 
-	gRPCServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
-		recovery.UnaryServerInterceptor(recoverOptions...),
-		logging.UnaryServerInterceptor(InterceptorLogger(log), loggingOpts...),
-		HMACInterceptor(log, services, cfg.Mode),
-	))
+	proxies, err := clientip.NewResolver(cfg.GRPC.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	limiter, err := NewRateLimiter(cfg.RateLimit, services)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Лимит по адресу проверяется до HMAC, чтобы перебор подписей тоже ограничивался,
+	// а лимит приложения — после, чтобы чужой app_id не расходовал его токены
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			recovery.UnaryServerInterceptor(recoverOptions...),
+			RequestInfoInterceptor(cfg.DPoP, proxies),
+			logging.UnaryServerInterceptor(InterceptorLogger(log), loggingOpts...),
+			RateLimitInterceptor(log, limiter, cfg.RateLimit, RateLimitByIP),
+			HMACInterceptor(log, services, cfg.Mode, cfg.HMAC),
+			RateLimitInterceptor(log, limiter, cfg.RateLimit, RateLimitByApp),
+		),
+	}
+
+	if cfg.GRPC.TLS.Enabled {
+		creds, err := serverCredentials(cfg.GRPC.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+		logger.Debug("grpc tls enabled", slog.String("client_auth", cfg.GRPC.TLS.ClientAuth))
+	}
+
+	gRPCServer := grpc.NewServer(serverOpts...)
 
 	grpcauth.RegServer(gRPCServer, services.Auth())
 	grpcjwks.RegService(gRPCServer, services.Jwks())
@@ -74,7 +104,7 @@ func NewGrpcApp(
 		gRPCServer: gRPCServer,
 		port:       int(cfg.GRPC.Port),
 		mode:       cfg.Mode,
-	}
+	}, nil
 }
 
 // Run запускает GRPC-сервер
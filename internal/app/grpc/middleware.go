@@ -2,29 +2,36 @@ package grpc
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"database/sql"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	sso_v1 "github.com/Grino777/sso-proto/gen/go/sso"
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/appsign"
+	"github.com/Grino777/sso/internal/lib/clientip"
 	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/lib/reqctx"
+	"github.com/Grino777/sso/internal/utils/certs"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
 var (
 	errAppSecret        = errors.New("secret key not found")
-	errInvalidTimestamp = errors.New("invalid timestamp")
+	errInvalidTimestamp = appsign.ErrInvalidTimestamp
 	errInvalidArgs      = status.Error(codes.Unauthenticated, "invalid or missing authorization")
 )
 
@@ -33,6 +40,10 @@ type ReqMetadata struct {
 	appID     uint64
 	timestamp string
 	secret    string
+	// Поля подписи v2
+	method  string
+	nonce   string
+	headers string
 }
 
 // InterceptorLogger adapts slog logger to interceptor logger.
@@ -43,11 +54,89 @@ func InterceptorLogger(l *slog.Logger) logging.Logger {
 	})
 }
 
-// HMACInterceptor проверяет HMAC-токен в заголовке запроса
+// RequestInfoInterceptor сохраняет в контексте данные запроса,
+// необходимые бизнес-логике (DPoP proof, метод и URL запроса, адрес клиента,
+// имя устройства и запрос offline доступа).
+// Адрес клиента берется из x-forwarded-for, только если соединение установлено доверенным прокси.
+// htu DPoP proof сравнивается с base_url + полное имя метода; base_url обязателен
+// при включенном DPoP и проверяется при загрузке конфигурации.
+func RequestInfoInterceptor(dpopCfg config.DPoPConfig, proxies *clientip.Resolver) grpc.UnaryServerInterceptor {
+	baseURL := strings.TrimSuffix(dpopCfg.BaseURL, "/")
+
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		reqInfo := reqctx.Info{
+			Method: http.MethodPost,
+			URL:    baseURL + info.FullMethod,
+		}
+
+		var forwardedFor []string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("dpop"); len(values) == 1 {
+				reqInfo.DPoP = values[0]
+			}
+			if values := md.Get("user-agent"); len(values) > 0 {
+				reqInfo.UserAgent = values[0]
+			}
+			if values := md.Get("x-device-name"); len(values) > 0 {
+				reqInfo.DeviceName = models.NormalizeDeviceName(values[0])
+			}
+			var rememberMe string
+			if values := md.Get("x-remember-me"); len(values) > 0 {
+				rememberMe = values[0]
+			}
+			reqInfo.OfflineAccess = models.OfflineAccessRequested(strings.Join(md.Get("x-scope"), " "), rememberMe)
+			forwardedFor = md.Get("x-forwarded-for")
+		}
+		if remote := peerAddr(ctx); remote != "" {
+			reqInfo.ClientIP = proxies.ClientIP(remote, forwardedFor)
+		}
+		reqInfo.CertThumbprint = peerCertThumbprint(ctx)
+
+		return handler(reqctx.WithInfo(ctx, reqInfo), req)
+	}
+}
+
+// peerAddr возвращает IP адрес соединения клиента
+func peerAddr(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// peerCertThumbprint возвращает отпечаток клиентского сертификата,
+// если он был предъявлен и проверен при TLS рукопожатии
+func peerCertThumbprint(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.PeerCertificates) == 0 {
+		return ""
+	}
+
+	return certs.Thumbprint(tlsInfo.State.PeerCertificates[0])
+}
+
+// HMACInterceptor проверяет HMAC-подпись запроса (v1 или v2, см. signature.go).
+// Приложения, предъявившие зарегистрированный клиентский сертификат, HMAC не передают.
 func HMACInterceptor(
 	log *slog.Logger,
 	services Services,
 	mode string,
+	cfg config.HMACConfig,
 ) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
@@ -63,22 +152,34 @@ func HMACInterceptor(
 		case "local":
 			return handler(ctx, req)
 		default:
-			return validateHMAC(ctx, log, req, services, handler)
+			return validateHMAC(ctx, log, req, info.FullMethod, services, cfg, handler)
 		}
 	}
 }
 
-// validateHMAC проверяет HMAC-токен в заголовке запроса
+// validateHMAC проверяет HMAC-подпись в заголовке запроса
 func validateHMAC(ctx context.Context,
 	log *slog.Logger,
 	req any,
+	method string,
 	services Services,
+	cfg config.HMACConfig,
 	handler grpc.UnaryHandler,
 ) (any, error) {
 	const op = opGrpc + "validateHMAC"
 
 	log = log.With("op", op)
 
+	rm, err := extractMetadata(req)
+	if err != nil {
+		log.Error("failed to extract metadata", logger.Error(err))
+		return nil, err
+	}
+
+	if validateClientCert(ctx, rm, services) {
+		return handler(ctx, req)
+	}
+
 	md, exist := metadata.FromIncomingContext(ctx)
 	if !exist {
 		log.Error("metadata is empty")
@@ -91,23 +192,39 @@ func validateHMAC(ctx context.Context,
 		return nil, status.Error(codes.Unauthenticated, err.Error())
 	}
 
-	rm, err := extractMetadata(req)
-	if err != nil {
-		log.Error("failed to extract metadata", logger.Error(err))
-		return nil, err
-	}
-
 	rm.secret = secret
 	log = log.With(slog.Uint64("app_id", rm.appID))
 
-	valid, err := validateSecret(rm, services)
+	var valid bool
+	switch version := mdValue(md, signatureVersionHeader); version {
+	case "", signatureV1:
+		if !cfg.V1Accepted(time.Now()) {
+			log.Warn("hmac v1 signature rejected")
+			return nil, status.Error(codes.Unauthenticated, "signature version v1 is no longer accepted")
+		}
+		log.Warn("deprecated hmac v1 signature")
+		valid, err = validateSecret(rm, services, cfg)
+	case signatureV2:
+		rm.method = method
+		rm.nonce = mdValue(md, signatureNonceHeader)
+		rm.headers = appsign.CanonicalHeaders(md.Get)
+		valid, err = validateSignatureV2(ctx, rm, req, services, cfg)
+	default:
+		log.Warn("unsupported signature version", slog.String("version", version))
+		return nil, status.Error(codes.Unauthenticated, "unsupported signature version")
+	}
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			log.Error("app not found", logger.Error(err))
+		if errors.Is(err, errReplayedNonce) {
+			log.Warn("replayed request rejected", slog.String("nonce", rm.nonce))
+			return nil, status.Error(codes.Unauthenticated, errReplayedNonce.Error())
+		}
+		if errors.Is(err, errNonceStore) {
+			log.Error("failed to save nonce", logger.Error(err))
+			return nil, status.Error(codes.Unavailable, "failed to verify request")
 		}
 
 		log.Error(
-			"failed to parse timestamp",
+			"failed to validate signature",
 			slog.String("timestamp", rm.timestamp),
 			logger.Error(err))
 		return nil, status.Error(codes.Unauthenticated, "invalid data transmitted")
@@ -153,26 +270,22 @@ func extractMetadata(req any) (rm *ReqMetadata, err error) {
 
 }
 
-// Валидирует secret из запроса
+// Валидирует secret из запроса (подпись v1: timestamp + app_id)
 func validateSecret(
 	rm *ReqMetadata,
 	services Services,
+	cfg config.HMACConfig,
 ) (bool, error) {
 	const op = opGrpc + "validateSecret"
 
-	ts, err := time.Parse(time.RFC3339, rm.timestamp)
+	ts, err := appsign.CheckTimestamp(rm.timestamp, cfg)
 	if err != nil {
-		return false, fmt.Errorf("%s: failed parse timestamp %s: %w", op, rm.timestamp, err)
+		return false, fmt.Errorf("%s: %w", op, err)
 	}
 
 	ctx := context.Background()
 	data := ts.Format(time.RFC3339) + strconv.FormatUint(rm.appID, 10)
 
-	now := time.Now().UTC()
-	if ts.Before(now.Add(-2*time.Minute)) || ts.After(now.Add(5*time.Second)) {
-		return false, fmt.Errorf("%w: current time %s", errInvalidTimestamp, now.Format(time.RFC3339))
-	}
-
 	auth := services.Auth()
 
 	app, err := auth.GetCachedApp(ctx, uint32(rm.appID))
@@ -180,14 +293,24 @@ func validateSecret(
 		return false, err
 	}
 
-	expectedHMAC := computeHMAC(data, app.Secret)
-
-	return expectedHMAC == rm.secret, nil
+	return appsign.Match(app, data, rm.secret), nil
 }
 
-// Вычисляет HMAC
-func computeHMAC(data, secret string) string {
-	h := hmac.New(sha256.New, []byte(secret))
-	h.Write([]byte(data))
-	return hex.EncodeToString(h.Sum(nil))
+// Проверяет, что клиентский сертификат запроса зарегистрирован за приложением
+func validateClientCert(
+	ctx context.Context,
+	rm *ReqMetadata,
+	services Services,
+) bool {
+	thumbprint := reqctx.FromContext(ctx).CertThumbprint
+	if thumbprint == "" {
+		return false
+	}
+
+	app, err := services.Auth().GetCachedApp(ctx, uint32(rm.appID))
+	if err != nil {
+		return false
+	}
+
+	return app.HasCertificate(thumbprint)
 }
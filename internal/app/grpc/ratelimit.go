@@ -0,0 +1,156 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/lib/ratelimit"
+	"github.com/Grino777/sso/internal/lib/reqctx"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// retryAfterHeader заголовок ответа с числом секунд до следующей попытки
+const retryAfterHeader = "retry-after"
+
+// RateLimiter хранилище token bucket
+type RateLimiter interface {
+	AllowRequest(ctx context.Context, key string, limit models.RateLimit) (models.RateLimitResult, error)
+}
+
+// NewRateLimiter возвращает хранилище bucket, выбранное в конфигурации
+func NewRateLimiter(cfg config.RateLimitConfig, services Services) (RateLimiter, error) {
+	const op = opGrpc + "NewRateLimiter"
+
+	switch cfg.Backend {
+	case config.RateLimitMemory, "":
+		return ratelimit.NewMemoryLimiter(), nil
+	case config.RateLimitRedis:
+		return services.Auth().Cache, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown rate limit backend %q", op, cfg.Backend)
+	}
+}
+
+// RateLimitScope признак, по которому считается лимит
+type RateLimitScope int
+
+const (
+	// RateLimitByIP лимит по адресу клиента, проверяется до HMAC
+	RateLimitByIP RateLimitScope = iota
+	// RateLimitByApp лимит по приложению, проверяется после HMAC,
+	// чтобы чужой app_id не расходовал токены приложения
+	RateLimitByApp
+)
+
+// rateLimitRule bucket, из которого запрос должен взять токен
+type rateLimitRule struct {
+	key   string
+	limit models.RateLimit
+}
+
+// RateLimitInterceptor ограничивает частоту запросов к каждому методу
+// для адреса клиента или для приложения (app_id из AuthMetadata) в зависимости от scope.
+// При ошибке хранилища запрос пропускается.
+func RateLimitInterceptor(
+	log *slog.Logger,
+	limiter RateLimiter,
+	cfg config.RateLimitConfig,
+	scope RateLimitScope,
+) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		const op = opGrpc + "RateLimitInterceptor"
+
+		if !cfg.IsEnabled() {
+			return handler(ctx, req)
+		}
+
+		var retryAfter time.Duration
+		allowed := true
+		for _, rule := range rateLimitRules(ctx, req, info.FullMethod, cfg, scope) {
+			result, err := limiter.AllowRequest(ctx, rule.key, rule.limit)
+			if err != nil {
+				log.Warn("rate limiter unavailable",
+					slog.String("op", op),
+					slog.String("key", rule.key),
+					logger.Error(err),
+				)
+				continue
+			}
+			if !result.Allowed {
+				allowed = false
+				retryAfter = max(retryAfter, result.RetryAfter)
+			}
+		}
+
+		if !allowed {
+			log.Warn("rate limit exceeded",
+				slog.String("op", op),
+				slog.String("method", info.FullMethod),
+				slog.Duration("retry_after", retryAfter),
+			)
+			return nil, rateLimitError(ctx, retryAfter)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitRules возвращает bucket приложения или адреса клиента для метода
+func rateLimitRules(
+	ctx context.Context,
+	req any,
+	method string,
+	cfg config.RateLimitConfig,
+	scope RateLimitScope,
+) []rateLimitRule {
+	perApp, perIP := cfg.MethodLimits(method)
+
+	var rules []rateLimitRule
+	switch scope {
+	case RateLimitByApp:
+		if rm, err := extractMetadata(req); err == nil && rm.appID != 0 {
+			rules = append(rules, rateLimitRule{
+				key:   fmt.Sprintf("app:%d:%s", rm.appID, method),
+				limit: models.RateLimit(perApp),
+			})
+		}
+	case RateLimitByIP:
+		if ip := reqctx.FromContext(ctx).ClientIP; ip != "" {
+			rules = append(rules, rateLimitRule{
+				key:   fmt.Sprintf("ip:%s:%s", ip, method),
+				limit: models.RateLimit(perIP),
+			})
+		}
+	}
+	return rules
+}
+
+// rateLimitError возвращает ResourceExhausted с RetryInfo и заголовком retry-after
+func rateLimitError(ctx context.Context, retryAfter time.Duration) error {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	_ = grpc.SetHeader(ctx, metadata.Pairs(retryAfterHeader, strconv.FormatInt(seconds, 10)))
+
+	st := status.New(codes.ResourceExhausted, "rate limit exceeded")
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
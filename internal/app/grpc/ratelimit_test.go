@@ -0,0 +1,111 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+
+	sso_v1 "github.com/Grino777/sso-proto/gen/go/sso"
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/lib/clientip"
+	"github.com/Grino777/sso/internal/lib/ratelimit"
+	"github.com/Grino777/sso/internal/lib/reqctx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const testMethod = "/auth.Auth/Login"
+
+// rateLimitedCall проходит RequestInfoInterceptor и RateLimitInterceptor, как запрос к серверу
+func rateLimitedCall(t *testing.T, trustedProxies []string) func(peerIP, forwardedFor string) codes.Code {
+	t.Helper()
+
+	proxies, err := clientip.NewResolver(trustedProxies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.RateLimitConfig{PerIP: config.RateLimit{Rate: 0.001, Burst: 2}}
+	requestInfo := RequestInfoInterceptor(config.DPoPConfig{BaseURL: "https://sso.example.com"}, proxies)
+	limit := RateLimitInterceptor(slog.New(slog.NewTextHandler(io.Discard, nil)), ratelimit.NewMemoryLimiter(), cfg, RateLimitByIP)
+	info := &grpc.UnaryServerInfo{FullMethod: testMethod}
+
+	return func(peerIP, forwardedFor string) codes.Code {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{
+			Addr: &net.TCPAddr{IP: net.ParseIP(peerIP), Port: 51000},
+		})
+		if forwardedFor != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", forwardedFor))
+		}
+
+		_, err := requestInfo(ctx, &sso_v1.LoginRequest{}, info, func(ctx context.Context, req any) (any, error) {
+			return limit(ctx, req, info, func(context.Context, any) (any, error) { return nil, nil })
+		})
+		return status.Code(err)
+	}
+}
+
+func TestRateLimit__ForgedForwardedFor(t *testing.T) {
+	call := rateLimitedCall(t, nil)
+
+	// Клиент без доверенного прокси не получает новый bucket, подменяя x-forwarded-for
+	forged := []string{"198.51.100.1", "198.51.100.2", "198.51.100.3", "198.51.100.4"}
+	for i, xff := range forged {
+		want := codes.OK
+		if i >= 2 {
+			want = codes.ResourceExhausted
+		}
+		if got := call("203.0.113.7", xff); got != want {
+			t.Fatalf("request %d with x-forwarded-for %s: code = %s, want %s", i+1, xff, got, want)
+		}
+	}
+
+	if got := call("203.0.113.8", ""); got != codes.OK {
+		t.Fatalf("other client limited: %s", got)
+	}
+}
+
+func TestRateLimit__TrustedProxy(t *testing.T) {
+	call := rateLimitedCall(t, []string{"10.0.0.0/8"})
+
+	// За доверенным прокси лимит считается по адресу клиента из x-forwarded-for
+	for i := 0; i < 2; i++ {
+		if got := call("10.0.0.2", "198.51.100.1"); got != codes.OK {
+			t.Fatalf("request %d: code = %s", i+1, got)
+		}
+	}
+	if got := call("10.0.0.3", "198.51.100.1"); got != codes.ResourceExhausted {
+		t.Fatalf("client behind another proxy instance not limited: %s", got)
+	}
+	if got := call("10.0.0.2", "198.51.100.2"); got != codes.OK {
+		t.Fatalf("other client behind the proxy limited: %s", got)
+	}
+}
+
+func TestRateLimitRules__Scope(t *testing.T) {
+	cfg := config.RateLimitConfig{
+		PerApp: config.RateLimit{Rate: 1, Burst: 1},
+		PerIP:  config.RateLimit{Rate: 1, Burst: 1},
+	}
+	ctx := reqctx.WithInfo(context.Background(), reqctx.Info{ClientIP: "203.0.113.7"})
+	req := &sso_v1.LoginRequest{Metadata: &sso_v1.AuthMetadata{AppId: 1}}
+
+	// До HMAC считается только адрес клиента, после — только приложение
+	tests := []struct {
+		scope RateLimitScope
+		want  string
+	}{
+		{RateLimitByIP, "ip:203.0.113.7:" + testMethod},
+		{RateLimitByApp, "app:1:" + testMethod},
+	}
+	for _, tt := range tests {
+		rules := rateLimitRules(ctx, req, testMethod, cfg, tt.scope)
+		if len(rules) != 1 || rules[0].key != tt.want {
+			t.Fatalf("scope %d: rules = %+v, want key %s", tt.scope, rules, tt.want)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	sso_v1 "github.com/Grino777/sso-proto/gen/go/sso"
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/appsign"
+	"github.com/Grino777/sso/internal/lib/secretbox"
+	"github.com/Grino777/sso/internal/services/auth"
+	"github.com/Grino777/sso/internal/services/jwks"
+	redisStorage "github.com/Grino777/sso/internal/storage/redis"
+	"github.com/alicebob/miniredis/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	testAppID         = 1
+	testSecret        = "current-secret"
+	testPrevSecret    = "previous-secret"
+	testExpiredSecret = "expired-secret"
+)
+
+var testHMACConfig = config.HMACConfig{MaxAge: 2 * time.Minute, ClockSkew: 5 * time.Second}
+
+// Подписываемые заголовки запроса без них и запроса входа по passkey
+const (
+	noHeaders      = "dpop:\nx-login-method:\nx-permission:\nx-remember-me:\nx-scope:\nx-webauthn-assertion:"
+	passkeyHeaders = "dpop:\nx-login-method:passkey\nx-permission:\nx-remember-me:\nx-scope:\nx-webauthn-assertion:"
+)
+
+// signedRequest запрос приложения: protobuf сообщение с AuthMetadata
+type signedRequest struct {
+	*wrapperspb.StringValue
+	Metadata *sso_v1.AuthMetadata
+}
+
+type testServices struct{ auth *auth.AuthService }
+
+func (s testServices) Auth() *auth.AuthService { return s.auth }
+func (s testServices) Jwks() *jwks.JwksService { return nil }
+
+// failingNonceCache кэш, в котором не удается сохранить nonce
+type failingNonceCache struct{ *redisStorage.RedisStorage }
+
+func (failingNonceCache) SaveNonce(context.Context, string, string, time.Duration) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func newTestServices(t *testing.T) (testServices, *redisStorage.RedisStorage) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cache := redisStorage.NewRedisStorage(log, config.RedisConfig{
+		Addr:        mr.Addr(),
+		MaxRetries:  1,
+		DialTimeout: time.Second,
+		Timeout:     time.Second,
+	}, testSecrets(t), nil)
+	if err := cache.Connect(context.Background(), make(chan error, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	apps := []models.App{
+		{ID: testAppID, Secret: testSecret, PreviousSecret: testPrevSecret, PreviousSecretExpiresAt: now.Add(time.Hour)},
+		{ID: testAppID + 1, Secret: testSecret, PreviousSecret: testExpiredSecret, PreviousSecretExpiresAt: now.Add(-time.Second)},
+	}
+	for _, app := range apps {
+		if err := cache.SaveApp(context.Background(), app); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return testServices{auth.NewAuthService(auth.AuthService{Logger: log, Cache: cache}, nil)}, cache
+}
+
+// signV2 подписывает запрос по схеме v2 независимо от appsign.Payload
+func signV2(t *testing.T, secret string, ts time.Time, appID uint64, method, nonce, headers string, req proto.Message) string {
+	t.Helper()
+
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(body)
+	payload := "v2\n" + ts.Format(time.RFC3339) + "\n" + strconv.FormatUint(appID, 10) + "\n" + method + "\n" + nonce + "\n" + headers + "\n" + hex.EncodeToString(sum[:])
+	return appsign.Compute(payload, secret)
+}
+
+func TestValidateSignatureV2(t *testing.T) {
+	services, _ := newTestServices(t)
+	ctx := context.Background()
+	req := wrapperspb.String("username=alice")
+	now := time.Now().UTC()
+
+	nonces := 0
+	newNonce := func() string {
+		nonces++
+		return "nonce-0123456789-" + strconv.Itoa(nonces)
+	}
+
+	tests := []struct {
+		name    string
+		appID   uint64
+		secret  string
+		ts      time.Time
+		method  string
+		nonce   string
+		headers string
+		req     proto.Message
+		valid   bool
+		wantErr error
+	}{
+		{name: "current secret", appID: testAppID, secret: testSecret, valid: true},
+		{name: "previous secret during overlap", appID: testAppID, secret: testPrevSecret, valid: true},
+		{name: "previous secret after overlap", appID: testAppID + 1, secret: testExpiredSecret},
+		{name: "unknown secret", appID: testAppID, secret: "other-secret"},
+		{name: "signed for another method", appID: testAppID, secret: testSecret, method: "/auth.Auth/Register"},
+		{name: "signed for another body", appID: testAppID, secret: testSecret, req: wrapperspb.String("username=mallory")},
+		{name: "signed with another login method", appID: testAppID, secret: testSecret, headers: passkeyHeaders},
+		{name: "expired timestamp", appID: testAppID, secret: testSecret, ts: now.Add(-3 * time.Minute), wantErr: errInvalidTimestamp},
+		{name: "timestamp in the future", appID: testAppID, secret: testSecret, ts: now.Add(time.Minute), wantErr: errInvalidTimestamp},
+		{name: "short nonce", appID: testAppID, secret: testSecret, nonce: "short", wantErr: errInvalidNonce},
+		{name: "nonce with invalid characters", appID: testAppID, secret: testSecret, nonce: "nonce/0123456789abc", wantErr: errInvalidNonce},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.ts.IsZero() {
+				tt.ts = now
+			}
+			if tt.nonce == "" {
+				tt.nonce = newNonce()
+			}
+			signedMethod := testMethod
+			if tt.method != "" {
+				signedMethod = tt.method
+			}
+			signedHeaders := noHeaders
+			if tt.headers != "" {
+				signedHeaders = tt.headers
+			}
+			signedReq := proto.Message(req)
+			if tt.req != nil {
+				signedReq = tt.req
+			}
+
+			rm := &ReqMetadata{
+				appID:     tt.appID,
+				timestamp: tt.ts.Format(time.RFC3339),
+				secret:    signV2(t, tt.secret, tt.ts, tt.appID, signedMethod, tt.nonce, signedHeaders, signedReq),
+				method:    testMethod,
+				nonce:     tt.nonce,
+				headers:   noHeaders,
+			}
+			valid, err := validateSignatureV2(ctx, rm, req, services, testHMACConfig)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || valid != tt.valid {
+				t.Fatalf("valid = %v, err = %v; want %v", valid, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValidateSignatureV2__Nonce(t *testing.T) {
+	services, _ := newTestServices(t)
+	ctx := context.Background()
+	req := wrapperspb.String("username=alice")
+	ts := time.Now().UTC()
+	nonce := "replay-0123456789"
+
+	rm := func(secret string) *ReqMetadata {
+		return &ReqMetadata{
+			appID:     testAppID,
+			timestamp: ts.Format(time.RFC3339),
+			secret:    signV2(t, secret, ts, testAppID, testMethod, nonce, noHeaders, req),
+			method:    testMethod,
+			nonce:     nonce,
+			headers:   noHeaders,
+		}
+	}
+
+	// Запрос с неверной подписью не занимает nonce
+	if valid, err := validateSignatureV2(ctx, rm("other-secret"), req, services, testHMACConfig); valid || err != nil {
+		t.Fatalf("forged request: valid = %v, err = %v", valid, err)
+	}
+	if valid, err := validateSignatureV2(ctx, rm(testSecret), req, services, testHMACConfig); !valid || err != nil {
+		t.Fatalf("first request: valid = %v, err = %v", valid, err)
+	}
+	if _, err := validateSignatureV2(ctx, rm(testSecret), req, services, testHMACConfig); !errors.Is(err, errReplayedNonce) {
+		t.Fatalf("replayed request: err = %v, want errReplayedNonce", err)
+	}
+}
+
+func TestValidateHMAC__Status(t *testing.T) {
+	services, cache := newTestServices(t)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ts := time.Now().UTC()
+	ok := func(context.Context, any) (any, error) { return "ok", nil }
+	v1Disabled := false
+
+	request := func() *signedRequest {
+		return &signedRequest{
+			StringValue: wrapperspb.String("username=alice"),
+			Metadata:    &sso_v1.AuthMetadata{AppId: testAppID, Timestamp: ts.Format(time.RFC3339)},
+		}
+	}
+	// v2Context подписывает запрос с заголовками signed и передает заголовки sent
+	v2Context := func(req *signedRequest, nonce, signed string, sent ...string) context.Context {
+		md := metadata.Pairs(
+			"authorization", signV2(t, testSecret, ts, testAppID, testMethod, nonce, signed, req),
+			signatureVersionHeader, signatureV2,
+			signatureNonceHeader, nonce,
+		)
+		return metadata.NewIncomingContext(context.Background(), metadata.Join(md, metadata.Pairs(sent...)))
+	}
+	v1Context := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"authorization", appsign.Compute(ts.Format(time.RFC3339)+strconv.Itoa(testAppID), testSecret),
+	))
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		services Services
+		cfg      config.HMACConfig
+		want     codes.Code
+	}{
+		{"v2 signature", v2Context(request(), "status-0123456789-1", noHeaders), services, testHMACConfig, codes.OK},
+		{"replayed v2 signature", v2Context(request(), "status-0123456789-1", noHeaders), services, testHMACConfig, codes.Unauthenticated},
+		{"signed login method", v2Context(request(), "status-0123456789-3", passkeyHeaders, "x-login-method", "passkey"),
+			services, testHMACConfig, codes.OK},
+		{"unsigned login method", v2Context(request(), "status-0123456789-4", noHeaders, "x-login-method", "passkey"),
+			services, testHMACConfig, codes.Unauthenticated},
+		{"nonce store unavailable", v2Context(request(), "status-0123456789-2", noHeaders),
+			testServices{auth.NewAuthService(auth.AuthService{Logger: log, Cache: failingNonceCache{cache}}, nil)},
+			testHMACConfig, codes.Unavailable},
+		{"v1 signature", v1Context, services, testHMACConfig, codes.OK},
+		{"v1 signature after switch off", v1Context, services,
+			config.HMACConfig{MaxAge: time.Minute, ClockSkew: time.Second, AcceptV1: &v1Disabled}, codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateHMAC(tt.ctx, log, request(), testMethod, tt.services, tt.cfg, ok)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("code = %s, want %s (%v)", got, tt.want, err)
+			}
+		})
+	}
+}
+
+// testSecrets шифрование секретов приложений на тестовом ключе
+func testSecrets(t *testing.T) *secretbox.Box {
+	t.Helper()
+
+	box, err := secretbox.New("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return box
+}
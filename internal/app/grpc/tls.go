@@ -0,0 +1,53 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/utils/certs"
+	"google.golang.org/grpc/credentials"
+)
+
+// serverCredentials создает TLS credentials gRPC-сервера.
+// Сертификат перечитывается при ротации файлов без перезапуска сервера.
+func serverCredentials(cfg config.GRPCTLSConfig) (credentials.TransportCredentials, error) {
+	const op = opGrpc + "serverCredentials"
+
+	clientAuth, err := clientAuthType(cfg.ClientAuth)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	reloader, err := certs.NewServerReloader(
+		cfg.CertFile,
+		cfg.KeyFile,
+		cfg.CertsDir,
+		certs.Options{Hosts: cfg.Hosts, KeyType: cfg.KeyType, Validity: cfg.Validity},
+		cfg.ReloadInterval,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tlsConfig, err := certs.NewServerTLSConfig(reloader, cfg.ClientCAFile, clientAuth)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// clientAuthType преобразует режим проверки клиентских сертификатов из конфигурации
+func clientAuthType(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case "", config.ClientAuthNone:
+		return tls.NoClientCert, nil
+	case config.ClientAuthRequest:
+		return tls.VerifyClientCertIfGiven, nil
+	case config.ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("unknown client auth mode %q", mode)
+	}
+}
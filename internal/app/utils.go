@@ -10,12 +10,22 @@ import (
 	"github.com/Grino777/sso/internal/app/admin"
 	grpcapp "github.com/Grino777/sso/internal/app/grpc"
 	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
 	storageI "github.com/Grino777/sso/internal/interfaces/storage"
 	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/lib/notify"
+	"github.com/Grino777/sso/internal/lib/passhash"
+	"github.com/Grino777/sso/internal/lib/secretbox"
+	"github.com/Grino777/sso/internal/services/account"
+	adminS "github.com/Grino777/sso/internal/services/admin"
 	"github.com/Grino777/sso/internal/services/auth"
 	"github.com/Grino777/sso/internal/services/jwks"
 	"github.com/Grino777/sso/internal/services/keys/store"
 	keysStore "github.com/Grino777/sso/internal/services/keys/store"
+	"github.com/Grino777/sso/internal/services/mfa"
+	"github.com/Grino777/sso/internal/services/passkeys"
+	"github.com/Grino777/sso/internal/services/password"
+	"github.com/Grino777/sso/internal/services/profile"
 	"github.com/Grino777/sso/internal/storage/postgres"
 	redisApp "github.com/Grino777/sso/internal/storage/redis"
 	dbApp "github.com/Grino777/sso/internal/storage/sqlite"
@@ -27,10 +37,76 @@ const (
 	DBTypeSQLite   = "sqlite"
 )
 
-func (a *SSOApp) initApiServer(ks *store.KeysStore) {
-	server := admin.NewApiServer(a.Logger, a.Config.ApiServer, ks)
+func (a *SSOApp) initApiServer(ks *store.KeysStore, s *GrpcServices) error {
+	const op = "app.initApiServer"
+
+	adminService := adminS.NewAdminService(a.Logger, a.Storages.Db, a.Storages.Cache, a.internal.hasher, s.passwordService, a.usernamePolicy())
+	accountService := account.NewAccountService(a.Logger, a.Storages.Db, a.Storages.Cache, s.mfaService, s.passkeyService, s.profileService)
+	limiter, err := grpcapp.NewRateLimiter(a.Config.RateLimit, s)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	apps := admin.AppAuth{
+		Mode:      a.Config.Mode,
+		HMAC:      a.Config.HMAC,
+		RateLimit: a.Config.RateLimit,
+		Apps:      s.authService,
+		Nonces:    a.Storages.Cache,
+		Limiter:   limiter,
+	}
+	server, err := admin.NewApiServer(a.Logger, a.Config.ApiServer, apps, ks, adminService, accountService, s.authService)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 	a.Apps.Api = server
 	a.Logger.Debug("api server successfully initialized")
+	return nil
+}
+
+func (a *SSOApp) initHasher() error {
+	const op = "app.initHasher"
+
+	cfg := a.Config.PasswordHash
+	hasher, err := passhash.New(cfg)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if cfg.Pepper != "" && cfg.Algorithm != config.PasswordHashArgon2id {
+		a.Logger.Warn("password pepper is only applied to argon2id hashes", slog.String("algorithm", cfg.Algorithm))
+	}
+
+	a.internal.hasher = hasher
+	a.Logger.Debug("password hasher successfully initialized", slog.String("algorithm", cfg.Algorithm))
+	return nil
+}
+
+// initSecrets создает шифрование секретов приложений на ключе APP_SECRETS_KEY
+func (a *SSOApp) initSecrets() error {
+	const op = "app.initSecrets"
+
+	box, err := secretbox.New(a.Config.AppSecretsKey)
+	if err != nil {
+		return fmt.Errorf("%s: APP_SECRETS_KEY: %w", op, err)
+	}
+
+	a.internal.secrets = box
+	a.Logger.Debug("app secrets encryption successfully initialized")
+	return nil
+}
+
+// usernamePolicy возвращает требования к именам пользователей из конфигурации
+func (a *SSOApp) usernamePolicy() models.UsernamePolicy {
+	cfg := a.Config.UsernamePolicy
+	return models.UsernamePolicy{
+		MinLength:    cfg.MinLength,
+		MaxLength:    cfg.MaxLength,
+		CaseFold:     cfg.CaseFolded(),
+		NFKC:         cfg.NFKCNormalized(),
+		Classes:      cfg.Allowed,
+		Symbols:      cfg.Symbols,
+		SingleScript: cfg.SingleScriptOnly(),
+		Reserved:     cfg.Reserved,
+	}
 }
 
 func (a *SSOApp) initDB() error {
@@ -43,7 +119,8 @@ func (a *SSOApp) initDB() error {
 
 	switch a.Config.Database.DBType {
 	case DBTypePostgres:
-		db = postgres.NewPostgresStorage(a.Config.Database, a.Logger)
+		// Хранилище Postgres реализовано не полностью: запуск с ним прервал бы первый же вход
+		return fmt.Errorf("%s: postgres storage is not supported yet, use -db sqlite: %w", op, postgres.ErrNotImplemented)
 	case DBTypeSQLite:
 		if err := storageU.CheckStorageFolder(); err != nil {
 			a.Logger.Error(
@@ -51,7 +128,7 @@ func (a *SSOApp) initDB() error {
 				logger.Error(err),
 			)
 		}
-		db = dbApp.New("sqlite3", a.Config.Database.LocalStoragePath, a.Config.SuperUser, a.Logger)
+		db = dbApp.New("sqlite3", a.Config.Database.LocalStoragePath, a.Config.SuperUser, a.internal.hasher, a.internal.secrets, a.Logger)
 	default:
 		a.Logger.Error(
 			"unknown database type",
@@ -70,17 +147,23 @@ func (a *SSOApp) initCache() error {
 	log := a.Logger.With(slog.String("op", op))
 
 	// FIXME
-	redis := redisApp.NewRedisStorage(a.Logger, a.Config.Redis, a.internal.errChan)
+	redis := redisApp.NewRedisStorage(a.Logger, a.Config.Redis, a.internal.secrets, a.internal.errChan)
 
 	a.Storages.Cache = redis
 	log.Debug("cache initialized successfully", slog.String("addr", a.Config.Redis.Addr))
 	return nil
 }
 
-func (a *SSOApp) initGRPCApp(s *GrpcServices, keysStore *keysStore.KeysStore) {
-	grpcApp := grpcapp.NewGrpcApp(a.Logger, s, a.Config)
+func (a *SSOApp) initGRPCApp(s *GrpcServices, keysStore *keysStore.KeysStore) error {
+	const op = "app.initGRPCApp"
+
+	grpcApp, err := grpcapp.NewGrpcApp(a.Logger, s, a.Config)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 	a.Apps.Grpc = grpcApp
 	a.Logger.Debug("gRPC server successfully initialized")
+	return nil
 }
 
 func (a *SSOApp) initServices(ks *keysStore.KeysStore) *GrpcServices {
@@ -96,20 +179,72 @@ func (a *SSOApp) initServices(ks *keysStore.KeysStore) *GrpcServices {
 		os.Exit(1)
 	}
 
+	a.internal.logins = auth.NewLoginRecorder(a.Logger, a.Storages.Db, auth.DefaultLoginQueueSize)
+
+	mfaService := mfa.NewMFAService(a.Logger, a.Storages.Db, a.Config.MFA)
+	passkeyService := passkeys.NewPasskeyService(a.Logger, a.Storages.Db, a.Storages.Cache, a.Config.WebAuthn)
+	passwordService, err := password.NewPasswordService(a.Logger, a.Storages.Db, a.internal.hasher, a.Config.PasswordPolicy)
+	if err != nil {
+		a.Logger.Warn(
+			"password service not initialized",
+			slog.String("op", op),
+			logger.Error(err),
+		)
+		os.Exit(1)
+	}
+
+	notifier, err := notify.New(a.Logger, a.Config.Notifier)
+	if err != nil {
+		a.Logger.Warn(
+			"notifier not initialized",
+			slog.String("op", op),
+			logger.Error(err),
+		)
+		os.Exit(1)
+	}
+
+	profileService, err := profile.NewProfileService(a.Logger, a.Storages.Db, a.Storages.Cache, notifier, a.Config.EmailVerification)
+	if err != nil {
+		a.Logger.Warn(
+			"profile service not initialized",
+			slog.String("op", op),
+			logger.Error(err),
+		)
+		os.Exit(1)
+	}
+
 	authConfigs := auth.AuthService{
-		Logger:    a.Logger,
-		DB:        a.Storages.Db,
-		Cache:     a.Storages.Cache,
-		Tokens:    a.Config.TTL,
-		KeysStore: ks,
+		Logger:            a.Logger,
+		DB:                a.Storages.Db,
+		Cache:             a.Storages.Cache,
+		Tokens:            a.Config.TTL,
+		DPoP:              a.Config.DPoP,
+		Lockout:           a.Config.Lockout,
+		MFAConfig:         a.Config.MFA,
+		KeysStore:         ks,
+		Hasher:            a.internal.hasher,
+		MFA:               mfaService,
+		Passkeys:          passkeyService,
+		Logins:            a.internal.logins,
+		Passwords:         passwordService,
+		Usernames:         a.usernamePolicy(),
+		Sessions:          a.Config.Sessions,
+		Notifier:          notifier,
+		UsernameAsAddress: a.Config.Notifier.UsernameAsAddress,
+		Passwordless:      a.Config.Passwordless,
+		PasswordReset:     a.Config.PasswordReset,
 	}
 
 	authService := auth.NewAuthService(authConfigs, ks)
 	a.Logger.Debug("all services successfully initialized")
 
 	return &GrpcServices{
-		jwksService: jwksService,
-		authService: authService,
+		jwksService:     jwksService,
+		authService:     authService,
+		mfaService:      mfaService,
+		passkeyService:  passkeyService,
+		profileService:  profileService,
+		passwordService: passwordService,
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sync"
@@ -32,6 +33,15 @@ const (
 	suUsernameEnv = "DB_USER"
 	suPassEnv     = "DB_PASSWORD"
 	keysDirEnv    = "KEYS_DIR"
+	appSecretsEnv = "APP_SECRETS_KEY"
+)
+
+// Необязательные переменные окружения
+const (
+	adminTokenEnv       = "ADMIN_BOOTSTRAP_TOKEN"
+	smtpPasswordEnv     = "SMTP_PASSWORD"
+	emailTokenSecretEnv = "EMAIL_TOKEN_SECRET"
+	passwordPepperEnv   = "PASSWORD_PEPPER"
 )
 
 // Константы с кредами для Postgres
@@ -53,6 +63,8 @@ const configOp = "config.config."
 var (
 	ErrModeFlag = errors.New("invalid mode flag")
 	ErrDbFlag   = errors.New("invalid db flag")
+
+	ErrDPoPBaseURL = errors.New("dpop.base_url is required when dpop is enabled")
 )
 
 var (
@@ -69,6 +81,14 @@ var envMapping = map[string]func(*Config, string){
 	suUsernameEnv: func(c *Config, v string) { c.SuperUser.Username = v },
 	suPassEnv:     func(c *Config, v string) { c.SuperUser.Password = v },
 	keysDirEnv:    func(c *Config, v string) { c.Path.KeysDir = v },
+	appSecretsEnv: func(c *Config, v string) { c.AppSecretsKey = v },
+}
+
+var envOptionalMapping = map[string]func(*Config, string){
+	adminTokenEnv:       func(c *Config, v string) { c.ApiServer.BootstrapToken = v },
+	smtpPasswordEnv:     func(c *Config, v string) { c.Notifier.SMTP.Password = v },
+	emailTokenSecretEnv: func(c *Config, v string) { c.EmailVerification.Secret = v },
+	passwordPepperEnv:   func(c *Config, v string) { c.PasswordHash.Pepper = v },
 }
 
 var envPGMapping = map[string]func(*Config, string){
@@ -88,6 +108,24 @@ type Config struct {
 	Path      PathConfig
 	SuperUser SuperUser
 	ApiServer ApiServerConfig `yaml:"api_server" env-required:"true"`
+	DPoP      DPoPConfig      `yaml:"dpop"`
+	Lockout   LockoutConfig   `yaml:"lockout"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	HMAC      HMACConfig      `yaml:"hmac"`
+	MFA       MFAConfig       `yaml:"mfa"`
+	WebAuthn  WebAuthnConfig  `yaml:"webauthn"`
+	Notifier  NotifierConfig  `yaml:"notifier"`
+	// Вход по одноразовому коду или ссылке из письма
+	Passwordless      PasswordlessConfig      `yaml:"passwordless"`
+	EmailVerification EmailVerificationConfig `yaml:"email_verification"`
+	PasswordReset     PasswordResetConfig     `yaml:"password_reset"`
+	PasswordPolicy    PasswordPolicyConfig    `yaml:"password_policy"`
+	PasswordHash      PasswordHashConfig      `yaml:"password_hash"`
+	UsernamePolicy    UsernamePolicyConfig    `yaml:"username_policy"`
+	Sessions          SessionsConfig          `yaml:"sessions"`
+	// Ключ шифрования секретов приложений в базе и кэше (base64, 32 байта).
+	// Задается переменной окружения APP_SECRETS_KEY.
+	AppSecretsKey string `yaml:"-"`
 }
 
 type DatabaseConfig struct {
@@ -100,9 +138,17 @@ type DatabaseConfig struct {
 	DBPort           string
 }
 
+// TTLConfig содержит сроки действия по умолчанию; сроки токенов и сеансов
+// можно переопределить для отдельного приложения.
+// idleTimeout — максимальный перерыв между обновлениями токенов, maxSessionAge —
+// максимальный срок сеанса с момента входа; 0 — без ограничения.
+// offlineTokenTTL — срок refresh токена сеанса, выданного по запросу offline_access.
 type TTLConfig struct {
 	TokenTTL        time.Duration `yaml:"tokenTTL" env-default:"1h"`
 	RefreshTokenTTL time.Duration `yaml:"refreshTokenTTL" env-default:"168h"`
+	IdleTimeout     time.Duration `yaml:"idleTimeout" env-default:"0s"`
+	MaxSessionAge   time.Duration `yaml:"maxSessionAge" env-default:"0s"`
+	OfflineTokenTTL time.Duration `yaml:"offlineTokenTTL" env-default:"720h"`
 	KeyTTL          time.Duration `yaml:"keyTTL" env-default:"720h"`
 }
 
@@ -119,10 +165,43 @@ type SuperUser struct {
 }
 
 // GRPCConfig содержит настройки gRPC-сервера.
+// trusted_proxies — обратные прокси (CIDR или IP), от которых принимается адрес клиента
+// в x-forwarded-for; от остальных соединений заголовок игнорируется.
 type GRPCConfig struct {
-	Addr    string        `yaml:"grpc_addr" env-required:"true"`
-	Port    uint16        `yaml:"grpc_port" env-required:"true"`
-	Timeout time.Duration `yaml:"grpc_timeout" env-default:"5s"`
+	Addr           string        `yaml:"grpc_addr" env-required:"true"`
+	Port           uint16        `yaml:"grpc_port" env-required:"true"`
+	Timeout        time.Duration `yaml:"grpc_timeout" env-default:"5s"`
+	TLS            GRPCTLSConfig `yaml:"tls"`
+	TrustedProxies []string      `yaml:"trusted_proxies"`
+}
+
+// Режимы проверки клиентских сертификатов
+const (
+	ClientAuthNone    = "none"
+	ClientAuthRequest = "request"
+	ClientAuthRequire = "require"
+)
+
+// GRPCTLSConfig содержит настройки TLS/mTLS для gRPC-сервера.
+type GRPCTLSConfig struct {
+	Enabled      bool `yaml:"enabled"`
+	CertsConfig  `yaml:",inline"`
+	ClientCAFile string `yaml:"client_ca_file"`
+	ClientAuth   string `yaml:"client_auth" env-default:"none"`
+}
+
+// CertsConfig содержит настройки TLS сертификата сервера.
+// Если cert_file и key_file не заданы, самоподписанный сертификат
+// с параметрами hosts, key_type и validity создается в CertsDir.
+// Файлы перечитываются при изменении не чаще одного раза в reload_interval.
+type CertsConfig struct {
+	CertFile       string        `yaml:"cert_file"`
+	KeyFile        string        `yaml:"key_file"`
+	Hosts          []string      `yaml:"hosts" env-default:"localhost,127.0.0.1"`
+	KeyType        string        `yaml:"key_type" env-default:"ecdsa"`
+	Validity       time.Duration `yaml:"validity" env-default:"8760h"`
+	ReloadInterval time.Duration `yaml:"reload_interval" env-default:"30s"`
+	CertsDir       string
 }
 
 // RedisConfig содержит настройки Redis.
@@ -138,10 +217,341 @@ type RedisConfig struct {
 	TokenTTL    time.Duration `yaml:"tokenTTL" env-default:"1h"`
 }
 
+// DPoPConfig содержит настройки проверки DPoP proof (RFC 9449, по умолчанию включена).
+// Если проверка выключена, заголовок DPoP игнорируется и выдаются обычные bearer токены.
+type DPoPConfig struct {
+	Enabled *bool `yaml:"enabled"`
+	// Внешний адрес сервера (https://sso.example.com), с которым сравнивается htu.
+	// Обязателен, если DPoP включен.
+	BaseURL   string        `yaml:"base_url"`
+	ProofTTL  time.Duration `yaml:"proof_ttl" env-default:"60s"`
+	ClockSkew time.Duration `yaml:"clock_skew" env-default:"5s"`
+}
+
+// IsEnabled возвращает true, если проверка DPoP не отключена явно
+func (c DPoPConfig) IsEnabled() bool {
+	return boolOr(c.Enabled, true)
+}
+
+// Validate проверяет, что при включенном DPoP задан абсолютный base_url
+func (c DPoPConfig) Validate() error {
+	const op = configOp + "DPoPConfig.Validate"
+
+	if !c.IsEnabled() {
+		return nil
+	}
+	if c.BaseURL == "" {
+		return fmt.Errorf("%s: %w", op, ErrDPoPBaseURL)
+	}
+	u, err := url.Parse(c.BaseURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%s: %w: %q is not an absolute http(s) url", op, ErrDPoPBaseURL, c.BaseURL)
+	}
+	return nil
+}
+
+// LockoutConfig содержит настройки защиты от перебора паролей (по умолчанию включена).
+// После max_user_attempts (max_ip_attempts) неудачных попыток за window
+// вход блокируется на base_lockout, каждая следующая блокировка в течение
+// strikes_ttl вдвое длиннее предыдущей, но не больше max_lockout.
+type LockoutConfig struct {
+	Enabled         *bool         `yaml:"enabled"`
+	MaxUserAttempts int64         `yaml:"max_user_attempts" env-default:"5"`
+	MaxIPAttempts   int64         `yaml:"max_ip_attempts" env-default:"20"`
+	Window          time.Duration `yaml:"window" env-default:"15m"`
+	BaseLockout     time.Duration `yaml:"base_lockout" env-default:"1m"`
+	MaxLockout      time.Duration `yaml:"max_lockout" env-default:"1h"`
+	StrikesTTL      time.Duration `yaml:"strikes_ttl" env-default:"24h"`
+}
+
+// IsEnabled возвращает true, если защита не отключена явно
+func (c LockoutConfig) IsEnabled() bool {
+	return boolOr(c.Enabled, true)
+}
+
+// HMACConfig содержит настройки проверки подписи запросов приложений.
+// Подпись v1 (timestamp + app_id) принимается, пока accept_v1 не выключен
+// (по умолчанию включен) и не наступил accept_v1_until (если задан).
+type HMACConfig struct {
+	MaxAge        time.Duration `yaml:"max_age" env-default:"2m"`
+	ClockSkew     time.Duration `yaml:"clock_skew" env-default:"5s"`
+	AcceptV1      *bool         `yaml:"accept_v1"`
+	AcceptV1Until time.Time     `yaml:"accept_v1_until"`
+}
+
+// V1Accepted возвращает true, если подпись v1 принимается на момент now
+func (c HMACConfig) V1Accepted(now time.Time) bool {
+	return boolOr(c.AcceptV1, true) && (c.AcceptV1Until.IsZero() || now.Before(c.AcceptV1Until))
+}
+
+// MFAConfig содержит настройки двухфакторной аутентификации (TOTP).
+// challenge_ttl — время на ввод кода после проверки пароля,
+// skew — допустимое расхождение часов клиента в шагах TOTP.
+type MFAConfig struct {
+	Issuer        string        `yaml:"issuer" env-default:"SSO"`
+	ChallengeTTL  time.Duration `yaml:"challenge_ttl" env-default:"5m"`
+	MaxAttempts   int           `yaml:"max_attempts" env-default:"5"`
+	Skew          int           `yaml:"skew" env-default:"1"`
+	RecoveryCodes int           `yaml:"recovery_codes" env-default:"10"`
+}
+
+// WebAuthnConfig содержит настройки входа по passkey (WebAuthn).
+// rp_id — домен, к которому привязываются ключи, origins — origin страниц,
+// с которых разрешены церемонии, user_verification — required, preferred или discouraged.
+type WebAuthnConfig struct {
+	RPID             string        `yaml:"rp_id" env-default:"localhost"`
+	RPName           string        `yaml:"rp_name" env-default:"SSO"`
+	Origins          []string      `yaml:"origins" env-default:"https://localhost"`
+	UserVerification string        `yaml:"user_verification" env-default:"required"`
+	Attestation      string        `yaml:"attestation" env-default:"none"`
+	ChallengeTTL     time.Duration `yaml:"challenge_ttl" env-default:"5m"`
+	MaxCredentials   int           `yaml:"max_credentials" env-default:"10"`
+}
+
+// Способы доставки писем пользователям
+const (
+	NotifierSMTP = "smtp"
+	NotifierFile = "file"
+	NotifierLog  = "log"
+)
+
+// NotifierConfig содержит настройки доставки писем пользователям.
+// Способ file дописывает письма в file_path, log пишет их в журнал приложения:
+// оба предназначены для локального режима и тестов.
+// Письма отправляются только на подтвержденный email из профиля; username_as_address
+// разрешает отправку на имя пользователя, если оно является адресом (адрес не подтвержден).
+type NotifierConfig struct {
+	Type              string     `yaml:"type" env-default:"log"`
+	From              string     `yaml:"from" env-default:"SSO <no-reply@localhost>"`
+	FilePath          string     `yaml:"file_path" env-default:"./storage/outbox.jsonl"`
+	UsernameAsAddress bool       `yaml:"username_as_address" env-default:"false"`
+	SMTP              SMTPConfig `yaml:"smtp"`
+}
+
+// Режимы шифрования соединения с SMTP сервером
+const (
+	SMTPStartTLS = "starttls"
+	SMTPTLS      = "tls"
+)
+
+// SMTPConfig содержит настройки SMTP сервера. Пароль задается переменной окружения SMTP_PASSWORD.
+type SMTPConfig struct {
+	Host     string        `yaml:"host"`
+	Port     uint16        `yaml:"port" env-default:"587"`
+	Username string        `yaml:"username"`
+	Password string        `yaml:"-"`
+	Security string        `yaml:"security" env-default:"starttls"`
+	Timeout  time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
+// PasswordlessConfig содержит настройки входа без пароля. Код из code_length цифр
+// действует code_ttl и допускает max_attempts неверных попыток, ссылка link_url?token=...
+// действует link_ttl и принимается один раз. Письма одному пользователю отправляются
+// не чаще resend_interval: новый код не может сбрасывать счетчик попыток чаще.
+type PasswordlessConfig struct {
+	Enabled        bool          `yaml:"enabled" env-default:"false"`
+	CodeLength     int           `yaml:"code_length" env-default:"6"`
+	CodeTTL        time.Duration `yaml:"code_ttl" env-default:"10m"`
+	MaxAttempts    int           `yaml:"max_attempts" env-default:"5"`
+	LinkURL        string        `yaml:"link_url" env-default:"https://localhost:8443/login/link"`
+	LinkTTL        time.Duration `yaml:"link_ttl" env-default:"15m"`
+	ResendInterval time.Duration `yaml:"resend_interval" env-default:"1m"`
+}
+
+// EmailVerificationConfig содержит настройки подтверждения email. Ссылка link_url?token=...
+// действует token_ttl. Секрет подписи ссылок задается переменной окружения EMAIL_TOKEN_SECRET,
+// без нее секрет создается при запуске и отправленные ссылки перестают действовать после перезапуска.
+type EmailVerificationConfig struct {
+	LinkURL  string        `yaml:"link_url" env-default:"https://localhost:8443/verify-email"`
+	TokenTTL time.Duration `yaml:"token_ttl" env-default:"24h"`
+	Secret   string        `yaml:"-"`
+}
+
+// PasswordResetConfig содержит настройки сброса пароля по ссылке из письма.
+// Ссылка link_url?token=... действует token_ttl и принимается один раз.
+// Письма одному пользователю отправляются не чаще resend_interval.
+type PasswordResetConfig struct {
+	Enabled        bool          `yaml:"enabled" env-default:"false"`
+	LinkURL        string        `yaml:"link_url" env-default:"https://localhost:8443/reset-password"`
+	TokenTTL       time.Duration `yaml:"token_ttl" env-default:"1h"`
+	ResendInterval time.Duration `yaml:"resend_interval" env-default:"1m"`
+}
+
+// PasswordPolicyConfig содержит требования к паролям пользователей. Длина считается в символах.
+// history запрещает повторять столько последних паролей (не больше 24), max_age задает срок
+// действия пароля (0 — бессрочно). Вход с истекшим паролем возвращает токен смены пароля,
+// действующий change_token_ttl; войти можно только после смены пароля.
+type PasswordPolicyConfig struct {
+	MinLength        int           `yaml:"min_length" env-default:"8"`
+	MaxLength        int           `yaml:"max_length" env-default:"64"`
+	RequireUpper     bool          `yaml:"require_upper" env-default:"false"`
+	RequireLower     bool          `yaml:"require_lower" env-default:"false"`
+	RequireDigit     bool          `yaml:"require_digit" env-default:"false"`
+	RequireSymbol    bool          `yaml:"require_symbol" env-default:"false"`
+	DisallowUsername *bool         `yaml:"disallow_username"`
+	History          int           `yaml:"history" env-default:"0"`
+	MaxAge           time.Duration `yaml:"max_age" env-default:"0s"`
+	ChangeTokenTTL   time.Duration `yaml:"change_token_ttl" env-default:"10m"`
+	// Запрет паролей из известных утечек
+	Breached BreachedPasswordsConfig `yaml:"breached"`
+}
+
+// UsernameDisallowed возвращает true, если запрет пароля, похожего на имя пользователя, не отключен явно
+func (c PasswordPolicyConfig) UsernameDisallowed() bool {
+	return boolOr(c.DisallowUsername, true)
+}
+
+// BreachedPasswordsConfig содержит настройки проверки паролей по локальному списку утечек
+// в формате Have I Been Pwned. path указывает на файл SHA-1 хэшей, упорядоченный по хэшу,
+// или на каталог, разбитый по префиксам хэша. Пароль запрещен, если встречался не меньше min_count раз.
+type BreachedPasswordsConfig struct {
+	Enabled  bool   `yaml:"enabled" env-default:"false"`
+	Path     string `yaml:"path" env-default:"./storage/pwned-passwords.txt"`
+	MinCount int    `yaml:"min_count" env-default:"1"`
+}
+
+// Алгоритмы хэширования паролей
+const (
+	PasswordHashArgon2id = "argon2id"
+	PasswordHashBcrypt   = "bcrypt"
+)
+
+// PasswordHashConfig содержит настройки хэширования паролей. Новые хэши создаются алгоритмом algorithm,
+// хэши другого алгоритма или с более слабыми параметрами пересоздаются при успешном входе.
+// Перец (pepper) задается переменной окружения PASSWORD_PEPPER и применяется только к argon2id;
+// pepper_id сохраняется в хэше и должен меняться вместе с перцем.
+type PasswordHashConfig struct {
+	Algorithm  string         `yaml:"algorithm" env-default:"argon2id"`
+	Argon2id   Argon2idConfig `yaml:"argon2id"`
+	BcryptCost int            `yaml:"bcrypt_cost" env-default:"10"`
+	PepperID   string         `yaml:"pepper_id" env-default:"1"`
+	Pepper     string         `yaml:"-"`
+}
+
+// Argon2idConfig содержит параметры argon2id, memory задается в КиБ
+type Argon2idConfig struct {
+	Memory      uint32 `yaml:"memory" env-default:"19456"`
+	Iterations  uint32 `yaml:"iterations" env-default:"2"`
+	Parallelism uint8  `yaml:"parallelism" env-default:"1"`
+	SaltLength  uint32 `yaml:"salt_length" env-default:"16"`
+	KeyLength   uint32 `yaml:"key_length" env-default:"32"`
+}
+
+// UsernamePolicyConfig содержит требования к именам новых пользователей.
+// Имена сравниваются без учета регистра и формы Unicode независимо от этих настроек;
+// case_fold и nfkc определяют, в каком виде имя сохраняется.
+// Классы символов allowed: letters, ascii_letters, digits; пусто — любые символы, кроме пробельных.
+type UsernamePolicyConfig struct {
+	MinLength    int      `yaml:"min_length" env-default:"3"`
+	MaxLength    int      `yaml:"max_length" env-default:"32"`
+	CaseFold     *bool    `yaml:"case_fold"`
+	NFKC         *bool    `yaml:"nfkc"`
+	Allowed      []string `yaml:"allowed" env-default:"letters,digits"`
+	Symbols      string   `yaml:"symbols" env-default:"._-"`
+	SingleScript *bool    `yaml:"single_script"`
+	Reserved     []string `yaml:"reserved" env-default:"admin,administrator,root,system,support,security,sso"`
+}
+
+// CaseFolded возвращает true, если приведение имени к нижнему регистру не отключено явно
+func (c UsernamePolicyConfig) CaseFolded() bool {
+	return boolOr(c.CaseFold, true)
+}
+
+// NFKCNormalized возвращает true, если нормализация имени NFKC не отключена явно
+func (c UsernamePolicyConfig) NFKCNormalized() bool {
+	return boolOr(c.NFKC, true)
+}
+
+// SingleScriptOnly возвращает true, если запрет смешения письменностей не отключен явно
+func (c UsernamePolicyConfig) SingleScriptOnly() bool {
+	return boolOr(c.SingleScript, true)
+}
+
+// SessionsConfig содержит ограничения сеансов пользователя.
+// При входе сверх max_per_app сеансов в приложении отзываются самые старые;
+// без ключа — 10 сеансов, 0 — без ограничения.
+type SessionsConfig struct {
+	MaxPerApp *int `yaml:"max_per_app"`
+}
+
+// PerAppLimit возвращает число сеансов пользователя в приложении; 0 — без ограничения
+func (c SessionsConfig) PerAppLimit() int {
+	if c.MaxPerApp == nil {
+		return 10
+	}
+	return *c.MaxPerApp
+}
+
+// Хранилища счетчиков ограничения частоты запросов
+const (
+	RateLimitMemory = "memory"
+	RateLimitRedis  = "redis"
+)
+
+// RateLimitConfig содержит настройки ограничения частоты gRPC запросов и HTTP маршрутов входа
+// (по умолчанию включено). Для каждого метода ведутся отдельные bucket на приложение и на адрес клиента.
+// Backend memory ограничивает каждую реплику отдельно, redis — все реплики вместе.
+type RateLimitConfig struct {
+	Enabled *bool     `yaml:"enabled"`
+	Backend string    `yaml:"backend" env-default:"memory"`
+	PerApp  RateLimit `yaml:"per_app"`
+	PerIP   RateLimit `yaml:"per_ip"`
+	// Лимиты отдельных методов по полному имени (/auth.Auth/Register) или маршруту
+	// (POST /auth/passwordless), незаданные значения берутся из per_app и per_ip
+	Methods map[string]MethodRateLimit `yaml:"methods"`
+}
+
+// RateLimit параметры token bucket: rate запросов в секунду, всплеск до burst
+type RateLimit struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// IsEnabled возвращает true, если ограничение не отключено явно
+func (c RateLimitConfig) IsEnabled() bool {
+	return boolOr(c.Enabled, true)
+}
+
+// MethodLimits возвращает лимиты приложения и адреса клиента для метода
+func (c RateLimitConfig) MethodLimits(method string) (perApp, perIP RateLimit) {
+	perApp, perIP = c.PerApp, c.PerIP
+	if override, ok := c.Methods[method]; ok {
+		if override.PerApp.Rate > 0 {
+			perApp = override.PerApp
+		}
+		if override.PerIP.Rate > 0 {
+			perIP = override.PerIP
+		}
+	}
+	return perApp, perIP
+}
+
+type MethodRateLimit struct {
+	PerApp RateLimit `yaml:"per_app"`
+	PerIP  RateLimit `yaml:"per_ip"`
+}
+
 type ApiServerConfig struct {
-	Addr     string `yaml:"api_addr" env-required:"true"`
-	Port     string `yaml:"api_port" env-required:"true"`
-	CertsDir string
+	Addr string      `yaml:"api_addr" env-required:"true"`
+	Port string      `yaml:"api_port" env-required:"true"`
+	TLS  CertsConfig `yaml:"tls"`
+	// Обратные прокси (CIDR или IP), от которых принимается адрес клиента в X-Forwarded-For
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	// Приложение, access токены которого принимают административные маршруты (aud);
+	// 0 — принимается только bootstrap токен. Маршруты /account/... принимают токены любого приложения.
+	AppID uint32 `yaml:"app_id"`
+	// Статический токен с правами superadmin для первичной настройки (из env, необязателен)
+	BootstrapToken string
+}
+
+// boolOr возвращает значение необязательного флага или def, если флаг не задан.
+// Флаги, включенные по умолчанию, объявляются как *bool: cleanenv подставляет env-default
+// вместо нулевого значения из YAML, и false у bool с env-default:"true" не применяется.
+func boolOr(value *bool, def bool) bool {
+	if value == nil {
+		return def
+	}
+	return *value
 }
 
 // GetFlagSet возвращает flagSet для использования в других пакетах.
@@ -253,12 +663,17 @@ func loadConfig() (*Config, error) {
 		return nil, fmt.Errorf("%s: failed to parse environment variables: %w", op, err)
 	}
 
+	if err := cfg.DPoP.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	if err := setBaseDir(cfg, cfg.Path.ConfigPath); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
 	cfg.Path.KeysDir = filepath.Join(cfg.Path.BaseDir, "keys")
-	cfg.ApiServer.CertsDir = filepath.Join(cfg.Path.BaseDir, "certs")
+	cfg.ApiServer.TLS.CertsDir = filepath.Join(cfg.Path.BaseDir, "certs")
+	cfg.GRPC.TLS.CertsDir = cfg.ApiServer.TLS.CertsDir
 
 	return cfg, nil
 }
@@ -290,6 +705,12 @@ func parseEnv(cfg *Config) error {
 		setter(cfg, value)
 	}
 
+	for envKey, setter := range envOptionalMapping {
+		if value := os.Getenv(envKey); value != "" {
+			setter(cfg, value)
+		}
+	}
+
 	if cfg.Database.DBType == DBTypePostgres {
 		if err := parseEnvPG(cfg); err != nil {
 			return fmt.Errorf("%s: %w", op, err)
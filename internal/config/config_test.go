@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
+
+// Обязательные параметры, без которых конфигурация не читается
+const requiredYAML = `
+grpc:
+  grpc_addr: "127.0.0.1"
+  grpc_port: 8088
+redis:
+  redis_addr: "127.0.0.1:6379"
+ttl:
+  tokenTTL: "1h"
+api_server:
+  api_addr: "127.0.0.1"
+  api_port: "8089"
+`
+
+// readTestConfig читает конфигурацию так же, как loadConfig
+func readTestConfig(t *testing.T, yaml string) Config {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(requiredYAML+yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg Config
+	if err := cleanenv.ReadConfig(path, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestConfig__DefaultOnFlags(t *testing.T) {
+	cfg := readTestConfig(t, "")
+	if !cfg.Lockout.IsEnabled() {
+		t.Error("lockout is disabled by default")
+	}
+
+	if !cfg.RateLimit.IsEnabled() {
+		t.Error("rate limit is disabled by default")
+	}
+	if !cfg.HMAC.V1Accepted(time.Now()) {
+		t.Error("hmac v1 is rejected by default")
+	}
+	if !cfg.PasswordPolicy.UsernameDisallowed() {
+		t.Error("passwords similar to username are allowed by default")
+	}
+	if !cfg.UsernamePolicy.CaseFolded() || !cfg.UsernamePolicy.NFKCNormalized() || !cfg.UsernamePolicy.SingleScriptOnly() {
+		t.Error("username normalization is disabled by default")
+	}
+	if got := cfg.Sessions.PerAppLimit(); got != 10 {
+		t.Errorf("sessions per app = %d by default, want 10", got)
+	}
+	if !cfg.DPoP.IsEnabled() {
+		t.Error("dpop is disabled by default")
+	}
+
+	cfg = readTestConfig(t, `
+lockout:
+  enabled: false
+rate_limit:
+  enabled: false
+hmac:
+  accept_v1: false
+password_policy:
+  disallow_username: false
+username_policy:
+  case_fold: false
+  nfkc: false
+  single_script: false
+  allowed: []
+sessions:
+  max_per_app: 0
+dpop:
+  enabled: false
+`)
+	if cfg.Lockout.IsEnabled() {
+		t.Error("lockout.enabled: false is ignored")
+	}
+	if cfg.RateLimit.IsEnabled() {
+		t.Error("rate_limit.enabled: false is ignored")
+	}
+	if cfg.HMAC.V1Accepted(time.Now()) {
+		t.Error("hmac.accept_v1: false is ignored")
+	}
+	if cfg.PasswordPolicy.UsernameDisallowed() {
+		t.Error("password_policy.disallow_username: false is ignored")
+	}
+	if cfg.UsernamePolicy.CaseFolded() {
+		t.Error("username_policy.case_fold: false is ignored")
+	}
+	if cfg.UsernamePolicy.NFKCNormalized() {
+		t.Error("username_policy.nfkc: false is ignored")
+	}
+	if cfg.UsernamePolicy.SingleScriptOnly() {
+		t.Error("username_policy.single_script: false is ignored")
+	}
+	if len(cfg.UsernamePolicy.Allowed) != 0 {
+		t.Errorf("username_policy.allowed: [] is replaced with %v", cfg.UsernamePolicy.Allowed)
+	}
+	if got := cfg.Sessions.PerAppLimit(); got != 0 {
+		t.Errorf("sessions.max_per_app: 0 is replaced with %d", got)
+	}
+	if cfg.DPoP.IsEnabled() {
+		t.Error("dpop.enabled: false is ignored")
+	}
+}
+
+func TestHMACConfig__V1Until(t *testing.T) {
+	cfg := readTestConfig(t, `
+hmac:
+  accept_v1_until: "2026-01-01T00:00:00Z"
+`)
+	deadline := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if !cfg.HMAC.V1Accepted(deadline.Add(-time.Second)) {
+		t.Error("hmac v1 rejected before accept_v1_until")
+	}
+	if cfg.HMAC.V1Accepted(deadline) {
+		t.Error("hmac v1 accepted after accept_v1_until")
+	}
+}
+
+func TestDPoPConfig__Validate(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name    string
+		cfg     DPoPConfig
+		wantErr bool
+	}{
+		{"base url", DPoPConfig{BaseURL: "https://sso.example.com"}, false},
+		{"empty base url", DPoPConfig{}, true},
+		{"relative base url", DPoPConfig{BaseURL: "/auth"}, true},
+		{"base url without scheme", DPoPConfig{BaseURL: "sso.example.com"}, true},
+		{"disabled without base url", DPoPConfig{Enabled: &disabled}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrDPoPBaseURL) {
+				t.Fatalf("err = %v, want ErrDPoPBaseURL", err)
+			}
+		})
+	}
+}
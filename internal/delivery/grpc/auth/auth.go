@@ -3,26 +3,68 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"math"
 	"strconv"
+	"time"
 
 	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/webauthn"
 	"github.com/Grino777/sso/internal/services/auth"
+	"github.com/Grino777/sso/internal/services/passkeys"
 	"github.com/Grino777/sso/internal/storage"
 
 	sso_v1 "github.com/Grino777/sso-proto/gen/go/sso"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
+// permissionHeader заголовок metadata, превращающий IsAdmin в проверку произвольного права
+const permissionHeader = "x-permission"
+
+// retryAfterHeader заголовок ответа с числом секунд до снятия блокировки входа
+const retryAfterHeader = "retry-after"
+
+// Вход по passkey через Login: заголовок x-login-method: passkey без x-webauthn-assertion
+// возвращает параметры navigator.credentials.get (FailedPrecondition, WEBAUTHN_ASSERTION_REQUIRED),
+// с x-webauthn-assertion (AuthenticationResponseJSON) — выпускает токены. Пароль не передается.
+//
+// Вход без пароля через Login: x-login-method: email_code или magic_link с пустым паролем
+// отправляет письмо с кодом или ссылкой (FailedPrecondition, LOGIN_CODE_SENT),
+// с кодом (токеном из ссылки) в поле password — выпускает токены.
+const (
+	loginMethodHeader       = "x-login-method"
+	webauthnAssertionHeader = "x-webauthn-assertion"
+	loginMethodPasskey      = "passkey"
+)
+
+// Причины ErrorInfo для входа, требующего дополнительный шаг
+const (
+	errorDomain             = "sso"
+	mfaRequiredReason       = "MFA_REQUIRED"
+	mfaEnrollmentReason     = "MFA_ENROLLMENT_REQUIRED"
+	assertionRequiredReason = "WEBAUTHN_ASSERTION_REQUIRED"
+	loginCodeSentReason     = "LOGIN_CODE_SENT"
+	passwordExpiredReason   = "PASSWORD_EXPIRED"
+)
+
 // Методы для работы с бизнес-логикой
 type AuthService interface {
 	Login(ctx context.Context, username string, password string, appID uint32) (token models.Tokens, err error)
 	Logout(ctx context.Context, token string) (success bool, err error)
 	Register(ctx context.Context, username string, password string) error
-	IsAdmin(ctx context.Context, username string) (isAdmin bool, err error)
-	RefreshToken(ctx context.Context, token, expired_at string) (*models.Token, error)
+	IsAdmin(ctx context.Context, username string, appID uint32) (isAdmin bool, err error)
+	HasPermission(ctx context.Context, username string, appID uint32, permission string) (bool, error)
+	RefreshToken(ctx context.Context, token string, appID uint32) (models.Tokens, error)
+	BeginPasskeyLogin(ctx context.Context, username string, appID uint32) (webauthn.RequestOptions, error)
+	PasskeyLogin(ctx context.Context, resp webauthn.AssertionResponse, appID uint32) (models.Tokens, error)
+	StartPasswordlessLogin(ctx context.Context, username string, appID uint32, method string) (time.Time, error)
+	LoginWithCode(ctx context.Context, username string, code string, appID uint32) (models.Tokens, error)
+	LoginWithLink(ctx context.Context, token string, appID uint32) (models.Tokens, error)
 }
 
 // Объект реализует gRPC-сервер для сервиса аутентификации с обязательными методами.
@@ -40,29 +82,177 @@ func (s *AuthServer) Login(
 	ctx context.Context,
 	req *sso_v1.LoginRequest,
 ) (*sso_v1.LoginResponse, error) {
-	tokens, err := s.auth.Login(ctx, req.GetUsername(), req.GetPassword(), req.Metadata.GetAppId())
+	var tokens models.Tokens
+	var err error
+
+	switch method, _ := metadataValue(ctx, loginMethodHeader); method {
+	case loginMethodPasskey:
+		tokens, err = s.passkeyLogin(ctx, req)
+	case models.PasswordlessCode, models.PasswordlessLink:
+		tokens, err = s.passwordlessLogin(ctx, req, method)
+	default:
+		tokens, err = s.auth.Login(ctx, req.GetUsername(), req.GetPassword(), req.Metadata.GetAppId())
+	}
 	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() != codes.Unknown {
+			return nil, err
+		}
 		var valErr *models.ValidationError
 		if errors.As(err, &valErr) {
-			return nil, status.Error(codes.InvalidArgument, valErr.Error())
+			return nil, validationError(valErr)
 		}
 		if errors.Is(err, auth.ErrInvalidCredentials) {
 			return nil, status.Error(codes.InvalidArgument, "invalid login or password")
 		}
+		if errors.Is(err, auth.ErrInvalidDPoPProof) {
+			return nil, status.Error(codes.Unauthenticated, "invalid DPoP proof")
+		}
+		if errors.Is(err, auth.ErrUserDisabled) {
+			return nil, status.Error(codes.PermissionDenied, "user is disabled")
+		}
+		if errors.Is(err, auth.ErrUserLocked) {
+			return nil, status.Error(codes.PermissionDenied, "user is locked")
+		}
+		var lockErr *auth.LockoutError
+		if errors.As(err, &lockErr) {
+			setRetryAfter(ctx, lockErr.RetryAfter)
+			return nil, status.Error(codes.ResourceExhausted, lockErr.Error())
+		}
+		if errors.Is(err, auth.ErrAppAccessDenied) {
+			return nil, status.Error(codes.PermissionDenied, auth.ErrAppAccessDenied.Error())
+		}
+		var mfaErr *auth.MFARequiredError
+		if errors.As(err, &mfaErr) {
+			return nil, preconditionError(mfaRequiredReason, mfaErr.Error(), map[string]string{
+				"challenge_token": mfaErr.ChallengeToken,
+				"expires_at":      mfaErr.ExpiresAt.Format(time.RFC3339),
+			})
+		}
+		if errors.Is(err, auth.ErrMFAEnrollmentRequired) {
+			return nil, preconditionError(mfaEnrollmentReason, auth.ErrMFAEnrollmentRequired.Error(), nil)
+		}
+		// Пароль меняется по токену через POST /auth/password/expired административного API
+		var expiredErr *auth.PasswordExpiredError
+		if errors.As(err, &expiredErr) {
+			return nil, preconditionError(passwordExpiredReason, expiredErr.Error(), map[string]string{
+				"change_token": expiredErr.ChangeToken,
+				"expires_at":   expiredErr.ExpiresAt.Format(time.RFC3339),
+			})
+		}
+		if errors.Is(err, passkeys.ErrInvalidPasskey) || errors.Is(err, passkeys.ErrPasskeyChallenge) {
+			return nil, status.Error(codes.Unauthenticated, "invalid passkey")
+		}
+		if errors.Is(err, auth.ErrPasskeysDisabled) {
+			return nil, status.Error(codes.Unimplemented, auth.ErrPasskeysDisabled.Error())
+		}
+		if errors.Is(err, auth.ErrInvalidLoginCode) {
+			return nil, status.Error(codes.Unauthenticated, auth.ErrInvalidLoginCode.Error())
+		}
+		if errors.Is(err, auth.ErrPasswordlessDisabled) {
+			return nil, status.Error(codes.Unimplemented, auth.ErrPasswordlessDisabled.Error())
+		}
+		if errors.Is(err, storage.ErrAppNotFound) {
+			return nil, status.Error(codes.InvalidArgument, "app not found")
+		}
 		return nil, status.Error(codes.Internal, "internal error")
 	}
 
-	return &sso_v1.LoginResponse{
-		AccessToken: &sso_v1.UserToken{
-			Token:     tokens.AccessToken.Token,
-			ExpiredAt: strconv.FormatInt(tokens.AccessToken.Expire_at, 10),
-		},
-		RefreshToken: &sso_v1.UserToken{
-			Token:     tokens.RefreshToken.Token,
-			ExpiredAt: strconv.FormatInt(tokens.RefreshToken.Expire_at, 10),
-		},
-	}, nil
+	return newLoginResponse(tokens), nil
+}
+
+// passkeyLogin начинает или завершает вход по passkey в зависимости от наличия x-webauthn-assertion
+func (s *AuthServer) passkeyLogin(
+	ctx context.Context,
+	req *sso_v1.LoginRequest,
+) (models.Tokens, error) {
+	raw, ok := metadataValue(ctx, webauthnAssertionHeader)
+	if !ok {
+		options, err := s.auth.BeginPasskeyLogin(ctx, req.GetUsername(), req.Metadata.GetAppId())
+		if err != nil {
+			return models.Tokens{}, err
+		}
+		data, err := json.Marshal(options)
+		if err != nil {
+			return models.Tokens{}, err
+		}
+		return models.Tokens{}, preconditionError(assertionRequiredReason, "passkey assertion required", map[string]string{
+			"options": string(data),
+		})
+	}
+
+	var resp webauthn.AssertionResponse
+	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+		return models.Tokens{}, status.Error(codes.InvalidArgument, "invalid x-webauthn-assertion header")
+	}
+	return s.auth.PasskeyLogin(ctx, resp, req.Metadata.GetAppId())
+}
+
+// passwordlessLogin отправляет письмо для входа без пароля, если пароль пуст,
+// иначе завершает вход кодом или токеном ссылки из поля password
+func (s *AuthServer) passwordlessLogin(
+	ctx context.Context,
+	req *sso_v1.LoginRequest,
+	method string,
+) (models.Tokens, error) {
+	if req.GetPassword() == "" {
+		expiresAt, err := s.auth.StartPasswordlessLogin(ctx, req.GetUsername(), req.Metadata.GetAppId(), method)
+		if err != nil {
+			return models.Tokens{}, err
+		}
+		return models.Tokens{}, preconditionError(loginCodeSentReason, "login code sent", map[string]string{
+			"method":     method,
+			"expires_at": expiresAt.Format(time.RFC3339),
+		})
+	}
 
+	if method == models.PasswordlessLink {
+		return s.auth.LoginWithLink(ctx, req.GetPassword(), req.Metadata.GetAppId())
+	}
+	return s.auth.LoginWithCode(ctx, req.GetUsername(), req.GetPassword(), req.Metadata.GetAppId())
+}
+
+// preconditionError возвращает FailedPrecondition с ErrorInfo. Данные следующего шага входа
+// (токен challenge MFA, параметры passkey) передаются в metadata деталей.
+func preconditionError(reason, msg string, md map[string]string) error {
+	st := status.New(codes.FailedPrecondition, msg)
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   errorDomain,
+		Metadata: md,
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// validationError возвращает InvalidArgument, перечисляя в BadRequest все нарушенные правила поля
+func validationError(valErr *models.ValidationError) error {
+	st := status.New(codes.InvalidArgument, valErr.Error())
+
+	descriptions := valErr.Details
+	if len(descriptions) == 0 {
+		descriptions = []string{valErr.Message}
+	}
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(descriptions))
+	for _, description := range descriptions {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       valErr.Field,
+			Description: description,
+		})
+	}
+
+	detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// setRetryAfter передает клиенту время до снятия блокировки, округленное вверх до секунды
+func setRetryAfter(ctx context.Context, retryAfter time.Duration) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	_ = grpc.SetHeader(ctx, metadata.Pairs(retryAfterHeader, strconv.FormatInt(seconds, 10)))
 }
 
 // FIXME
@@ -85,7 +275,7 @@ func (s *AuthServer) Register(
 
 		var errVal *models.ValidationError
 		if errors.As(err, &errVal) {
-			return nil, status.Error(codes.InvalidArgument, errVal.Error())
+			return nil, validationError(errVal)
 		}
 
 		return nil, status.Error(codes.Internal, "failed to register user")
@@ -94,17 +284,89 @@ func (s *AuthServer) Register(
 	return &sso_v1.RegisterResponse{Success: true}, nil
 }
 
-// FIXME
+// IsAdmin проверяет право администратора пользователя в приложении.
+// Если в metadata передан заголовок x-permission, проверяется указанное право (HasPermission).
 func (s *AuthServer) IsAdmin(
 	ctx context.Context,
 	req *sso_v1.IsAdminRequest,
 ) (*sso_v1.IsAdminResponse, error) {
-	panic("implement me!")
+	var allowed bool
+	var err error
+
+	if permission, ok := metadataValue(ctx, permissionHeader); ok {
+		allowed, err = s.auth.HasPermission(ctx, req.GetUsername(), req.GetMetadata().GetAppId(), permission)
+	} else {
+		allowed, err = s.auth.IsAdmin(ctx, req.GetUsername(), req.GetMetadata().GetAppId())
+	}
+	if err != nil {
+		var valErr *models.ValidationError
+		if errors.As(err, &valErr) {
+			return nil, validationError(valErr)
+		}
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return &sso_v1.IsAdminResponse{IsAdmin: allowed}, nil
+}
+
+// metadataValue возвращает единственное значение заголовка metadata запроса
+func metadataValue(ctx context.Context, key string) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	values := md.Get(key)
+	if len(values) != 1 {
+		return "", false
+	}
+	return values[0], true
 }
 
 func (s *AuthServer) RefreshToken(
 	ctx context.Context,
 	req *sso_v1.RefreshTokenRequest,
 ) (*sso_v1.LoginResponse, error) {
-	panic("implement me!")
+	tokens, err := s.auth.RefreshToken(ctx, req.GetRefreshToken().GetToken(), req.GetMetadata().GetAppId())
+	if err != nil {
+		var valErr *models.ValidationError
+		if errors.As(err, &valErr) {
+			return nil, validationError(valErr)
+		}
+		if errors.Is(err, auth.ErrInvalidRefreshToken) {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired refresh token")
+		}
+		if errors.Is(err, auth.ErrInvalidDPoPProof) {
+			return nil, status.Error(codes.Unauthenticated, "invalid DPoP proof")
+		}
+		if errors.Is(err, auth.ErrCertificateMismatch) {
+			return nil, status.Error(codes.Unauthenticated, "client certificate mismatch")
+		}
+		if errors.Is(err, auth.ErrUserDisabled) ||
+			errors.Is(err, auth.ErrUserLocked) ||
+			errors.Is(err, auth.ErrAppAccessDenied) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	return newLoginResponse(tokens), nil
+}
+
+func newLoginResponse(tokens models.Tokens) *sso_v1.LoginResponse {
+	return &sso_v1.LoginResponse{
+		AccessToken: &sso_v1.UserToken{
+			Token:     tokens.AccessToken.Token,
+			ExpiredAt: strconv.FormatInt(tokens.AccessToken.Expire_at, 10),
+		},
+		RefreshToken: &sso_v1.UserToken{
+			Token:     tokens.RefreshToken.Token,
+			ExpiredAt: strconv.FormatInt(tokens.RefreshToken.Expire_at, 10),
+		},
+	}
 }
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/secretbox"
+	"github.com/Grino777/sso/internal/services/auth"
+	"github.com/Grino777/sso/internal/storage/sqlite"
+
+	sso_v1 "github.com/Grino777/sso-proto/gen/go/sso"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testHasher хранит пароль открытым текстом, чтобы тесты не тратили время на argon2id
+type testHasher struct{}
+
+func (testHasher) Hash(password string) (string, error) { return "plain:" + password, nil }
+
+func (testHasher) Verify(hash []byte, password string) (bool, bool, error) {
+	return string(hash) == "plain:"+password, false, nil
+}
+
+// newTestServer возвращает сервер с сервисом аутентификации на sqlite во временном файле
+func newTestServer(t *testing.T) (*AuthServer, *sqlite.SQLiteStorage) {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	box, err := secretbox.New("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := sqlite.New(
+		"sqlite3",
+		filepath.Join(t.TempDir(), "sso.sqlite3"),
+		config.SuperUser{Username: "root", Password: "root-password"},
+		testHasher{},
+		box,
+		log,
+	)
+	if err := db.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close(context.Background()) })
+
+	return &AuthServer{auth: auth.NewAuthService(auth.AuthService{Logger: log, DB: db}, nil)}, db
+}
+
+func isAdmin(s *AuthServer, username string, appID uint32, permission string) (bool, error) {
+	ctx := context.Background()
+	if permission != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(permissionHeader, permission))
+	}
+	resp, err := s.IsAdmin(ctx, &sso_v1.IsAdminRequest{
+		Username: username,
+		Metadata: &sso_v1.AuthMetadata{AppId: appID},
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.IsAdmin, nil
+}
+
+func TestIsAdmin__Permission(t *testing.T) {
+	s, db := newTestServer(t)
+	ctx := context.Background()
+
+	if err := db.SaveUser(ctx, "alice", "plain:password1"); err != nil {
+		t.Fatal(err)
+	}
+	alice, err := db.GetUser(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var apps [2]uint32
+	for i := range apps {
+		if apps[i], err = db.CreateApp(ctx, models.App{Name: "app", Secret: "secret", CreatedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Роль с правом reports:read назначена alice только в первом приложении
+	if _, err := db.CreatePermission(ctx, models.Permission{Name: "reports:read"}); err != nil {
+		t.Fatal(err)
+	}
+	roleID, err := db.CreateRole(ctx, models.Role{Name: "reporter", Permissions: []string{"reports:read"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetUserAppRole(ctx, alice.ID, apps[0], roleID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		username   string
+		appID      uint32
+		permission string
+		want       bool
+	}{
+		{"assigned permission", "alice", apps[0], "reports:read", true},
+		{"permission in another app", "alice", apps[1], "reports:read", false},
+		{"permission not granted", "alice", apps[0], "reports:write", false},
+		{"not an admin without header", "alice", apps[0], "", false},
+		{"superadmin without header", "root", apps[1], "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := isAdmin(s, tt.username, tt.appID, tt.permission)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("allowed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Права отключенного пользователя не действуют
+	if err := db.SetUserDisabled(ctx, alice.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := isAdmin(s, "alice", apps[0], "reports:read"); err != nil || got {
+		t.Fatalf("disabled user: allowed = %v, err = %v", got, err)
+	}
+
+	if _, err := isAdmin(s, "bob", apps[0], "reports:read"); status.Code(err) != codes.NotFound {
+		t.Fatalf("unknown user: err = %v, want NotFound", err)
+	}
+	if _, err := isAdmin(s, "alice", apps[0], "reports read"); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("invalid permission: err = %v, want InvalidArgument", err)
+	}
+}
@@ -1,9 +1,99 @@
 package models
 
+import (
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MaxAppNameLength = 50
+	TooLongField     = "field is too long"
+)
+
+// Политики членства пользователей в приложении
+const (
+	// Войти может любой пользователь, кроме заблокированных
+	MembershipOpen = "open"
+	// Войти могут только добавленные администратором пользователи
+	MembershipInviteOnly = "invite_only"
+	// Пользователь становится участником при первом входе
+	MembershipAutoEnroll = "auto_enroll"
+)
+
 type App struct {
 	ID     uint32
 	Name   string
 	Secret string
+	// Секрет, действовавший до ротации. Принимается до PreviousSecretExpiresAt
+	PreviousSecret          string
+	PreviousSecretExpiresAt time.Time
+	CreatedAt               time.Time
+	MembershipPolicy        string
+	// Пользователи приложения обязаны входить со вторым фактором
+	RequireMFA bool
+	// Если задан, access токены приложения оборачиваются в JWE
+	EncryptionKey *EncryptionKey
+	// Отпечатки (x5t#S256) клиентских сертификатов для mTLS аутентификации
+	ClientCerts []string
+	// Сроки токенов и сеансов приложения, незаданные берутся из глобальной конфигурации
+	Lifetimes SessionLifetimes
+	// Приложению разрешено выдавать offline сеансы по запросу offline_access
+	OfflineAccess bool
+}
+
+// HasCertificate проверяет, зарегистрирован ли сертификат за приложением
+func (a *App) HasCertificate(thumbprint string) bool {
+	for _, cert := range a.ClientCerts {
+		if cert == thumbprint {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptedSecrets возвращает секреты, которыми приложение может подписывать запросы.
+// После ротации старый секрет действует до окончания периода перекрытия.
+func (a *App) AcceptedSecrets(now time.Time) []string {
+	secrets := make([]string, 0, 2)
+	if a.Secret != "" {
+		secrets = append(secrets, a.Secret)
+	}
+	if a.PreviousSecret != "" && now.Before(a.PreviousSecretExpiresAt) {
+		secrets = append(secrets, a.PreviousSecret)
+	}
+	return secrets
+}
+
+// Policy возвращает политику членства, по умолчанию open
+func (a *App) Policy() string {
+	if a.MembershipPolicy == "" {
+		return MembershipOpen
+	}
+	return a.MembershipPolicy
+}
+
+// ValidateMembershipPolicy проверяет политику членства
+func ValidateMembershipPolicy(policy string) error {
+	switch policy {
+	case MembershipOpen, MembershipInviteOnly, MembershipAutoEnroll:
+		return nil
+	default:
+		return &ValidationError{
+			Field:   "membership_policy",
+			Message: "must be one of: open, invite_only, auto_enroll",
+		}
+	}
+}
+
+// ValidateAppName проверяет название приложения
+func ValidateAppName(name string) error {
+	if name == "" {
+		return &ValidationError{Field: "name", Message: EmptyField}
+	}
+	if utf8.RuneCountInString(name) > MaxAppNameLength {
+		return &ValidationError{Field: "name", Message: TooLongField}
+	}
+	return nil
 }
 
 func (a *App) validateFields() error {
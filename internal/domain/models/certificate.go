@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// AppCertificate клиентский сертификат приложения для аутентификации по mTLS (RFC 8705)
+type AppCertificate struct {
+	AppID      uint32
+	Thumbprint string // x5t#S256
+	Subject    string
+	NotAfter   time.Time
+	CreatedAt  time.Time
+}
+
+func (c *AppCertificate) validateFields() error {
+	if c.AppID == 0 {
+		return &ValidationError{Field: "app_id", Message: EmptyField}
+	}
+	if c.Thumbprint == "" {
+		return &ValidationError{Field: "thumbprint", Message: EmptyField}
+	}
+
+	return nil
+}
+
+func (c *AppCertificate) IsValid() error {
+	return c.validateFields()
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Алгоритмы шифрования ключа содержимого для JWE
+const (
+	KeyAlgRSAOAEP256 = "RSA-OAEP-256"
+	KeyAlgECDHES     = "ECDH-ES"
+)
+
+const UnsupportedAlgorithm = "unsupported algorithm"
+
+// EncryptionKey публичный ключ приложения, которым шифруются выдаваемые access токены
+type EncryptionKey struct {
+	ID        string
+	AppID     uint32
+	Algorithm string
+	PublicKey string // PEM
+	CreatedAt time.Time
+}
+
+func (k *EncryptionKey) validateFields() error {
+	switch k.Algorithm {
+	case "":
+		return &ValidationError{Field: "algorithm", Message: EmptyField}
+	case KeyAlgRSAOAEP256, KeyAlgECDHES:
+	default:
+		return &ValidationError{Field: "algorithm", Message: UnsupportedAlgorithm}
+	}
+
+	if k.PublicKey == "" {
+		return &ValidationError{Field: "public_key", Message: EmptyField}
+	}
+
+	return nil
+}
+
+func (k *EncryptionKey) IsValid() error {
+	return k.validateFields()
+}
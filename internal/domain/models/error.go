@@ -1,6 +1,9 @@
 package models
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const (
 	EmptyField         = "filed cannot be empty"
@@ -11,8 +14,13 @@ const (
 type ValidationError struct {
 	Field   string
 	Message string
+	// Все нарушенные правила, если значение проверялось по нескольким
+	Details []string
 }
 
 func (e *ValidationError) Error() string {
+	if len(e.Details) > 0 {
+		return fmt.Sprintf("validation error for field %q: %s: %s", e.Field, e.Message, strings.Join(e.Details, "; "))
+	}
 	return fmt.Sprintf("validation error for field %q: %s", e.Field, e.Message)
 }
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Префиксы субъектов счетчиков неудачных попыток входа
+const (
+	lockoutUserPrefix = "user:"
+	lockoutIPPrefix   = "ip:"
+)
+
+// LockoutStatus состояние счетчиков неудачных попыток входа субъекта
+type LockoutStatus struct {
+	Subject string
+	// Неудачные попытки в текущем окне
+	Failures int64
+	// Число блокировок подряд, от него зависит длительность следующей
+	Strikes int64
+	// Нулевое значение, если вход не заблокирован
+	LockedUntil time.Time
+}
+
+// Locked возвращает true, если вход субъекта заблокирован на момент now
+func (s LockoutStatus) Locked(now time.Time) bool {
+	return now.Before(s.LockedUntil)
+}
+
+// RetryAfter возвращает время до снятия блокировки
+func (s LockoutStatus) RetryAfter(now time.Time) time.Duration {
+	if !s.Locked(now) {
+		return 0
+	}
+	return s.LockedUntil.Sub(now)
+}
+
+// UserLockoutSubject субъект счетчика неудачных попыток для имени пользователя.
+// Имена, отличающиеся только регистром или формой Unicode, считаются одним субъектом.
+func UserLockoutSubject(username string) string {
+	return lockoutUserPrefix + UsernameKey(username)
+}
+
+// IPLockoutSubject субъект счетчика неудачных попыток для адреса клиента
+func IPLockoutSubject(ip string) string {
+	return lockoutIPPrefix + ip
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Причины неудачного входа в журнале users_logs
+const (
+	LoginReasonInvalidRequest      = "invalid_request"
+	LoginReasonUserNotFound        = "user_not_found"
+	LoginReasonInvalidCredentials  = "invalid_credentials"
+	LoginReasonUserDisabled        = "user_disabled"
+	LoginReasonUserLocked          = "user_locked"
+	LoginReasonTooManyAttempts     = "too_many_attempts"
+	LoginReasonMFARequired         = "mfa_required"
+	LoginReasonMFAEnrollment       = "mfa_enrollment_required"
+	LoginReasonInvalidMFACode      = "invalid_mfa_code"
+	LoginReasonInvalidMFAChallenge = "invalid_mfa_challenge"
+	LoginReasonInvalidPasskey      = "invalid_passkey"
+	LoginReasonAppNotFound         = "app_not_found"
+	LoginReasonAppAccessDenied     = "app_access_denied"
+	LoginReasonInvalidDPoPProof    = "invalid_dpop_proof"
+	LoginReasonCertificateMismatch = "certificate_mismatch"
+	LoginReasonInternalError       = "internal_error"
+)
+
+// Размер выборки истории входов
+const (
+	DefaultLoginsLimit = 20
+	MaxLoginsLimit     = 100
+)
+
+// UnknownIP записывается, если адрес клиента определить не удалось
+const UnknownIP = "unknown"
+
+// LoginRecord попытка входа пользователя
+type LoginRecord struct {
+	ID uint64
+	// 0, если пользователь не найден
+	UserID    uint64
+	Username  string
+	AppID     uint32
+	IP        string
+	UserAgent string
+	Success   bool
+	// Причина отказа, пусто для успешного входа
+	Reason    string
+	CreatedAt time.Time
+}
+
+// ClampLoginsLimit приводит размер выборки истории входов к допустимому
+func ClampLoginsLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLoginsLimit
+	}
+	return min(limit, MaxLoginsLimit)
+}
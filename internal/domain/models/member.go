@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// AppMember запись user_apps: членство пользователя в приложении
+type AppMember struct {
+	UserID   uint64
+	AppID    uint32
+	Username string
+	Blocked  bool
+	// Роль в приложении, 0 — не назначена
+	RoleID    int
+	CreatedAt time.Time
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// UserMFA настройки TOTP пользователя
+type UserMFA struct {
+	UserID     uint64
+	TOTPSecret string
+	// false, пока пользователь не подтвердил привязку кодом
+	Enabled bool
+	// Последний принятый шаг TOTP, коды этого и более ранних шагов не принимаются повторно
+	LastUsedStep      int64
+	RecoveryCodesLeft int
+	CreatedAt         time.Time
+	EnabledAt         time.Time
+}
+
+// TOTPEnrollment данные для привязки приложения-аутентификатора
+type TOTPEnrollment struct {
+	Secret string
+	// otpauth:// URI для QR кода
+	URI string
+}
+
+// MFAChallenge незавершенный вход пользователя, ожидающий второй фактор
+type MFAChallenge struct {
+	UserID    uint64        `json:"user_id"`
+	AppID     uint32        `json:"app_id"`
+	Cnf       *Confirmation `json:"cnf,omitempty"`
+	Offline   bool          `json:"offline,omitempty"` // при входе запрошен offline доступ
+	Attempts  int           `json:"attempts"`
+	ExpiresAt time.Time     `json:"expires_at"`
+}
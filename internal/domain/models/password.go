@@ -0,0 +1,122 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Число предыдущих паролей, которое хранится для проверки повторного использования
+const MaxPasswordHistory = 24
+
+// Минимальная длина имени пользователя, которое ищется в пароле
+const minSimilarUsername = 3
+
+const PasswordPolicyViolation = "password does not meet the password policy"
+
+// PasswordPolicy требования к паролю. Нулевые значения отключают соответствующие правила.
+type PasswordPolicy struct {
+	MinLength        int
+	MaxLength        int
+	RequireUpper     bool
+	RequireLower     bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	DisallowUsername bool
+	// Число последних паролей, которые нельзя использовать повторно
+	History int
+	MaxAge  time.Duration
+}
+
+// Violations возвращает все правила политики, которым не соответствует пароль пользователя username
+func (p PasswordPolicy) Violations(username, password string) []string {
+	var violations []string
+
+	length := utf8.RuneCountInString(password)
+	if minLength := max(p.MinLength, MinLenPass); length < minLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", minLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("must be at most %d characters", p.MaxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		violations = append(violations, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		violations = append(violations, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "must contain a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "must contain a symbol")
+	}
+
+	if p.DisallowUsername && similarToUsername(username, password) {
+		violations = append(violations, "must not contain the username")
+	}
+	return violations
+}
+
+// Validate проверяет пароль по политике и возвращает ValidationError со всеми нарушенными правилами
+func (p PasswordPolicy) Validate(username, password string) error {
+	if password == "" {
+		return &ValidationError{Field: "password", Message: EmptyField}
+	}
+	if violations := p.Violations(username, password); len(violations) > 0 {
+		return &ValidationError{Field: "password", Message: PasswordPolicyViolation, Details: violations}
+	}
+	return nil
+}
+
+// Expired проверяет, истек ли срок действия пароля пользователя на момент now
+func (p PasswordPolicy) Expired(user User, now time.Time) bool {
+	if p.MaxAge <= 0 || user.PasswordChangedAt.IsZero() {
+		return false
+	}
+	return !now.Before(user.PasswordChangedAt.Add(p.MaxAge))
+}
+
+// similarToUsername проверяет, содержит ли пароль имя пользователя (или локальную часть email)
+// в прямом или обратном порядке без учета регистра
+func similarToUsername(username, password string) bool {
+	password = strings.ToLower(password)
+
+	names := []string{strings.ToLower(username)}
+	if local, _, ok := strings.Cut(names[0], "@"); ok {
+		names = append(names, local)
+	}
+	for _, name := range names {
+		if utf8.RuneCountInString(name) < minSimilarUsername {
+			continue
+		}
+		if strings.Contains(password, name) || strings.Contains(password, reverse(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
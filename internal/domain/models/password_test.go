@@ -0,0 +1,99 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPasswordPolicy__Validate(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:        8,
+		MaxLength:        16,
+		RequireUpper:     true,
+		RequireLower:     true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		DisallowUsername: true,
+	}
+
+	cases := []struct {
+		name       string
+		username   string
+		password   string
+		violations []string
+	}{
+		{name: "valid", username: "alice", password: "Correct-h0rse"},
+		{name: "unicode length", username: "alice", password: "Пароль-1x"},
+		{
+			name:     "every class missing",
+			username: "alice",
+			password: "        ",
+			violations: []string{
+				"must contain an uppercase letter",
+				"must contain a lowercase letter",
+				"must contain a digit",
+			},
+		},
+		{
+			name:       "too short",
+			username:   "alice",
+			password:   "Ab1-",
+			violations: []string{"must be at least 8 characters"},
+		},
+		{
+			name:       "too long",
+			username:   "alice",
+			password:   "Abcdefgh-1234567890",
+			violations: []string{"must be at most 16 characters"},
+		},
+		{
+			name:       "username",
+			username:   "alice@example.com",
+			password:   "My-ALICE-1",
+			violations: []string{"must not contain the username"},
+		},
+		{
+			name:       "reversed username",
+			username:   "alice",
+			password:   "Ecila-2024",
+			violations: []string{"must not contain the username"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Validate(tc.username, tc.password)
+			if len(tc.violations) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var valErr *ValidationError
+			if !errors.As(err, &valErr) {
+				t.Fatalf("expected validation error, got %v", err)
+			}
+			if valErr.Field != "password" || !slices.Equal(valErr.Details, tc.violations) {
+				t.Errorf("unexpected violations: %q", valErr.Details)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy__Expired(t *testing.T) {
+	now := time.Now()
+	user := User{PasswordChangedAt: now.Add(-48 * time.Hour)}
+
+	if (PasswordPolicy{}).Expired(user, now) {
+		t.Error("password without max age must not expire")
+	}
+	if !(PasswordPolicy{MaxAge: 24 * time.Hour}).Expired(user, now) {
+		t.Error("expected expired password")
+	}
+	if (PasswordPolicy{MaxAge: 72 * time.Hour}).Expired(user, now) {
+		t.Error("unexpected expired password")
+	}
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Способы входа без пароля
+const (
+	PasswordlessCode = "email_code"
+	PasswordlessLink = "magic_link"
+)
+
+// LoginCode одноразовый код или токен ссылки для входа без пароля.
+// В кэше хранится только хэш секрета.
+type LoginCode struct {
+	UserID    uint64    `json:"user_id"`
+	AppID     uint32    `json:"app_id"`
+	Hash      string    `json:"hash"`
+	Attempts  int       `json:"attempts"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
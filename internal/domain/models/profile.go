@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MaxDisplayNameLength = 128
+	MaxEmailLength       = 254
+	// Максимальный размер атрибутов профиля в JSON
+	MaxAttributesSize = 4096
+)
+
+// Тег языка BCP 47: ru, en-US, zh-Hant-TW
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8}){0,3}$`)
+
+// Profile данные профиля пользователя
+type Profile struct {
+	UserID uint64
+	// Пустая строка, если email не указан
+	Email string
+	// Email подтвержден переходом по ссылке из письма, по нему разрешен вход
+	EmailVerified bool
+	DisplayName   string
+	Locale        string
+	// Произвольные атрибуты пользователя (JSON объект)
+	Attributes map[string]any
+	UpdatedAt  time.Time
+}
+
+// ProfileUpdate изменение профиля, nil поля не изменяются
+type ProfileUpdate struct {
+	Email       *string
+	DisplayName *string
+	Locale      *string
+	Attributes  map[string]any
+}
+
+// Apply применяет изменение к профилю. Смена email сбрасывает его подтверждение.
+func (p *Profile) Apply(update ProfileUpdate) {
+	if update.Email != nil {
+		email := NormalizeEmail(*update.Email)
+		if email != p.Email {
+			p.Email = email
+			p.EmailVerified = false
+		}
+	}
+	if update.DisplayName != nil {
+		p.DisplayName = strings.TrimSpace(*update.DisplayName)
+	}
+	if update.Locale != nil {
+		p.Locale = *update.Locale
+	}
+	if update.Attributes != nil {
+		p.Attributes = update.Attributes
+	}
+}
+
+// Validate проверяет поля профиля
+func (p *Profile) Validate() error {
+	if p.Email != "" {
+		if err := ValidateEmail(p.Email); err != nil {
+			return err
+		}
+	}
+	if utf8.RuneCountInString(p.DisplayName) > MaxDisplayNameLength {
+		return &ValidationError{Field: "display_name", Message: "must be at most 128 characters"}
+	}
+	if p.Locale != "" && !localePattern.MatchString(p.Locale) {
+		return &ValidationError{Field: "locale", Message: "must be a BCP 47 language tag"}
+	}
+	data, err := json.Marshal(p.Attributes)
+	if err != nil {
+		return &ValidationError{Field: "attributes", Message: "must be a JSON object"}
+	}
+	if len(data) > MaxAttributesSize {
+		return &ValidationError{Field: "attributes", Message: "must be at most 4096 bytes"}
+	}
+	return nil
+}
+
+// ValidateEmail проверяет адрес электронной почты без имени получателя
+func ValidateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || len(email) > MaxEmailLength {
+		return &ValidationError{Field: "email", Message: "must be a valid email address"}
+	}
+	return nil
+}
+
+// NormalizeEmail приводит адрес к виду, в котором он хранится и сравнивается
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// RateLimit параметры token bucket: Rate токенов в секунду, емкость Burst.
+// Нулевое значение означает отсутствие ограничения.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited возвращает true, если ограничение не задано
+func (l RateLimit) Unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// RateLimitResult результат попытки взять токен из bucket
+type RateLimitResult struct {
+	Allowed bool
+	// Время до появления следующего токена, если запрос отклонен
+	RetryAfter time.Duration
+}
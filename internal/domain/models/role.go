@@ -1,6 +1,107 @@
 package models
 
+import (
+	"slices"
+	"strings"
+)
+
+// Идентификаторы ролей из таблицы roles
+const (
+	RoleUser       = 1
+	RoleAdmin      = 2
+	RoleSuperAdmin = 3
+)
+
+// PermissionAdmin встроенное право администратора приложения, его проверяет IsAdmin
+const PermissionAdmin = "admin"
+
+const (
+	MaxRoleNameLength       = 50
+	MaxPermissionNameLength = 100
+)
+
 type Role struct {
-	ID   int
-	Name string
+	ID          int
+	Name        string
+	Description string
+	Permissions []string
+}
+
+// IsBuiltin проверяет, что роль встроенная (user, admin, superadmin)
+func (r *Role) IsBuiltin() bool {
+	return r.ID >= RoleUser && r.ID <= RoleSuperAdmin
+}
+
+type Permission struct {
+	ID          int
+	Name        string
+	Description string
+}
+
+// Access роли и права пользователя в конкретном приложении
+type Access struct {
+	Roles       []string
+	Permissions []string
+}
+
+// NewAccess объединяет права ролей без повторов
+func NewAccess(roles []Role) Access {
+	access := Access{Roles: []string{}, Permissions: []string{}}
+	for _, role := range roles {
+		access.Roles = append(access.Roles, role.Name)
+		for _, permission := range role.Permissions {
+			if !slices.Contains(access.Permissions, permission) {
+				access.Permissions = append(access.Permissions, permission)
+			}
+		}
+	}
+	slices.Sort(access.Permissions)
+	return access
+}
+
+// HasPermission проверяет наличие права
+func (a Access) HasPermission(permission string) bool {
+	return slices.Contains(a.Permissions, permission)
+}
+
+// RoleName возвращает имя встроенной роли
+func RoleName(roleID int) string {
+	switch roleID {
+	case RoleUser:
+		return "user"
+	case RoleAdmin:
+		return "admin"
+	case RoleSuperAdmin:
+		return "superadmin"
+	default:
+		return "unknown"
+	}
+}
+
+// HasRole проверяет, что роль не ниже требуемой (superadmin > admin > user)
+func HasRole(roleID, required int) bool {
+	return roleID >= required && roleID <= RoleSuperAdmin
+}
+
+// ValidateRoleName проверяет имя роли
+func ValidateRoleName(name string) error {
+	return validateName("name", name, MaxRoleNameLength)
+}
+
+// ValidatePermissionName проверяет имя права, например "orders:read"
+func ValidatePermissionName(name string) error {
+	return validateName("permission", name, MaxPermissionNameLength)
+}
+
+func validateName(field, name string, maxLen int) error {
+	if name == "" {
+		return &ValidationError{Field: field, Message: EmptyField}
+	}
+	if strings.ContainsAny(name, " \t\n") {
+		return &ValidationError{Field: field, Message: FieldContainSpaces}
+	}
+	if len(name) > maxLen {
+		return &ValidationError{Field: field, Message: TooLongField}
+	}
+	return nil
 }
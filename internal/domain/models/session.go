@@ -0,0 +1,192 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MaxDeviceNameLength максимальная длина имени устройства, переданного клиентом
+const MaxDeviceNameLength = 64
+
+// ScopeOfflineAccess scope, запрашивающий долгоживущий refresh токен (OpenID Connect offline_access)
+const ScopeOfflineAccess = "offline_access"
+
+// Session сеанс пользователя в приложении. Сеанс создается при входе и продлевается
+// при обновлении токенов; у каждого сеанса свой refresh токен, отзыв сеанса удаляет его.
+type Session struct {
+	ID         string
+	UserID     uint64
+	AppID      uint32
+	IP         string
+	UserAgent  string
+	DeviceName string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	// Срок действия текущего refresh токена сеанса
+	ExpiresAt time.Time
+	// Сеанс выдан по запросу offline_access ("запомнить меня") с долгоживущим refresh токеном
+	Offline bool
+}
+
+// SessionFilter параметры выборки сеансов пользователя
+type SessionFilter struct {
+	UserID uint64
+	// Пусто — все сеансы пользователя
+	SessionID string
+	// 0 — сеансы во всех приложениях
+	AppID uint32
+	// nil — любые сеансы, иначе только offline (true) или обычные (false)
+	Offline *bool
+}
+
+// SessionLifetimes сроки действия токенов и сеансов приложения.
+// Нулевое значение поля означает, что используется глобальная настройка.
+type SessionLifetimes struct {
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	// Максимальный перерыв между обновлениями токенов; каждое обновление продлевает сеанс
+	IdleTimeout time.Duration
+	// Максимальный срок сеанса с момента входа независимо от обновлений
+	MaxAge time.Duration
+	// Срок refresh токена offline сеанса
+	OfflineTTL time.Duration
+}
+
+// Or возвращает сроки, в которых незаданные значения взяты из fallback
+func (l SessionLifetimes) Or(fallback SessionLifetimes) SessionLifetimes {
+	if l.AccessTTL == 0 {
+		l.AccessTTL = fallback.AccessTTL
+	}
+	if l.RefreshTTL == 0 {
+		l.RefreshTTL = fallback.RefreshTTL
+	}
+	if l.IdleTimeout == 0 {
+		l.IdleTimeout = fallback.IdleTimeout
+	}
+	if l.MaxAge == 0 {
+		l.MaxAge = fallback.MaxAge
+	}
+	if l.OfflineTTL == 0 {
+		l.OfflineTTL = fallback.OfflineTTL
+	}
+	return l
+}
+
+// For возвращает сроки, действующие для сеанса. Offline сеанс продлевается каждым
+// обновлением на OfflineTTL; время простоя и максимальный срок к нему не применяются.
+func (l SessionLifetimes) For(session Session) SessionLifetimes {
+	if !session.Offline {
+		return l
+	}
+	return SessionLifetimes{AccessTTL: l.AccessTTL, RefreshTTL: l.OfflineTTL, OfflineTTL: l.OfflineTTL}
+}
+
+// Validate проверяет сроки: значения не отрицательны и кратны секунде,
+// перерыв между обновлениями не превышает максимальный срок сеанса
+func (l SessionLifetimes) Validate() error {
+	fields := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"access_token_ttl", l.AccessTTL},
+		{"refresh_token_ttl", l.RefreshTTL},
+		{"idle_timeout", l.IdleTimeout},
+		{"max_session_age", l.MaxAge},
+		{"offline_token_ttl", l.OfflineTTL},
+	}
+	for _, field := range fields {
+		if field.value < 0 || field.value%time.Second != 0 {
+			return &ValidationError{Field: field.name, Message: "must be a non-negative whole number of seconds"}
+		}
+	}
+
+	if l.IdleTimeout > 0 && l.MaxAge > 0 && l.IdleTimeout > l.MaxAge {
+		return &ValidationError{Field: "idle_timeout", Message: "must not exceed max_session_age"}
+	}
+	return nil
+}
+
+// Expired проверяет, истек ли сеанс по времени простоя или по максимальному сроку
+func (l SessionLifetimes) Expired(session Session, now time.Time) bool {
+	if l.IdleTimeout > 0 && !now.Before(session.LastUsedAt.Add(l.IdleTimeout)) {
+		return true
+	}
+	return l.MaxAge > 0 && !now.Before(session.CreatedAt.Add(l.MaxAge))
+}
+
+// RefreshExpiry возвращает срок действия refresh токена, выпущенного сеансу в момент now:
+// не дольше RefreshTTL, времени простоя и оставшегося срока сеанса
+func (l SessionLifetimes) RefreshExpiry(session Session, now time.Time) time.Time {
+	expiry := now.Add(l.RefreshTTL)
+	if l.IdleTimeout > 0 {
+		expiry = minTime(expiry, now.Add(l.IdleTimeout))
+	}
+	if l.MaxAge > 0 {
+		expiry = minTime(expiry, session.CreatedAt.Add(l.MaxAge))
+	}
+	return expiry
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// OfflineAccessRequested проверяет, запросил ли клиент offline доступ:
+// scope (через пробел) содержит offline_access или rememberMe равен true
+func OfflineAccessRequested(scope, rememberMe string) bool {
+	if slices.Contains(strings.Fields(scope), ScopeOfflineAccess) {
+		return true
+	}
+	remember, err := strconv.ParseBool(rememberMe)
+	return err == nil && remember
+}
+
+// NewSessionID создает случайный идентификатор сеанса
+func NewSessionID() (string, error) {
+	const op = "models.session.NewSessionID"
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ValidateSessionID проверяет формат идентификатора сеанса
+func ValidateSessionID(id string) error {
+	if id == "" {
+		return &ValidationError{Field: "session_id", Message: EmptyField}
+	}
+	if len(id) != 32 {
+		return &ValidationError{Field: "session_id", Message: "invalid session id"}
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return &ValidationError{Field: "session_id", Message: "invalid session id"}
+	}
+	return nil
+}
+
+// NormalizeDeviceName убирает из имени устройства управляющие символы
+// и обрезает его до MaxDeviceNameLength символов
+func NormalizeDeviceName(name string) string {
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+
+	if runes := []rune(name); len(runes) > MaxDeviceNameLength {
+		name = string(runes[:MaxDeviceNameLength])
+	}
+	return name
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSession__ID(t *testing.T) {
+	id, err := NewSessionID()
+	if err != nil {
+		t.Fatalf("failed to create session id: %v", err)
+	}
+	if err := ValidateSessionID(id); err != nil {
+		t.Errorf("generated session id %q is invalid: %v", id, err)
+	}
+
+	for _, id := range []string{"", "abc", strings.Repeat("z", 32)} {
+		if err := ValidateSessionID(id); err == nil {
+			t.Errorf("session id %q accepted", id)
+		}
+	}
+}
+
+func TestSession__NormalizeDeviceName(t *testing.T) {
+	if got := NormalizeDeviceName("  Pixel\n8\x00 "); got != "Pixel8" {
+		t.Errorf("got %q, want %q", got, "Pixel8")
+	}
+
+	got := NormalizeDeviceName(strings.Repeat("ж", MaxDeviceNameLength+10))
+	if utf8.RuneCountInString(got) != MaxDeviceNameLength || !utf8.ValidString(got) {
+		t.Errorf("device name is not truncated to %d characters: %q", MaxDeviceNameLength, got)
+	}
+}
+
+func TestSessionLifetimes(t *testing.T) {
+	global := SessionLifetimes{AccessTTL: time.Hour, RefreshTTL: 168 * time.Hour}
+	lifetimes := SessionLifetimes{AccessTTL: 5 * time.Minute, IdleTimeout: 30 * time.Minute, MaxAge: 12 * time.Hour}.Or(global)
+	if lifetimes.AccessTTL != 5*time.Minute || lifetimes.RefreshTTL != 168*time.Hour {
+		t.Fatalf("unexpected lifetimes: %+v", lifetimes)
+	}
+
+	created := time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)
+	session := Session{CreatedAt: created, LastUsedAt: created}
+
+	if got := lifetimes.RefreshExpiry(session, created); !got.Equal(created.Add(30 * time.Minute)) {
+		t.Errorf("refresh expiry is not limited by idle timeout: %s", got)
+	}
+	if got := lifetimes.RefreshExpiry(session, created.Add(11*time.Hour+50*time.Minute)); !got.Equal(created.Add(12 * time.Hour)) {
+		t.Errorf("refresh expiry is not limited by max age: %s", got)
+	}
+
+	if lifetimes.Expired(session, created.Add(29*time.Minute)) {
+		t.Error("active session expired")
+	}
+	if !lifetimes.Expired(session, created.Add(30*time.Minute)) {
+		t.Error("idle session not expired")
+	}
+	session.LastUsedAt = created.Add(12*time.Hour - time.Minute)
+	if !lifetimes.Expired(session, created.Add(12*time.Hour)) {
+		t.Error("session older than max age not expired")
+	}
+
+	for _, invalid := range []SessionLifetimes{
+		{AccessTTL: -time.Minute},
+		{RefreshTTL: 1500 * time.Millisecond},
+		{IdleTimeout: 2 * time.Hour, MaxAge: time.Hour},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("lifetimes %+v accepted", invalid)
+		}
+	}
+}
+
+func TestSessionLifetimes__Offline(t *testing.T) {
+	lifetimes := SessionLifetimes{
+		AccessTTL:   5 * time.Minute,
+		RefreshTTL:  time.Hour,
+		IdleTimeout: 30 * time.Minute,
+		MaxAge:      12 * time.Hour,
+		OfflineTTL:  720 * time.Hour,
+	}
+	created := time.Date(2025, 7, 5, 9, 0, 0, 0, time.UTC)
+	session := Session{CreatedAt: created, LastUsedAt: created, Offline: true}
+
+	offline := lifetimes.For(session)
+	if offline.AccessTTL != lifetimes.AccessTTL {
+		t.Errorf("offline access ttl = %s", offline.AccessTTL)
+	}
+	if got := offline.RefreshExpiry(session, created); !got.Equal(created.Add(720 * time.Hour)) {
+		t.Errorf("offline refresh expiry = %s", got)
+	}
+	if offline.Expired(session, created.Add(24*time.Hour)) {
+		t.Error("offline session expired by idle timeout or max age")
+	}
+	if got := lifetimes.For(Session{}); got != lifetimes {
+		t.Errorf("regular session lifetimes changed: %+v", got)
+	}
+}
+
+func TestOfflineAccessRequested(t *testing.T) {
+	tests := []struct {
+		scope, rememberMe string
+		want              bool
+	}{
+		{"", "", false},
+		{"openid profile", "", false},
+		{"openid offline_access", "", true},
+		{"offline_accessx", "", false},
+		{"", "true", true},
+		{"", "1", true},
+		{"", "false", false},
+		{"", "yes", false},
+	}
+	for _, tt := range tests {
+		if got := OfflineAccessRequested(tt.scope, tt.rememberMe); got != tt.want {
+			t.Errorf("OfflineAccessRequested(%q, %q) = %v, want %v", tt.scope, tt.rememberMe, got, tt.want)
+		}
+	}
+}
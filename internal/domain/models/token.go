@@ -8,4 +8,19 @@ type Tokens struct {
 type Token struct {
 	Token     string
 	Expire_at int64
+	Cnf       *Confirmation
+}
+
+// Confirmation привязка токена к ключу клиента (claim cnf, RFC 7800)
+type Confirmation struct {
+	JKT     string `json:"jkt,omitempty"`      // DPoP, RFC 9449
+	X5TS256 string `json:"x5t#S256,omitempty"` // mTLS, RFC 8705
+}
+
+// RefreshTokenInfo сохраненный refresh токен пользователя и сеанс, которому он выдан
+type RefreshTokenInfo struct {
+	UserID  uint64
+	AppID   uint32
+	Token   Token
+	Session Session
 }
@@ -2,10 +2,11 @@ package models
 
 import (
 	"strings"
+	"time"
 )
 
 const (
-	MinLenPass = 5
+	MinLenPass = 6
 )
 
 type User struct {
@@ -14,18 +15,49 @@ type User struct {
 	Password string `json:"-"`
 	PassHash []byte
 	Role_id  int
-	Tokens   Tokens
+	Disabled bool
+	// Вход запрещен до этого момента (нулевое значение — не заблокирован)
+	LockedUntil time.Time
+	CreatedAt   time.Time
+	// Время последней смены пароля, от него отсчитывается срок действия пароля
+	PasswordChangedAt time.Time
+	// Роли и права в приложении, для которого выпускаются токены
+	Access Access
+	Tokens Tokens
 }
 
-func (u *User) validateFields() error {
-	if u.Username == "" {
+// UserFilter параметры выборки пользователей
+type UserFilter struct {
+	Username string // подстрока имени пользователя
+	RoleID   int
+	Disabled *bool
+	Limit    int
+	Offset   int
+}
+
+// IsLocked проверяет, заблокирован ли вход пользователя на момент now
+func (u *User) IsLocked(now time.Time) bool {
+	return now.Before(u.LockedUntil)
+}
+
+// ValidateUsername проверяет имя пользователя
+func ValidateUsername(username string) error {
+	if username == "" {
 		return &ValidationError{Field: "username", Message: EmptyField}
 	}
 
-	if strings.Contains(u.Username, " ") {
+	if strings.Contains(username, " ") {
 		return &ValidationError{Field: "username", Message: FieldContainSpaces}
 	}
 
+	return nil
+}
+
+func (u *User) validateFields() error {
+	if err := ValidateUsername(u.Username); err != nil {
+		return err
+	}
+
 	if u.Password == "" {
 		return &ValidationError{Field: "password", Message: EmptyField}
 	}
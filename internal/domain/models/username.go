@@ -0,0 +1,176 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+const UsernamePolicyViolation = "username does not meet the username policy"
+
+// Классы символов, из которых может состоять имя пользователя
+const (
+	UsernameLetters      = "letters"       // буквы любого алфавита
+	UsernameASCIILetters = "ascii_letters" // только латинские буквы a-z, A-Z
+	UsernameDigits       = "digits"        // цифры 0-9
+)
+
+// Письменности, не относящиеся к конкретному алфавиту (цифры, знаки препинания, диакритика)
+var neutralScripts = []*unicode.RangeTable{unicode.Common, unicode.Inherited}
+
+// Письменности CJK используются вместе и считаются одним алфавитом
+var cjkScripts = []*unicode.RangeTable{unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Bopomofo}
+
+// UsernameKey возвращает канонический вид имени пользователя для сравнения и поиска:
+// NFKC с приведением регистра. Имена с одинаковым ключом считаются одним именем,
+// поэтому "Alice", "alice" и "Ａｌｉｃｅ" не могут принадлежать разным пользователям.
+func UsernameKey(username string) string {
+	return norm.NFKC.String(cases.Fold().String(norm.NFKC.String(username)))
+}
+
+// UsernamePolicy требования к имени пользователя. Нулевые значения отключают соответствующие правила.
+type UsernamePolicy struct {
+	MinLength int
+	MaxLength int
+	// Сохранять имя в нижнем регистре
+	CaseFold bool
+	// Приводить имя к форме NFKC
+	NFKC bool
+	// Разрешенные классы символов, пусто — любые символы, кроме пробельных
+	Classes []string
+	// Разрешенные символы помимо классов
+	Symbols string
+	// Запретить смешивать буквы разных алфавитов
+	SingleScript bool
+	// Имена, которые нельзя занять, сравниваются по UsernameKey
+	Reserved []string
+}
+
+// Normalize приводит имя пользователя к виду, в котором оно сохраняется
+func (p UsernamePolicy) Normalize(username string) string {
+	if p.NFKC {
+		username = norm.NFKC.String(username)
+	}
+	if p.CaseFold {
+		username = cases.Fold().String(username)
+		if p.NFKC {
+			username = norm.NFKC.String(username)
+		}
+	}
+	return username
+}
+
+// Validate проверяет нормализованное имя нового пользователя.
+// Ошибка *ValidationError перечисляет все нарушенные правила.
+func (p UsernamePolicy) Validate(username string) error {
+	if err := ValidateUsername(username); err != nil {
+		return err
+	}
+
+	if violations := p.Violations(username); len(violations) > 0 {
+		return &ValidationError{Field: "username", Message: UsernamePolicyViolation, Details: violations}
+	}
+	return nil
+}
+
+// Violations возвращает все правила политики, которым не соответствует имя пользователя
+func (p UsernamePolicy) Violations(username string) []string {
+	var violations []string
+
+	length := utf8.RuneCountInString(username)
+	if p.MinLength > 0 && length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("must be at most %d characters", p.MaxLength))
+	}
+
+	if !utf8.ValidString(username) || strings.IndexFunc(username, p.forbidden) >= 0 {
+		violations = append(violations, "contains characters that are not allowed")
+	}
+	if len(p.Classes) > 0 && username != "" {
+		first, _ := utf8.DecodeRuneInString(username)
+		last, _ := utf8.DecodeLastRuneInString(username)
+		if strings.ContainsRune(p.Symbols, first) || strings.ContainsRune(p.Symbols, last) {
+			violations = append(violations, "must start and end with a letter or digit")
+		}
+	}
+	if p.SingleScript && mixedScripts(username) {
+		violations = append(violations, "must not mix letters of different alphabets")
+	}
+
+	if p.reserved(username) {
+		violations = append(violations, "is reserved")
+	}
+	return violations
+}
+
+// forbidden проверяет, что символ не входит в разрешенные классы и символы
+func (p UsernamePolicy) forbidden(r rune) bool {
+	if unicode.IsSpace(r) || unicode.IsControl(r) || !unicode.IsPrint(r) {
+		return true
+	}
+	if len(p.Classes) == 0 || strings.ContainsRune(p.Symbols, r) {
+		return false
+	}
+
+	for _, class := range p.Classes {
+		switch class {
+		case UsernameLetters:
+			if unicode.IsLetter(r) || unicode.Is(unicode.Mn, r) {
+				return false
+			}
+		case UsernameASCIILetters:
+			if r < utf8.RuneSelf && unicode.IsLetter(r) {
+				return false
+			}
+		case UsernameDigits:
+			if r >= '0' && r <= '9' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (p UsernamePolicy) reserved(username string) bool {
+	key := UsernameKey(username)
+	return slices.ContainsFunc(p.Reserved, func(name string) bool {
+		return UsernameKey(name) == key
+	})
+}
+
+// mixedScripts проверяет, содержит ли имя буквы разных алфавитов, например латиницу и кириллицу
+func mixedScripts(username string) bool {
+	var scripts []*unicode.RangeTable
+	for _, r := range username {
+		if unicode.In(r, neutralScripts...) {
+			continue
+		}
+		if scripts == nil {
+			scripts = scriptOf(r)
+			continue
+		}
+		if !unicode.In(r, scripts...) {
+			return true
+		}
+	}
+	return false
+}
+
+func scriptOf(r rune) []*unicode.RangeTable {
+	if unicode.In(r, cjkScripts...) {
+		return cjkScripts
+	}
+	for _, table := range unicode.Scripts {
+		if unicode.Is(table, r) {
+			return []*unicode.RangeTable{table}
+		}
+	}
+	return nil
+}
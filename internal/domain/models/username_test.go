@@ -0,0 +1,69 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestUsernameKey(t *testing.T) {
+	key := UsernameKey("alice")
+	for _, username := range []string{"Alice", "ALICE", "Ａｌｉｃｅ"} {
+		if got := UsernameKey(username); got != key {
+			t.Errorf("UsernameKey(%q) = %q; want %q", username, got, key)
+		}
+	}
+	if UsernameKey("аlice") == key {
+		t.Error("cyrillic homoglyph must not match the latin username")
+	}
+}
+
+func TestUsernamePolicy__Validate(t *testing.T) {
+	policy := UsernamePolicy{
+		MinLength:    3,
+		MaxLength:    12,
+		CaseFold:     true,
+		NFKC:         true,
+		Classes:      []string{UsernameLetters, UsernameDigits},
+		Symbols:      "._-",
+		SingleScript: true,
+		Reserved:     []string{"admin"},
+	}
+
+	cases := []struct {
+		name       string
+		username   string
+		violations []string
+	}{
+		{name: "valid", username: "alice.smith"},
+		{name: "cyrillic", username: "алиса_1"},
+		{name: "japanese", username: "やまだ太郎"},
+		{name: "too short", username: "al", violations: []string{"must be at least 3 characters"}},
+		{name: "too long", username: "alice-smith-jr", violations: []string{"must be at most 12 characters"}},
+		{name: "forbidden symbol", username: "alice@home", violations: []string{"contains characters that are not allowed"}},
+		{name: "leading symbol", username: ".alice", violations: []string{"must start and end with a letter or digit"}},
+		{name: "mixed scripts", username: "pаypal", violations: []string{"must not mix letters of different alphabets"}},
+		{name: "reserved", username: "ADMIN", violations: []string{"is reserved"}},
+		{name: "reserved fullwidth", username: "ａｄｍｉｎ", violations: []string{"is reserved"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := policy.Violations(policy.Normalize(tc.username))
+			if !slices.Equal(got, tc.violations) {
+				t.Errorf("Violations(%q) = %q; want %q", tc.username, got, tc.violations)
+			}
+		})
+	}
+}
+
+func TestUsernamePolicy__Normalize(t *testing.T) {
+	policy := UsernamePolicy{CaseFold: true, NFKC: true}
+	if got := policy.Normalize("Ａｌｉｃｅ"); got != "alice" {
+		t.Errorf("Normalize = %q; want %q", got, "alice")
+	}
+
+	var legacy UsernamePolicy
+	if got := legacy.Normalize("Alice"); got != "Alice" {
+		t.Errorf("zero policy Normalize = %q; want unchanged", got)
+	}
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Церемонии WebAuthn, для которых выдается challenge
+const (
+	WebAuthnRegistration = "registration"
+	WebAuthnLogin        = "login"
+)
+
+// WebAuthnCredential зарегистрированный ключ (passkey) пользователя
+type WebAuthnCredential struct {
+	ID           uint64
+	UserID       uint64
+	CredentialID []byte
+	// Публичный ключ в формате COSE
+	PublicKey []byte
+	Algorithm int64
+	// Последнее значение счетчика подписей аутентификатора
+	SignCount         uint32
+	AAGUID            []byte
+	AttestationFormat string
+	Transports        []string
+	Name              string
+	CreatedAt         time.Time
+	// Нулевое значение, если ключ еще не использовался для входа
+	LastUsedAt time.Time
+}
+
+// WebAuthnSession незавершенная церемония WebAuthn, хранится в кэше по challenge
+type WebAuthnSession struct {
+	Ceremony string `json:"ceremony"`
+	// 0 при входе без имени пользователя (discoverable credential)
+	UserID    uint64    `json:"user_id,omitempty"`
+	AppID     uint32    `json:"app_id,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
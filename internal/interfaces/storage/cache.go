@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/Grino777/sso/internal/domain/models"
 )
@@ -10,18 +11,71 @@ import (
 type CacheStorage interface {
 	CacheUserProvider
 	CacheAppProvider
+	CacheNonceProvider
+	CacheLockoutProvider
+	CacheRateLimitProvider
+	CacheMFAProvider
+	CacheWebAuthnProvider
+	CacheLoginCodeProvider
 	CacheConnector
 }
 
 type CacheUserProvider interface {
 	GetUser(ctx context.Context, username string, appID uint32) (models.User, error)
 	SaveUser(ctx context.Context, user models.User, appID uint32) (models.User, error)
-	IsAdmin(ctx context.Context, user models.User, app models.App) (bool, error)
+	// DeleteUser удаляет пользователя из кэша всех приложений
+	DeleteUser(ctx context.Context, username string) error
 }
 
 type CacheAppProvider interface {
 	GetApp(ctx context.Context, appID uint32) (models.App, error)
 	SaveApp(ctx context.Context, app models.App) error
+	DeleteApp(ctx context.Context, appID uint32) error
+}
+
+type CacheNonceProvider interface {
+	// SaveNonce запоминает одноразовое значение, возвращает false если оно уже использовалось
+	SaveNonce(ctx context.Context, scope, nonce string, ttl time.Duration) (bool, error)
+}
+
+// CacheLockoutProvider счетчики неудачных попыток входа, общие для всех реплик
+type CacheLockoutProvider interface {
+	// IncrLoginFailures увеличивает счетчик неудачных попыток, окно отсчитывается от первой
+	IncrLoginFailures(ctx context.Context, subject string, window time.Duration) (int64, error)
+	// IncrLockoutStrikes увеличивает число блокировок подряд и продлевает его хранение на ttl
+	IncrLockoutStrikes(ctx context.Context, subject string, ttl time.Duration) (int64, error)
+	// SetLockout блокирует вход до until и сбрасывает счетчик неудачных попыток
+	SetLockout(ctx context.Context, subject string, until time.Time) error
+	GetLockout(ctx context.Context, subject string) (models.LockoutStatus, error)
+	// ClearLockout снимает блокировку и сбрасывает все счетчики субъекта
+	ClearLockout(ctx context.Context, subject string) error
+}
+
+// CacheRateLimitProvider token bucket, общие для всех реплик
+type CacheRateLimitProvider interface {
+	// AllowRequest берет токен из bucket с ключом key
+	AllowRequest(ctx context.Context, key string, limit models.RateLimit) (models.RateLimitResult, error)
+}
+
+// CacheMFAProvider незавершенные входы, ожидающие второй фактор
+type CacheMFAProvider interface {
+	SaveMFAChallenge(ctx context.Context, token string, challenge models.MFAChallenge, ttl time.Duration) error
+	// TakeMFAChallenge возвращает и удаляет challenge, чтобы его нельзя было использовать дважды
+	TakeMFAChallenge(ctx context.Context, token string) (models.MFAChallenge, error)
+}
+
+// CacheWebAuthnProvider незавершенные церемонии WebAuthn
+type CacheWebAuthnProvider interface {
+	SaveWebAuthnSession(ctx context.Context, challenge string, session models.WebAuthnSession, ttl time.Duration) error
+	// TakeWebAuthnSession возвращает и удаляет сессию, чтобы challenge нельзя было использовать дважды
+	TakeWebAuthnSession(ctx context.Context, challenge string) (models.WebAuthnSession, error)
+}
+
+// CacheLoginCodeProvider одноразовые коды и ссылки входа без пароля
+type CacheLoginCodeProvider interface {
+	SaveLoginCode(ctx context.Context, key string, code models.LoginCode, ttl time.Duration) error
+	// TakeLoginCode возвращает и удаляет код, чтобы его нельзя было использовать дважды
+	TakeLoginCode(ctx context.Context, key string) (models.LoginCode, error)
 }
 
 type CacheConnector interface {
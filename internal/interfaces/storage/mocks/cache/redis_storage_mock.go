@@ -7,6 +7,7 @@ package mock_cache
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/Grino777/sso/internal/domain/models"
 	gomock "github.com/golang/mock/gomock"
@@ -35,6 +36,35 @@ func (m *MockCacheStorage) EXPECT() *MockCacheStorageMockRecorder {
 	return m.recorder
 }
 
+// AllowRequest mocks base method.
+func (m *MockCacheStorage) AllowRequest(ctx context.Context, key string, limit models.RateLimit) (models.RateLimitResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllowRequest", ctx, key, limit)
+	ret0, _ := ret[0].(models.RateLimitResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllowRequest indicates an expected call of AllowRequest.
+func (mr *MockCacheStorageMockRecorder) AllowRequest(ctx, key, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllowRequest", reflect.TypeOf((*MockCacheStorage)(nil).AllowRequest), ctx, key, limit)
+}
+
+// ClearLockout mocks base method.
+func (m *MockCacheStorage) ClearLockout(ctx context.Context, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearLockout", ctx, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearLockout indicates an expected call of ClearLockout.
+func (mr *MockCacheStorageMockRecorder) ClearLockout(ctx, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearLockout", reflect.TypeOf((*MockCacheStorage)(nil).ClearLockout), ctx, subject)
+}
+
 // Close mocks base method.
 func (m *MockCacheStorage) Close(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -63,6 +93,34 @@ func (mr *MockCacheStorageMockRecorder) Connect(ctx, errChan interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockCacheStorage)(nil).Connect), ctx, errChan)
 }
 
+// DeleteApp mocks base method.
+func (m *MockCacheStorage) DeleteApp(ctx context.Context, appID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteApp", ctx, appID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteApp indicates an expected call of DeleteApp.
+func (mr *MockCacheStorageMockRecorder) DeleteApp(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteApp", reflect.TypeOf((*MockCacheStorage)(nil).DeleteApp), ctx, appID)
+}
+
+// DeleteUser mocks base method.
+func (m *MockCacheStorage) DeleteUser(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockCacheStorageMockRecorder) DeleteUser(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockCacheStorage)(nil).DeleteUser), ctx, username)
+}
+
 // GetApp mocks base method.
 func (m *MockCacheStorage) GetApp(ctx context.Context, appID uint32) (models.App, error) {
 	m.ctrl.T.Helper()
@@ -78,6 +136,21 @@ func (mr *MockCacheStorageMockRecorder) GetApp(ctx, appID interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApp", reflect.TypeOf((*MockCacheStorage)(nil).GetApp), ctx, appID)
 }
 
+// GetLockout mocks base method.
+func (m *MockCacheStorage) GetLockout(ctx context.Context, subject string) (models.LockoutStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLockout", ctx, subject)
+	ret0, _ := ret[0].(models.LockoutStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLockout indicates an expected call of GetLockout.
+func (mr *MockCacheStorageMockRecorder) GetLockout(ctx, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLockout", reflect.TypeOf((*MockCacheStorage)(nil).GetLockout), ctx, subject)
+}
+
 // GetUser mocks base method.
 func (m *MockCacheStorage) GetUser(ctx context.Context, username string, appID uint32) (models.User, error) {
 	m.ctrl.T.Helper()
@@ -93,19 +166,34 @@ func (mr *MockCacheStorageMockRecorder) GetUser(ctx, username, appID interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockCacheStorage)(nil).GetUser), ctx, username, appID)
 }
 
-// IsAdmin mocks base method.
-func (m *MockCacheStorage) IsAdmin(ctx context.Context, user models.User, app models.App) (bool, error) {
+// IncrLockoutStrikes mocks base method.
+func (m *MockCacheStorage) IncrLockoutStrikes(ctx context.Context, subject string, ttl time.Duration) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsAdmin", ctx, user, app)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "IncrLockoutStrikes", ctx, subject, ttl)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrLockoutStrikes indicates an expected call of IncrLockoutStrikes.
+func (mr *MockCacheStorageMockRecorder) IncrLockoutStrikes(ctx, subject, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrLockoutStrikes", reflect.TypeOf((*MockCacheStorage)(nil).IncrLockoutStrikes), ctx, subject, ttl)
+}
+
+// IncrLoginFailures mocks base method.
+func (m *MockCacheStorage) IncrLoginFailures(ctx context.Context, subject string, window time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrLoginFailures", ctx, subject, window)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// IsAdmin indicates an expected call of IsAdmin.
-func (mr *MockCacheStorageMockRecorder) IsAdmin(ctx, user, app interface{}) *gomock.Call {
+// IncrLoginFailures indicates an expected call of IncrLoginFailures.
+func (mr *MockCacheStorageMockRecorder) IncrLoginFailures(ctx, subject, window interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAdmin", reflect.TypeOf((*MockCacheStorage)(nil).IsAdmin), ctx, user, app)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrLoginFailures", reflect.TypeOf((*MockCacheStorage)(nil).IncrLoginFailures), ctx, subject, window)
 }
 
 // SaveApp mocks base method.
@@ -122,6 +210,49 @@ func (mr *MockCacheStorageMockRecorder) SaveApp(ctx, app interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveApp", reflect.TypeOf((*MockCacheStorage)(nil).SaveApp), ctx, app)
 }
 
+// SaveLoginCode mocks base method.
+func (m *MockCacheStorage) SaveLoginCode(ctx context.Context, key string, code models.LoginCode, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveLoginCode", ctx, key, code, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveLoginCode indicates an expected call of SaveLoginCode.
+func (mr *MockCacheStorageMockRecorder) SaveLoginCode(ctx, key, code, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveLoginCode", reflect.TypeOf((*MockCacheStorage)(nil).SaveLoginCode), ctx, key, code, ttl)
+}
+
+// SaveMFAChallenge mocks base method.
+func (m *MockCacheStorage) SaveMFAChallenge(ctx context.Context, token string, challenge models.MFAChallenge, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMFAChallenge", ctx, token, challenge, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveMFAChallenge indicates an expected call of SaveMFAChallenge.
+func (mr *MockCacheStorageMockRecorder) SaveMFAChallenge(ctx, token, challenge, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMFAChallenge", reflect.TypeOf((*MockCacheStorage)(nil).SaveMFAChallenge), ctx, token, challenge, ttl)
+}
+
+// SaveNonce mocks base method.
+func (m *MockCacheStorage) SaveNonce(ctx context.Context, scope, nonce string, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveNonce", ctx, scope, nonce, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveNonce indicates an expected call of SaveNonce.
+func (mr *MockCacheStorageMockRecorder) SaveNonce(ctx, scope, nonce, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveNonce", reflect.TypeOf((*MockCacheStorage)(nil).SaveNonce), ctx, scope, nonce, ttl)
+}
+
 // SaveUser mocks base method.
 func (m *MockCacheStorage) SaveUser(ctx context.Context, user models.User, appID uint32) (models.User, error) {
 	m.ctrl.T.Helper()
@@ -137,6 +268,79 @@ func (mr *MockCacheStorageMockRecorder) SaveUser(ctx, user, appID interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUser", reflect.TypeOf((*MockCacheStorage)(nil).SaveUser), ctx, user, appID)
 }
 
+// SaveWebAuthnSession mocks base method.
+func (m *MockCacheStorage) SaveWebAuthnSession(ctx context.Context, challenge string, session models.WebAuthnSession, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWebAuthnSession", ctx, challenge, session, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveWebAuthnSession indicates an expected call of SaveWebAuthnSession.
+func (mr *MockCacheStorageMockRecorder) SaveWebAuthnSession(ctx, challenge, session, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWebAuthnSession", reflect.TypeOf((*MockCacheStorage)(nil).SaveWebAuthnSession), ctx, challenge, session, ttl)
+}
+
+// SetLockout mocks base method.
+func (m *MockCacheStorage) SetLockout(ctx context.Context, subject string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLockout", ctx, subject, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLockout indicates an expected call of SetLockout.
+func (mr *MockCacheStorageMockRecorder) SetLockout(ctx, subject, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLockout", reflect.TypeOf((*MockCacheStorage)(nil).SetLockout), ctx, subject, until)
+}
+
+// TakeLoginCode mocks base method.
+func (m *MockCacheStorage) TakeLoginCode(ctx context.Context, key string) (models.LoginCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeLoginCode", ctx, key)
+	ret0, _ := ret[0].(models.LoginCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeLoginCode indicates an expected call of TakeLoginCode.
+func (mr *MockCacheStorageMockRecorder) TakeLoginCode(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeLoginCode", reflect.TypeOf((*MockCacheStorage)(nil).TakeLoginCode), ctx, key)
+}
+
+// TakeMFAChallenge mocks base method.
+func (m *MockCacheStorage) TakeMFAChallenge(ctx context.Context, token string) (models.MFAChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeMFAChallenge", ctx, token)
+	ret0, _ := ret[0].(models.MFAChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeMFAChallenge indicates an expected call of TakeMFAChallenge.
+func (mr *MockCacheStorageMockRecorder) TakeMFAChallenge(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeMFAChallenge", reflect.TypeOf((*MockCacheStorage)(nil).TakeMFAChallenge), ctx, token)
+}
+
+// TakeWebAuthnSession mocks base method.
+func (m *MockCacheStorage) TakeWebAuthnSession(ctx context.Context, challenge string) (models.WebAuthnSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeWebAuthnSession", ctx, challenge)
+	ret0, _ := ret[0].(models.WebAuthnSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeWebAuthnSession indicates an expected call of TakeWebAuthnSession.
+func (mr *MockCacheStorageMockRecorder) TakeWebAuthnSession(ctx, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeWebAuthnSession", reflect.TypeOf((*MockCacheStorage)(nil).TakeWebAuthnSession), ctx, challenge)
+}
+
 // MockCacheUserProvider is a mock of CacheUserProvider interface.
 type MockCacheUserProvider struct {
 	ctrl     *gomock.Controller
@@ -160,6 +364,20 @@ func (m *MockCacheUserProvider) EXPECT() *MockCacheUserProviderMockRecorder {
 	return m.recorder
 }
 
+// DeleteUser mocks base method.
+func (m *MockCacheUserProvider) DeleteUser(ctx context.Context, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockCacheUserProviderMockRecorder) DeleteUser(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockCacheUserProvider)(nil).DeleteUser), ctx, username)
+}
+
 // GetUser mocks base method.
 func (m *MockCacheUserProvider) GetUser(ctx context.Context, username string, appID uint32) (models.User, error) {
 	m.ctrl.T.Helper()
@@ -175,21 +393,6 @@ func (mr *MockCacheUserProviderMockRecorder) GetUser(ctx, username, appID interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockCacheUserProvider)(nil).GetUser), ctx, username, appID)
 }
 
-// IsAdmin mocks base method.
-func (m *MockCacheUserProvider) IsAdmin(ctx context.Context, user models.User, app models.App) (bool, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsAdmin", ctx, user, app)
-	ret0, _ := ret[0].(bool)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// IsAdmin indicates an expected call of IsAdmin.
-func (mr *MockCacheUserProviderMockRecorder) IsAdmin(ctx, user, app interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAdmin", reflect.TypeOf((*MockCacheUserProvider)(nil).IsAdmin), ctx, user, app)
-}
-
 // SaveUser mocks base method.
 func (m *MockCacheUserProvider) SaveUser(ctx context.Context, user models.User, appID uint32) (models.User, error) {
 	m.ctrl.T.Helper()
@@ -228,6 +431,20 @@ func (m *MockCacheAppProvider) EXPECT() *MockCacheAppProviderMockRecorder {
 	return m.recorder
 }
 
+// DeleteApp mocks base method.
+func (m *MockCacheAppProvider) DeleteApp(ctx context.Context, appID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteApp", ctx, appID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteApp indicates an expected call of DeleteApp.
+func (mr *MockCacheAppProviderMockRecorder) DeleteApp(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteApp", reflect.TypeOf((*MockCacheAppProvider)(nil).DeleteApp), ctx, appID)
+}
+
 // GetApp mocks base method.
 func (m *MockCacheAppProvider) GetApp(ctx context.Context, appID uint32) (models.App, error) {
 	m.ctrl.T.Helper()
@@ -257,6 +474,334 @@ func (mr *MockCacheAppProviderMockRecorder) SaveApp(ctx, app interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveApp", reflect.TypeOf((*MockCacheAppProvider)(nil).SaveApp), ctx, app)
 }
 
+// MockCacheNonceProvider is a mock of CacheNonceProvider interface.
+type MockCacheNonceProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheNonceProviderMockRecorder
+}
+
+// MockCacheNonceProviderMockRecorder is the mock recorder for MockCacheNonceProvider.
+type MockCacheNonceProviderMockRecorder struct {
+	mock *MockCacheNonceProvider
+}
+
+// NewMockCacheNonceProvider creates a new mock instance.
+func NewMockCacheNonceProvider(ctrl *gomock.Controller) *MockCacheNonceProvider {
+	mock := &MockCacheNonceProvider{ctrl: ctrl}
+	mock.recorder = &MockCacheNonceProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheNonceProvider) EXPECT() *MockCacheNonceProviderMockRecorder {
+	return m.recorder
+}
+
+// SaveNonce mocks base method.
+func (m *MockCacheNonceProvider) SaveNonce(ctx context.Context, scope, nonce string, ttl time.Duration) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveNonce", ctx, scope, nonce, ttl)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveNonce indicates an expected call of SaveNonce.
+func (mr *MockCacheNonceProviderMockRecorder) SaveNonce(ctx, scope, nonce, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveNonce", reflect.TypeOf((*MockCacheNonceProvider)(nil).SaveNonce), ctx, scope, nonce, ttl)
+}
+
+// MockCacheLockoutProvider is a mock of CacheLockoutProvider interface.
+type MockCacheLockoutProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheLockoutProviderMockRecorder
+}
+
+// MockCacheLockoutProviderMockRecorder is the mock recorder for MockCacheLockoutProvider.
+type MockCacheLockoutProviderMockRecorder struct {
+	mock *MockCacheLockoutProvider
+}
+
+// NewMockCacheLockoutProvider creates a new mock instance.
+func NewMockCacheLockoutProvider(ctrl *gomock.Controller) *MockCacheLockoutProvider {
+	mock := &MockCacheLockoutProvider{ctrl: ctrl}
+	mock.recorder = &MockCacheLockoutProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheLockoutProvider) EXPECT() *MockCacheLockoutProviderMockRecorder {
+	return m.recorder
+}
+
+// ClearLockout mocks base method.
+func (m *MockCacheLockoutProvider) ClearLockout(ctx context.Context, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearLockout", ctx, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearLockout indicates an expected call of ClearLockout.
+func (mr *MockCacheLockoutProviderMockRecorder) ClearLockout(ctx, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearLockout", reflect.TypeOf((*MockCacheLockoutProvider)(nil).ClearLockout), ctx, subject)
+}
+
+// GetLockout mocks base method.
+func (m *MockCacheLockoutProvider) GetLockout(ctx context.Context, subject string) (models.LockoutStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLockout", ctx, subject)
+	ret0, _ := ret[0].(models.LockoutStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLockout indicates an expected call of GetLockout.
+func (mr *MockCacheLockoutProviderMockRecorder) GetLockout(ctx, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLockout", reflect.TypeOf((*MockCacheLockoutProvider)(nil).GetLockout), ctx, subject)
+}
+
+// IncrLockoutStrikes mocks base method.
+func (m *MockCacheLockoutProvider) IncrLockoutStrikes(ctx context.Context, subject string, ttl time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrLockoutStrikes", ctx, subject, ttl)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrLockoutStrikes indicates an expected call of IncrLockoutStrikes.
+func (mr *MockCacheLockoutProviderMockRecorder) IncrLockoutStrikes(ctx, subject, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrLockoutStrikes", reflect.TypeOf((*MockCacheLockoutProvider)(nil).IncrLockoutStrikes), ctx, subject, ttl)
+}
+
+// IncrLoginFailures mocks base method.
+func (m *MockCacheLockoutProvider) IncrLoginFailures(ctx context.Context, subject string, window time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrLoginFailures", ctx, subject, window)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrLoginFailures indicates an expected call of IncrLoginFailures.
+func (mr *MockCacheLockoutProviderMockRecorder) IncrLoginFailures(ctx, subject, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrLoginFailures", reflect.TypeOf((*MockCacheLockoutProvider)(nil).IncrLoginFailures), ctx, subject, window)
+}
+
+// SetLockout mocks base method.
+func (m *MockCacheLockoutProvider) SetLockout(ctx context.Context, subject string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLockout", ctx, subject, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLockout indicates an expected call of SetLockout.
+func (mr *MockCacheLockoutProviderMockRecorder) SetLockout(ctx, subject, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLockout", reflect.TypeOf((*MockCacheLockoutProvider)(nil).SetLockout), ctx, subject, until)
+}
+
+// MockCacheRateLimitProvider is a mock of CacheRateLimitProvider interface.
+type MockCacheRateLimitProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheRateLimitProviderMockRecorder
+}
+
+// MockCacheRateLimitProviderMockRecorder is the mock recorder for MockCacheRateLimitProvider.
+type MockCacheRateLimitProviderMockRecorder struct {
+	mock *MockCacheRateLimitProvider
+}
+
+// NewMockCacheRateLimitProvider creates a new mock instance.
+func NewMockCacheRateLimitProvider(ctrl *gomock.Controller) *MockCacheRateLimitProvider {
+	mock := &MockCacheRateLimitProvider{ctrl: ctrl}
+	mock.recorder = &MockCacheRateLimitProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheRateLimitProvider) EXPECT() *MockCacheRateLimitProviderMockRecorder {
+	return m.recorder
+}
+
+// AllowRequest mocks base method.
+func (m *MockCacheRateLimitProvider) AllowRequest(ctx context.Context, key string, limit models.RateLimit) (models.RateLimitResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllowRequest", ctx, key, limit)
+	ret0, _ := ret[0].(models.RateLimitResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllowRequest indicates an expected call of AllowRequest.
+func (mr *MockCacheRateLimitProviderMockRecorder) AllowRequest(ctx, key, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllowRequest", reflect.TypeOf((*MockCacheRateLimitProvider)(nil).AllowRequest), ctx, key, limit)
+}
+
+// MockCacheMFAProvider is a mock of CacheMFAProvider interface.
+type MockCacheMFAProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheMFAProviderMockRecorder
+}
+
+// MockCacheMFAProviderMockRecorder is the mock recorder for MockCacheMFAProvider.
+type MockCacheMFAProviderMockRecorder struct {
+	mock *MockCacheMFAProvider
+}
+
+// NewMockCacheMFAProvider creates a new mock instance.
+func NewMockCacheMFAProvider(ctrl *gomock.Controller) *MockCacheMFAProvider {
+	mock := &MockCacheMFAProvider{ctrl: ctrl}
+	mock.recorder = &MockCacheMFAProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheMFAProvider) EXPECT() *MockCacheMFAProviderMockRecorder {
+	return m.recorder
+}
+
+// SaveMFAChallenge mocks base method.
+func (m *MockCacheMFAProvider) SaveMFAChallenge(ctx context.Context, token string, challenge models.MFAChallenge, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMFAChallenge", ctx, token, challenge, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveMFAChallenge indicates an expected call of SaveMFAChallenge.
+func (mr *MockCacheMFAProviderMockRecorder) SaveMFAChallenge(ctx, token, challenge, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMFAChallenge", reflect.TypeOf((*MockCacheMFAProvider)(nil).SaveMFAChallenge), ctx, token, challenge, ttl)
+}
+
+// TakeMFAChallenge mocks base method.
+func (m *MockCacheMFAProvider) TakeMFAChallenge(ctx context.Context, token string) (models.MFAChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeMFAChallenge", ctx, token)
+	ret0, _ := ret[0].(models.MFAChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeMFAChallenge indicates an expected call of TakeMFAChallenge.
+func (mr *MockCacheMFAProviderMockRecorder) TakeMFAChallenge(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeMFAChallenge", reflect.TypeOf((*MockCacheMFAProvider)(nil).TakeMFAChallenge), ctx, token)
+}
+
+// MockCacheWebAuthnProvider is a mock of CacheWebAuthnProvider interface.
+type MockCacheWebAuthnProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheWebAuthnProviderMockRecorder
+}
+
+// MockCacheWebAuthnProviderMockRecorder is the mock recorder for MockCacheWebAuthnProvider.
+type MockCacheWebAuthnProviderMockRecorder struct {
+	mock *MockCacheWebAuthnProvider
+}
+
+// NewMockCacheWebAuthnProvider creates a new mock instance.
+func NewMockCacheWebAuthnProvider(ctrl *gomock.Controller) *MockCacheWebAuthnProvider {
+	mock := &MockCacheWebAuthnProvider{ctrl: ctrl}
+	mock.recorder = &MockCacheWebAuthnProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheWebAuthnProvider) EXPECT() *MockCacheWebAuthnProviderMockRecorder {
+	return m.recorder
+}
+
+// SaveWebAuthnSession mocks base method.
+func (m *MockCacheWebAuthnProvider) SaveWebAuthnSession(ctx context.Context, challenge string, session models.WebAuthnSession, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWebAuthnSession", ctx, challenge, session, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveWebAuthnSession indicates an expected call of SaveWebAuthnSession.
+func (mr *MockCacheWebAuthnProviderMockRecorder) SaveWebAuthnSession(ctx, challenge, session, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWebAuthnSession", reflect.TypeOf((*MockCacheWebAuthnProvider)(nil).SaveWebAuthnSession), ctx, challenge, session, ttl)
+}
+
+// TakeWebAuthnSession mocks base method.
+func (m *MockCacheWebAuthnProvider) TakeWebAuthnSession(ctx context.Context, challenge string) (models.WebAuthnSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeWebAuthnSession", ctx, challenge)
+	ret0, _ := ret[0].(models.WebAuthnSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeWebAuthnSession indicates an expected call of TakeWebAuthnSession.
+func (mr *MockCacheWebAuthnProviderMockRecorder) TakeWebAuthnSession(ctx, challenge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeWebAuthnSession", reflect.TypeOf((*MockCacheWebAuthnProvider)(nil).TakeWebAuthnSession), ctx, challenge)
+}
+
+// MockCacheLoginCodeProvider is a mock of CacheLoginCodeProvider interface.
+type MockCacheLoginCodeProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheLoginCodeProviderMockRecorder
+}
+
+// MockCacheLoginCodeProviderMockRecorder is the mock recorder for MockCacheLoginCodeProvider.
+type MockCacheLoginCodeProviderMockRecorder struct {
+	mock *MockCacheLoginCodeProvider
+}
+
+// NewMockCacheLoginCodeProvider creates a new mock instance.
+func NewMockCacheLoginCodeProvider(ctrl *gomock.Controller) *MockCacheLoginCodeProvider {
+	mock := &MockCacheLoginCodeProvider{ctrl: ctrl}
+	mock.recorder = &MockCacheLoginCodeProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheLoginCodeProvider) EXPECT() *MockCacheLoginCodeProviderMockRecorder {
+	return m.recorder
+}
+
+// SaveLoginCode mocks base method.
+func (m *MockCacheLoginCodeProvider) SaveLoginCode(ctx context.Context, key string, code models.LoginCode, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveLoginCode", ctx, key, code, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveLoginCode indicates an expected call of SaveLoginCode.
+func (mr *MockCacheLoginCodeProviderMockRecorder) SaveLoginCode(ctx, key, code, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveLoginCode", reflect.TypeOf((*MockCacheLoginCodeProvider)(nil).SaveLoginCode), ctx, key, code, ttl)
+}
+
+// TakeLoginCode mocks base method.
+func (m *MockCacheLoginCodeProvider) TakeLoginCode(ctx context.Context, key string) (models.LoginCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeLoginCode", ctx, key)
+	ret0, _ := ret[0].(models.LoginCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeLoginCode indicates an expected call of TakeLoginCode.
+func (mr *MockCacheLoginCodeProviderMockRecorder) TakeLoginCode(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeLoginCode", reflect.TypeOf((*MockCacheLoginCodeProvider)(nil).TakeLoginCode), ctx, key)
+}
+
 // MockCacheConnector is a mock of CacheConnector interface.
 type MockCacheConnector struct {
 	ctrl     *gomock.Controller
@@ -35,224 +35,1990 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// AddAppMember mocks base method.
+func (m *MockStorage) AddAppMember(ctx context.Context, userID uint64, appID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAppMember", ctx, userID, appID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAppMember indicates an expected call of AddAppMember.
+func (mr *MockStorageMockRecorder) AddAppMember(ctx, userID, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAppMember", reflect.TypeOf((*MockStorage)(nil).AddAppMember), ctx, userID, appID)
+}
+
 // Close mocks base method.
 func (m *MockStorage) Close(ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Close", ctx)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Close", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockStorageMockRecorder) Close(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStorage)(nil).Close), ctx)
+}
+
+// Connect mocks base method.
+func (m *MockStorage) Connect(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Connect", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Connect indicates an expected call of Connect.
+func (mr *MockStorageMockRecorder) Connect(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockStorage)(nil).Connect), ctx)
+}
+
+// CreateApp mocks base method.
+func (m *MockStorage) CreateApp(ctx context.Context, app models.App) (uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApp", ctx, app)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApp indicates an expected call of CreateApp.
+func (mr *MockStorageMockRecorder) CreateApp(ctx, app interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApp", reflect.TypeOf((*MockStorage)(nil).CreateApp), ctx, app)
+}
+
+// CreatePermission mocks base method.
+func (m *MockStorage) CreatePermission(ctx context.Context, permission models.Permission) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePermission", ctx, permission)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePermission indicates an expected call of CreatePermission.
+func (mr *MockStorageMockRecorder) CreatePermission(ctx, permission interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePermission", reflect.TypeOf((*MockStorage)(nil).CreatePermission), ctx, permission)
+}
+
+// CreateRole mocks base method.
+func (m *MockStorage) CreateRole(ctx context.Context, role models.Role) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRole", ctx, role)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRole indicates an expected call of CreateRole.
+func (mr *MockStorageMockRecorder) CreateRole(ctx, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRole", reflect.TypeOf((*MockStorage)(nil).CreateRole), ctx, role)
+}
+
+// CreateUser mocks base method.
+func (m *MockStorage) CreateUser(ctx context.Context, user models.User) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, user)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStorageMockRecorder) CreateUser(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStorage)(nil).CreateUser), ctx, user)
+}
+
+// DeleteApp mocks base method.
+func (m *MockStorage) DeleteApp(ctx context.Context, appID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteApp", ctx, appID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteApp indicates an expected call of DeleteApp.
+func (mr *MockStorageMockRecorder) DeleteApp(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteApp", reflect.TypeOf((*MockStorage)(nil).DeleteApp), ctx, appID)
+}
+
+// DeleteAppCertificate mocks base method.
+func (m *MockStorage) DeleteAppCertificate(ctx context.Context, appID uint32, thumbprint string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAppCertificate", ctx, appID, thumbprint)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAppCertificate indicates an expected call of DeleteAppCertificate.
+func (mr *MockStorageMockRecorder) DeleteAppCertificate(ctx, appID, thumbprint interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAppCertificate", reflect.TypeOf((*MockStorage)(nil).DeleteAppCertificate), ctx, appID, thumbprint)
+}
+
+// DeleteAppEncryptionKey mocks base method.
+func (m *MockStorage) DeleteAppEncryptionKey(ctx context.Context, appID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAppEncryptionKey", ctx, appID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAppEncryptionKey indicates an expected call of DeleteAppEncryptionKey.
+func (mr *MockStorageMockRecorder) DeleteAppEncryptionKey(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAppEncryptionKey", reflect.TypeOf((*MockStorage)(nil).DeleteAppEncryptionKey), ctx, appID)
+}
+
+// DeleteOfflineSessions mocks base method.
+func (m *MockStorage) DeleteOfflineSessions(ctx context.Context, userID uint64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOfflineSessions", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOfflineSessions indicates an expected call of DeleteOfflineSessions.
+func (mr *MockStorageMockRecorder) DeleteOfflineSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOfflineSessions", reflect.TypeOf((*MockStorage)(nil).DeleteOfflineSessions), ctx, userID)
+}
+
+// DeletePermission mocks base method.
+func (m *MockStorage) DeletePermission(ctx context.Context, permissionID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePermission", ctx, permissionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePermission indicates an expected call of DeletePermission.
+func (mr *MockStorageMockRecorder) DeletePermission(ctx, permissionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePermission", reflect.TypeOf((*MockStorage)(nil).DeletePermission), ctx, permissionID)
+}
+
+// DeleteRefreshToken mocks base method.
+func (m *MockStorage) DeleteRefreshToken(ctx context.Context, userID uint64, appID uint32, token models.Token) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRefreshToken", ctx, userID, appID, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRefreshToken indicates an expected call of DeleteRefreshToken.
+func (mr *MockStorageMockRecorder) DeleteRefreshToken(ctx, userID, appID, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRefreshToken", reflect.TypeOf((*MockStorage)(nil).DeleteRefreshToken), ctx, userID, appID, token)
+}
+
+// DeleteRole mocks base method.
+func (m *MockStorage) DeleteRole(ctx context.Context, roleID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRole", ctx, roleID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRole indicates an expected call of DeleteRole.
+func (mr *MockStorageMockRecorder) DeleteRole(ctx, roleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRole", reflect.TypeOf((*MockStorage)(nil).DeleteRole), ctx, roleID)
+}
+
+// DeleteSession mocks base method.
+func (m *MockStorage) DeleteSession(ctx context.Context, userID uint64, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", ctx, userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockStorageMockRecorder) DeleteSession(ctx, userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockStorage)(nil).DeleteSession), ctx, userID, sessionID)
+}
+
+// DeleteUser mocks base method.
+func (m *MockStorage) DeleteUser(ctx context.Context, userID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockStorageMockRecorder) DeleteUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockStorage)(nil).DeleteUser), ctx, userID)
+}
+
+// DeleteUserMFA mocks base method.
+func (m *MockStorage) DeleteUserMFA(ctx context.Context, userID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserMFA", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserMFA indicates an expected call of DeleteUserMFA.
+func (mr *MockStorageMockRecorder) DeleteUserMFA(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserMFA", reflect.TypeOf((*MockStorage)(nil).DeleteUserMFA), ctx, userID)
+}
+
+// DeleteUserRefreshTokens mocks base method.
+func (m *MockStorage) DeleteUserRefreshTokens(ctx context.Context, userID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserRefreshTokens", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserRefreshTokens indicates an expected call of DeleteUserRefreshTokens.
+func (mr *MockStorageMockRecorder) DeleteUserRefreshTokens(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserRefreshTokens", reflect.TypeOf((*MockStorage)(nil).DeleteUserRefreshTokens), ctx, userID)
+}
+
+// DeleteUserSessions mocks base method.
+func (m *MockStorage) DeleteUserSessions(ctx context.Context, userID uint64, except string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserSessions", ctx, userID, except)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserSessions indicates an expected call of DeleteUserSessions.
+func (mr *MockStorageMockRecorder) DeleteUserSessions(ctx, userID, except interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserSessions", reflect.TypeOf((*MockStorage)(nil).DeleteUserSessions), ctx, userID, except)
+}
+
+// DeleteWebAuthnCredential mocks base method.
+func (m *MockStorage) DeleteWebAuthnCredential(ctx context.Context, userID, id uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebAuthnCredential", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebAuthnCredential indicates an expected call of DeleteWebAuthnCredential.
+func (mr *MockStorageMockRecorder) DeleteWebAuthnCredential(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebAuthnCredential", reflect.TypeOf((*MockStorage)(nil).DeleteWebAuthnCredential), ctx, userID, id)
+}
+
+// EnableUserMFA mocks base method.
+func (m *MockStorage) EnableUserMFA(ctx context.Context, userID uint64, step int64, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableUserMFA", ctx, userID, step, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableUserMFA indicates an expected call of EnableUserMFA.
+func (mr *MockStorageMockRecorder) EnableUserMFA(ctx, userID, step, codeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUserMFA", reflect.TypeOf((*MockStorage)(nil).EnableUserMFA), ctx, userID, step, codeHashes)
+}
+
+// GetApp mocks base method.
+func (m *MockStorage) GetApp(ctx context.Context, appID uint32) (models.App, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApp", ctx, appID)
+	ret0, _ := ret[0].(models.App)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApp indicates an expected call of GetApp.
+func (mr *MockStorageMockRecorder) GetApp(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApp", reflect.TypeOf((*MockStorage)(nil).GetApp), ctx, appID)
+}
+
+// GetAppCertificates mocks base method.
+func (m *MockStorage) GetAppCertificates(ctx context.Context, appID uint32) ([]models.AppCertificate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAppCertificates", ctx, appID)
+	ret0, _ := ret[0].([]models.AppCertificate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAppCertificates indicates an expected call of GetAppCertificates.
+func (mr *MockStorageMockRecorder) GetAppCertificates(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppCertificates", reflect.TypeOf((*MockStorage)(nil).GetAppCertificates), ctx, appID)
+}
+
+// GetAppEncryptionKey mocks base method.
+func (m *MockStorage) GetAppEncryptionKey(ctx context.Context, appID uint32) (models.EncryptionKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAppEncryptionKey", ctx, appID)
+	ret0, _ := ret[0].(models.EncryptionKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAppEncryptionKey indicates an expected call of GetAppEncryptionKey.
+func (mr *MockStorageMockRecorder) GetAppEncryptionKey(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppEncryptionKey", reflect.TypeOf((*MockStorage)(nil).GetAppEncryptionKey), ctx, appID)
+}
+
+// GetAppMember mocks base method.
+func (m *MockStorage) GetAppMember(ctx context.Context, userID uint64, appID uint32) (models.AppMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAppMember", ctx, userID, appID)
+	ret0, _ := ret[0].(models.AppMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAppMember indicates an expected call of GetAppMember.
+func (mr *MockStorageMockRecorder) GetAppMember(ctx, userID, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppMember", reflect.TypeOf((*MockStorage)(nil).GetAppMember), ctx, userID, appID)
+}
+
+// GetProfile mocks base method.
+func (m *MockStorage) GetProfile(ctx context.Context, userID uint64) (models.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfile", ctx, userID)
+	ret0, _ := ret[0].(models.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockStorageMockRecorder) GetProfile(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockStorage)(nil).GetProfile), ctx, userID)
+}
+
+// GetRefreshToken mocks base method.
+func (m *MockStorage) GetRefreshToken(ctx context.Context, token string) (models.RefreshTokenInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshToken", ctx, token)
+	ret0, _ := ret[0].(models.RefreshTokenInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshToken indicates an expected call of GetRefreshToken.
+func (mr *MockStorageMockRecorder) GetRefreshToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshToken", reflect.TypeOf((*MockStorage)(nil).GetRefreshToken), ctx, token)
+}
+
+// GetRole mocks base method.
+func (m *MockStorage) GetRole(ctx context.Context, roleID int) (models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRole", ctx, roleID)
+	ret0, _ := ret[0].(models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRole indicates an expected call of GetRole.
+func (mr *MockStorageMockRecorder) GetRole(ctx, roleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*MockStorage)(nil).GetRole), ctx, roleID)
+}
+
+// GetUser mocks base method.
+func (m *MockStorage) GetUser(ctx context.Context, username string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, username)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStorageMockRecorder) GetUser(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStorage)(nil).GetUser), ctx, username)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockStorage) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", ctx, email)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockStorageMockRecorder) GetUserByEmail(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockStorage)(nil).GetUserByEmail), ctx, email)
+}
+
+// GetUserByID mocks base method.
+func (m *MockStorage) GetUserByID(ctx context.Context, userID uint64) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, userID)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockStorageMockRecorder) GetUserByID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockStorage)(nil).GetUserByID), ctx, userID)
+}
+
+// GetUserMFA mocks base method.
+func (m *MockStorage) GetUserMFA(ctx context.Context, userID uint64) (models.UserMFA, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserMFA", ctx, userID)
+	ret0, _ := ret[0].(models.UserMFA)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserMFA indicates an expected call of GetUserMFA.
+func (mr *MockStorageMockRecorder) GetUserMFA(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserMFA", reflect.TypeOf((*MockStorage)(nil).GetUserMFA), ctx, userID)
+}
+
+// GetUserRoles mocks base method.
+func (m *MockStorage) GetUserRoles(ctx context.Context, userID uint64, appID uint32) ([]models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoles", ctx, userID, appID)
+	ret0, _ := ret[0].([]models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRoles indicates an expected call of GetUserRoles.
+func (mr *MockStorageMockRecorder) GetUserRoles(ctx, userID, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoles", reflect.TypeOf((*MockStorage)(nil).GetUserRoles), ctx, userID, appID)
+}
+
+// GetWebAuthnCredential mocks base method.
+func (m *MockStorage) GetWebAuthnCredential(ctx context.Context, credentialID []byte) (models.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnCredential", ctx, credentialID)
+	ret0, _ := ret[0].(models.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebAuthnCredential indicates an expected call of GetWebAuthnCredential.
+func (mr *MockStorageMockRecorder) GetWebAuthnCredential(ctx, credentialID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnCredential", reflect.TypeOf((*MockStorage)(nil).GetWebAuthnCredential), ctx, credentialID)
+}
+
+// ListAppMembers mocks base method.
+func (m *MockStorage) ListAppMembers(ctx context.Context, appID uint32) ([]models.AppMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAppMembers", ctx, appID)
+	ret0, _ := ret[0].([]models.AppMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAppMembers indicates an expected call of ListAppMembers.
+func (mr *MockStorageMockRecorder) ListAppMembers(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppMembers", reflect.TypeOf((*MockStorage)(nil).ListAppMembers), ctx, appID)
+}
+
+// ListApps mocks base method.
+func (m *MockStorage) ListApps(ctx context.Context) ([]models.App, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListApps", ctx)
+	ret0, _ := ret[0].([]models.App)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListApps indicates an expected call of ListApps.
+func (mr *MockStorageMockRecorder) ListApps(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApps", reflect.TypeOf((*MockStorage)(nil).ListApps), ctx)
+}
+
+// ListLoginRecords mocks base method.
+func (m *MockStorage) ListLoginRecords(ctx context.Context, userID uint64, limit int) ([]models.LoginRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoginRecords", ctx, userID, limit)
+	ret0, _ := ret[0].([]models.LoginRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoginRecords indicates an expected call of ListLoginRecords.
+func (mr *MockStorageMockRecorder) ListLoginRecords(ctx, userID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoginRecords", reflect.TypeOf((*MockStorage)(nil).ListLoginRecords), ctx, userID, limit)
+}
+
+// ListPasswordHistory mocks base method.
+func (m *MockStorage) ListPasswordHistory(ctx context.Context, userID uint64, limit int) ([][]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPasswordHistory", ctx, userID, limit)
+	ret0, _ := ret[0].([][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPasswordHistory indicates an expected call of ListPasswordHistory.
+func (mr *MockStorageMockRecorder) ListPasswordHistory(ctx, userID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPasswordHistory", reflect.TypeOf((*MockStorage)(nil).ListPasswordHistory), ctx, userID, limit)
+}
+
+// ListPermissions mocks base method.
+func (m *MockStorage) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPermissions", ctx)
+	ret0, _ := ret[0].([]models.Permission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPermissions indicates an expected call of ListPermissions.
+func (mr *MockStorageMockRecorder) ListPermissions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPermissions", reflect.TypeOf((*MockStorage)(nil).ListPermissions), ctx)
+}
+
+// ListRoles mocks base method.
+func (m *MockStorage) ListRoles(ctx context.Context) ([]models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRoles", ctx)
+	ret0, _ := ret[0].([]models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRoles indicates an expected call of ListRoles.
+func (mr *MockStorageMockRecorder) ListRoles(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRoles", reflect.TypeOf((*MockStorage)(nil).ListRoles), ctx)
+}
+
+// ListSessions mocks base method.
+func (m *MockStorage) ListSessions(ctx context.Context, filter models.SessionFilter) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", ctx, filter)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockStorageMockRecorder) ListSessions(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockStorage)(nil).ListSessions), ctx, filter)
+}
+
+// ListUsers mocks base method.
+func (m *MockStorage) ListUsers(ctx context.Context, filter models.UserFilter) ([]models.User, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, filter)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockStorageMockRecorder) ListUsers(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockStorage)(nil).ListUsers), ctx, filter)
+}
+
+// ListWebAuthnCredentials mocks base method.
+func (m *MockStorage) ListWebAuthnCredentials(ctx context.Context, userID uint64) ([]models.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebAuthnCredentials", ctx, userID)
+	ret0, _ := ret[0].([]models.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebAuthnCredentials indicates an expected call of ListWebAuthnCredentials.
+func (mr *MockStorageMockRecorder) ListWebAuthnCredentials(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebAuthnCredentials", reflect.TypeOf((*MockStorage)(nil).ListWebAuthnCredentials), ctx, userID)
+}
+
+// RehashUserPassword mocks base method.
+func (m *MockStorage) RehashUserPassword(ctx context.Context, userID uint64, oldHash, newHash []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RehashUserPassword", ctx, userID, oldHash, newHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RehashUserPassword indicates an expected call of RehashUserPassword.
+func (mr *MockStorageMockRecorder) RehashUserPassword(ctx, userID, oldHash, newHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RehashUserPassword", reflect.TypeOf((*MockStorage)(nil).RehashUserPassword), ctx, userID, oldHash, newHash)
+}
+
+// RemoveAppMember mocks base method.
+func (m *MockStorage) RemoveAppMember(ctx context.Context, userID uint64, appID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveAppMember", ctx, userID, appID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveAppMember indicates an expected call of RemoveAppMember.
+func (mr *MockStorageMockRecorder) RemoveAppMember(ctx, userID, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAppMember", reflect.TypeOf((*MockStorage)(nil).RemoveAppMember), ctx, userID, appID)
+}
+
+// ReplaceRecoveryCodes mocks base method.
+func (m *MockStorage) ReplaceRecoveryCodes(ctx context.Context, userID uint64, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceRecoveryCodes", ctx, userID, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceRecoveryCodes indicates an expected call of ReplaceRecoveryCodes.
+func (mr *MockStorageMockRecorder) ReplaceRecoveryCodes(ctx, userID, codeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRecoveryCodes", reflect.TypeOf((*MockStorage)(nil).ReplaceRecoveryCodes), ctx, userID, codeHashes)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockStorage) RotateRefreshToken(ctx context.Context, session models.Session, old string, token models.Token) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", ctx, session, old, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockStorageMockRecorder) RotateRefreshToken(ctx, session, old, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockStorage)(nil).RotateRefreshToken), ctx, session, old, token)
+}
+
+// SaveAppCertificate mocks base method.
+func (m *MockStorage) SaveAppCertificate(ctx context.Context, cert models.AppCertificate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAppCertificate", ctx, cert)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAppCertificate indicates an expected call of SaveAppCertificate.
+func (mr *MockStorageMockRecorder) SaveAppCertificate(ctx, cert interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAppCertificate", reflect.TypeOf((*MockStorage)(nil).SaveAppCertificate), ctx, cert)
+}
+
+// SaveAppEncryptionKey mocks base method.
+func (m *MockStorage) SaveAppEncryptionKey(ctx context.Context, key models.EncryptionKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAppEncryptionKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAppEncryptionKey indicates an expected call of SaveAppEncryptionKey.
+func (mr *MockStorageMockRecorder) SaveAppEncryptionKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAppEncryptionKey", reflect.TypeOf((*MockStorage)(nil).SaveAppEncryptionKey), ctx, key)
+}
+
+// SaveLoginRecord mocks base method.
+func (m *MockStorage) SaveLoginRecord(ctx context.Context, record models.LoginRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveLoginRecord", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveLoginRecord indicates an expected call of SaveLoginRecord.
+func (mr *MockStorageMockRecorder) SaveLoginRecord(ctx, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveLoginRecord", reflect.TypeOf((*MockStorage)(nil).SaveLoginRecord), ctx, record)
+}
+
+// SaveProfile mocks base method.
+func (m *MockStorage) SaveProfile(ctx context.Context, profile models.Profile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveProfile", ctx, profile)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveProfile indicates an expected call of SaveProfile.
+func (mr *MockStorageMockRecorder) SaveProfile(ctx, profile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveProfile", reflect.TypeOf((*MockStorage)(nil).SaveProfile), ctx, profile)
+}
+
+// SaveRefreshToken mocks base method.
+func (m *MockStorage) SaveRefreshToken(ctx context.Context, session models.Session, token models.Token) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRefreshToken", ctx, session, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRefreshToken indicates an expected call of SaveRefreshToken.
+func (mr *MockStorageMockRecorder) SaveRefreshToken(ctx, session, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRefreshToken", reflect.TypeOf((*MockStorage)(nil).SaveRefreshToken), ctx, session, token)
+}
+
+// SaveUser mocks base method.
+func (m *MockStorage) SaveUser(ctx context.Context, user, passHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveUser", ctx, user, passHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveUser indicates an expected call of SaveUser.
+func (mr *MockStorageMockRecorder) SaveUser(ctx, user, passHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUser", reflect.TypeOf((*MockStorage)(nil).SaveUser), ctx, user, passHash)
+}
+
+// SaveUserMFA mocks base method.
+func (m *MockStorage) SaveUserMFA(ctx context.Context, mfa models.UserMFA) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveUserMFA", ctx, mfa)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveUserMFA indicates an expected call of SaveUserMFA.
+func (mr *MockStorageMockRecorder) SaveUserMFA(ctx, mfa interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUserMFA", reflect.TypeOf((*MockStorage)(nil).SaveUserMFA), ctx, mfa)
+}
+
+// SaveWebAuthnCredential mocks base method.
+func (m *MockStorage) SaveWebAuthnCredential(ctx context.Context, cred models.WebAuthnCredential) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveWebAuthnCredential", ctx, cred)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveWebAuthnCredential indicates an expected call of SaveWebAuthnCredential.
+func (mr *MockStorageMockRecorder) SaveWebAuthnCredential(ctx, cred interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWebAuthnCredential", reflect.TypeOf((*MockStorage)(nil).SaveWebAuthnCredential), ctx, cred)
+}
+
+// SetAppMemberBlocked mocks base method.
+func (m *MockStorage) SetAppMemberBlocked(ctx context.Context, userID uint64, appID uint32, blocked bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAppMemberBlocked", ctx, userID, appID, blocked)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAppMemberBlocked indicates an expected call of SetAppMemberBlocked.
+func (mr *MockStorageMockRecorder) SetAppMemberBlocked(ctx, userID, appID, blocked interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAppMemberBlocked", reflect.TypeOf((*MockStorage)(nil).SetAppMemberBlocked), ctx, userID, appID, blocked)
+}
+
+// SetRolePermissions mocks base method.
+func (m *MockStorage) SetRolePermissions(ctx context.Context, roleID int, permissions []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRolePermissions", ctx, roleID, permissions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRolePermissions indicates an expected call of SetRolePermissions.
+func (mr *MockStorageMockRecorder) SetRolePermissions(ctx, roleID, permissions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRolePermissions", reflect.TypeOf((*MockStorage)(nil).SetRolePermissions), ctx, roleID, permissions)
+}
+
+// SetUserAppRole mocks base method.
+func (m *MockStorage) SetUserAppRole(ctx context.Context, userID uint64, appID uint32, roleID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserAppRole", ctx, userID, appID, roleID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserAppRole indicates an expected call of SetUserAppRole.
+func (mr *MockStorageMockRecorder) SetUserAppRole(ctx, userID, appID, roleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserAppRole", reflect.TypeOf((*MockStorage)(nil).SetUserAppRole), ctx, userID, appID, roleID)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockStorage) SetUserDisabled(ctx context.Context, userID uint64, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, userID, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockStorageMockRecorder) SetUserDisabled(ctx, userID, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockStorage)(nil).SetUserDisabled), ctx, userID, disabled)
+}
+
+// TrimSessions mocks base method.
+func (m *MockStorage) TrimSessions(ctx context.Context, userID uint64, appID uint32, keep int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrimSessions", ctx, userID, appID, keep)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TrimSessions indicates an expected call of TrimSessions.
+func (mr *MockStorageMockRecorder) TrimSessions(ctx, userID, appID, keep interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrimSessions", reflect.TypeOf((*MockStorage)(nil).TrimSessions), ctx, userID, appID, keep)
+}
+
+// UnlockUser mocks base method.
+func (m *MockStorage) UnlockUser(ctx context.Context, userID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockStorageMockRecorder) UnlockUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockStorage)(nil).UnlockUser), ctx, userID)
+}
+
+// UpdateApp mocks base method.
+func (m *MockStorage) UpdateApp(ctx context.Context, app models.App) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateApp", ctx, app)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateApp indicates an expected call of UpdateApp.
+func (mr *MockStorageMockRecorder) UpdateApp(ctx, app interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateApp", reflect.TypeOf((*MockStorage)(nil).UpdateApp), ctx, app)
+}
+
+// UpdateAppSecret mocks base method.
+func (m *MockStorage) UpdateAppSecret(ctx context.Context, app models.App) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAppSecret", ctx, app)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAppSecret indicates an expected call of UpdateAppSecret.
+func (mr *MockStorageMockRecorder) UpdateAppSecret(ctx, app interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAppSecret", reflect.TypeOf((*MockStorage)(nil).UpdateAppSecret), ctx, app)
+}
+
+// UpdateUser mocks base method.
+func (m *MockStorage) UpdateUser(ctx context.Context, user models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockStorageMockRecorder) UpdateUser(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockStorage)(nil).UpdateUser), ctx, user)
+}
+
+// UpdateUserPassword mocks base method.
+func (m *MockStorage) UpdateUserPassword(ctx context.Context, userID uint64, passHash []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPassword", ctx, userID, passHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserPassword indicates an expected call of UpdateUserPassword.
+func (mr *MockStorageMockRecorder) UpdateUserPassword(ctx, userID, passHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockStorage)(nil).UpdateUserPassword), ctx, userID, passHash)
+}
+
+// UpdateWebAuthnSignCount mocks base method.
+func (m *MockStorage) UpdateWebAuthnSignCount(ctx context.Context, id uint64, signCount uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebAuthnSignCount", ctx, id, signCount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebAuthnSignCount indicates an expected call of UpdateWebAuthnSignCount.
+func (mr *MockStorageMockRecorder) UpdateWebAuthnSignCount(ctx, id, signCount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebAuthnSignCount", reflect.TypeOf((*MockStorage)(nil).UpdateWebAuthnSignCount), ctx, id, signCount)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockStorage) UseRecoveryCode(ctx context.Context, userID uint64, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userID, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockStorageMockRecorder) UseRecoveryCode(ctx, userID, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockStorage)(nil).UseRecoveryCode), ctx, userID, codeHash)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockStorage) UseTOTPStep(ctx context.Context, userID uint64, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", ctx, userID, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockStorageMockRecorder) UseTOTPStep(ctx, userID, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockStorage)(nil).UseTOTPStep), ctx, userID, step)
+}
+
+// VerifyProfileEmail mocks base method.
+func (m *MockStorage) VerifyProfileEmail(ctx context.Context, userID uint64, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyProfileEmail", ctx, userID, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyProfileEmail indicates an expected call of VerifyProfileEmail.
+func (mr *MockStorageMockRecorder) VerifyProfileEmail(ctx, userID, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyProfileEmail", reflect.TypeOf((*MockStorage)(nil).VerifyProfileEmail), ctx, userID, email)
+}
+
+// MockStorageUserProvider is a mock of StorageUserProvider interface.
+type MockStorageUserProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageUserProviderMockRecorder
+}
+
+// MockStorageUserProviderMockRecorder is the mock recorder for MockStorageUserProvider.
+type MockStorageUserProviderMockRecorder struct {
+	mock *MockStorageUserProvider
+}
+
+// NewMockStorageUserProvider creates a new mock instance.
+func NewMockStorageUserProvider(ctrl *gomock.Controller) *MockStorageUserProvider {
+	mock := &MockStorageUserProvider{ctrl: ctrl}
+	mock.recorder = &MockStorageUserProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorageUserProvider) EXPECT() *MockStorageUserProviderMockRecorder {
+	return m.recorder
+}
+
+// CreateUser mocks base method.
+func (m *MockStorageUserProvider) CreateUser(ctx context.Context, user models.User) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, user)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStorageUserProviderMockRecorder) CreateUser(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStorageUserProvider)(nil).CreateUser), ctx, user)
+}
+
+// DeleteUser mocks base method.
+func (m *MockStorageUserProvider) DeleteUser(ctx context.Context, userID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockStorageUserProviderMockRecorder) DeleteUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockStorageUserProvider)(nil).DeleteUser), ctx, userID)
+}
+
+// GetUser mocks base method.
+func (m *MockStorageUserProvider) GetUser(ctx context.Context, username string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, username)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStorageUserProviderMockRecorder) GetUser(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStorageUserProvider)(nil).GetUser), ctx, username)
+}
+
+// GetUserByID mocks base method.
+func (m *MockStorageUserProvider) GetUserByID(ctx context.Context, userID uint64) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, userID)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockStorageUserProviderMockRecorder) GetUserByID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockStorageUserProvider)(nil).GetUserByID), ctx, userID)
+}
+
+// ListPasswordHistory mocks base method.
+func (m *MockStorageUserProvider) ListPasswordHistory(ctx context.Context, userID uint64, limit int) ([][]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPasswordHistory", ctx, userID, limit)
+	ret0, _ := ret[0].([][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPasswordHistory indicates an expected call of ListPasswordHistory.
+func (mr *MockStorageUserProviderMockRecorder) ListPasswordHistory(ctx, userID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPasswordHistory", reflect.TypeOf((*MockStorageUserProvider)(nil).ListPasswordHistory), ctx, userID, limit)
+}
+
+// ListUsers mocks base method.
+func (m *MockStorageUserProvider) ListUsers(ctx context.Context, filter models.UserFilter) ([]models.User, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, filter)
+	ret0, _ := ret[0].([]models.User)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockStorageUserProviderMockRecorder) ListUsers(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockStorageUserProvider)(nil).ListUsers), ctx, filter)
+}
+
+// RehashUserPassword mocks base method.
+func (m *MockStorageUserProvider) RehashUserPassword(ctx context.Context, userID uint64, oldHash, newHash []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RehashUserPassword", ctx, userID, oldHash, newHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RehashUserPassword indicates an expected call of RehashUserPassword.
+func (mr *MockStorageUserProviderMockRecorder) RehashUserPassword(ctx, userID, oldHash, newHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RehashUserPassword", reflect.TypeOf((*MockStorageUserProvider)(nil).RehashUserPassword), ctx, userID, oldHash, newHash)
+}
+
+// SaveUser mocks base method.
+func (m *MockStorageUserProvider) SaveUser(ctx context.Context, user, passHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveUser", ctx, user, passHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveUser indicates an expected call of SaveUser.
+func (mr *MockStorageUserProviderMockRecorder) SaveUser(ctx, user, passHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUser", reflect.TypeOf((*MockStorageUserProvider)(nil).SaveUser), ctx, user, passHash)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockStorageUserProvider) SetUserDisabled(ctx context.Context, userID uint64, disabled bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, userID, disabled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockStorageUserProviderMockRecorder) SetUserDisabled(ctx, userID, disabled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockStorageUserProvider)(nil).SetUserDisabled), ctx, userID, disabled)
+}
+
+// UnlockUser mocks base method.
+func (m *MockStorageUserProvider) UnlockUser(ctx context.Context, userID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockUser", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockStorageUserProviderMockRecorder) UnlockUser(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockStorageUserProvider)(nil).UnlockUser), ctx, userID)
+}
+
+// UpdateUser mocks base method.
+func (m *MockStorageUserProvider) UpdateUser(ctx context.Context, user models.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUser", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUser indicates an expected call of UpdateUser.
+func (mr *MockStorageUserProviderMockRecorder) UpdateUser(ctx, user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockStorageUserProvider)(nil).UpdateUser), ctx, user)
+}
+
+// UpdateUserPassword mocks base method.
+func (m *MockStorageUserProvider) UpdateUserPassword(ctx context.Context, userID uint64, passHash []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserPassword", ctx, userID, passHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateUserPassword indicates an expected call of UpdateUserPassword.
+func (mr *MockStorageUserProviderMockRecorder) UpdateUserPassword(ctx, userID, passHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserPassword", reflect.TypeOf((*MockStorageUserProvider)(nil).UpdateUserPassword), ctx, userID, passHash)
+}
+
+// MockStorageAppProvider is a mock of StorageAppProvider interface.
+type MockStorageAppProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageAppProviderMockRecorder
+}
+
+// MockStorageAppProviderMockRecorder is the mock recorder for MockStorageAppProvider.
+type MockStorageAppProviderMockRecorder struct {
+	mock *MockStorageAppProvider
+}
+
+// NewMockStorageAppProvider creates a new mock instance.
+func NewMockStorageAppProvider(ctrl *gomock.Controller) *MockStorageAppProvider {
+	mock := &MockStorageAppProvider{ctrl: ctrl}
+	mock.recorder = &MockStorageAppProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorageAppProvider) EXPECT() *MockStorageAppProviderMockRecorder {
+	return m.recorder
+}
+
+// AddAppMember mocks base method.
+func (m *MockStorageAppProvider) AddAppMember(ctx context.Context, userID uint64, appID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAppMember", ctx, userID, appID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAppMember indicates an expected call of AddAppMember.
+func (mr *MockStorageAppProviderMockRecorder) AddAppMember(ctx, userID, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAppMember", reflect.TypeOf((*MockStorageAppProvider)(nil).AddAppMember), ctx, userID, appID)
+}
+
+// CreateApp mocks base method.
+func (m *MockStorageAppProvider) CreateApp(ctx context.Context, app models.App) (uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApp", ctx, app)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApp indicates an expected call of CreateApp.
+func (mr *MockStorageAppProviderMockRecorder) CreateApp(ctx, app interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApp", reflect.TypeOf((*MockStorageAppProvider)(nil).CreateApp), ctx, app)
+}
+
+// DeleteApp mocks base method.
+func (m *MockStorageAppProvider) DeleteApp(ctx context.Context, appID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteApp", ctx, appID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteApp indicates an expected call of DeleteApp.
+func (mr *MockStorageAppProviderMockRecorder) DeleteApp(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteApp", reflect.TypeOf((*MockStorageAppProvider)(nil).DeleteApp), ctx, appID)
+}
+
+// DeleteAppCertificate mocks base method.
+func (m *MockStorageAppProvider) DeleteAppCertificate(ctx context.Context, appID uint32, thumbprint string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAppCertificate", ctx, appID, thumbprint)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAppCertificate indicates an expected call of DeleteAppCertificate.
+func (mr *MockStorageAppProviderMockRecorder) DeleteAppCertificate(ctx, appID, thumbprint interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAppCertificate", reflect.TypeOf((*MockStorageAppProvider)(nil).DeleteAppCertificate), ctx, appID, thumbprint)
+}
+
+// DeleteAppEncryptionKey mocks base method.
+func (m *MockStorageAppProvider) DeleteAppEncryptionKey(ctx context.Context, appID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAppEncryptionKey", ctx, appID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAppEncryptionKey indicates an expected call of DeleteAppEncryptionKey.
+func (mr *MockStorageAppProviderMockRecorder) DeleteAppEncryptionKey(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAppEncryptionKey", reflect.TypeOf((*MockStorageAppProvider)(nil).DeleteAppEncryptionKey), ctx, appID)
+}
+
+// GetApp mocks base method.
+func (m *MockStorageAppProvider) GetApp(ctx context.Context, appID uint32) (models.App, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetApp", ctx, appID)
+	ret0, _ := ret[0].(models.App)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetApp indicates an expected call of GetApp.
+func (mr *MockStorageAppProviderMockRecorder) GetApp(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApp", reflect.TypeOf((*MockStorageAppProvider)(nil).GetApp), ctx, appID)
+}
+
+// GetAppCertificates mocks base method.
+func (m *MockStorageAppProvider) GetAppCertificates(ctx context.Context, appID uint32) ([]models.AppCertificate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAppCertificates", ctx, appID)
+	ret0, _ := ret[0].([]models.AppCertificate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAppCertificates indicates an expected call of GetAppCertificates.
+func (mr *MockStorageAppProviderMockRecorder) GetAppCertificates(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppCertificates", reflect.TypeOf((*MockStorageAppProvider)(nil).GetAppCertificates), ctx, appID)
+}
+
+// GetAppEncryptionKey mocks base method.
+func (m *MockStorageAppProvider) GetAppEncryptionKey(ctx context.Context, appID uint32) (models.EncryptionKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAppEncryptionKey", ctx, appID)
+	ret0, _ := ret[0].(models.EncryptionKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAppEncryptionKey indicates an expected call of GetAppEncryptionKey.
+func (mr *MockStorageAppProviderMockRecorder) GetAppEncryptionKey(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppEncryptionKey", reflect.TypeOf((*MockStorageAppProvider)(nil).GetAppEncryptionKey), ctx, appID)
+}
+
+// GetAppMember mocks base method.
+func (m *MockStorageAppProvider) GetAppMember(ctx context.Context, userID uint64, appID uint32) (models.AppMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAppMember", ctx, userID, appID)
+	ret0, _ := ret[0].(models.AppMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAppMember indicates an expected call of GetAppMember.
+func (mr *MockStorageAppProviderMockRecorder) GetAppMember(ctx, userID, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppMember", reflect.TypeOf((*MockStorageAppProvider)(nil).GetAppMember), ctx, userID, appID)
+}
+
+// ListAppMembers mocks base method.
+func (m *MockStorageAppProvider) ListAppMembers(ctx context.Context, appID uint32) ([]models.AppMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAppMembers", ctx, appID)
+	ret0, _ := ret[0].([]models.AppMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAppMembers indicates an expected call of ListAppMembers.
+func (mr *MockStorageAppProviderMockRecorder) ListAppMembers(ctx, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppMembers", reflect.TypeOf((*MockStorageAppProvider)(nil).ListAppMembers), ctx, appID)
+}
+
+// ListApps mocks base method.
+func (m *MockStorageAppProvider) ListApps(ctx context.Context) ([]models.App, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListApps", ctx)
+	ret0, _ := ret[0].([]models.App)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListApps indicates an expected call of ListApps.
+func (mr *MockStorageAppProviderMockRecorder) ListApps(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApps", reflect.TypeOf((*MockStorageAppProvider)(nil).ListApps), ctx)
+}
+
+// RemoveAppMember mocks base method.
+func (m *MockStorageAppProvider) RemoveAppMember(ctx context.Context, userID uint64, appID uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveAppMember", ctx, userID, appID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveAppMember indicates an expected call of RemoveAppMember.
+func (mr *MockStorageAppProviderMockRecorder) RemoveAppMember(ctx, userID, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveAppMember", reflect.TypeOf((*MockStorageAppProvider)(nil).RemoveAppMember), ctx, userID, appID)
+}
+
+// SaveAppCertificate mocks base method.
+func (m *MockStorageAppProvider) SaveAppCertificate(ctx context.Context, cert models.AppCertificate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAppCertificate", ctx, cert)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAppCertificate indicates an expected call of SaveAppCertificate.
+func (mr *MockStorageAppProviderMockRecorder) SaveAppCertificate(ctx, cert interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAppCertificate", reflect.TypeOf((*MockStorageAppProvider)(nil).SaveAppCertificate), ctx, cert)
+}
+
+// SaveAppEncryptionKey mocks base method.
+func (m *MockStorageAppProvider) SaveAppEncryptionKey(ctx context.Context, key models.EncryptionKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAppEncryptionKey", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAppEncryptionKey indicates an expected call of SaveAppEncryptionKey.
+func (mr *MockStorageAppProviderMockRecorder) SaveAppEncryptionKey(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAppEncryptionKey", reflect.TypeOf((*MockStorageAppProvider)(nil).SaveAppEncryptionKey), ctx, key)
+}
+
+// SetAppMemberBlocked mocks base method.
+func (m *MockStorageAppProvider) SetAppMemberBlocked(ctx context.Context, userID uint64, appID uint32, blocked bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAppMemberBlocked", ctx, userID, appID, blocked)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAppMemberBlocked indicates an expected call of SetAppMemberBlocked.
+func (mr *MockStorageAppProviderMockRecorder) SetAppMemberBlocked(ctx, userID, appID, blocked interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAppMemberBlocked", reflect.TypeOf((*MockStorageAppProvider)(nil).SetAppMemberBlocked), ctx, userID, appID, blocked)
+}
+
+// UpdateApp mocks base method.
+func (m *MockStorageAppProvider) UpdateApp(ctx context.Context, app models.App) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateApp", ctx, app)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateApp indicates an expected call of UpdateApp.
+func (mr *MockStorageAppProviderMockRecorder) UpdateApp(ctx, app interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateApp", reflect.TypeOf((*MockStorageAppProvider)(nil).UpdateApp), ctx, app)
+}
+
+// UpdateAppSecret mocks base method.
+func (m *MockStorageAppProvider) UpdateAppSecret(ctx context.Context, app models.App) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAppSecret", ctx, app)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAppSecret indicates an expected call of UpdateAppSecret.
+func (mr *MockStorageAppProviderMockRecorder) UpdateAppSecret(ctx, app interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAppSecret", reflect.TypeOf((*MockStorageAppProvider)(nil).UpdateAppSecret), ctx, app)
+}
+
+// MockStorageRoleProvider is a mock of StorageRoleProvider interface.
+type MockStorageRoleProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageRoleProviderMockRecorder
+}
+
+// MockStorageRoleProviderMockRecorder is the mock recorder for MockStorageRoleProvider.
+type MockStorageRoleProviderMockRecorder struct {
+	mock *MockStorageRoleProvider
+}
+
+// NewMockStorageRoleProvider creates a new mock instance.
+func NewMockStorageRoleProvider(ctrl *gomock.Controller) *MockStorageRoleProvider {
+	mock := &MockStorageRoleProvider{ctrl: ctrl}
+	mock.recorder = &MockStorageRoleProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorageRoleProvider) EXPECT() *MockStorageRoleProviderMockRecorder {
+	return m.recorder
+}
+
+// CreatePermission mocks base method.
+func (m *MockStorageRoleProvider) CreatePermission(ctx context.Context, permission models.Permission) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePermission", ctx, permission)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePermission indicates an expected call of CreatePermission.
+func (mr *MockStorageRoleProviderMockRecorder) CreatePermission(ctx, permission interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePermission", reflect.TypeOf((*MockStorageRoleProvider)(nil).CreatePermission), ctx, permission)
+}
+
+// CreateRole mocks base method.
+func (m *MockStorageRoleProvider) CreateRole(ctx context.Context, role models.Role) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRole", ctx, role)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRole indicates an expected call of CreateRole.
+func (mr *MockStorageRoleProviderMockRecorder) CreateRole(ctx, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRole", reflect.TypeOf((*MockStorageRoleProvider)(nil).CreateRole), ctx, role)
+}
+
+// DeletePermission mocks base method.
+func (m *MockStorageRoleProvider) DeletePermission(ctx context.Context, permissionID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePermission", ctx, permissionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePermission indicates an expected call of DeletePermission.
+func (mr *MockStorageRoleProviderMockRecorder) DeletePermission(ctx, permissionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePermission", reflect.TypeOf((*MockStorageRoleProvider)(nil).DeletePermission), ctx, permissionID)
+}
+
+// DeleteRole mocks base method.
+func (m *MockStorageRoleProvider) DeleteRole(ctx context.Context, roleID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRole", ctx, roleID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRole indicates an expected call of DeleteRole.
+func (mr *MockStorageRoleProviderMockRecorder) DeleteRole(ctx, roleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRole", reflect.TypeOf((*MockStorageRoleProvider)(nil).DeleteRole), ctx, roleID)
+}
+
+// GetRole mocks base method.
+func (m *MockStorageRoleProvider) GetRole(ctx context.Context, roleID int) (models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRole", ctx, roleID)
+	ret0, _ := ret[0].(models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRole indicates an expected call of GetRole.
+func (mr *MockStorageRoleProviderMockRecorder) GetRole(ctx, roleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*MockStorageRoleProvider)(nil).GetRole), ctx, roleID)
+}
+
+// GetUserRoles mocks base method.
+func (m *MockStorageRoleProvider) GetUserRoles(ctx context.Context, userID uint64, appID uint32) ([]models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoles", ctx, userID, appID)
+	ret0, _ := ret[0].([]models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRoles indicates an expected call of GetUserRoles.
+func (mr *MockStorageRoleProviderMockRecorder) GetUserRoles(ctx, userID, appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoles", reflect.TypeOf((*MockStorageRoleProvider)(nil).GetUserRoles), ctx, userID, appID)
+}
+
+// ListPermissions mocks base method.
+func (m *MockStorageRoleProvider) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPermissions", ctx)
+	ret0, _ := ret[0].([]models.Permission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPermissions indicates an expected call of ListPermissions.
+func (mr *MockStorageRoleProviderMockRecorder) ListPermissions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPermissions", reflect.TypeOf((*MockStorageRoleProvider)(nil).ListPermissions), ctx)
+}
+
+// ListRoles mocks base method.
+func (m *MockStorageRoleProvider) ListRoles(ctx context.Context) ([]models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRoles", ctx)
+	ret0, _ := ret[0].([]models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Close indicates an expected call of Close.
-func (mr *MockStorageMockRecorder) Close(ctx interface{}) *gomock.Call {
+// ListRoles indicates an expected call of ListRoles.
+func (mr *MockStorageRoleProviderMockRecorder) ListRoles(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockStorage)(nil).Close), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRoles", reflect.TypeOf((*MockStorageRoleProvider)(nil).ListRoles), ctx)
 }
 
-// Connect mocks base method.
-func (m *MockStorage) Connect(ctx context.Context) error {
+// SetRolePermissions mocks base method.
+func (m *MockStorageRoleProvider) SetRolePermissions(ctx context.Context, roleID int, permissions []string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Connect", ctx)
+	ret := m.ctrl.Call(m, "SetRolePermissions", ctx, roleID, permissions)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Connect indicates an expected call of Connect.
-func (mr *MockStorageMockRecorder) Connect(ctx interface{}) *gomock.Call {
+// SetRolePermissions indicates an expected call of SetRolePermissions.
+func (mr *MockStorageRoleProviderMockRecorder) SetRolePermissions(ctx, roleID, permissions interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Connect", reflect.TypeOf((*MockStorage)(nil).Connect), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRolePermissions", reflect.TypeOf((*MockStorageRoleProvider)(nil).SetRolePermissions), ctx, roleID, permissions)
 }
 
-// DeleteRefreshToken mocks base method.
-func (m *MockStorage) DeleteRefreshToken(ctx context.Context, userID uint64, appID uint32, token models.Token) error {
+// SetUserAppRole mocks base method.
+func (m *MockStorageRoleProvider) SetUserAppRole(ctx context.Context, userID uint64, appID uint32, roleID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteRefreshToken", ctx, userID, appID, token)
+	ret := m.ctrl.Call(m, "SetUserAppRole", ctx, userID, appID, roleID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteRefreshToken indicates an expected call of DeleteRefreshToken.
-func (mr *MockStorageMockRecorder) DeleteRefreshToken(ctx, userID, appID, token interface{}) *gomock.Call {
+// SetUserAppRole indicates an expected call of SetUserAppRole.
+func (mr *MockStorageRoleProviderMockRecorder) SetUserAppRole(ctx, userID, appID, roleID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRefreshToken", reflect.TypeOf((*MockStorage)(nil).DeleteRefreshToken), ctx, userID, appID, token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserAppRole", reflect.TypeOf((*MockStorageRoleProvider)(nil).SetUserAppRole), ctx, userID, appID, roleID)
 }
 
-// GetApp mocks base method.
-func (m *MockStorage) GetApp(ctx context.Context, appID uint32) (models.App, error) {
+// MockStorageLoginProvider is a mock of StorageLoginProvider interface.
+type MockStorageLoginProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageLoginProviderMockRecorder
+}
+
+// MockStorageLoginProviderMockRecorder is the mock recorder for MockStorageLoginProvider.
+type MockStorageLoginProviderMockRecorder struct {
+	mock *MockStorageLoginProvider
+}
+
+// NewMockStorageLoginProvider creates a new mock instance.
+func NewMockStorageLoginProvider(ctrl *gomock.Controller) *MockStorageLoginProvider {
+	mock := &MockStorageLoginProvider{ctrl: ctrl}
+	mock.recorder = &MockStorageLoginProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorageLoginProvider) EXPECT() *MockStorageLoginProviderMockRecorder {
+	return m.recorder
+}
+
+// ListLoginRecords mocks base method.
+func (m *MockStorageLoginProvider) ListLoginRecords(ctx context.Context, userID uint64, limit int) ([]models.LoginRecord, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetApp", ctx, appID)
-	ret0, _ := ret[0].(models.App)
+	ret := m.ctrl.Call(m, "ListLoginRecords", ctx, userID, limit)
+	ret0, _ := ret[0].([]models.LoginRecord)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetApp indicates an expected call of GetApp.
-func (mr *MockStorageMockRecorder) GetApp(ctx, appID interface{}) *gomock.Call {
+// ListLoginRecords indicates an expected call of ListLoginRecords.
+func (mr *MockStorageLoginProviderMockRecorder) ListLoginRecords(ctx, userID, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApp", reflect.TypeOf((*MockStorage)(nil).GetApp), ctx, appID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoginRecords", reflect.TypeOf((*MockStorageLoginProvider)(nil).ListLoginRecords), ctx, userID, limit)
 }
 
-// GetUser mocks base method.
-func (m *MockStorage) GetUser(ctx context.Context, username string) (models.User, error) {
+// SaveLoginRecord mocks base method.
+func (m *MockStorageLoginProvider) SaveLoginRecord(ctx context.Context, record models.LoginRecord) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", ctx, username)
-	ret0, _ := ret[0].(models.User)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "SaveLoginRecord", ctx, record)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockStorageMockRecorder) GetUser(ctx, username interface{}) *gomock.Call {
+// SaveLoginRecord indicates an expected call of SaveLoginRecord.
+func (mr *MockStorageLoginProviderMockRecorder) SaveLoginRecord(ctx, record interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStorage)(nil).GetUser), ctx, username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveLoginRecord", reflect.TypeOf((*MockStorageLoginProvider)(nil).SaveLoginRecord), ctx, record)
+}
+
+// MockStorageMFAProvider is a mock of StorageMFAProvider interface.
+type MockStorageMFAProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageMFAProviderMockRecorder
+}
+
+// MockStorageMFAProviderMockRecorder is the mock recorder for MockStorageMFAProvider.
+type MockStorageMFAProviderMockRecorder struct {
+	mock *MockStorageMFAProvider
+}
+
+// NewMockStorageMFAProvider creates a new mock instance.
+func NewMockStorageMFAProvider(ctrl *gomock.Controller) *MockStorageMFAProvider {
+	mock := &MockStorageMFAProvider{ctrl: ctrl}
+	mock.recorder = &MockStorageMFAProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorageMFAProvider) EXPECT() *MockStorageMFAProviderMockRecorder {
+	return m.recorder
 }
 
-// IsAdmin mocks base method.
-func (m *MockStorage) IsAdmin(ctx context.Context, username string) (bool, error) {
+// DeleteUserMFA mocks base method.
+func (m *MockStorageMFAProvider) DeleteUserMFA(ctx context.Context, userID uint64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsAdmin", ctx, username)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "DeleteUserMFA", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserMFA indicates an expected call of DeleteUserMFA.
+func (mr *MockStorageMFAProviderMockRecorder) DeleteUserMFA(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserMFA", reflect.TypeOf((*MockStorageMFAProvider)(nil).DeleteUserMFA), ctx, userID)
+}
+
+// EnableUserMFA mocks base method.
+func (m *MockStorageMFAProvider) EnableUserMFA(ctx context.Context, userID uint64, step int64, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableUserMFA", ctx, userID, step, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableUserMFA indicates an expected call of EnableUserMFA.
+func (mr *MockStorageMFAProviderMockRecorder) EnableUserMFA(ctx, userID, step, codeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableUserMFA", reflect.TypeOf((*MockStorageMFAProvider)(nil).EnableUserMFA), ctx, userID, step, codeHashes)
+}
+
+// GetUserMFA mocks base method.
+func (m *MockStorageMFAProvider) GetUserMFA(ctx context.Context, userID uint64) (models.UserMFA, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserMFA", ctx, userID)
+	ret0, _ := ret[0].(models.UserMFA)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// IsAdmin indicates an expected call of IsAdmin.
-func (mr *MockStorageMockRecorder) IsAdmin(ctx, username interface{}) *gomock.Call {
+// GetUserMFA indicates an expected call of GetUserMFA.
+func (mr *MockStorageMFAProviderMockRecorder) GetUserMFA(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAdmin", reflect.TypeOf((*MockStorage)(nil).IsAdmin), ctx, username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserMFA", reflect.TypeOf((*MockStorageMFAProvider)(nil).GetUserMFA), ctx, userID)
 }
 
-// SaveRefreshToken mocks base method.
-func (m *MockStorage) SaveRefreshToken(ctx context.Context, userID uint64, appID uint32, token models.Token) error {
+// ReplaceRecoveryCodes mocks base method.
+func (m *MockStorageMFAProvider) ReplaceRecoveryCodes(ctx context.Context, userID uint64, codeHashes []string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveRefreshToken", ctx, userID, appID, token)
+	ret := m.ctrl.Call(m, "ReplaceRecoveryCodes", ctx, userID, codeHashes)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SaveRefreshToken indicates an expected call of SaveRefreshToken.
-func (mr *MockStorageMockRecorder) SaveRefreshToken(ctx, userID, appID, token interface{}) *gomock.Call {
+// ReplaceRecoveryCodes indicates an expected call of ReplaceRecoveryCodes.
+func (mr *MockStorageMFAProviderMockRecorder) ReplaceRecoveryCodes(ctx, userID, codeHashes interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRefreshToken", reflect.TypeOf((*MockStorage)(nil).SaveRefreshToken), ctx, userID, appID, token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRecoveryCodes", reflect.TypeOf((*MockStorageMFAProvider)(nil).ReplaceRecoveryCodes), ctx, userID, codeHashes)
 }
 
-// SaveUser mocks base method.
-func (m *MockStorage) SaveUser(ctx context.Context, user, passHash string) error {
+// SaveUserMFA mocks base method.
+func (m *MockStorageMFAProvider) SaveUserMFA(ctx context.Context, mfa models.UserMFA) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveUser", ctx, user, passHash)
+	ret := m.ctrl.Call(m, "SaveUserMFA", ctx, mfa)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SaveUser indicates an expected call of SaveUser.
-func (mr *MockStorageMockRecorder) SaveUser(ctx, user, passHash interface{}) *gomock.Call {
+// SaveUserMFA indicates an expected call of SaveUserMFA.
+func (mr *MockStorageMFAProviderMockRecorder) SaveUserMFA(ctx, mfa interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUser", reflect.TypeOf((*MockStorage)(nil).SaveUser), ctx, user, passHash)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUserMFA", reflect.TypeOf((*MockStorageMFAProvider)(nil).SaveUserMFA), ctx, mfa)
 }
 
-// MockStorageUserProvider is a mock of StorageUserProvider interface.
-type MockStorageUserProvider struct {
+// UseRecoveryCode mocks base method.
+func (m *MockStorageMFAProvider) UseRecoveryCode(ctx context.Context, userID uint64, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", ctx, userID, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockStorageMFAProviderMockRecorder) UseRecoveryCode(ctx, userID, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockStorageMFAProvider)(nil).UseRecoveryCode), ctx, userID, codeHash)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockStorageMFAProvider) UseTOTPStep(ctx context.Context, userID uint64, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", ctx, userID, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockStorageMFAProviderMockRecorder) UseTOTPStep(ctx, userID, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockStorageMFAProvider)(nil).UseTOTPStep), ctx, userID, step)
+}
+
+// MockStorageWebAuthnProvider is a mock of StorageWebAuthnProvider interface.
+type MockStorageWebAuthnProvider struct {
 	ctrl     *gomock.Controller
-	recorder *MockStorageUserProviderMockRecorder
+	recorder *MockStorageWebAuthnProviderMockRecorder
 }
 
-// MockStorageUserProviderMockRecorder is the mock recorder for MockStorageUserProvider.
-type MockStorageUserProviderMockRecorder struct {
-	mock *MockStorageUserProvider
+// MockStorageWebAuthnProviderMockRecorder is the mock recorder for MockStorageWebAuthnProvider.
+type MockStorageWebAuthnProviderMockRecorder struct {
+	mock *MockStorageWebAuthnProvider
 }
 
-// NewMockStorageUserProvider creates a new mock instance.
-func NewMockStorageUserProvider(ctrl *gomock.Controller) *MockStorageUserProvider {
-	mock := &MockStorageUserProvider{ctrl: ctrl}
-	mock.recorder = &MockStorageUserProviderMockRecorder{mock}
+// NewMockStorageWebAuthnProvider creates a new mock instance.
+func NewMockStorageWebAuthnProvider(ctrl *gomock.Controller) *MockStorageWebAuthnProvider {
+	mock := &MockStorageWebAuthnProvider{ctrl: ctrl}
+	mock.recorder = &MockStorageWebAuthnProviderMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockStorageUserProvider) EXPECT() *MockStorageUserProviderMockRecorder {
+func (m *MockStorageWebAuthnProvider) EXPECT() *MockStorageWebAuthnProviderMockRecorder {
 	return m.recorder
 }
 
-// GetUser mocks base method.
-func (m *MockStorageUserProvider) GetUser(ctx context.Context, username string) (models.User, error) {
+// DeleteWebAuthnCredential mocks base method.
+func (m *MockStorageWebAuthnProvider) DeleteWebAuthnCredential(ctx context.Context, userID, id uint64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", ctx, username)
-	ret0, _ := ret[0].(models.User)
+	ret := m.ctrl.Call(m, "DeleteWebAuthnCredential", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebAuthnCredential indicates an expected call of DeleteWebAuthnCredential.
+func (mr *MockStorageWebAuthnProviderMockRecorder) DeleteWebAuthnCredential(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebAuthnCredential", reflect.TypeOf((*MockStorageWebAuthnProvider)(nil).DeleteWebAuthnCredential), ctx, userID, id)
+}
+
+// GetWebAuthnCredential mocks base method.
+func (m *MockStorageWebAuthnProvider) GetWebAuthnCredential(ctx context.Context, credentialID []byte) (models.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebAuthnCredential", ctx, credentialID)
+	ret0, _ := ret[0].(models.WebAuthnCredential)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockStorageUserProviderMockRecorder) GetUser(ctx, username interface{}) *gomock.Call {
+// GetWebAuthnCredential indicates an expected call of GetWebAuthnCredential.
+func (mr *MockStorageWebAuthnProviderMockRecorder) GetWebAuthnCredential(ctx, credentialID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStorageUserProvider)(nil).GetUser), ctx, username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebAuthnCredential", reflect.TypeOf((*MockStorageWebAuthnProvider)(nil).GetWebAuthnCredential), ctx, credentialID)
 }
 
-// IsAdmin mocks base method.
-func (m *MockStorageUserProvider) IsAdmin(ctx context.Context, username string) (bool, error) {
+// ListWebAuthnCredentials mocks base method.
+func (m *MockStorageWebAuthnProvider) ListWebAuthnCredentials(ctx context.Context, userID uint64) ([]models.WebAuthnCredential, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsAdmin", ctx, username)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "ListWebAuthnCredentials", ctx, userID)
+	ret0, _ := ret[0].([]models.WebAuthnCredential)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// IsAdmin indicates an expected call of IsAdmin.
-func (mr *MockStorageUserProviderMockRecorder) IsAdmin(ctx, username interface{}) *gomock.Call {
+// ListWebAuthnCredentials indicates an expected call of ListWebAuthnCredentials.
+func (mr *MockStorageWebAuthnProviderMockRecorder) ListWebAuthnCredentials(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAdmin", reflect.TypeOf((*MockStorageUserProvider)(nil).IsAdmin), ctx, username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebAuthnCredentials", reflect.TypeOf((*MockStorageWebAuthnProvider)(nil).ListWebAuthnCredentials), ctx, userID)
 }
 
-// SaveUser mocks base method.
-func (m *MockStorageUserProvider) SaveUser(ctx context.Context, user, passHash string) error {
+// SaveWebAuthnCredential mocks base method.
+func (m *MockStorageWebAuthnProvider) SaveWebAuthnCredential(ctx context.Context, cred models.WebAuthnCredential) (uint64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveUser", ctx, user, passHash)
+	ret := m.ctrl.Call(m, "SaveWebAuthnCredential", ctx, cred)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveWebAuthnCredential indicates an expected call of SaveWebAuthnCredential.
+func (mr *MockStorageWebAuthnProviderMockRecorder) SaveWebAuthnCredential(ctx, cred interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveWebAuthnCredential", reflect.TypeOf((*MockStorageWebAuthnProvider)(nil).SaveWebAuthnCredential), ctx, cred)
+}
+
+// UpdateWebAuthnSignCount mocks base method.
+func (m *MockStorageWebAuthnProvider) UpdateWebAuthnSignCount(ctx context.Context, id uint64, signCount uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebAuthnSignCount", ctx, id, signCount)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SaveUser indicates an expected call of SaveUser.
-func (mr *MockStorageUserProviderMockRecorder) SaveUser(ctx, user, passHash interface{}) *gomock.Call {
+// UpdateWebAuthnSignCount indicates an expected call of UpdateWebAuthnSignCount.
+func (mr *MockStorageWebAuthnProviderMockRecorder) UpdateWebAuthnSignCount(ctx, id, signCount interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUser", reflect.TypeOf((*MockStorageUserProvider)(nil).SaveUser), ctx, user, passHash)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebAuthnSignCount", reflect.TypeOf((*MockStorageWebAuthnProvider)(nil).UpdateWebAuthnSignCount), ctx, id, signCount)
 }
 
-// MockStorageAppProvider is a mock of StorageAppProvider interface.
-type MockStorageAppProvider struct {
+// MockStorageProfileProvider is a mock of StorageProfileProvider interface.
+type MockStorageProfileProvider struct {
 	ctrl     *gomock.Controller
-	recorder *MockStorageAppProviderMockRecorder
+	recorder *MockStorageProfileProviderMockRecorder
 }
 
-// MockStorageAppProviderMockRecorder is the mock recorder for MockStorageAppProvider.
-type MockStorageAppProviderMockRecorder struct {
-	mock *MockStorageAppProvider
+// MockStorageProfileProviderMockRecorder is the mock recorder for MockStorageProfileProvider.
+type MockStorageProfileProviderMockRecorder struct {
+	mock *MockStorageProfileProvider
 }
 
-// NewMockStorageAppProvider creates a new mock instance.
-func NewMockStorageAppProvider(ctrl *gomock.Controller) *MockStorageAppProvider {
-	mock := &MockStorageAppProvider{ctrl: ctrl}
-	mock.recorder = &MockStorageAppProviderMockRecorder{mock}
+// NewMockStorageProfileProvider creates a new mock instance.
+func NewMockStorageProfileProvider(ctrl *gomock.Controller) *MockStorageProfileProvider {
+	mock := &MockStorageProfileProvider{ctrl: ctrl}
+	mock.recorder = &MockStorageProfileProviderMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockStorageAppProvider) EXPECT() *MockStorageAppProviderMockRecorder {
+func (m *MockStorageProfileProvider) EXPECT() *MockStorageProfileProviderMockRecorder {
 	return m.recorder
 }
 
-// GetApp mocks base method.
-func (m *MockStorageAppProvider) GetApp(ctx context.Context, appID uint32) (models.App, error) {
+// GetProfile mocks base method.
+func (m *MockStorageProfileProvider) GetProfile(ctx context.Context, userID uint64) (models.Profile, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetApp", ctx, appID)
-	ret0, _ := ret[0].(models.App)
+	ret := m.ctrl.Call(m, "GetProfile", ctx, userID)
+	ret0, _ := ret[0].(models.Profile)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetApp indicates an expected call of GetApp.
-func (mr *MockStorageAppProviderMockRecorder) GetApp(ctx, appID interface{}) *gomock.Call {
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockStorageProfileProviderMockRecorder) GetProfile(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetApp", reflect.TypeOf((*MockStorageAppProvider)(nil).GetApp), ctx, appID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockStorageProfileProvider)(nil).GetProfile), ctx, userID)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockStorageProfileProvider) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", ctx, email)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockStorageProfileProviderMockRecorder) GetUserByEmail(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockStorageProfileProvider)(nil).GetUserByEmail), ctx, email)
+}
+
+// SaveProfile mocks base method.
+func (m *MockStorageProfileProvider) SaveProfile(ctx context.Context, profile models.Profile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveProfile", ctx, profile)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveProfile indicates an expected call of SaveProfile.
+func (mr *MockStorageProfileProviderMockRecorder) SaveProfile(ctx, profile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveProfile", reflect.TypeOf((*MockStorageProfileProvider)(nil).SaveProfile), ctx, profile)
+}
+
+// VerifyProfileEmail mocks base method.
+func (m *MockStorageProfileProvider) VerifyProfileEmail(ctx context.Context, userID uint64, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyProfileEmail", ctx, userID, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyProfileEmail indicates an expected call of VerifyProfileEmail.
+func (mr *MockStorageProfileProviderMockRecorder) VerifyProfileEmail(ctx, userID, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyProfileEmail", reflect.TypeOf((*MockStorageProfileProvider)(nil).VerifyProfileEmail), ctx, userID, email)
 }
 
 // MockStorageTokenProvider is a mock of StorageTokenProvider interface.
@@ -278,6 +2044,21 @@ func (m *MockStorageTokenProvider) EXPECT() *MockStorageTokenProviderMockRecorde
 	return m.recorder
 }
 
+// DeleteOfflineSessions mocks base method.
+func (m *MockStorageTokenProvider) DeleteOfflineSessions(ctx context.Context, userID uint64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOfflineSessions", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteOfflineSessions indicates an expected call of DeleteOfflineSessions.
+func (mr *MockStorageTokenProviderMockRecorder) DeleteOfflineSessions(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOfflineSessions", reflect.TypeOf((*MockStorageTokenProvider)(nil).DeleteOfflineSessions), ctx, userID)
+}
+
 // DeleteRefreshToken mocks base method.
 func (m *MockStorageTokenProvider) DeleteRefreshToken(ctx context.Context, userID uint64, appID uint32, token models.Token) error {
 	m.ctrl.T.Helper()
@@ -292,18 +2073,120 @@ func (mr *MockStorageTokenProviderMockRecorder) DeleteRefreshToken(ctx, userID,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRefreshToken", reflect.TypeOf((*MockStorageTokenProvider)(nil).DeleteRefreshToken), ctx, userID, appID, token)
 }
 
+// DeleteSession mocks base method.
+func (m *MockStorageTokenProvider) DeleteSession(ctx context.Context, userID uint64, sessionID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSession", ctx, userID, sessionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSession indicates an expected call of DeleteSession.
+func (mr *MockStorageTokenProviderMockRecorder) DeleteSession(ctx, userID, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSession", reflect.TypeOf((*MockStorageTokenProvider)(nil).DeleteSession), ctx, userID, sessionID)
+}
+
+// DeleteUserRefreshTokens mocks base method.
+func (m *MockStorageTokenProvider) DeleteUserRefreshTokens(ctx context.Context, userID uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserRefreshTokens", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserRefreshTokens indicates an expected call of DeleteUserRefreshTokens.
+func (mr *MockStorageTokenProviderMockRecorder) DeleteUserRefreshTokens(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserRefreshTokens", reflect.TypeOf((*MockStorageTokenProvider)(nil).DeleteUserRefreshTokens), ctx, userID)
+}
+
+// DeleteUserSessions mocks base method.
+func (m *MockStorageTokenProvider) DeleteUserSessions(ctx context.Context, userID uint64, except string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserSessions", ctx, userID, except)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUserSessions indicates an expected call of DeleteUserSessions.
+func (mr *MockStorageTokenProviderMockRecorder) DeleteUserSessions(ctx, userID, except interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserSessions", reflect.TypeOf((*MockStorageTokenProvider)(nil).DeleteUserSessions), ctx, userID, except)
+}
+
+// GetRefreshToken mocks base method.
+func (m *MockStorageTokenProvider) GetRefreshToken(ctx context.Context, token string) (models.RefreshTokenInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshToken", ctx, token)
+	ret0, _ := ret[0].(models.RefreshTokenInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshToken indicates an expected call of GetRefreshToken.
+func (mr *MockStorageTokenProviderMockRecorder) GetRefreshToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshToken", reflect.TypeOf((*MockStorageTokenProvider)(nil).GetRefreshToken), ctx, token)
+}
+
+// ListSessions mocks base method.
+func (m *MockStorageTokenProvider) ListSessions(ctx context.Context, filter models.SessionFilter) ([]models.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSessions", ctx, filter)
+	ret0, _ := ret[0].([]models.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSessions indicates an expected call of ListSessions.
+func (mr *MockStorageTokenProviderMockRecorder) ListSessions(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockStorageTokenProvider)(nil).ListSessions), ctx, filter)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockStorageTokenProvider) RotateRefreshToken(ctx context.Context, session models.Session, old string, token models.Token) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", ctx, session, old, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockStorageTokenProviderMockRecorder) RotateRefreshToken(ctx, session, old, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockStorageTokenProvider)(nil).RotateRefreshToken), ctx, session, old, token)
+}
+
 // SaveRefreshToken mocks base method.
-func (m *MockStorageTokenProvider) SaveRefreshToken(ctx context.Context, userID uint64, appID uint32, token models.Token) error {
+func (m *MockStorageTokenProvider) SaveRefreshToken(ctx context.Context, session models.Session, token models.Token) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveRefreshToken", ctx, userID, appID, token)
+	ret := m.ctrl.Call(m, "SaveRefreshToken", ctx, session, token)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SaveRefreshToken indicates an expected call of SaveRefreshToken.
-func (mr *MockStorageTokenProviderMockRecorder) SaveRefreshToken(ctx, userID, appID, token interface{}) *gomock.Call {
+func (mr *MockStorageTokenProviderMockRecorder) SaveRefreshToken(ctx, session, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRefreshToken", reflect.TypeOf((*MockStorageTokenProvider)(nil).SaveRefreshToken), ctx, session, token)
+}
+
+// TrimSessions mocks base method.
+func (m *MockStorageTokenProvider) TrimSessions(ctx context.Context, userID uint64, appID uint32, keep int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrimSessions", ctx, userID, appID, keep)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TrimSessions indicates an expected call of TrimSessions.
+func (mr *MockStorageTokenProviderMockRecorder) TrimSessions(ctx, userID, appID, keep interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRefreshToken", reflect.TypeOf((*MockStorageTokenProvider)(nil).SaveRefreshToken), ctx, userID, appID, token)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrimSessions", reflect.TypeOf((*MockStorageTokenProvider)(nil).TrimSessions), ctx, userID, appID, keep)
 }
 
 // MockConnector is a mock of Connector interface.
@@ -11,22 +11,116 @@ type Storage interface {
 	StorageUserProvider
 	StorageAppProvider
 	StorageTokenProvider
+	StorageRoleProvider
+	StorageLoginProvider
+	StorageMFAProvider
+	StorageWebAuthnProvider
+	StorageProfileProvider
 	Connector
 }
 
 type StorageUserProvider interface {
 	SaveUser(ctx context.Context, user, passHash string) error
 	GetUser(ctx context.Context, username string) (models.User, error)
-	IsAdmin(ctx context.Context, username string) (bool, error)
+	GetUserByID(ctx context.Context, userID uint64) (models.User, error)
+	ListUsers(ctx context.Context, filter models.UserFilter) ([]models.User, int, error)
+	CreateUser(ctx context.Context, user models.User) (uint64, error)
+	UpdateUser(ctx context.Context, user models.User) error
+	SetUserDisabled(ctx context.Context, userID uint64, disabled bool) error
+	UnlockUser(ctx context.Context, userID uint64) error
+	UpdateUserPassword(ctx context.Context, userID uint64, passHash []byte) error
+	// RehashUserPassword заменяет хэш пароля, если он не изменился с момента проверки
+	RehashUserPassword(ctx context.Context, userID uint64, oldHash, newHash []byte) error
+	// ListPasswordHistory возвращает хэши последних limit предыдущих паролей пользователя
+	ListPasswordHistory(ctx context.Context, userID uint64, limit int) ([][]byte, error)
+	DeleteUser(ctx context.Context, userID uint64) error
 }
 
 type StorageAppProvider interface {
 	GetApp(ctx context.Context, appID uint32) (models.App, error)
+	ListApps(ctx context.Context) ([]models.App, error)
+	CreateApp(ctx context.Context, app models.App) (uint32, error)
+	UpdateApp(ctx context.Context, app models.App) error
+	UpdateAppSecret(ctx context.Context, app models.App) error
+	DeleteApp(ctx context.Context, appID uint32) error
+	GetAppMember(ctx context.Context, userID uint64, appID uint32) (models.AppMember, error)
+	ListAppMembers(ctx context.Context, appID uint32) ([]models.AppMember, error)
+	AddAppMember(ctx context.Context, userID uint64, appID uint32) error
+	RemoveAppMember(ctx context.Context, userID uint64, appID uint32) error
+	SetAppMemberBlocked(ctx context.Context, userID uint64, appID uint32, blocked bool) error
+	GetAppEncryptionKey(ctx context.Context, appID uint32) (models.EncryptionKey, error)
+	SaveAppEncryptionKey(ctx context.Context, key models.EncryptionKey) error
+	DeleteAppEncryptionKey(ctx context.Context, appID uint32) error
+	GetAppCertificates(ctx context.Context, appID uint32) ([]models.AppCertificate, error)
+	SaveAppCertificate(ctx context.Context, cert models.AppCertificate) error
+	DeleteAppCertificate(ctx context.Context, appID uint32, thumbprint string) error
+}
+
+type StorageRoleProvider interface {
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	GetRole(ctx context.Context, roleID int) (models.Role, error)
+	CreateRole(ctx context.Context, role models.Role) (int, error)
+	SetRolePermissions(ctx context.Context, roleID int, permissions []string) error
+	DeleteRole(ctx context.Context, roleID int) error
+	ListPermissions(ctx context.Context) ([]models.Permission, error)
+	CreatePermission(ctx context.Context, permission models.Permission) (int, error)
+	DeletePermission(ctx context.Context, permissionID int) error
+	SetUserAppRole(ctx context.Context, userID uint64, appID uint32, roleID int) error
+	GetUserRoles(ctx context.Context, userID uint64, appID uint32) ([]models.Role, error)
+}
+
+type StorageLoginProvider interface {
+	SaveLoginRecord(ctx context.Context, record models.LoginRecord) error
+	ListLoginRecords(ctx context.Context, userID uint64, limit int) ([]models.LoginRecord, error)
+}
+
+type StorageMFAProvider interface {
+	GetUserMFA(ctx context.Context, userID uint64) (models.UserMFA, error)
+	SaveUserMFA(ctx context.Context, mfa models.UserMFA) error
+	EnableUserMFA(ctx context.Context, userID uint64, step int64, codeHashes []string) error
+	// UseTOTPStep возвращает false, если код этого шага уже использовался
+	UseTOTPStep(ctx context.Context, userID uint64, step int64) (bool, error)
+	// UseRecoveryCode возвращает false, если код не найден или уже использован
+	UseRecoveryCode(ctx context.Context, userID uint64, codeHash string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uint64, codeHashes []string) error
+	DeleteUserMFA(ctx context.Context, userID uint64) error
+}
+
+type StorageWebAuthnProvider interface {
+	SaveWebAuthnCredential(ctx context.Context, cred models.WebAuthnCredential) (uint64, error)
+	ListWebAuthnCredentials(ctx context.Context, userID uint64) ([]models.WebAuthnCredential, error)
+	GetWebAuthnCredential(ctx context.Context, credentialID []byte) (models.WebAuthnCredential, error)
+	// UpdateWebAuthnSignCount сохраняет счетчик подписей и время последнего входа
+	UpdateWebAuthnSignCount(ctx context.Context, id uint64, signCount uint32) error
+	DeleteWebAuthnCredential(ctx context.Context, userID uint64, id uint64) error
+}
+
+type StorageProfileProvider interface {
+	GetProfile(ctx context.Context, userID uint64) (models.Profile, error)
+	// SaveProfile создает или заменяет профиль пользователя
+	SaveProfile(ctx context.Context, profile models.Profile) error
+	// VerifyProfileEmail подтверждает email, если он не изменился с момента отправки письма
+	VerifyProfileEmail(ctx context.Context, userID uint64, email string) error
+	// GetUserByEmail возвращает пользователя по подтвержденному email
+	GetUserByEmail(ctx context.Context, email string) (models.User, error)
 }
 
 type StorageTokenProvider interface {
 	DeleteRefreshToken(ctx context.Context, userID uint64, appID uint32, token models.Token) error
-	SaveRefreshToken(ctx context.Context, userID uint64, appID uint32, token models.Token) error
+	// SaveRefreshToken создает сеанс с refresh токеном
+	SaveRefreshToken(ctx context.Context, session models.Session, token models.Token) error
+	// RotateRefreshToken заменяет refresh токен сеанса, если сеанс не отозван и токен old еще не заменен
+	RotateRefreshToken(ctx context.Context, session models.Session, old string, token models.Token) error
+	GetRefreshToken(ctx context.Context, token string) (models.RefreshTokenInfo, error)
+	DeleteUserRefreshTokens(ctx context.Context, userID uint64) error
+	ListSessions(ctx context.Context, filter models.SessionFilter) ([]models.Session, error)
+	DeleteSession(ctx context.Context, userID uint64, sessionID string) error
+	// DeleteUserSessions удаляет сеансы пользователя, кроме сеанса except
+	DeleteUserSessions(ctx context.Context, userID uint64, except string) (int64, error)
+	// DeleteOfflineSessions удаляет offline сеансы пользователя
+	DeleteOfflineSessions(ctx context.Context, userID uint64) (int64, error)
+	// TrimSessions оставляет keep последних сеансов пользователя в приложении и возвращает число удаленных
+	TrimSessions(ctx context.Context, userID uint64, appID uint32, keep int) (int64, error)
 }
 
 type Connector interface {
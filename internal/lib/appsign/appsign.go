@@ -0,0 +1,139 @@
+// Пакет для проверки подписи запросов приложений (схема v2, общая для gRPC и HTTP)
+package appsign
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+)
+
+// Подписываются строки, соединенные переводом строки:
+//
+//	v2
+//	timestamp (RFC3339)
+//	app_id
+//	метод: полное имя gRPC метода (/auth.Auth/Login) или "POST /auth/mfa/verify" для HTTP
+//	nonce
+//	заголовки SignedHeaders, по строке "имя:значение" (см. CanonicalHeaders)
+//	hex(sha256(тела запроса))
+//
+// Подпись — hex HMAC-SHA256 на секрете приложения.
+const (
+	Version = "v2"
+
+	minNonceLength = 16
+	maxNonceLength = 128
+)
+
+// SignedHeaders заголовки, от которых зависят способ входа, проверяемое право
+// и привязка токенов, в порядке подписи. Имена в нижнем регистре.
+var SignedHeaders = []string{
+	"dpop",
+	"x-login-method",
+	"x-permission",
+	"x-remember-me",
+	"x-scope",
+	"x-webauthn-assertion",
+}
+
+var (
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+	ErrInvalidNonce     = errors.New("invalid nonce")
+)
+
+// Payload возвращает подписываемые данные. headers — результат CanonicalHeaders.
+func Payload(ts time.Time, appID uint64, method, nonce, headers, bodyHash string) string {
+	return strings.Join([]string{
+		Version,
+		ts.Format(time.RFC3339),
+		strconv.FormatUint(appID, 10),
+		method,
+		nonce,
+		headers,
+		bodyHash,
+	}, "\n")
+}
+
+// CanonicalHeaders возвращает подписываемые заголовки: по строке "имя:значение" на каждый
+// из SignedHeaders в их порядке. Несколько значений заголовка соединяются запятой без пробелов,
+// отсутствующий заголовок подписывается с пустым значением, чтобы его нельзя было добавить.
+// values возвращает значения заголовка по имени в нижнем регистре.
+func CanonicalHeaders(values func(name string) []string) string {
+	lines := make([]string, 0, len(SignedHeaders))
+	for _, name := range SignedHeaders {
+		vals := values(name)
+		trimmed := make([]string, 0, len(vals))
+		for _, v := range vals {
+			trimmed = append(trimmed, strings.TrimSpace(v))
+		}
+		lines = append(lines, name+":"+strings.Join(trimmed, ","))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// BodyHash возвращает hex SHA-256 тела запроса
+func BodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Compute вычисляет подпись данных на секрете
+func Compute(data, secret string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(data))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Match сравнивает подпись с HMAC данных на секретах приложения.
+// В период перекрытия после ротации принимается и предыдущий секрет.
+func Match(app models.App, data, signature string) bool {
+	for _, secret := range app.AcceptedSecrets(time.Now().UTC()) {
+		if hmac.Equal([]byte(Compute(data, secret)), []byte(signature)) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTimestamp разбирает timestamp запроса и проверяет, что он не старше MaxAge
+// и не опережает время сервера больше чем на ClockSkew
+func CheckTimestamp(raw string, cfg config.HMACConfig) (time.Time, error) {
+	ts, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidTimestamp, raw)
+	}
+
+	now := time.Now().UTC()
+	if ts.Before(now.Add(-cfg.MaxAge)) || ts.After(now.Add(cfg.ClockSkew)) {
+		return time.Time{}, fmt.Errorf("%w: current time %s", ErrInvalidTimestamp, now.Format(time.RFC3339))
+	}
+	return ts, nil
+}
+
+// ValidateNonce проверяет длину и допустимые символы nonce
+func ValidateNonce(nonce string) error {
+	if len(nonce) < minNonceLength || len(nonce) > maxNonceLength {
+		return ErrInvalidNonce
+	}
+	for _, r := range nonce {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return ErrInvalidNonce
+		}
+	}
+	return nil
+}
+
+// NonceTTL возвращает время хранения nonce: дольше него запрос с тем же timestamp не примут
+func NonceTTL(cfg config.HMACConfig) time.Duration {
+	return cfg.MaxAge + cfg.ClockSkew
+}
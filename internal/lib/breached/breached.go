@@ -0,0 +1,201 @@
+// Пакет проверки паролей по локальному списку утекших паролей в формате Have I Been Pwned.
+// Поддерживаются файл SHA-1 хэшей, упорядоченный по хэшу (строки "HASH:COUNT"),
+// и каталог, разбитый по первым пяти символам хэша (файлы PREFIX или PREFIX.txt
+// со строками "SUFFIX:COUNT", как в ответах range API). Сетевые запросы не выполняются.
+package breached
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const breachedOp = "lib.breached."
+
+const (
+	hashLength   = 40
+	prefixLength = 5
+	// Размер чтения с диска при бинарном поиске, превышает длину любой строки списка
+	chunkSize = 512
+)
+
+var ErrMalformedList = errors.New("malformed breached password list")
+
+// List список утекших паролей
+type List struct {
+	path     string
+	minCount int
+	// Упорядоченный файл; nil, если список разбит по префиксам
+	file *os.File
+	size int64
+}
+
+// Open открывает список по пути к упорядоченному файлу или к каталогу, разбитому по префиксам.
+// Пароль считается утекшим, если он встречался в утечках не меньше minCount раз.
+func Open(path string, minCount int) (*List, error) {
+	const op = breachedOp + "Open"
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	list := &List{path: path, minCount: max(minCount, 1)}
+	if info.IsDir() {
+		return list, nil
+	}
+
+	if list.file, err = os.Open(path); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	list.size = info.Size()
+	return list, nil
+}
+
+// Close закрывает упорядоченный файл списка
+func (l *List) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// Contains проверяет, есть ли пароль в списке
+func (l *List) Contains(password string) (bool, error) {
+	const op = breachedOp + "Contains"
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	var count int
+	var err error
+	if l.file != nil {
+		count, err = l.searchFile(hash)
+	} else {
+		count, err = l.searchPartition(hash)
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return count >= l.minCount, nil
+}
+
+// searchFile ищет хэш бинарным поиском по упорядоченному файлу и возвращает число утечек (0 — не найден).
+// Файл не загружается в память: на каждом шаге читается одна строка.
+func (l *List) searchFile(hash string) (int, error) {
+	lo, hi := int64(0), l.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		line, start, end, err := l.lineFrom(mid)
+		if err != nil {
+			return 0, err
+		}
+		if line == nil || start >= hi {
+			hi = mid
+			continue
+		}
+
+		key, count, err := parseLine(line)
+		if err != nil {
+			return 0, err
+		}
+		switch strings.Compare(strings.ToUpper(key), hash) {
+		case 0:
+			return count, nil
+		case -1:
+			lo = end
+		default:
+			hi = mid
+		}
+	}
+	return 0, nil
+}
+
+// lineFrom возвращает первую строку файла, начинающуюся не раньше offset,
+// вместе с ее началом и началом следующей строки. nil — после offset строк нет.
+func (l *List) lineFrom(offset int64) ([]byte, int64, int64, error) {
+	readAt := max(offset-1, 0)
+	buf := make([]byte, chunkSize)
+	n, err := l.file.ReadAt(buf, readAt)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, 0, 0, err
+	}
+	buf = buf[:n]
+
+	start := readAt
+	if offset > 0 {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			if n < chunkSize {
+				return nil, 0, 0, nil
+			}
+			return nil, 0, 0, ErrMalformedList
+		}
+		buf = buf[i+1:]
+		start = readAt + int64(i) + 1
+	}
+	if len(buf) == 0 {
+		return nil, 0, 0, nil
+	}
+
+	line := buf
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		line = buf[:i]
+	} else if start+int64(len(buf)) < l.size {
+		return nil, 0, 0, ErrMalformedList
+	}
+	return line, start, start + int64(len(line)) + 1, nil
+}
+
+// searchPartition ищет хэш в файле каталога с его префиксом и возвращает число утечек (0 — не найден)
+func (l *List) searchPartition(hash string) (int, error) {
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	file, err := os.Open(filepath.Join(l.path, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		file, err = os.Open(filepath.Join(l.path, prefix))
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, count, err := parseLine(scanner.Bytes())
+		if err != nil {
+			return 0, err
+		}
+		if strings.EqualFold(key, suffix) {
+			return count, nil
+		}
+	}
+	return 0, scanner.Err()
+}
+
+// parseLine разбирает строку "HASH:COUNT"; без счетчика пароль считается встреченным один раз
+func parseLine(line []byte) (string, int, error) {
+	key, rawCount, found := strings.Cut(strings.TrimSpace(string(line)), ":")
+	if len(key) == 0 || len(key) > hashLength {
+		return "", 0, ErrMalformedList
+	}
+	if !found {
+		return key, 1, nil
+	}
+	count, err := strconv.Atoi(rawCount)
+	if err != nil {
+		return "", 0, ErrMalformedList
+	}
+	return key, count, nil
+}
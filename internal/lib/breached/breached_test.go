@@ -0,0 +1,91 @@
+package breached
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func hashOf(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// breachedFixture возвращает пароли из утечки и их число вхождений
+func breachedFixture() map[string]int {
+	passwords := map[string]int{"password": 9545824, "123456": 37359195, "rare-one": 1}
+	for i := range 500 {
+		passwords[fmt.Sprintf("leaked-%d", i)] = i + 1
+	}
+	return passwords
+}
+
+func TestBreached__SortedFile(t *testing.T) {
+	passwords := breachedFixture()
+
+	lines := make([]string, 0, len(passwords))
+	for password, count := range passwords {
+		lines = append(lines, fmt.Sprintf("%s:%d\r\n", hashOf(password), count))
+	}
+	slices.Sort(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := Open(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer list.Close()
+
+	for password, count := range passwords {
+		found, err := list.Contains(password)
+		if err != nil {
+			t.Fatalf("%s: %v", password, err)
+		}
+		if found != (count >= 2) {
+			t.Errorf("%s: expected found=%v", password, count >= 2)
+		}
+	}
+	for _, password := range []string{"Correct-h0rse-battery", "", "leaked-500"} {
+		if found, err := list.Contains(password); err != nil || found {
+			t.Errorf("%q: unexpected match (err %v)", password, err)
+		}
+	}
+}
+
+func TestBreached__Partitioned(t *testing.T) {
+	dir := t.TempDir()
+
+	partitions := map[string][]string{}
+	for password, count := range breachedFixture() {
+		hash := hashOf(password)
+		partitions[hash[:prefixLength]] = append(partitions[hash[:prefixLength]], fmt.Sprintf("%s:%d", hash[prefixLength:], count))
+	}
+	for prefix, lines := range partitions {
+		if err := os.WriteFile(filepath.Join(dir, prefix+".txt"), []byte(strings.Join(lines, "\r\n")), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := Open(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, password := range []string{"password", "rare-one", "leaked-499"} {
+		if found, err := list.Contains(password); err != nil || !found {
+			t.Errorf("%s: expected match (err %v)", password, err)
+		}
+	}
+	if found, err := list.Contains("Correct-h0rse-battery"); err != nil || found {
+		t.Errorf("unexpected match (err %v)", err)
+	}
+}
@@ -0,0 +1,95 @@
+// Пакет для определения адреса клиента за доверенными обратными прокси
+package clientip
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Resolver определяет адрес клиента по адресу соединения и заголовку X-Forwarded-For.
+// Заголовку верят, только если соединение установлено доверенным прокси:
+// иначе клиент мог бы подставить любой адрес и обойти ограничения по IP.
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+// NewResolver разбирает список доверенных прокси: подсети CIDR или отдельные адреса
+func NewResolver(proxies []string) (*Resolver, error) {
+	const op = "lib.clientip.NewResolver"
+
+	r := &Resolver{}
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid trusted proxy %q: %w", op, proxy, err)
+			}
+			r.trusted = append(r.trusted, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid trusted proxy %q: %w", op, proxy, err)
+		}
+		r.trusted = append(r.trusted, prefix.Masked())
+	}
+	return r, nil
+}
+
+// ClientIP возвращает адрес клиента. remoteAddr — адрес соединения (IP или IP:port),
+// forwardedFor — значения заголовка X-Forwarded-For. Цепочка просматривается справа налево:
+// адреса доверенных прокси пропускаются, первый недоверенный адрес считается адресом клиента.
+func (r *Resolver) ClientIP(remoteAddr string, forwardedFor []string) string {
+	remote, ok := parseAddr(remoteAddr)
+	if !ok {
+		return remoteAddr
+	}
+	if !r.isTrusted(remote) {
+		return remote.String()
+	}
+
+	var chain []string
+	for _, value := range forwardedFor {
+		chain = append(chain, strings.Split(value, ",")...)
+	}
+
+	client := remote
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, ok := parseAddr(strings.TrimSpace(chain[i]))
+		if !ok {
+			break
+		}
+		client = addr
+		if !r.isTrusted(addr) {
+			break
+		}
+	}
+	return client.String()
+}
+
+func (r *Resolver) isTrusted(addr netip.Addr) bool {
+	if r == nil {
+		return false
+	}
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAddr разбирает IP адрес, в том числе с портом
+func parseAddr(value string) (netip.Addr, bool) {
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
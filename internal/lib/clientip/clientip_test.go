@@ -0,0 +1,48 @@
+package clientip
+
+import "testing"
+
+func TestResolver__ClientIP(t *testing.T) {
+	r, err := NewResolver([]string{"10.0.0.0/8", "192.168.1.10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    []string
+		want   string
+	}{
+		{"direct client", "203.0.113.7:51000", nil, "203.0.113.7"},
+		{"forged header from untrusted peer", "203.0.113.7:51000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", "10.1.2.3:443", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"trusted proxy by address", "192.168.1.10", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"client prepends forged address", "10.1.2.3:443", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", "10.1.2.3:443", []string{"198.51.100.1", "10.9.9.9"}, "198.51.100.1"},
+		{"trusted proxy without header", "10.1.2.3:443", nil, "10.1.2.3"},
+		{"garbage in header", "10.1.2.3:443", []string{"not-an-ip"}, "10.1.2.3"},
+		{"ipv6 peer", "[2001:db8::1]:443", []string{"198.51.100.1"}, "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.ClientIP(tt.remote, tt.xff); got != tt.want {
+				t.Errorf("ClientIP(%q, %q) = %q, want %q", tt.remote, tt.xff, got, tt.want)
+			}
+		})
+	}
+
+	// Без доверенных прокси заголовок игнорируется
+	var none *Resolver
+	if got := none.ClientIP("10.1.2.3:443", []string{"198.51.100.1"}); got != "10.1.2.3" {
+		t.Errorf("nil resolver trusted x-forwarded-for: %q", got)
+	}
+}
+
+func TestNewResolver__Invalid(t *testing.T) {
+	for _, proxy := range []string{"10.0.0.0/33", "proxy.local", ""} {
+		if _, err := NewResolver([]string{proxy}); err == nil {
+			t.Errorf("proxy %q accepted", proxy)
+		}
+	}
+}
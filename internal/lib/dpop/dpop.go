@@ -0,0 +1,167 @@
+// Пакет для проверки DPoP proof (RFC 9449)
+package dpop
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+const proofType = "dpop+jwt"
+
+var (
+	ErrInvalidProof = errors.New("invalid DPoP proof")
+)
+
+// Допустимые алгоритмы подписи proof
+var signatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256,
+	jose.PS256,
+	jose.ES256,
+	jose.ES384,
+	jose.EdDSA,
+}
+
+// Proof проверенный DPoP proof
+type Proof struct {
+	JTI      string
+	JKT      string // SHA-256 thumbprint публичного ключа клиента (RFC 7638)
+	IssuedAt time.Time
+}
+
+type claims struct {
+	JTI string `json:"jti"`
+	HTM string `json:"htm"`
+	HTU string `json:"htu"`
+	IAT int64  `json:"iat"`
+	ATH string `json:"ath"`
+}
+
+// Validator проверяет DPoP proof
+type Validator struct {
+	maxAge    time.Duration
+	clockSkew time.Duration
+}
+
+func NewValidator(maxAge, clockSkew time.Duration) *Validator {
+	return &Validator{
+		maxAge:    maxAge,
+		clockSkew: clockSkew,
+	}
+}
+
+// Validate проверяет подпись proof ключом из заголовка jwk,
+// соответствие метода и URL запроса и время выпуска.
+// Если передан accessToken, proof должен содержать его хэш в claim ath.
+func (v *Validator) Validate(proof, method, uri, accessToken string) (*Proof, error) {
+	const op = "lib.dpop.Validate"
+
+	jws, err := jose.ParseSignedCompact(proof, signatureAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", op, ErrInvalidProof, err)
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, fmt.Errorf("%s: %w: expected one signature", op, ErrInvalidProof)
+	}
+
+	header := jws.Signatures[0].Protected
+	if typ, _ := header.ExtraHeaders[jose.HeaderType].(string); typ != proofType {
+		return nil, fmt.Errorf("%s: %w: invalid typ %q", op, ErrInvalidProof, typ)
+	}
+
+	jwk := header.JSONWebKey
+	if jwk == nil || !jwk.Valid() || !jwk.IsPublic() {
+		return nil, fmt.Errorf("%s: %w: missing or invalid jwk header", op, ErrInvalidProof)
+	}
+
+	payload, err := jws.Verify(jwk)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", op, ErrInvalidProof, err)
+	}
+
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", op, ErrInvalidProof, err)
+	}
+
+	if err := v.validateClaims(c, method, uri, accessToken); err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", op, ErrInvalidProof, err)
+	}
+
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", op, ErrInvalidProof, err)
+	}
+
+	return &Proof{
+		JTI:      c.JTI,
+		JKT:      base64.RawURLEncoding.EncodeToString(thumbprint),
+		IssuedAt: time.Unix(c.IAT, 0),
+	}, nil
+}
+
+func (v *Validator) validateClaims(c claims, method, uri, accessToken string) error {
+	if c.JTI == "" {
+		return errors.New("missing jti")
+	}
+	if !strings.EqualFold(c.HTM, method) {
+		return fmt.Errorf("htm %q does not match request method", c.HTM)
+	}
+	if !matchURI(c.HTU, uri) {
+		return fmt.Errorf("htu %q does not match request uri", c.HTU)
+	}
+
+	now := time.Now()
+	iat := time.Unix(c.IAT, 0)
+	if iat.After(now.Add(v.clockSkew)) {
+		return errors.New("proof issued in the future")
+	}
+	if iat.Before(now.Add(-v.maxAge)) {
+		return errors.New("proof is expired")
+	}
+
+	if accessToken != "" && subtle.ConstantTimeCompare([]byte(c.ATH), []byte(TokenHash(accessToken))) != 1 {
+		return errors.New("ath does not match access token")
+	}
+	return nil
+}
+
+// TokenHash возвращает значение ath для access токена: base64url(SHA-256(token))
+func TokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// matchURI сравнивает htu с URL запроса без учета query и fragment.
+// Если в ожидаемом URL не указан хост, сравниваются только пути.
+func matchURI(htu, expected string) bool {
+	got, err := url.Parse(htu)
+	if err != nil {
+		return false
+	}
+	want, err := url.Parse(expected)
+	if err != nil {
+		return false
+	}
+
+	if want.Host != "" {
+		if !strings.EqualFold(got.Scheme, want.Scheme) || !strings.EqualFold(got.Host, want.Host) {
+			return false
+		}
+	}
+	return got.Path == want.Path
+}
+
+// TTL возвращает время, в течение которого jti proof должен храниться для защиты от повторов
+func (v *Validator) TTL() time.Duration {
+	return v.maxAge + v.clockSkew
+}
@@ -0,0 +1,162 @@
+package dpop
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+const (
+	testMethod = "POST"
+	testURL    = "https://sso.example.com/auth.Auth/RefreshToken"
+	testToken  = "access-token"
+)
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// signProof подписывает proof с заданными claims и заголовками
+func signProof(t *testing.T, key *ecdsa.PrivateKey, typ string, embedJWK bool, claims map[string]any) string {
+	t.Helper()
+
+	opts := (&jose.SignerOptions{EmbedJWK: embedJWK}).WithType(jose.ContentType(typ))
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := jws.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"jti": "4f1c2a9e-0b7d-4a5e-9c3f-6d8e1b2a7c40",
+		"htm": testMethod,
+		"htu": testURL,
+		"iat": time.Now().Unix(),
+		"ath": TokenHash(testToken),
+	}
+}
+
+func TestValidator__Valid(t *testing.T) {
+	key := newKey(t)
+	v := NewValidator(time.Minute, 5*time.Second)
+
+	proof, err := v.Validate(signProof(t, key, proofType, true, validClaims()), testMethod, testURL, testToken)
+	if err != nil {
+		t.Fatalf("valid proof rejected: %v", err)
+	}
+
+	jwk := jose.JSONWebKey{Key: key.Public()}
+	want, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proof.JKT != base64.RawURLEncoding.EncodeToString(want) {
+		t.Errorf("jkt = %s, want thumbprint of the signing key", proof.JKT)
+	}
+
+	// Query и fragment не участвуют в сравнении, без хоста сравнивается только путь
+	if _, err := v.Validate(signProof(t, key, proofType, true, validClaims()), "post", testURL+"?a=1", ""); err != nil {
+		t.Errorf("proof rejected for url with query: %v", err)
+	}
+	if _, err := v.Validate(signProof(t, key, proofType, true, validClaims()), testMethod, "/auth.Auth/RefreshToken", ""); err != nil {
+		t.Errorf("proof rejected for path-only url: %v", err)
+	}
+}
+
+func TestValidator__Rejected(t *testing.T) {
+	key := newKey(t)
+	v := NewValidator(time.Minute, 5*time.Second)
+
+	with := func(key string, value any) map[string]any {
+		c := validClaims()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		proof string
+		url   string
+		token string
+	}{
+		{"malformed", "not-a-jwt", testURL, ""},
+		{"wrong typ", signProof(t, key, "JWT", true, validClaims()), testURL, ""},
+		{"no jwk", signProof(t, key, proofType, false, validClaims()), testURL, ""},
+		{"missing jti", signProof(t, key, proofType, true, with("jti", nil)), testURL, ""},
+		{"htm mismatch", signProof(t, key, proofType, true, with("htm", "GET")), testURL, ""},
+		{"htu path mismatch", signProof(t, key, proofType, true, with("htu", "https://sso.example.com/auth.Auth/Login")), testURL, ""},
+		{"htu host mismatch", signProof(t, key, proofType, true, with("htu", "https://evil.example.com/auth.Auth/RefreshToken")), testURL, ""},
+		{"htu scheme mismatch", signProof(t, key, proofType, true, with("htu", "http://sso.example.com/auth.Auth/RefreshToken")), testURL, ""},
+		{"iat in the future", signProof(t, key, proofType, true, with("iat", time.Now().Add(time.Minute).Unix())), testURL, ""},
+		{"iat expired", signProof(t, key, proofType, true, with("iat", time.Now().Add(-2*time.Minute).Unix())), testURL, ""},
+		{"ath missing", signProof(t, key, proofType, true, with("ath", nil)), testURL, testToken},
+		{"ath for another token", signProof(t, key, proofType, true, with("ath", TokenHash("other-token"))), testURL, testToken},
+		{"tampered signature", tamper(signProof(t, key, proofType, true, validClaims())), testURL, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Validate(tt.proof, testMethod, tt.url, tt.token)
+			if !errors.Is(err, ErrInvalidProof) {
+				t.Fatalf("err = %v, want ErrInvalidProof", err)
+			}
+		})
+	}
+}
+
+func TestValidator__ClockSkew(t *testing.T) {
+	key := newKey(t)
+	v := NewValidator(time.Minute, 5*time.Second)
+
+	claims := validClaims()
+	claims["iat"] = time.Now().Add(3 * time.Second).Unix()
+	if _, err := v.Validate(signProof(t, key, proofType, true, claims), testMethod, testURL, ""); err != nil {
+		t.Errorf("proof within clock skew rejected: %v", err)
+	}
+
+	if ttl := v.TTL(); ttl != time.Minute+5*time.Second {
+		t.Errorf("jti ttl = %s, want proof lifetime plus clock skew", ttl)
+	}
+}
+
+// tamper заменяет первый символ подписи
+func tamper(proof string) string {
+	i := strings.LastIndex(proof, ".") + 1
+	replacement := "A"
+	if proof[i] == 'A' {
+		replacement = "B"
+	}
+	return proof[:i] + replacement + proof[i+1:]
+}
@@ -0,0 +1,84 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/Grino777/sso/internal/domain/models"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+const minRSAKeyBits = 2048
+
+var (
+	ErrInvalidEncryptionKey = errors.New("invalid encryption key")
+)
+
+// ParseEncryptionKey разбирает PEM публичного ключа приложения
+// и проверяет, что тип ключа подходит для указанного алгоритма
+func ParseEncryptionKey(publicKeyPEM, algorithm string) (any, error) {
+	const op = "lib.jwt.ParseEncryptionKey"
+
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("%s: %w: failed to decode PEM block", op, ErrInvalidEncryptionKey)
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %v", op, ErrInvalidEncryptionKey, err)
+	}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if algorithm != models.KeyAlgRSAOAEP256 {
+			return nil, fmt.Errorf("%s: %w: RSA key requires %s", op, ErrInvalidEncryptionKey, models.KeyAlgRSAOAEP256)
+		}
+		if key.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("%s: %w: RSA key must be at least %d bits", op, ErrInvalidEncryptionKey, minRSAKeyBits)
+		}
+	case *ecdsa.PublicKey:
+		if algorithm != models.KeyAlgECDHES {
+			return nil, fmt.Errorf("%s: %w: EC key requires %s", op, ErrInvalidEncryptionKey, models.KeyAlgECDHES)
+		}
+	default:
+		return nil, fmt.Errorf("%s: %w: unsupported key type %T", op, ErrInvalidEncryptionKey, publicKey)
+	}
+
+	return publicKey, nil
+}
+
+// EncryptToken оборачивает подписанный JWT во вложенный JWE (A256GCM),
+// зашифрованный публичным ключом приложения
+func EncryptToken(signedToken string, key *models.EncryptionKey) (string, error) {
+	const op = "lib.jwt.EncryptToken"
+
+	publicKey, err := ParseEncryptionKey(key.PublicKey, key.Algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	recipient := jose.Recipient{
+		Algorithm: jose.KeyAlgorithm(key.Algorithm),
+		Key:       publicKey,
+		KeyID:     key.ID,
+	}
+	opts := (&jose.EncrypterOptions{}).WithContentType("JWT").WithType("JWT")
+
+	encrypter, err := jose.NewEncrypter(jose.A256GCM, recipient, opts)
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to create encrypter: %w", op, err)
+	}
+
+	object, err := encrypter.Encrypt([]byte(signedToken))
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to encrypt token: %w", op, err)
+	}
+
+	return object.CompactSerialize()
+}
@@ -0,0 +1,161 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/Grino777/sso/internal/domain/models"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+const signedToken = "header.payload.signature"
+
+func publicKeyPEM(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// decryptToken расшифровывает JWE так же, как это делает приложение-получатель
+func decryptToken(token string, key any) (string, *jose.JSONWebEncryption, error) {
+	object, err := jose.ParseEncrypted(
+		token,
+		[]jose.KeyAlgorithm{jose.RSA_OAEP_256, jose.ECDH_ES},
+		[]jose.ContentEncryption{jose.A256GCM},
+	)
+	if err != nil {
+		return "", nil, err
+	}
+	plain, err := object.Decrypt(key)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(plain), object, nil
+}
+
+func TestEncryptToken__RoundTrip(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		algorithm string
+		public    crypto.PublicKey
+		private   any
+	}{
+		{"RSA-OAEP-256", models.KeyAlgRSAOAEP256, &rsaKey.PublicKey, rsaKey},
+		{"ECDH-ES", models.KeyAlgECDHES, &ecKey.PublicKey, ecKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := &models.EncryptionKey{ID: "key-1", AppID: 1, Algorithm: tt.algorithm, PublicKey: publicKeyPEM(t, tt.public)}
+
+			token, err := EncryptToken(signedToken, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			plain, object, err := decryptToken(token, tt.private)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plain != signedToken {
+				t.Fatalf("decrypted %q, want %q", plain, signedToken)
+			}
+			header := object.Header
+			if header.KeyID != "key-1" || header.Algorithm != tt.algorithm {
+				t.Fatalf("header kid = %q, alg = %q", header.KeyID, header.Algorithm)
+			}
+			if cty := header.ExtraHeaders[jose.HeaderContentType]; cty != "JWT" {
+				t.Fatalf("header cty = %v, want JWT", cty)
+			}
+		})
+	}
+}
+
+func TestEncryptToken__WrongKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := EncryptToken(signedToken, &models.EncryptionKey{
+		ID:        "key-1",
+		Algorithm: models.KeyAlgRSAOAEP256,
+		PublicKey: publicKeyPEM(t, &key.PublicKey),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Токен не расшифровывается ключом другого приложения
+	if _, _, err := decryptToken(token, other); err == nil {
+		t.Fatal("token decrypted with another key")
+	}
+}
+
+func TestParseEncryptionKey__Invalid(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		publicKey string
+		algorithm string
+	}{
+		{"not PEM", "public key", models.KeyAlgRSAOAEP256},
+		{"RSA key with ECDH-ES", publicKeyPEM(t, &rsaKey.PublicKey), models.KeyAlgECDHES},
+		{"EC key with RSA-OAEP-256", publicKeyPEM(t, &ecKey.PublicKey), models.KeyAlgRSAOAEP256},
+		{"unsupported algorithm", publicKeyPEM(t, &rsaKey.PublicKey), "RSA1_5"},
+		{"small RSA key", publicKeyPEM(t, &smallKey.PublicKey), models.KeyAlgRSAOAEP256},
+		{"unsupported key type", publicKeyPEM(t, edKey), models.KeyAlgECDHES},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseEncryptionKey(tt.publicKey, tt.algorithm); !errors.Is(err, ErrInvalidEncryptionKey) {
+				t.Fatalf("err = %v, want ErrInvalidEncryptionKey", err)
+			}
+			key := &models.EncryptionKey{ID: "key-1", Algorithm: tt.algorithm, PublicKey: tt.publicKey}
+			if _, err := EncryptToken(signedToken, key); !errors.Is(err, ErrInvalidEncryptionKey) {
+				t.Fatalf("encrypt: err = %v, want ErrInvalidEncryptionKey", err)
+			}
+		})
+	}
+}
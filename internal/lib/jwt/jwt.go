@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/Grino777/sso/internal/config"
@@ -18,16 +19,26 @@ func CreateNewTokens(
 	app models.App,
 	pk *keysModels.PrivateKey,
 	tokens config.TTLConfig,
+	sessionID string,
+	cnf *models.Confirmation,
 ) (models.Tokens, error) {
-	acessToken, err := NewAccessToken(user, app, pk, tokens.TokenTTL)
+	acessToken, err := NewAccessToken(user, app, pk, tokens.TokenTTL, sessionID, cnf)
 	if err != nil {
 		return models.Tokens{}, err
 	}
 
+	if app.EncryptionKey != nil {
+		acessToken.Token, err = EncryptToken(acessToken.Token, app.EncryptionKey)
+		if err != nil {
+			return models.Tokens{}, err
+		}
+	}
+
 	refreshToken, err := NewRefreshToken(tokens.RefreshTokenTTL)
 	if err != nil {
 		return models.Tokens{}, err
 	}
+	refreshToken.Cnf = cnf
 
 	return models.Tokens{
 		AccessToken:  acessToken,
@@ -35,12 +46,18 @@ func CreateNewTokens(
 	}, nil
 }
 
-// Create new token for user
+// Create new token for user.
+// Токен содержит роли и права пользователя в приложении app.
+// Если передан cnf, токен привязывается к ключу клиента.
+// sessionID записывается в claim sid, чтобы клиент мог отличить текущий сеанс.
+// Claim aud содержит идентификатор приложения (см. Audience).
 func NewAccessToken(
 	user models.User,
 	app models.App,
 	pk *keysModels.PrivateKey,
 	d time.Duration,
+	sessionID string,
+	cnf *models.Confirmation,
 ) (models.Token, error) {
 	const op = "lib.jwt.NewAccessToken"
 
@@ -52,10 +69,18 @@ func NewAccessToken(
 	claims := token.Claims.(jwt.MapClaims)
 	claims["kid"] = pk.ID
 	claims["user_id"] = user.ID
-	claims["role_id"] = user.Role_id
 	claims["username"] = user.Username
 	claims["app_id"] = app.ID
+	claims["aud"] = Audience(app.ID)
+	claims["roles"] = user.Access.Roles
+	claims["permissions"] = user.Access.Permissions
 	claims["exp"] = expire_at
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
+	if cnf != nil {
+		claims["cnf"] = cnf
+	}
 
 	tokenString, err := token.SignedString(pk.Key)
 	if err != nil {
@@ -65,11 +90,17 @@ func NewAccessToken(
 	tObj = models.Token{
 		Token:     tokenString,
 		Expire_at: expire_at,
+		Cnf:       cnf,
 	}
 
 	return tObj, nil
 }
 
+// Audience возвращает значение claim aud токенов приложения appID
+func Audience(appID uint32) string {
+	return strconv.FormatUint(uint64(appID), 10)
+}
+
 func NewRefreshToken(d time.Duration) (models.Token, error) {
 	const op = "lib.jwt.NewRefreshToken"
 	const tokenLenght = 32
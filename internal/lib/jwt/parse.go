@@ -0,0 +1,69 @@
+package jwt
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// AccessClaims данные access токена, выпущенного этим SSO
+type AccessClaims struct {
+	Kid         string               `json:"kid"`
+	UserID      uint64               `json:"user_id"`
+	Username    string               `json:"username"`
+	AppID       uint32               `json:"app_id"`
+	Roles       []string             `json:"roles"`
+	Permissions []string             `json:"permissions"`
+	SessionID   string               `json:"sid,omitempty"`
+	Cnf         *models.Confirmation `json:"cnf,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ParseAccessToken проверяет подпись, срок действия и получателя (aud) access токена.
+// Пустой audience принимает токен любого приложения, aud которого совпадает с его app_id.
+// keyFunc возвращает публичный ключ по kid (из заголовка или claims).
+func ParseAccessToken(
+	tokenString string,
+	audience string,
+	keyFunc func(kid string) (*rsa.PublicKey, error),
+) (*AccessClaims, error) {
+	claims := &AccessClaims{}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+
+	_, err := jwt.ParseWithClaims(
+		tokenString,
+		claims,
+		func(token *jwt.Token) (any, error) {
+			kid, _ := token.Header["kid"].(string)
+			if kid == "" {
+				kid = claims.Kid
+			}
+			if kid == "" {
+				return nil, errors.New("kid not found")
+			}
+			return keyFunc(kid)
+		},
+		options...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if audience == "" && (claims.AppID == 0 || !slices.Contains(claims.Audience, Audience(claims.AppID))) {
+		return nil, fmt.Errorf("%w: aud does not match app_id", ErrInvalidToken)
+	}
+
+	return claims, nil
+}
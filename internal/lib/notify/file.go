@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileNotifier дописывает письма в файл по одному JSON объекту на строку
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+type fileRecord struct {
+	Message
+	SentAt time.Time `json:"sent_at"`
+}
+
+func (n *FileNotifier) Send(ctx context.Context, msg Message) error {
+	const op = notifyOp + "FileNotifier.Send"
+
+	if _, err := recipient(msg.To); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	data, err := json.Marshal(fileRecord{Message: msg, SentAt: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(n.path), 0o700); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// LogNotifier пишет письма в журнал приложения
+type LogNotifier struct {
+	logger *slog.Logger
+}
+
+func NewLogNotifier(log *slog.Logger) *LogNotifier {
+	return &LogNotifier{logger: log}
+}
+
+func (n *LogNotifier) Send(ctx context.Context, msg Message) error {
+	const op = notifyOp + "LogNotifier.Send"
+
+	if _, err := recipient(msg.To); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n.logger.Info("email notification",
+		slog.String("op", op),
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+	return nil
+}
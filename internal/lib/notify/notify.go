@@ -0,0 +1,65 @@
+// Пакет доставки писем пользователям (коды входа, ссылки подтверждения)
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+
+	"github.com/Grino777/sso/internal/config"
+)
+
+const notifyOp = "lib.notify."
+
+var ErrInvalidRecipient = errors.New("invalid recipient address")
+
+// Message письмо пользователю в виде простого текста
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Notifier доставляет письма пользователям
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New создает Notifier по способу доставки из конфигурации
+func New(log *slog.Logger, cfg config.NotifierConfig) (Notifier, error) {
+	const op = notifyOp + "New"
+
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid from address: %w", op, err)
+	}
+
+	switch cfg.Type {
+	case config.NotifierSMTP:
+		return NewSMTPNotifier(from, cfg.SMTP)
+	case config.NotifierFile:
+		return NewFileNotifier(cfg.FilePath), nil
+	case config.NotifierLog:
+		return NewLogNotifier(log), nil
+	default:
+		return nil, fmt.Errorf("%s: unknown notifier type %q", op, cfg.Type)
+	}
+}
+
+// recipient проверяет адрес получателя. Адрес должен быть голым (без имени),
+// чтобы его нельзя было использовать для подстановки заголовков письма.
+func recipient(to string) (*mail.Address, error) {
+	addr, err := mail.ParseAddress(to)
+	if err != nil || addr.Address != to {
+		return nil, ErrInvalidRecipient
+	}
+	return addr, nil
+}
+
+// IsAddress возвращает true, если s — адрес электронной почты без имени
+func IsAddress(s string) bool {
+	_, err := recipient(s)
+	return err == nil
+}
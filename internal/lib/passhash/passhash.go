@@ -0,0 +1,242 @@
+// Пакет хэширования паролей: argon2id в формате PHC и bcrypt
+package passhash
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Grino777/sso/internal/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const passhashOp = "lib.passhash."
+
+const argon2idPrefix = "$argon2id$"
+
+var (
+	ErrUnknownHash   = errors.New("unknown password hash format")
+	ErrUnknownPepper = errors.New("password hash uses an unknown pepper")
+)
+
+// Допустимые символы keyid в формате PHC
+var pepperIDPattern = regexp.MustCompile(`^[A-Za-z0-9/+.-]{1,64}$`)
+
+// PasswordHasher создает и проверяет хэши паролей
+type PasswordHasher interface {
+	// Hash возвращает хэш пароля, готовый к сохранению
+	Hash(password string) (string, error)
+	// Verify проверяет пароль. rehash сообщает, что хэш совпал, но создан устаревшим
+	// алгоритмом или параметрами и его нужно пересоздать.
+	Verify(hash []byte, password string) (match bool, rehash bool, err error)
+}
+
+// Hasher создает хэши настроенным алгоритмом и проверяет хэши argon2id и bcrypt
+type Hasher struct {
+	algorithm  string
+	argon2id   argon2idParams
+	bcryptCost int
+	pepperID   string
+	pepper     []byte
+}
+
+var _ PasswordHasher = (*Hasher)(nil)
+
+// New создает Hasher по настройкам хэширования паролей
+func New(cfg config.PasswordHashConfig) (*Hasher, error) {
+	const op = passhashOp + "New"
+
+	switch cfg.Algorithm {
+	case config.PasswordHashArgon2id, config.PasswordHashBcrypt:
+	default:
+		return nil, fmt.Errorf("%s: unknown password hash algorithm %q", op, cfg.Algorithm)
+	}
+
+	params := argon2idParams{
+		memory:      cfg.Argon2id.Memory,
+		iterations:  cfg.Argon2id.Iterations,
+		parallelism: cfg.Argon2id.Parallelism,
+		saltLength:  cfg.Argon2id.SaltLength,
+		keyLength:   cfg.Argon2id.KeyLength,
+	}
+	if params.memory == 0 || params.iterations == 0 || params.parallelism == 0 ||
+		params.saltLength < 8 || params.keyLength < 16 {
+		return nil, fmt.Errorf("%s: invalid argon2id parameters", op)
+	}
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("%s: bcrypt cost must be between %d and %d", op, bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	h := &Hasher{
+		algorithm:  cfg.Algorithm,
+		argon2id:   params,
+		bcryptCost: cfg.BcryptCost,
+	}
+	if cfg.Pepper != "" {
+		if !pepperIDPattern.MatchString(cfg.PepperID) {
+			return nil, fmt.Errorf("%s: invalid pepper id %q", op, cfg.PepperID)
+		}
+		h.pepperID = cfg.PepperID
+		h.pepper = []byte(cfg.Pepper)
+	}
+	return h, nil
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	const op = passhashOp + "Hash"
+
+	if h.algorithm == config.PasswordHashBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		return string(hash), nil
+	}
+
+	params := h.argon2id
+	params.keyID = h.pepperID
+	salt := make([]byte, params.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	key := params.key(h.peppered(password), salt)
+	return params.encode(salt, key), nil
+}
+
+func (h *Hasher) Verify(hash []byte, password string) (bool, bool, error) {
+	const op = passhashOp + "Verify"
+
+	encoded := string(hash)
+	if !strings.HasPrefix(encoded, argon2idPrefix) {
+		if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, false, nil
+			}
+			return false, false, fmt.Errorf("%s: %w: %w", op, ErrUnknownHash, err)
+		}
+		cost, err := bcrypt.Cost(hash)
+		if err != nil {
+			return false, false, fmt.Errorf("%s: %w", op, err)
+		}
+		return true, h.algorithm != config.PasswordHashBcrypt || cost < h.bcryptCost, nil
+	}
+
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	secret := []byte(password)
+	if params.keyID != "" {
+		if params.keyID != h.pepperID {
+			return false, false, fmt.Errorf("%s: %w %q", op, ErrUnknownPepper, params.keyID)
+		}
+		secret = h.peppered(password)
+	}
+	if subtle.ConstantTimeCompare(params.key(secret, salt), key) != 1 {
+		return false, false, nil
+	}
+
+	rehash := h.algorithm != config.PasswordHashArgon2id ||
+		params.weakerThan(h.argon2id) ||
+		params.keyID != h.pepperID
+	return true, rehash, nil
+}
+
+// peppered возвращает HMAC-SHA256 пароля на перце или сам пароль, если перец не задан
+func (h *Hasher) peppered(password string) []byte {
+	if h.pepper == nil {
+		return []byte(password)
+	}
+	mac := hmac.New(sha256.New, h.pepper)
+	mac.Write([]byte(password))
+	return mac.Sum(nil)
+}
+
+// argon2idParams параметры хэша argon2id
+type argon2idParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	saltLength  uint32
+	keyLength   uint32
+	// Идентификатор перца, пусто — хэш создан без перца
+	keyID string
+}
+
+func (p argon2idParams) key(secret, salt []byte) []byte {
+	return argon2.IDKey(secret, salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+}
+
+func (p argon2idParams) weakerThan(target argon2idParams) bool {
+	return p.memory < target.memory ||
+		p.iterations < target.iterations ||
+		p.parallelism < target.parallelism ||
+		p.saltLength < target.saltLength ||
+		p.keyLength < target.keyLength
+}
+
+// encode возвращает хэш в формате PHC: $argon2id$v=19$m=...,t=...,p=...[,keyid=...]$salt$hash
+func (p argon2idParams) encode(salt, key []byte) string {
+	params := fmt.Sprintf("m=%d,t=%d,p=%d", p.memory, p.iterations, p.parallelism)
+	if p.keyID != "" {
+		params += ",keyid=" + p.keyID
+	}
+	return fmt.Sprintf(
+		"%sv=%d$%s$%s$%s",
+		argon2idPrefix, argon2.Version, params,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	)
+}
+
+func decodeArgon2id(encoded string) (argon2idParams, []byte, []byte, error) {
+	var p argon2idParams
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[2] != fmt.Sprintf("v=%d", argon2.Version) {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	for _, param := range strings.Split(parts[3], ",") {
+		name, value, _ := strings.Cut(param, "=")
+		var err error
+		switch name {
+		case "m":
+			_, err = fmt.Sscanf(value, "%d", &p.memory)
+		case "t":
+			_, err = fmt.Sscanf(value, "%d", &p.iterations)
+		case "p":
+			_, err = fmt.Sscanf(value, "%d", &p.parallelism)
+		case "keyid":
+			p.keyID = value
+		default:
+			err = ErrUnknownHash
+		}
+		if err != nil {
+			return p, nil, nil, ErrUnknownHash
+		}
+	}
+	if p.memory == 0 || p.iterations == 0 || p.parallelism == 0 {
+		return p, nil, nil, ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrUnknownHash
+	}
+	p.saltLength = uint32(len(salt))
+	p.keyLength = uint32(len(key))
+	return p, salt, key, nil
+}
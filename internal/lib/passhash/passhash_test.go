@@ -0,0 +1,116 @@
+package passhash
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Grino777/sso/internal/config"
+)
+
+func testConfig() config.PasswordHashConfig {
+	return config.PasswordHashConfig{
+		Algorithm: config.PasswordHashArgon2id,
+		Argon2id: config.Argon2idConfig{
+			Memory:      1024,
+			Iterations:  1,
+			Parallelism: 1,
+			SaltLength:  16,
+			KeyLength:   32,
+		},
+		BcryptCost: 4,
+		PepperID:   "1",
+	}
+}
+
+func newHasher(t *testing.T, cfg config.PasswordHashConfig) *Hasher {
+	t.Helper()
+	h, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func verify(t *testing.T, h *Hasher, hash, password string) (bool, bool) {
+	t.Helper()
+	match, rehash, err := h.Verify([]byte(hash), password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return match, rehash
+}
+
+func TestHasher__Argon2id(t *testing.T) {
+	h := newHasher(t, testConfig())
+
+	hash, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("unexpected hash format: %s", hash)
+	}
+
+	if match, rehash := verify(t, h, hash, "correct horse"); !match || rehash {
+		t.Fatalf("match = %v, rehash = %v; want true, false", match, rehash)
+	}
+	if match, _ := verify(t, h, hash, "wrong horse"); match {
+		t.Fatal("wrong password matched")
+	}
+}
+
+func TestHasher__Rehash(t *testing.T) {
+	bcryptCfg := testConfig()
+	bcryptCfg.Algorithm = config.PasswordHashBcrypt
+	legacy, err := newHasher(t, bcryptCfg).Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	weak, err := newHasher(t, testConfig()).Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stronger := testConfig()
+	stronger.Argon2id.Iterations = 2
+	h := newHasher(t, stronger)
+
+	if match, rehash := verify(t, h, legacy, "correct horse"); !match || !rehash {
+		t.Fatalf("bcrypt hash: match = %v, rehash = %v; want true, true", match, rehash)
+	}
+	if match, rehash := verify(t, h, weak, "correct horse"); !match || !rehash {
+		t.Fatalf("weaker argon2id hash: match = %v, rehash = %v; want true, true", match, rehash)
+	}
+}
+
+func TestHasher__Pepper(t *testing.T) {
+	plain, err := newHasher(t, testConfig()).Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := testConfig()
+	cfg.Pepper = "pepper-one"
+	h := newHasher(t, cfg)
+
+	peppered, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(peppered, ",keyid=1$") {
+		t.Fatalf("pepper id is missing: %s", peppered)
+	}
+	if match, rehash := verify(t, h, peppered, "correct horse"); !match || rehash {
+		t.Fatalf("match = %v, rehash = %v; want true, false", match, rehash)
+	}
+	if match, rehash := verify(t, h, plain, "correct horse"); !match || !rehash {
+		t.Fatalf("hash without pepper: match = %v, rehash = %v; want true, true", match, rehash)
+	}
+
+	cfg.Pepper = "pepper-two"
+	cfg.PepperID = "2"
+	if _, _, err := newHasher(t, cfg).Verify([]byte(peppered), "correct horse"); !errors.Is(err, ErrUnknownPepper) {
+		t.Fatalf("err = %v; want %v", err, ErrUnknownPepper)
+	}
+}
@@ -0,0 +1,95 @@
+// Пакет ограничения частоты запросов по алгоритму token bucket
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+)
+
+// Интервал, с которым из памяти удаляются заполненные bucket
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  models.RateLimit
+}
+
+// MemoryLimiter хранит bucket в памяти процесса,
+// поэтому лимиты действуют на каждую реплику отдельно
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// AllowRequest берет токен из bucket с ключом key
+func (l *MemoryLimiter) AllowRequest(
+	_ context.Context,
+	key string,
+	limit models.RateLimit,
+) (models.RateLimitResult, error) {
+	if limit.Unlimited() {
+		return models.RateLimitResult{Allowed: true}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.limit = limit
+	b.tokens = refill(b.tokens, now.Sub(b.last), limit)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return models.RateLimitResult{Allowed: true}, nil
+	}
+
+	wait := (1 - b.tokens) / limit.Rate
+	return models.RateLimitResult{
+		RetryAfter: time.Duration(math.Ceil(wait * float64(time.Second))),
+	}, nil
+}
+
+// sweep удаляет bucket, успевшие заполниться: они не отличаются от новых
+func (l *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if refill(b.tokens, now.Sub(b.last), b.limit) >= float64(b.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// refill возвращает число токенов после пополнения за elapsed
+func refill(tokens float64, elapsed time.Duration, limit models.RateLimit) float64 {
+	if elapsed <= 0 {
+		return tokens
+	}
+	return math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate)
+}
@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+)
+
+// testClock управляемое время лимитера
+type testClock struct{ now time.Time }
+
+func (c *testClock) Now() time.Time          { return c.now }
+func (c *testClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestLimiter(c *testClock) *MemoryLimiter {
+	l := NewMemoryLimiter()
+	l.now = c.Now
+	l.lastSweep = c.now
+	return l
+}
+
+func allow(l *MemoryLimiter, key string, limit models.RateLimit) models.RateLimitResult {
+	result, _ := l.AllowRequest(context.Background(), key, limit)
+	return result
+}
+
+func TestMemoryLimiter__Refill(t *testing.T) {
+	clock := &testClock{now: time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)}
+	l := newTestLimiter(clock)
+	limit := models.RateLimit{Rate: 2, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		if !allow(l, "k", limit).Allowed {
+			t.Fatalf("request %d within burst rejected", i+1)
+		}
+	}
+
+	result := allow(l, "k", limit)
+	if result.Allowed {
+		t.Fatal("request over burst allowed")
+	}
+	if result.RetryAfter != 500*time.Millisecond {
+		t.Errorf("retry after = %s, want 500ms at 2 tokens per second", result.RetryAfter)
+	}
+
+	// Отклоненный запрос не расходует токен: через 500ms появляется ровно один
+	clock.Advance(500 * time.Millisecond)
+	if !allow(l, "k", limit).Allowed {
+		t.Fatal("request after refill rejected")
+	}
+	if allow(l, "k", limit).Allowed {
+		t.Fatal("second request allowed with a single refilled token")
+	}
+
+	// Bucket не наполняется сверх burst
+	clock.Advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		if !allow(l, "k", limit).Allowed {
+			t.Fatalf("request %d after idle period rejected", i+1)
+		}
+	}
+	if allow(l, "k", limit).Allowed {
+		t.Fatal("bucket refilled over burst")
+	}
+}
+
+func TestMemoryLimiter__Keys(t *testing.T) {
+	clock := &testClock{now: time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)}
+	l := newTestLimiter(clock)
+	limit := models.RateLimit{Rate: 1, Burst: 1}
+
+	if !allow(l, "ip:203.0.113.7", limit).Allowed || allow(l, "ip:203.0.113.7", limit).Allowed {
+		t.Fatal("bucket of the first key does not hold one token")
+	}
+	if !allow(l, "ip:198.51.100.1", limit).Allowed {
+		t.Fatal("buckets of different keys are shared")
+	}
+	if !allow(l, "ip:203.0.113.7", models.RateLimit{}).Allowed {
+		t.Fatal("unlimited request rejected")
+	}
+
+	// Заполнившиеся bucket удаляются из памяти
+	clock.Advance(sweepInterval)
+	allow(l, "other", limit)
+	if len(l.buckets) != 1 {
+		t.Errorf("buckets after sweep = %d, want 1", len(l.buckets))
+	}
+}
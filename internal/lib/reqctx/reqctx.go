@@ -0,0 +1,32 @@
+// Пакет для передачи данных транспортного уровня (gRPC/HTTP) в бизнес-логику
+package reqctx
+
+import "context"
+
+type ctxKey struct{}
+
+// Info содержит данные входящего запроса, не относящиеся к его телу
+type Info struct {
+	Method string // HTTP метод запроса (для gRPC всегда POST)
+	URL    string // URL запроса без query (для gRPC: base url + полное имя метода)
+	DPoP   string // DPoP proof из заголовка запроса
+	// x5t#S256 проверенного клиентского сертификата (mTLS), пусто без mTLS
+	CertThumbprint string
+	ClientIP       string // адрес клиента (x-forwarded-for или адрес соединения)
+	UserAgent      string
+	// Имя устройства, которое клиент передал для списка сеансов
+	DeviceName string
+	// Клиент запросил offline доступ (scope offline_access или "запомнить меня")
+	OfflineAccess bool
+}
+
+// WithInfo сохраняет данные запроса в контексте
+func WithInfo(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, ctxKey{}, info)
+}
+
+// FromContext возвращает данные запроса из контекста
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(ctxKey{}).(Info)
+	return info
+}
@@ -0,0 +1,91 @@
+// Пакет для шифрования секретов, которые нужно хранить в исходном виде
+// (секреты приложений для HMAC): AES-256-GCM на ключе сервера.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// prefix отличает зашифрованное значение от секрета, сохраненного до шифрования
+const prefix = "enc:v1:"
+
+// KeySize длина ключа в байтах
+const KeySize = 32
+
+var (
+	ErrInvalidKey   = errors.New("secret key must be 32 bytes encoded in base64")
+	ErrNotSealed    = errors.New("value is not encrypted")
+	ErrInvalidValue = errors.New("failed to decrypt value")
+)
+
+// Box шифрует и расшифровывает секреты одним ключом
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New возвращает Box для ключа key (base64, 32 байта)
+func New(key string) (*Box, error) {
+	const op = "lib.secretbox.New"
+
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(raw) != KeySize {
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidKey)
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal шифрует value. Пустая строка не шифруется.
+func (b *Box) Seal(value string) (string, error) {
+	const op = "lib.secretbox.Seal"
+
+	if value == "" {
+		return "", nil
+	}
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(value), nil)
+	return prefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open расшифровывает значение, полученное Seal. Пустая строка возвращается как есть.
+func (b *Box) Open(value string) (string, error) {
+	const op = "lib.secretbox.Open"
+
+	if value == "" {
+		return "", nil
+	}
+	if !IsSealed(value) {
+		return "", fmt.Errorf("%s: %w", op, ErrNotSealed)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, prefix))
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return "", fmt.Errorf("%s: %w", op, ErrInvalidValue)
+	}
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plain, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, ErrInvalidValue)
+	}
+	return string(plain), nil
+}
+
+// IsSealed возвращает true, если value зашифровано Seal
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
@@ -0,0 +1,79 @@
+package secretbox
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(rune(b)), KeySize)))
+}
+
+func TestBox__RoundTrip(t *testing.T) {
+	box, err := New(testKey('a'))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := box.Seal("app-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) || strings.Contains(sealed, "app-secret") {
+		t.Fatalf("sealed value %q exposes the secret", sealed)
+	}
+
+	again, err := box.Seal("app-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again == sealed {
+		t.Fatal("same secret sealed twice gives the same value")
+	}
+
+	got, err := box.Open(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "app-secret" {
+		t.Fatalf("opened %q, want app-secret", got)
+	}
+}
+
+func TestBox__Open(t *testing.T) {
+	box, _ := New(testKey('a'))
+	other, _ := New(testKey('b'))
+	sealed, _ := box.Seal("app-secret")
+
+	tests := []struct {
+		name  string
+		box   *Box
+		value string
+		want  error
+	}{
+		{"other key", other, sealed, ErrInvalidValue},
+		{"tampered", box, sealed[:len(sealed)-2] + "AA", ErrInvalidValue},
+		{"plaintext", box, "app-secret", ErrNotSealed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.box.Open(tt.value); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if got, err := box.Open(""); err != nil || got != "" {
+		t.Fatalf("empty value: %q, %v", got, err)
+	}
+}
+
+func TestNew__InvalidKey(t *testing.T) {
+	for _, key := range []string{"", "not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := New(key); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("key %q: err = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package totp
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Секрет "12345678901234567890" из приложения B RFC 6238 в base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode__RFC6238(t *testing.T) {
+	// Векторы SHA-1 из RFC 6238, последние 6 цифр восьмизначных кодов
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Fatalf("code at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+
+	// Секрет принимается в нижнем регистре
+	if got, _ := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", Step(time.Unix(59, 0))); got != "287082" {
+		t.Fatalf("lowercase secret: code = %s, want 287082", got)
+	}
+}
+
+func TestCode__InvalidSecret(t *testing.T) {
+	for _, secret := range []string{"", "not base32!"} {
+		if _, err := Code(secret, 1); !errors.Is(err, ErrInvalidSecret) {
+			t.Fatalf("secret %q: err = %v, want ErrInvalidSecret", secret, err)
+		}
+	}
+}
+
+func TestValidate__Skew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	codeAt := func(offset int64) string {
+		code, err := Code(rfcSecret, current+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name   string
+		offset int64
+		skew   int
+		ok     bool
+	}{
+		{"current step", 0, 0, true},
+		{"previous step without skew", -1, 0, false},
+		{"previous step", -1, 1, true},
+		{"next step", 1, 1, true},
+		{"outside window", 2, 1, false},
+		{"outside window in the past", -2, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok, err := Validate(rfcSecret, codeAt(tt.offset), now, tt.skew)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			// Шаг нужен для защиты от повторного использования кода
+			if ok && step != current+tt.offset {
+				t.Fatalf("step = %d, want %d", step, current+tt.offset)
+			}
+		})
+	}
+
+	if _, ok, err := Validate(rfcSecret, "12345", now, 1); ok || err != nil {
+		t.Fatalf("short code: ok = %v, err = %v", ok, err)
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(ProvisioningURI(rfcSecret, "SSO", "alice@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/SSO:alice@example.com" {
+		t.Fatalf("uri = %s", uri)
+	}
+	query := uri.Query()
+	if query.Get("secret") != rfcSecret || query.Get("issuer") != "SSO" || query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Fatalf("query = %v", query)
+	}
+}
@@ -0,0 +1,151 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Форматы аттестации, которые принимает сервер
+const (
+	FormatNone   = "none"
+	FormatPacked = "packed"
+)
+
+var ErrInvalidAttestation = errors.New("invalid webauthn attestation")
+
+// id-fido-gen-ce-aaguid: AAGUID аутентификатора в сертификате аттестации
+var oidFIDOAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+type attestationObject struct {
+	format   string
+	attStmt  map[any]any
+	authData []byte
+}
+
+// parseAttestationObject разбирает attestationObject (WebAuthn §6.5)
+func parseAttestationObject(raw []byte) (attestationObject, error) {
+	item, rest, err := decodeCBOR(raw)
+	if err != nil || len(rest) != 0 {
+		return attestationObject{}, ErrInvalidResponse
+	}
+	obj, ok := item.(map[any]any)
+	if !ok {
+		return attestationObject{}, ErrInvalidResponse
+	}
+
+	format, _ := obj["fmt"].(string)
+	attStmt, _ := obj["attStmt"].(map[any]any)
+	authData, _ := obj["authData"].([]byte)
+	if format == "" || attStmt == nil || authData == nil {
+		return attestationObject{}, ErrInvalidResponse
+	}
+
+	return attestationObject{format: format, attStmt: attStmt, authData: authData}, nil
+}
+
+// verifyAttestation проверяет заявление аттестации форматов none и packed.
+// Цепочка сертификатов packed не проверяется по корневым сертификатам
+// производителей: сервер не ограничивает модели аутентификаторов.
+func verifyAttestation(
+	att attestationObject,
+	authData authenticatorData,
+	credentialKey crypto.PublicKey,
+	credentialAlg int64,
+	clientDataHash []byte,
+) error {
+	switch att.format {
+	case FormatNone:
+		if len(att.attStmt) != 0 {
+			return fmt.Errorf("%w: none attestation statement must be empty", ErrInvalidAttestation)
+		}
+		return nil
+	case FormatPacked:
+		return verifyPacked(att, authData, credentialKey, credentialAlg, clientDataHash)
+	default:
+		return fmt.Errorf("%w: unsupported format %q", ErrInvalidAttestation, att.format)
+	}
+}
+
+// verifyPacked проверяет аттестацию packed (WebAuthn §8.2): самоаттестацию
+// ключом самого credential или подпись сертификатом аттестации из x5c
+func verifyPacked(
+	att attestationObject,
+	authData authenticatorData,
+	credentialKey crypto.PublicKey,
+	credentialAlg int64,
+	clientDataHash []byte,
+) error {
+	alg, ok := att.attStmt["alg"].(int64)
+	if !ok {
+		return fmt.Errorf("%w: alg missing", ErrInvalidAttestation)
+	}
+	sig, ok := att.attStmt["sig"].([]byte)
+	if !ok {
+		return fmt.Errorf("%w: sig missing", ErrInvalidAttestation)
+	}
+	signed := append(append([]byte(nil), att.authData...), clientDataHash...)
+
+	x5c, hasX5C := att.attStmt["x5c"].([]any)
+	if !hasX5C {
+		if alg != credentialAlg {
+			return fmt.Errorf("%w: self attestation alg mismatch", ErrInvalidAttestation)
+		}
+		if !verifySignature(credentialKey, alg, signed, sig) {
+			return fmt.Errorf("%w: %w", ErrInvalidAttestation, ErrSignature)
+		}
+		return nil
+	}
+
+	if len(x5c) == 0 {
+		return fmt.Errorf("%w: empty x5c", ErrInvalidAttestation)
+	}
+	der, ok := x5c[0].([]byte)
+	if !ok {
+		return fmt.Errorf("%w: invalid x5c", ErrInvalidAttestation)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAttestation, err)
+	}
+	if err := checkPackedCertificate(cert, authData.aaguid); err != nil {
+		return err
+	}
+	if !slices.Contains(SupportedAlgorithms, alg) || !verifySignature(cert.PublicKey, alg, signed, sig) {
+		return fmt.Errorf("%w: %w", ErrInvalidAttestation, ErrSignature)
+	}
+	return nil
+}
+
+// checkPackedCertificate проверяет требования к сертификату аттестации packed (WebAuthn §8.2.1)
+func checkPackedCertificate(cert *x509.Certificate, aaguid []byte) error {
+	if cert.Version != 3 {
+		return fmt.Errorf("%w: certificate version must be 3", ErrInvalidAttestation)
+	}
+	subject := cert.Subject
+	if len(subject.Country) == 0 || len(subject.Organization) == 0 || subject.CommonName == "" ||
+		!slices.Contains(subject.OrganizationalUnit, "Authenticator Attestation") {
+		return fmt.Errorf("%w: invalid certificate subject", ErrInvalidAttestation)
+	}
+	if cert.BasicConstraintsValid && cert.IsCA {
+		return fmt.Errorf("%w: attestation certificate must not be a CA", ErrInvalidAttestation)
+	}
+
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidFIDOAAGUID) {
+			continue
+		}
+		if ext.Critical {
+			return fmt.Errorf("%w: aaguid extension must not be critical", ErrInvalidAttestation)
+		}
+		var certAAGUID []byte
+		if _, err := asn1.Unmarshal(ext.Value, &certAAGUID); err != nil || !bytes.Equal(certAAGUID, aaguid) {
+			return fmt.Errorf("%w: aaguid mismatch", ErrInvalidAttestation)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+)
+
+// Алгоритмы COSE (RFC 9053), которые принимает сервер
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgES384 int64 = -35
+	AlgES512 int64 = -36
+	AlgPS256 int64 = -37
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms алгоритмы в порядке предпочтения для pubKeyCredParams
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256, AlgPS256, AlgES384, AlgES512}
+
+// Параметры ключа COSE
+const (
+	coseKty        = 1
+	coseAlg        = 3
+	coseCrv        = -1
+	coseX          = -2 // для RSA — модуль n
+	coseY          = -3 // для RSA — экспонента e
+	coseKtyOKP     = 1
+	coseKtyEC2     = 2
+	coseKtyRSA     = 3
+	coseCrvP256    = 1
+	coseCrvP384    = 2
+	coseCrvP521    = 3
+	coseCrvEd25519 = 6
+)
+
+var ErrUnsupportedAlgorithm = errors.New("unsupported credential algorithm")
+
+// ParsePublicKey разбирает публичный ключ COSE и возвращает его вместе с алгоритмом
+func ParsePublicKey(coseKey []byte) (crypto.PublicKey, int64, error) {
+	item, rest, err := decodeCBOR(coseKey)
+	if err != nil || len(rest) != 0 {
+		return nil, 0, errInvalidCBOR
+	}
+	key, ok := item.(map[any]any)
+	if !ok {
+		return nil, 0, errInvalidCBOR
+	}
+
+	kty, _ := key[int64(coseKty)].(int64)
+	alg, _ := key[int64(coseAlg)].(int64)
+	crv, _ := key[int64(coseCrv)].(int64)
+	x, _ := key[int64(coseX)].([]byte)
+	y, _ := key[int64(coseY)].([]byte)
+
+	switch {
+	case kty == coseKtyEC2 && alg == AlgES256 && crv == coseCrvP256:
+		return ecdsaKey(elliptic.P256(), alg, x, y)
+	case kty == coseKtyEC2 && alg == AlgES384 && crv == coseCrvP384:
+		return ecdsaKey(elliptic.P384(), alg, x, y)
+	case kty == coseKtyEC2 && alg == AlgES512 && crv == coseCrvP521:
+		return ecdsaKey(elliptic.P521(), alg, x, y)
+	case kty == coseKtyOKP && alg == AlgEdDSA && crv == coseCrvEd25519:
+		if len(x) != ed25519.PublicKeySize {
+			return nil, 0, ErrUnsupportedAlgorithm
+		}
+		return ed25519.PublicKey(x), alg, nil
+	case kty == coseKtyRSA && (alg == AlgRS256 || alg == AlgPS256):
+		e := new(big.Int).SetBytes(y)
+		if len(x) < 256 || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, 0, ErrUnsupportedAlgorithm
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(x), E: int(e.Int64())}, alg, nil
+	default:
+		return nil, 0, ErrUnsupportedAlgorithm
+	}
+}
+
+func ecdsaKey(curve elliptic.Curve, alg int64, x, y []byte) (crypto.PublicKey, int64, error) {
+	size := (curve.Params().BitSize + 7) / 8
+	if len(x) != size || len(y) != size {
+		return nil, 0, ErrUnsupportedAlgorithm
+	}
+
+	pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	// ECDH проверяет, что точка лежит на кривой
+	if _, err := pub.ECDH(); err != nil {
+		return nil, 0, ErrUnsupportedAlgorithm
+	}
+	return pub, alg, nil
+}
+
+// verifySignature проверяет подпись data ключом pub по алгоритму COSE alg
+func verifySignature(pub crypto.PublicKey, alg int64, data, sig []byte) bool {
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		switch alg {
+		case AlgES256:
+			sum := sha256.Sum256(data)
+			return ecdsa.VerifyASN1(key, sum[:], sig)
+		case AlgES384:
+			sum := sha512.Sum384(data)
+			return ecdsa.VerifyASN1(key, sum[:], sig)
+		case AlgES512:
+			sum := sha512.Sum512(data)
+			return ecdsa.VerifyASN1(key, sum[:], sig)
+		}
+	case ed25519.PublicKey:
+		return alg == AlgEdDSA && ed25519.Verify(key, data, sig)
+	case *rsa.PublicKey:
+		sum := sha256.Sum256(data)
+		switch alg {
+		case AlgRS256:
+			return rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig) == nil
+		case AlgPS256:
+			return rsa.VerifyPSS(key, crypto.SHA256, sum[:], sig, nil) == nil
+		}
+	}
+	return false
+}
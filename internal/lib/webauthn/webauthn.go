@@ -0,0 +1,525 @@
+// Пакет для проверки церемоний WebAuthn (регистрация и вход по passkey)
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+
+	credentialType = "public-key"
+
+	challengeSize = 32
+)
+
+// Флаги authenticatorData
+const (
+	FlagUserPresent    byte = 0x01
+	FlagUserVerified   byte = 0x04
+	FlagBackupEligible byte = 0x08
+	FlagBackupState    byte = 0x10
+	FlagAttestedData   byte = 0x40
+	FlagExtensionData  byte = 0x80
+)
+
+const (
+	authDataMinLength     = 37
+	aaguidLength          = 16
+	maxCredentialIDLength = 1023
+)
+
+// Требование проверки пользователя (PIN, биометрия)
+const (
+	UserVerificationRequired    = "required"
+	UserVerificationPreferred   = "preferred"
+	UserVerificationDiscouraged = "discouraged"
+)
+
+// Запрашиваемая аттестация
+const (
+	AttestationNone   = "none"
+	AttestationDirect = "direct"
+)
+
+var (
+	ErrInvalidResponse  = errors.New("invalid webauthn response")
+	ErrChallenge        = errors.New("webauthn challenge mismatch")
+	ErrOrigin           = errors.New("webauthn origin is not allowed")
+	ErrRPID             = errors.New("webauthn rp id mismatch")
+	ErrUserPresence     = errors.New("user presence is required")
+	ErrUserVerification = errors.New("user verification is required")
+	ErrSignature        = errors.New("invalid webauthn signature")
+	ErrSignCount        = errors.New("authenticator sign counter did not increase")
+)
+
+// RelyingParty параметры сервера, от имени которого выполняются церемонии
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+	// required, preferred или discouraged
+	UserVerification string
+	// Запрашиваемая аттестация: none или direct. Проверяются форматы none и packed.
+	Attestation string
+	Timeout     time.Duration
+}
+
+// Credential проверенный при регистрации ключ
+type Credential struct {
+	ID []byte
+	// Публичный ключ в формате COSE
+	PublicKey         []byte
+	Algorithm         int64
+	SignCount         uint32
+	AAGUID            []byte
+	AttestationFormat string
+	UserVerified      bool
+	BackupEligible    bool
+}
+
+// Assertion результат проверки входа
+type Assertion struct {
+	SignCount    uint32
+	UserVerified bool
+}
+
+// User владелец ключа для PublicKeyCredentialCreationOptions
+type User struct {
+	// Идентификатор пользователя (user handle), не более 64 байт
+	ID          []byte
+	Name        string
+	DisplayName string
+}
+
+// CredentialDescriptor ссылка на ключ в allowCredentials/excludeCredentials
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type rpEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type userEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type credentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type authenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions PublicKeyCredentialCreationOptionsJSON для navigator.credentials.create
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     rpEntity               `json:"rp"`
+	User                   userEntity             `json:"user"`
+	PubKeyCredParams       []credentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout,omitempty"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection authenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions PublicKeyCredentialRequestOptionsJSON для navigator.credentials.get
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout,omitempty"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// RegistrationResponse RegistrationResponseJSON (PublicKeyCredential.toJSON())
+type RegistrationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports,omitempty"`
+	} `json:"response"`
+}
+
+// AssertionResponse AuthenticationResponseJSON (PublicKeyCredential.toJSON())
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type authenticatorData struct {
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+	// Заполняются только при флаге AT
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+// NewChallenge возвращает случайный challenge в base64url
+func NewChallenge() (string, error) {
+	buf := make([]byte, challengeSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// EncodeID кодирует идентификатор ключа или пользователя в base64url
+func EncodeID(id []byte) string {
+	return base64.RawURLEncoding.EncodeToString(id)
+}
+
+// DecodeID декодирует base64url (с выравниванием или без)
+func DecodeID(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// CreationOptions возвращает параметры регистрации нового ключа
+func (rp *RelyingParty) CreationOptions(
+	challenge string,
+	user User,
+	exclude []CredentialDescriptor,
+) CreationOptions {
+	params := make([]credentialParameter, 0, len(SupportedAlgorithms))
+	for _, alg := range SupportedAlgorithms {
+		params = append(params, credentialParameter{Type: credentialType, Alg: alg})
+	}
+	if exclude == nil {
+		exclude = []CredentialDescriptor{}
+	}
+
+	return CreationOptions{
+		Challenge: challenge,
+		RP:        rpEntity{ID: rp.ID, Name: rp.Name},
+		User: userEntity{
+			ID:          EncodeID(user.ID),
+			Name:        user.Name,
+			DisplayName: user.DisplayName,
+		},
+		PubKeyCredParams:   params,
+		Timeout:            rp.Timeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: authenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: rp.userVerification(),
+		},
+		Attestation: rp.attestation(),
+	}
+}
+
+// RequestOptions возвращает параметры входа. Пустой allow разрешает
+// любой ключ пользователя, сохраненный в аутентификаторе (discoverable credential).
+func (rp *RelyingParty) RequestOptions(challenge string, allow []CredentialDescriptor) RequestOptions {
+	if allow == nil {
+		allow = []CredentialDescriptor{}
+	}
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.Timeout.Milliseconds(),
+		RPID:             rp.ID,
+		AllowCredentials: allow,
+		UserVerification: rp.userVerification(),
+	}
+}
+
+// Descriptor возвращает ссылку на ключ для allowCredentials/excludeCredentials
+func Descriptor(id []byte, transports []string) CredentialDescriptor {
+	return CredentialDescriptor{Type: credentialType, ID: EncodeID(id), Transports: transports}
+}
+
+// Challenge возвращает challenge из clientDataJSON ответа без проверки подписи
+func (r *RegistrationResponse) Challenge() (string, error) {
+	return challengeOf(r.Response.ClientDataJSON)
+}
+
+// Challenge возвращает challenge из clientDataJSON ответа без проверки подписи
+func (r *AssertionResponse) Challenge() (string, error) {
+	return challengeOf(r.Response.ClientDataJSON)
+}
+
+// CredentialID возвращает идентификатор ключа, которым выполнен вход
+func (r *AssertionResponse) CredentialID() ([]byte, error) {
+	id, err := DecodeID(r.RawID)
+	if err != nil || len(id) == 0 || len(id) > maxCredentialIDLength {
+		return nil, ErrInvalidResponse
+	}
+	return id, nil
+}
+
+// UserHandle возвращает user handle из ответа, nil если аутентификатор его не передал
+func (r *AssertionResponse) UserHandle() ([]byte, error) {
+	if r.Response.UserHandle == "" {
+		return nil, nil
+	}
+	handle, err := DecodeID(r.Response.UserHandle)
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	return handle, nil
+}
+
+// VerifyRegistration проверяет ответ navigator.credentials.create и аттестацию
+func (rp *RelyingParty) VerifyRegistration(challenge string, resp RegistrationResponse) (*Credential, error) {
+	if resp.Type != credentialType {
+		return nil, ErrInvalidResponse
+	}
+
+	rawClientData, err := DecodeID(resp.Response.ClientDataJSON)
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	if err := rp.verifyClientData(rawClientData, ceremonyCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	rawAttestation, err := DecodeID(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	att, err := parseAttestationObject(rawAttestation)
+	if err != nil {
+		return nil, err
+	}
+
+	authData, err := parseAuthenticatorData(att.authData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+	if authData.flags&FlagAttestedData == 0 {
+		return nil, fmt.Errorf("%w: attested credential data missing", ErrInvalidResponse)
+	}
+
+	rawID, err := DecodeID(resp.RawID)
+	if err != nil || !bytes.Equal(rawID, authData.credentialID) {
+		return nil, fmt.Errorf("%w: credential id mismatch", ErrInvalidResponse)
+	}
+
+	pub, alg, err := ParsePublicKey(authData.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(rawClientData)
+	if err := verifyAttestation(att, authData, pub, alg, clientDataHash[:]); err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:                authData.credentialID,
+		PublicKey:         authData.publicKey,
+		Algorithm:         alg,
+		SignCount:         authData.signCount,
+		AAGUID:            authData.aaguid,
+		AttestationFormat: att.format,
+		UserVerified:      authData.flags&FlagUserVerified != 0,
+		BackupEligible:    authData.flags&FlagBackupEligible != 0,
+	}, nil
+}
+
+// VerifyAssertion проверяет ответ navigator.credentials.get подписью сохраненного ключа.
+// storedCount — последнее известное значение счетчика подписей ключа.
+func (rp *RelyingParty) VerifyAssertion(
+	challenge string,
+	publicKey []byte,
+	storedCount uint32,
+	resp AssertionResponse,
+) (Assertion, error) {
+	if resp.Type != credentialType {
+		return Assertion{}, ErrInvalidResponse
+	}
+
+	rawClientData, err := DecodeID(resp.Response.ClientDataJSON)
+	if err != nil {
+		return Assertion{}, ErrInvalidResponse
+	}
+	if err := rp.verifyClientData(rawClientData, ceremonyGet, challenge); err != nil {
+		return Assertion{}, err
+	}
+
+	rawAuthData, err := DecodeID(resp.Response.AuthenticatorData)
+	if err != nil {
+		return Assertion{}, ErrInvalidResponse
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return Assertion{}, err
+	}
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return Assertion{}, err
+	}
+
+	sig, err := DecodeID(resp.Response.Signature)
+	if err != nil {
+		return Assertion{}, ErrInvalidResponse
+	}
+	pub, alg, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return Assertion{}, err
+	}
+
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(append([]byte(nil), rawAuthData...), clientDataHash[:]...)
+	if !verifySignature(pub, alg, signed, sig) {
+		return Assertion{}, ErrSignature
+	}
+
+	// Аутентификаторы без счетчика всегда передают 0. Если счетчик не вырос,
+	// ключ мог быть скопирован.
+	if (authData.signCount != 0 || storedCount != 0) && authData.signCount <= storedCount {
+		return Assertion{}, ErrSignCount
+	}
+
+	return Assertion{
+		SignCount:    authData.signCount,
+		UserVerified: authData.flags&FlagUserVerified != 0,
+	}, nil
+}
+
+func (rp *RelyingParty) userVerification() string {
+	if rp.UserVerification == "" {
+		return UserVerificationPreferred
+	}
+	return rp.UserVerification
+}
+
+func (rp *RelyingParty) attestation() string {
+	if rp.Attestation == "" {
+		return AttestationNone
+	}
+	return rp.Attestation
+}
+
+// verifyClientData проверяет тип церемонии, challenge и origin
+func (rp *RelyingParty) verifyClientData(raw []byte, ceremony, challenge string) error {
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return ErrInvalidResponse
+	}
+	if data.Type != ceremony {
+		return fmt.Errorf("%w: unexpected type %q", ErrInvalidResponse, data.Type)
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.TrimRight(data.Challenge, "=")), []byte(challenge)) != 1 {
+		return ErrChallenge
+	}
+	if data.CrossOrigin || !slices.Contains(rp.Origins, data.Origin) {
+		return ErrOrigin
+	}
+	return nil
+}
+
+// verifyAuthenticatorData проверяет хэш RP ID и флаги присутствия и проверки пользователя
+func (rp *RelyingParty) verifyAuthenticatorData(data authenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if subtle.ConstantTimeCompare(data.rpIDHash, rpIDHash[:]) != 1 {
+		return ErrRPID
+	}
+	if data.flags&FlagUserPresent == 0 {
+		return ErrUserPresence
+	}
+	if rp.UserVerification == UserVerificationRequired && data.flags&FlagUserVerified == 0 {
+		return ErrUserVerification
+	}
+	return nil
+}
+
+// parseAuthenticatorData разбирает authenticatorData (WebAuthn §6.1)
+func parseAuthenticatorData(raw []byte) (authenticatorData, error) {
+	if len(raw) < authDataMinLength {
+		return authenticatorData{}, ErrInvalidResponse
+	}
+
+	data := authenticatorData{
+		rpIDHash:  raw[:32],
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	rest := raw[authDataMinLength:]
+
+	if data.flags&FlagAttestedData != 0 {
+		if len(rest) < aaguidLength+2 {
+			return authenticatorData{}, ErrInvalidResponse
+		}
+		data.aaguid = rest[:aaguidLength]
+		idLength := int(binary.BigEndian.Uint16(rest[aaguidLength:]))
+		rest = rest[aaguidLength+2:]
+		if idLength == 0 || idLength > maxCredentialIDLength || len(rest) < idLength {
+			return authenticatorData{}, ErrInvalidResponse
+		}
+		data.credentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return authenticatorData{}, ErrInvalidResponse
+		}
+		data.publicKey = rest[:len(rest)-len(after)]
+		rest = after
+	}
+
+	if data.flags&FlagExtensionData != 0 {
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return authenticatorData{}, ErrInvalidResponse
+		}
+		rest = after
+	}
+	if len(rest) != 0 {
+		return authenticatorData{}, ErrInvalidResponse
+	}
+	return data, nil
+}
+
+func challengeOf(clientDataJSON string) (string, error) {
+	raw, err := DecodeID(clientDataJSON)
+	if err != nil {
+		return "", ErrInvalidResponse
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil || data.Challenge == "" {
+		return "", ErrInvalidResponse
+	}
+	return strings.TrimRight(data.Challenge, "="), nil
+}
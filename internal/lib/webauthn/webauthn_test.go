@@ -0,0 +1,336 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+const (
+	testRPID   = "sso.example.com"
+	testOrigin = "https://sso.example.com"
+)
+
+var testAAGUID = []byte("sso-test-aaguid!")
+
+// softAuthenticator программный аутентификатор с ключом ES256
+type softAuthenticator struct {
+	key       *ecdsa.PrivateKey
+	id        []byte
+	signCount uint32
+	flags     byte
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		t.Fatal(err)
+	}
+	return &softAuthenticator{key: key, id: id, flags: FlagUserPresent | FlagUserVerified}
+}
+
+func (a *softAuthenticator) coseKey() []byte {
+	return encodeCBOR(map[any]any{
+		int64(coseKty): int64(coseKtyEC2),
+		int64(coseAlg): AlgES256,
+		int64(coseCrv): int64(coseCrvP256),
+		int64(coseX):   a.key.X.FillBytes(make([]byte, 32)),
+		int64(coseY):   a.key.Y.FillBytes(make([]byte, 32)),
+	})
+}
+
+func (a *softAuthenticator) authData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	data := append([]byte(nil), rpIDHash[:]...)
+	flags := a.flags
+	if attested {
+		flags |= FlagAttestedData
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, testAAGUID...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.id)))
+		data = append(data, a.id...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+func (a *softAuthenticator) sign(t *testing.T, key *ecdsa.PrivateKey, authData, clientDataJSON []byte) []byte {
+	t.Helper()
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	sum := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+// register выполняет navigator.credentials.create с аттестацией format.
+// Аттестация packed подписывается ключом signer (nil — самоаттестация), x5c добавляется, если передан.
+func (a *softAuthenticator) register(t *testing.T, challenge, format string, signer *ecdsa.PrivateKey, x5c []byte) RegistrationResponse {
+	t.Helper()
+
+	clientDataJSON := clientDataFixture(t, ceremonyCreate, challenge, testOrigin)
+	authData := a.authData(true)
+
+	attStmt := map[any]any{}
+	switch format {
+	case FormatPacked:
+		if signer == nil {
+			signer = a.key
+		}
+		attStmt["alg"] = AlgES256
+		attStmt["sig"] = a.sign(t, signer, authData, clientDataJSON)
+		if x5c != nil {
+			attStmt["x5c"] = []any{x5c}
+		}
+	}
+
+	var resp RegistrationResponse
+	resp.ID = EncodeID(a.id)
+	resp.RawID = EncodeID(a.id)
+	resp.Type = credentialType
+	resp.Response.ClientDataJSON = EncodeID(clientDataJSON)
+	resp.Response.AttestationObject = EncodeID(encodeCBOR(map[any]any{
+		"fmt":      format,
+		"attStmt":  attStmt,
+		"authData": authData,
+	}))
+	return resp
+}
+
+// assert выполняет navigator.credentials.get
+func (a *softAuthenticator) assert(t *testing.T, challenge, origin string) AssertionResponse {
+	t.Helper()
+
+	a.signCount++
+	clientDataJSON := clientDataFixture(t, ceremonyGet, challenge, origin)
+	authData := a.authData(false)
+
+	var resp AssertionResponse
+	resp.ID = EncodeID(a.id)
+	resp.RawID = EncodeID(a.id)
+	resp.Type = credentialType
+	resp.Response.ClientDataJSON = EncodeID(clientDataJSON)
+	resp.Response.AuthenticatorData = EncodeID(authData)
+	resp.Response.Signature = EncodeID(a.sign(t, a.key, authData, clientDataJSON))
+	return resp
+}
+
+func clientDataFixture(t *testing.T, ceremony, challenge, origin string) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(clientData{Type: ceremony, Challenge: challenge, Origin: origin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// attestationCertificate выпускает самоподписанный сертификат аттестации packed
+func attestationCertificate(t *testing.T) (*ecdsa.PrivateKey, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aaguid, err := asn1.Marshal(testAAGUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			Country:            []string{"RU"},
+			Organization:       []string{"SSO Test"},
+			OrganizationalUnit: []string{"Authenticator Attestation"},
+			CommonName:         "SSO Test Authenticator",
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		ExtraExtensions:       []pkix.Extension{{Id: oidFIDOAAGUID, Value: aaguid}},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, der
+}
+
+func testRelyingParty() *RelyingParty {
+	return &RelyingParty{
+		ID:               testRPID,
+		Name:             "SSO",
+		Origins:          []string{testOrigin},
+		UserVerification: UserVerificationRequired,
+	}
+}
+
+func TestWebAuthn__Registration(t *testing.T) {
+	rp := testRelyingParty()
+	certKey, certDER := attestationCertificate(t)
+
+	cases := []struct {
+		name    string
+		format  string
+		certKey *ecdsa.PrivateKey
+		x5c     []byte
+	}{
+		{name: "none", format: FormatNone},
+		{name: "packed self", format: FormatPacked},
+		{name: "packed x5c", format: FormatPacked, certKey: certKey, x5c: certDER},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			auth := newSoftAuthenticator(t)
+			challenge, err := NewChallenge()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cred, err := rp.VerifyRegistration(challenge, auth.register(t, challenge, tc.format, tc.certKey, tc.x5c))
+			if err != nil {
+				t.Fatalf("registration rejected: %v", err)
+			}
+			if string(cred.ID) != string(auth.id) || cred.Algorithm != AlgES256 || cred.AttestationFormat != tc.format {
+				t.Errorf("unexpected credential: %+v", cred)
+			}
+
+			other, err := NewChallenge()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := rp.VerifyRegistration(other, auth.register(t, challenge, tc.format, tc.certKey, tc.x5c)); !errors.Is(err, ErrChallenge) {
+				t.Errorf("expected challenge mismatch, got %v", err)
+			}
+		})
+	}
+
+	t.Run("packed wrong signer", func(t *testing.T) {
+		auth := newSoftAuthenticator(t)
+		other := newSoftAuthenticator(t)
+		challenge, _ := NewChallenge()
+
+		if _, err := rp.VerifyRegistration(challenge, auth.register(t, challenge, FormatPacked, other.key, nil)); !errors.Is(err, ErrInvalidAttestation) {
+			t.Errorf("expected attestation error, got %v", err)
+		}
+		if _, err := rp.VerifyRegistration(challenge, auth.register(t, challenge, FormatPacked, other.key, certDER)); !errors.Is(err, ErrInvalidAttestation) {
+			t.Errorf("expected attestation error, got %v", err)
+		}
+	})
+}
+
+func TestWebAuthn__Assertion(t *testing.T) {
+	rp := testRelyingParty()
+	auth := newSoftAuthenticator(t)
+
+	challenge, _ := NewChallenge()
+	cred, err := rp.VerifyRegistration(challenge, auth.register(t, challenge, FormatNone, nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge, _ = NewChallenge()
+	resp := auth.assert(t, challenge, testOrigin)
+	if got, err := resp.Challenge(); err != nil || got != challenge {
+		t.Fatalf("unexpected challenge %q: %v", got, err)
+	}
+
+	assertion, err := rp.VerifyAssertion(challenge, cred.PublicKey, cred.SignCount, resp)
+	if err != nil {
+		t.Fatalf("assertion rejected: %v", err)
+	}
+	if assertion.SignCount != 1 || !assertion.UserVerified {
+		t.Errorf("unexpected assertion: %+v", assertion)
+	}
+
+	// Повтор с тем же счетчиком указывает на копию ключа
+	if _, err := rp.VerifyAssertion(challenge, cred.PublicKey, assertion.SignCount, resp); !errors.Is(err, ErrSignCount) {
+		t.Errorf("expected sign count error, got %v", err)
+	}
+
+	challenge, _ = NewChallenge()
+	if _, err := rp.VerifyAssertion(challenge, cred.PublicKey, 1, auth.assert(t, challenge, "https://evil.example.com")); !errors.Is(err, ErrOrigin) {
+		t.Errorf("expected origin error, got %v", err)
+	}
+
+	challenge, _ = NewChallenge()
+	resp = auth.assert(t, challenge, testOrigin)
+	resp.Response.Signature = EncodeID([]byte("forged"))
+	if _, err := rp.VerifyAssertion(challenge, cred.PublicKey, 1, resp); !errors.Is(err, ErrSignature) {
+		t.Errorf("expected signature error, got %v", err)
+	}
+
+	auth.flags = FlagUserPresent
+	challenge, _ = NewChallenge()
+	if _, err := rp.VerifyAssertion(challenge, cred.PublicKey, 1, auth.assert(t, challenge, testOrigin)); !errors.Is(err, ErrUserVerification) {
+		t.Errorf("expected user verification error, got %v", err)
+	}
+}
+
+// encodeCBOR кодирует значения, используемые в фикстурах
+func encodeCBOR(value any) []byte {
+	header := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n <= 0xff:
+			return []byte{major<<5 | 24, byte(n)}
+		case n <= 0xffff:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		default:
+			return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+		}
+	}
+
+	switch v := value.(type) {
+	case int64:
+		if v < 0 {
+			return header(cborNegint, uint64(-1-v))
+		}
+		return header(cborUint, uint64(v))
+	case []byte:
+		return append(header(cborBytes, uint64(len(v))), v...)
+	case string:
+		return append(header(cborText, uint64(len(v))), v...)
+	case []any:
+		out := header(cborArray, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	case map[any]any:
+		out := header(cborMap, uint64(len(v)))
+		for key, item := range v {
+			out = append(out, encodeCBOR(key)...)
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	default:
+		panic("unsupported cbor fixture value")
+	}
+}
@@ -0,0 +1,60 @@
+// Пакет для бизнес-логики самообслуживания: пользователь управляет своей учетной записью
+package account
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	interfaces "github.com/Grino777/sso/internal/interfaces/storage"
+	"github.com/Grino777/sso/internal/services/mfa"
+	"github.com/Grino777/sso/internal/services/passkeys"
+	"github.com/Grino777/sso/internal/services/profile"
+)
+
+const accountOp = "services.account."
+
+type AccountService struct {
+	logger   *slog.Logger
+	db       interfaces.Storage
+	cache    interfaces.CacheStorage
+	mfa      *mfa.MFAService
+	passkeys *passkeys.PasskeyService
+	profile  *profile.ProfileService
+}
+
+func NewAccountService(
+	log *slog.Logger,
+	db interfaces.Storage,
+	cache interfaces.CacheStorage,
+	mfaService *mfa.MFAService,
+	passkeyService *passkeys.PasskeyService,
+	profileService *profile.ProfileService,
+) *AccountService {
+	log.Debug("account service successfully initialized")
+
+	return &AccountService{
+		logger:   log,
+		db:       db,
+		cache:    cache,
+		mfa:      mfaService,
+		passkeys: passkeyService,
+		profile:  profileService,
+	}
+}
+
+// ListLogins возвращает последние попытки входа пользователя
+func (s *AccountService) ListLogins(
+	ctx context.Context,
+	userID uint64,
+	limit int,
+) ([]models.LoginRecord, error) {
+	const op = accountOp + "ListLogins"
+
+	records, err := s.db.ListLoginRecords(ctx, userID, models.ClampLoginsLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return records, nil
+}
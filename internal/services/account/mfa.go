@@ -0,0 +1,69 @@
+package account
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Grino777/sso/internal/domain/models"
+)
+
+const mfaOp = accountOp + "mfa."
+
+// MFAStatus возвращает состояние второго фактора пользователя
+func (s *AccountService) MFAStatus(ctx context.Context, userID uint64) (models.UserMFA, error) {
+	const op = mfaOp + "MFAStatus"
+
+	status, err := s.mfa.Status(ctx, userID)
+	if err != nil {
+		return models.UserMFA{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return status, nil
+}
+
+// EnrollTOTP создает секрет TOTP для привязки приложения-аутентификатора
+func (s *AccountService) EnrollTOTP(ctx context.Context, userID uint64) (models.TOTPEnrollment, error) {
+	const op = mfaOp + "EnrollTOTP"
+
+	user, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	enrollment, err := s.mfa.Enroll(ctx, user)
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return enrollment, nil
+}
+
+// ConfirmTOTP включает MFA и возвращает коды восстановления
+func (s *AccountService) ConfirmTOTP(ctx context.Context, userID uint64, code string) ([]string, error) {
+	const op = mfaOp + "ConfirmTOTP"
+
+	codes, err := s.mfa.Confirm(ctx, userID, code)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return codes, nil
+}
+
+// DisableMFA отключает MFA после проверки текущего кода
+func (s *AccountService) DisableMFA(ctx context.Context, userID uint64, code string) error {
+	const op = mfaOp + "DisableMFA"
+
+	if err := s.mfa.Disable(ctx, userID, code); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes выпускает новые коды восстановления, старые перестают действовать
+func (s *AccountService) RegenerateRecoveryCodes(ctx context.Context, userID uint64, code string) ([]string, error) {
+	const op = mfaOp + "RegenerateRecoveryCodes"
+
+	codes, err := s.mfa.RegenerateRecoveryCodes(ctx, userID, code)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return codes, nil
+}
@@ -0,0 +1,64 @@
+package account
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/webauthn"
+)
+
+const passkeysOp = accountOp + "passkeys."
+
+// ListPasskeys возвращает passkey пользователя
+func (s *AccountService) ListPasskeys(ctx context.Context, userID uint64) ([]models.WebAuthnCredential, error) {
+	const op = passkeysOp + "ListPasskeys"
+
+	creds, err := s.passkeys.ListPasskeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return creds, nil
+}
+
+// BeginPasskeyRegistration возвращает параметры navigator.credentials.create
+func (s *AccountService) BeginPasskeyRegistration(ctx context.Context, userID uint64) (webauthn.CreationOptions, error) {
+	const op = passkeysOp + "BeginPasskeyRegistration"
+
+	user, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return webauthn.CreationOptions{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	options, err := s.passkeys.BeginRegistration(ctx, user)
+	if err != nil {
+		return webauthn.CreationOptions{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return options, nil
+}
+
+// FinishPasskeyRegistration проверяет ответ аутентификатора и сохраняет passkey
+func (s *AccountService) FinishPasskeyRegistration(
+	ctx context.Context,
+	userID uint64,
+	name string,
+	resp webauthn.RegistrationResponse,
+) (models.WebAuthnCredential, error) {
+	const op = passkeysOp + "FinishPasskeyRegistration"
+
+	cred, err := s.passkeys.FinishRegistration(ctx, userID, name, resp)
+	if err != nil {
+		return models.WebAuthnCredential{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return cred, nil
+}
+
+// DeletePasskey удаляет passkey пользователя
+func (s *AccountService) DeletePasskey(ctx context.Context, userID uint64, id uint64) error {
+	const op = passkeysOp + "DeletePasskey"
+
+	if err := s.passkeys.DeletePasskey(ctx, userID, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
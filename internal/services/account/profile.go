@@ -0,0 +1,57 @@
+package account
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Grino777/sso/internal/domain/models"
+)
+
+const profileOp = accountOp + "profile."
+
+// GetProfile возвращает профиль пользователя
+func (s *AccountService) GetProfile(ctx context.Context, userID uint64) (models.Profile, error) {
+	const op = profileOp + "GetProfile"
+
+	profile, err := s.profile.Get(ctx, userID)
+	if err != nil {
+		return models.Profile{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return profile, nil
+}
+
+// UpdateProfile изменяет профиль пользователя
+func (s *AccountService) UpdateProfile(
+	ctx context.Context,
+	userID uint64,
+	update models.ProfileUpdate,
+) (models.Profile, error) {
+	const op = profileOp + "UpdateProfile"
+
+	profile, err := s.profile.Update(ctx, userID, update)
+	if err != nil {
+		return models.Profile{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return profile, nil
+}
+
+// SendEmailVerification повторно отправляет письмо для подтверждения email
+func (s *AccountService) SendEmailVerification(ctx context.Context, userID uint64) error {
+	const op = profileOp + "SendEmailVerification"
+
+	if err := s.profile.SendVerification(ctx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// VerifyEmail подтверждает email по токену из письма
+func (s *AccountService) VerifyEmail(ctx context.Context, token string) (models.Profile, error) {
+	const op = profileOp + "VerifyEmail"
+
+	profile, err := s.profile.VerifyEmail(ctx, token)
+	if err != nil {
+		return models.Profile{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return profile, nil
+}
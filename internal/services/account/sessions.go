@@ -0,0 +1,51 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Grino777/sso/internal/domain/models"
+)
+
+const sessionsOp = accountOp + "sessions."
+
+// ListSessions возвращает действующие сеансы пользователя во всех приложениях
+func (s *AccountService) ListSessions(ctx context.Context, userID uint64) ([]models.Session, error) {
+	const op = sessionsOp + "ListSessions"
+
+	sessions, err := s.db.ListSessions(ctx, models.SessionFilter{UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession отзывает сеанс пользователя. Выданные сеансу access токены
+// действуют до истечения срока, обновить их уже нельзя.
+func (s *AccountService) RevokeSession(ctx context.Context, userID uint64, sessionID string) error {
+	const op = sessionsOp + "RevokeSession"
+
+	if err := models.ValidateSessionID(sessionID); err != nil {
+		return err
+	}
+	if err := s.db.DeleteSession(ctx, userID, sessionID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.logger.Info("session revoked", slog.String("op", op), slog.Uint64("user_id", userID), slog.String("session_id", sessionID))
+	return nil
+}
+
+// RevokeOtherSessions отзывает все сеансы пользователя, кроме текущего, и возвращает их число
+func (s *AccountService) RevokeOtherSessions(ctx context.Context, userID uint64, current string) (int64, error) {
+	const op = sessionsOp + "RevokeOtherSessions"
+
+	revoked, err := s.db.DeleteUserSessions(ctx, userID, current)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.logger.Info("other sessions revoked", slog.String("op", op), slog.Uint64("user_id", userID), slog.Int64("count", revoked))
+	return revoked, nil
+}
@@ -1,17 +1,44 @@
+// Пакет для бизнес-логики административного API
 package admin
 
 import (
 	"log/slog"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	interfaces "github.com/Grino777/sso/internal/interfaces/storage"
+	"github.com/Grino777/sso/internal/lib/passhash"
+	"github.com/Grino777/sso/internal/services/password"
 )
 
 const adminOp = "services.admin."
 
 type AdminService struct {
 	logger *slog.Logger
+	db     interfaces.Storage
+	cache  interfaces.CacheStorage
+	hasher passhash.PasswordHasher
+	// Политика паролей, nil — проверяется только минимальная длина
+	passwords *password.PasswordService
+	// Требования к именам новых пользователей
+	usernames models.UsernamePolicy
 }
 
-func NewAdminService(log *slog.Logger) *AdminService {
+func NewAdminService(
+	log *slog.Logger,
+	db interfaces.Storage,
+	cache interfaces.CacheStorage,
+	hasher passhash.PasswordHasher,
+	passwords *password.PasswordService,
+	usernames models.UsernamePolicy,
+) *AdminService {
+	log.Debug("admin service successfully initialized")
+
 	return &AdminService{
-		logger: log,
+		logger:    log,
+		db:        db,
+		cache:     cache,
+		hasher:    hasher,
+		passwords: passwords,
+		usernames: usernames,
 	}
 }
@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/secretbox"
+	redisStorage "github.com/Grino777/sso/internal/storage/redis"
+	"github.com/Grino777/sso/internal/storage/sqlite"
+	"github.com/alicebob/miniredis/v2"
+)
+
+// testHasher хранит пароль открытым текстом, чтобы тесты не тратили время на argon2id
+type testHasher struct{}
+
+func (testHasher) Hash(password string) (string, error) { return "plain:" + password, nil }
+
+func (testHasher) Verify(hash []byte, password string) (bool, bool, error) {
+	return string(hash) == "plain:"+password, false, nil
+}
+
+// Суперпользователь, которого хранилище создает при подключении
+const rootUserID = 1
+
+// newTestService возвращает сервис на sqlite во временном файле и miniredis
+func newTestService(t *testing.T) (*AdminService, *sqlite.SQLiteStorage, *redisStorage.RedisStorage) {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	box, err := secretbox.New("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := sqlite.New(
+		"sqlite3",
+		filepath.Join(t.TempDir(), "sso.sqlite3"),
+		config.SuperUser{Username: "root", Password: "root-password"},
+		testHasher{},
+		box,
+		log,
+	)
+	if err := db.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close(context.Background()) })
+
+	mr := miniredis.RunT(t)
+	cache := redisStorage.NewRedisStorage(log, config.RedisConfig{
+		Addr:        mr.Addr(),
+		MaxRetries:  1,
+		DialTimeout: time.Second,
+		Timeout:     time.Second,
+	}, box, nil)
+	if err := cache.Connect(context.Background(), make(chan error, 1)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cache.Close(context.Background()) })
+
+	return NewAdminService(log, db, cache, testHasher{}, nil, models.UsernamePolicy{}), db, cache
+}
@@ -0,0 +1,328 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/generator"
+	"github.com/Grino777/sso/internal/lib/jwt"
+	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/google/uuid"
+)
+
+const appsOp = adminOp + "apps."
+
+const (
+	AppSecretLength = 48
+
+	// Сколько после ротации принимается предыдущий секрет, если период не указан
+	DefaultSecretOverlap = 24 * time.Hour
+	MaxSecretOverlap     = 7 * 24 * time.Hour
+)
+
+// ListApps возвращает все зарегистрированные приложения
+func (s *AdminService) ListApps(ctx context.Context) ([]models.App, error) {
+	const op = appsOp + "ListApps"
+
+	apps, err := s.db.ListApps(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return apps, nil
+}
+
+// GetApp возвращает приложение по id
+func (s *AdminService) GetApp(ctx context.Context, appID uint32) (models.App, error) {
+	const op = appsOp + "GetApp"
+
+	app, err := s.db.GetApp(ctx, appID)
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return app, nil
+}
+
+// CreateApp регистрирует приложение и генерирует для него секрет.
+// Секрет возвращается только здесь, получить его повторно нельзя.
+// Пустая политика членства означает open.
+func (s *AdminService) CreateApp(ctx context.Context, name, policy string) (models.App, error) {
+	const op = appsOp + "CreateApp"
+
+	log := s.logger.With(slog.String("op", op), slog.String("name", name))
+
+	if err := models.ValidateAppName(name); err != nil {
+		return models.App{}, err
+	}
+	if policy == "" {
+		policy = models.MembershipOpen
+	}
+	if err := models.ValidateMembershipPolicy(policy); err != nil {
+		return models.App{}, err
+	}
+
+	secret, err := generator.GenerateRandomString(AppSecretLength)
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app := models.App{
+		Name:             name,
+		Secret:           secret,
+		CreatedAt:        time.Now().UTC(),
+		MembershipPolicy: policy,
+	}
+	if app.ID, err = s.db.CreateApp(ctx, app); err != nil {
+		log.Error("failed to create app", logger.Error(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateApp(ctx, log, app.ID)
+
+	log.Info("app created", slog.Any("app_id", app.ID))
+	return app, nil
+}
+
+// AppUpdate изменяемые поля приложения, nil — поле не меняется.
+// Нулевой срок сбрасывает переопределение, и приложение использует глобальную настройку.
+type AppUpdate struct {
+	Name             *string
+	MembershipPolicy *string
+	RequireMFA       *bool
+	AccessTokenTTL   *time.Duration
+	RefreshTokenTTL  *time.Duration
+	IdleTimeout      *time.Duration
+	MaxSessionAge    *time.Duration
+	OfflineAccess    *bool
+	OfflineTokenTTL  *time.Duration
+}
+
+// UpdateApp изменяет название, политику членства, требование MFA, сроки сеансов и offline доступ приложения.
+// Запрет offline доступа не отзывает выданные offline сеансы сразу: они отклоняются при обновлении токенов.
+func (s *AdminService) UpdateApp(ctx context.Context, appID uint32, update AppUpdate) (models.App, error) {
+	const op = appsOp + "UpdateApp"
+
+	log := s.logger.With(slog.String("op", op), slog.Any("app_id", appID))
+
+	app, err := s.db.GetApp(ctx, appID)
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if update.Name != nil {
+		if err := models.ValidateAppName(*update.Name); err != nil {
+			return models.App{}, err
+		}
+		app.Name = *update.Name
+	}
+	if update.MembershipPolicy != nil {
+		if err := models.ValidateMembershipPolicy(*update.MembershipPolicy); err != nil {
+			return models.App{}, err
+		}
+		app.MembershipPolicy = *update.MembershipPolicy
+	}
+	if update.RequireMFA != nil {
+		app.RequireMFA = *update.RequireMFA
+	}
+	if update.OfflineAccess != nil {
+		app.OfflineAccess = *update.OfflineAccess
+	}
+	if err := update.applyLifetimes(&app.Lifetimes); err != nil {
+		return models.App{}, err
+	}
+
+	if err := s.db.UpdateApp(ctx, app); err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateApp(ctx, log, appID)
+
+	log.Info(
+		"app updated",
+		slog.String("membership_policy", app.Policy()),
+		slog.Bool("require_mfa", app.RequireMFA),
+		slog.Any("lifetimes", app.Lifetimes),
+		slog.Bool("offline_access", app.OfflineAccess),
+	)
+	return app, nil
+}
+
+// applyLifetimes применяет к срокам сеансов приложения переданные изменения
+func (u AppUpdate) applyLifetimes(lifetimes *models.SessionLifetimes) error {
+	updated := *lifetimes
+	for _, field := range []struct {
+		value *time.Duration
+		dst   *time.Duration
+	}{
+		{u.AccessTokenTTL, &updated.AccessTTL},
+		{u.RefreshTokenTTL, &updated.RefreshTTL},
+		{u.IdleTimeout, &updated.IdleTimeout},
+		{u.MaxSessionAge, &updated.MaxAge},
+		{u.OfflineTokenTTL, &updated.OfflineTTL},
+	} {
+		if field.value != nil {
+			*field.dst = *field.value
+		}
+	}
+
+	if err := updated.Validate(); err != nil {
+		return err
+	}
+	*lifetimes = updated
+	return nil
+}
+
+// RotateAppSecret генерирует новый секрет приложения.
+// Текущий секрет остается действительным в течение overlap,
+// чтобы клиенты успели перейти на новый. overlap = 0 отзывает его сразу.
+func (s *AdminService) RotateAppSecret(
+	ctx context.Context,
+	appID uint32,
+	overlap time.Duration,
+) (models.App, error) {
+	const op = appsOp + "RotateAppSecret"
+
+	log := s.logger.With(slog.String("op", op), slog.Any("app_id", appID))
+
+	if overlap < 0 || overlap > MaxSecretOverlap {
+		return models.App{}, &models.ValidationError{
+			Field:   "overlap",
+			Message: "overlap must be between 0 and " + MaxSecretOverlap.String(),
+		}
+	}
+
+	app, err := s.db.GetApp(ctx, appID)
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	secret, err := generator.GenerateRandomString(AppSecretLength)
+	if err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	app.PreviousSecret = ""
+	app.PreviousSecretExpiresAt = time.Time{}
+	if overlap > 0 && app.Secret != "" {
+		app.PreviousSecret = app.Secret
+		app.PreviousSecretExpiresAt = time.Now().UTC().Add(overlap)
+	}
+	app.Secret = secret
+
+	if err := s.db.UpdateAppSecret(ctx, app); err != nil {
+		log.Error("failed to save app secret", logger.Error(err))
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateApp(ctx, log, appID)
+
+	log.Info("app secret rotated", slog.Duration("overlap", overlap))
+	return app, nil
+}
+
+// DeleteApp удаляет приложение. Выданные ему refresh токены отзываются.
+func (s *AdminService) DeleteApp(ctx context.Context, appID uint32) error {
+	const op = appsOp + "DeleteApp"
+
+	log := s.logger.With(slog.String("op", op), slog.Any("app_id", appID))
+
+	if err := s.db.DeleteApp(ctx, appID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateApp(ctx, log, appID)
+
+	log.Info("app deleted")
+	return nil
+}
+
+// GetAppEncryptionKey возвращает текущий ключ шифрования токенов приложения
+func (s *AdminService) GetAppEncryptionKey(
+	ctx context.Context,
+	appID uint32,
+) (models.EncryptionKey, error) {
+	const op = appsOp + "GetAppEncryptionKey"
+
+	if _, err := s.db.GetApp(ctx, appID); err != nil {
+		return models.EncryptionKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key, err := s.db.GetAppEncryptionKey(ctx, appID)
+	if err != nil {
+		return models.EncryptionKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return key, nil
+}
+
+// SetAppEncryptionKey регистрирует новый ключ шифрования для приложения.
+// Предыдущий ключ (если был) деактивируется, что позволяет ротировать ключи.
+func (s *AdminService) SetAppEncryptionKey(
+	ctx context.Context,
+	appID uint32,
+	algorithm, publicKey string,
+) (models.EncryptionKey, error) {
+	const op = appsOp + "SetAppEncryptionKey"
+
+	log := s.logger.With(slog.String("op", op), slog.Any("app_id", appID))
+
+	key := models.EncryptionKey{
+		AppID:     appID,
+		Algorithm: algorithm,
+		PublicKey: publicKey,
+	}
+	if err := key.IsValid(); err != nil {
+		return models.EncryptionKey{}, err
+	}
+	if _, err := jwt.ParseEncryptionKey(publicKey, algorithm); err != nil {
+		return models.EncryptionKey{}, err
+	}
+
+	if _, err := s.db.GetApp(ctx, appID); err != nil {
+		return models.EncryptionKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return models.EncryptionKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+	key.ID = id.String()
+	key.CreatedAt = time.Now().UTC()
+
+	if err := s.db.SaveAppEncryptionKey(ctx, key); err != nil {
+		log.Error("failed to save encryption key", logger.Error(err))
+		return models.EncryptionKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateApp(ctx, log, appID)
+
+	log.Info("app encryption key registered", slog.String("kid", key.ID))
+	return key, nil
+}
+
+// DisableAppEncryption отключает шифрование токенов для приложения
+func (s *AdminService) DisableAppEncryption(
+	ctx context.Context,
+	appID uint32,
+) error {
+	const op = appsOp + "DisableAppEncryption"
+
+	log := s.logger.With(slog.String("op", op), slog.Any("app_id", appID))
+
+	if err := s.db.DeleteAppEncryptionKey(ctx, appID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateApp(ctx, log, appID)
+
+	log.Info("app encryption disabled")
+	return nil
+}
+
+// invalidateApp удаляет приложение из кэша, чтобы изменения применились сразу
+func (s *AdminService) invalidateApp(ctx context.Context, log *slog.Logger, appID uint32) {
+	if err := s.cache.DeleteApp(ctx, appID); err != nil {
+		log.Warn("failed to invalidate cached app", logger.Error(err))
+	}
+}
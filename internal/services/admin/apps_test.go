@@ -0,0 +1,112 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/storage"
+	redisStorage "github.com/Grino777/sso/internal/storage/redis"
+)
+
+func TestRotateAppSecret(t *testing.T) {
+	s, _, cache := newTestService(t)
+	ctx := context.Background()
+
+	app, err := s.CreateApp(ctx, "app", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(app.Secret) != AppSecretLength || app.Policy() != models.MembershipOpen {
+		t.Fatalf("created app = %+v", app)
+	}
+	first := app.Secret
+
+	// Ротация удаляет приложение из кэша, старый секрет действует до конца перекрытия
+	if err := cache.SaveApp(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := s.RotateAppSecret(ctx, app.ID, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.GetApp(ctx, app.ID); !errors.Is(err, redisStorage.ErrCacheNotFound) {
+		t.Fatalf("cached app after rotation: err = %v, want ErrCacheNotFound", err)
+	}
+
+	stored, err := s.GetApp(ctx, app.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Secret != rotated.Secret || stored.Secret == first {
+		t.Fatalf("stored secret %q, rotated %q", stored.Secret, rotated.Secret)
+	}
+	now := time.Now()
+	if accepted := stored.AcceptedSecrets(now); !slices.Equal(accepted, []string{rotated.Secret, first}) {
+		t.Fatalf("accepted secrets during overlap = %q", accepted)
+	}
+	if accepted := stored.AcceptedSecrets(now.Add(2 * time.Hour)); !slices.Equal(accepted, []string{rotated.Secret}) {
+		t.Fatalf("accepted secrets after overlap = %q", accepted)
+	}
+
+	// Без перекрытия предыдущий секрет отзывается сразу
+	if _, err := s.RotateAppSecret(ctx, app.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ = s.GetApp(ctx, app.ID); len(stored.AcceptedSecrets(now)) != 1 {
+		t.Fatalf("accepted secrets without overlap = %q", stored.AcceptedSecrets(now))
+	}
+
+	var valErr *models.ValidationError
+	if _, err := s.RotateAppSecret(ctx, app.ID, MaxSecretOverlap+time.Hour); !errors.As(err, &valErr) {
+		t.Fatalf("too long overlap: err = %v, want ValidationError", err)
+	}
+}
+
+func TestApps__Lifecycle(t *testing.T) {
+	s, _, cache := newTestService(t)
+	ctx := context.Background()
+
+	var valErr *models.ValidationError
+	if _, err := s.CreateApp(ctx, "app", "closed"); !errors.As(err, &valErr) {
+		t.Fatalf("unknown policy: err = %v, want ValidationError", err)
+	}
+
+	app, err := s.CreateApp(ctx, "app", models.MembershipInviteOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.SaveApp(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	name, offline := "renamed", true
+	updated, err := s.UpdateApp(ctx, app.ID, AppUpdate{Name: &name, OfflineAccess: &offline})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != name || !updated.OfflineAccess || updated.Policy() != models.MembershipInviteOnly {
+		t.Fatalf("updated app = %+v", updated)
+	}
+	if _, err := cache.GetApp(ctx, app.ID); !errors.Is(err, redisStorage.ErrCacheNotFound) {
+		t.Fatalf("cached app after update: err = %v, want ErrCacheNotFound", err)
+	}
+
+	apps, err := s.ListApps(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(apps) != 1 || apps[0].Name != name || apps[0].Secret != app.Secret {
+		t.Fatalf("apps = %+v", apps)
+	}
+
+	if err := s.DeleteApp(ctx, app.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetApp(ctx, app.ID); !errors.Is(err, storage.ErrAppNotFound) {
+		t.Fatalf("deleted app: err = %v, want ErrAppNotFound", err)
+	}
+}
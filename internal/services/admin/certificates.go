@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/storage"
+	"github.com/Grino777/sso/internal/utils/certs"
+)
+
+// GetAppCertificates возвращает клиентские сертификаты приложения
+func (s *AdminService) GetAppCertificates(
+	ctx context.Context,
+	appID uint32,
+) ([]models.AppCertificate, error) {
+	const op = appsOp + "GetAppCertificates"
+
+	if _, err := s.db.GetApp(ctx, appID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	appCerts, err := s.db.GetAppCertificates(ctx, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return appCerts, nil
+}
+
+// AddAppCertificate регистрирует клиентский сертификат (PEM) для mTLS аутентификации приложения
+func (s *AdminService) AddAppCertificate(
+	ctx context.Context,
+	appID uint32,
+	certPEM string,
+) (models.AppCertificate, error) {
+	const op = appsOp + "AddAppCertificate"
+
+	log := s.logger.With(slog.String("op", op), slog.Any("app_id", appID))
+
+	parsed, err := certs.ParseCertificatePEM([]byte(certPEM))
+	if err != nil {
+		return models.AppCertificate{}, err
+	}
+
+	now := time.Now().UTC()
+	if now.After(parsed.NotAfter) {
+		return models.AppCertificate{}, fmt.Errorf("%w: certificate expired", certs.ErrInvalidCertificate)
+	}
+
+	cert := models.AppCertificate{
+		AppID:      appID,
+		Thumbprint: certs.Thumbprint(parsed),
+		Subject:    parsed.Subject.String(),
+		NotAfter:   parsed.NotAfter.UTC(),
+		CreatedAt:  now,
+	}
+	if err := cert.IsValid(); err != nil {
+		return models.AppCertificate{}, err
+	}
+
+	if _, err := s.db.GetApp(ctx, appID); err != nil {
+		return models.AppCertificate{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.SaveAppCertificate(ctx, cert); err != nil {
+		return models.AppCertificate{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateApp(ctx, log, appID)
+
+	log.Info("app certificate registered", slog.String("thumbprint", cert.Thumbprint))
+	return cert, nil
+}
+
+// DeleteAppCertificate отзывает клиентский сертификат приложения
+func (s *AdminService) DeleteAppCertificate(
+	ctx context.Context,
+	appID uint32,
+	thumbprint string,
+) error {
+	const op = appsOp + "DeleteAppCertificate"
+
+	log := s.logger.With(slog.String("op", op), slog.Any("app_id", appID))
+
+	if err := s.db.DeleteAppCertificate(ctx, appID, thumbprint); err != nil {
+		if !errors.Is(err, storage.ErrCertificateNotFound) {
+			log.Error("failed to delete app certificate", logger.Error(err))
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateApp(ctx, log, appID)
+
+	log.Info("app certificate removed", slog.String("thumbprint", thumbprint))
+	return nil
+}
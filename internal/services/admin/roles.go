@@ -0,0 +1,249 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/Grino777/sso/internal/domain/models"
+)
+
+const rolesOp = adminOp + "roles."
+
+var (
+	ErrBuiltinRole       = errors.New("builtin role cannot be deleted")
+	ErrBuiltinPermission = errors.New("builtin permission cannot be deleted")
+)
+
+// ListRoles возвращает все роли с их правами
+func (s *AdminService) ListRoles(ctx context.Context) ([]models.Role, error) {
+	const op = rolesOp + "ListRoles"
+
+	roles, err := s.db.ListRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return roles, nil
+}
+
+// GetRole возвращает роль с ее правами
+func (s *AdminService) GetRole(ctx context.Context, roleID int) (models.Role, error) {
+	const op = rolesOp + "GetRole"
+
+	role, err := s.db.GetRole(ctx, roleID)
+	if err != nil {
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return role, nil
+}
+
+// CreateRole создает роль с набором прав. Права должны быть зарегистрированы заранее.
+func (s *AdminService) CreateRole(
+	ctx context.Context,
+	name, description string,
+	permissions []string,
+) (models.Role, error) {
+	const op = rolesOp + "CreateRole"
+
+	log := s.logger.With(slog.String("op", op), slog.String("role", name))
+
+	if err := models.ValidateRoleName(name); err != nil {
+		return models.Role{}, err
+	}
+	permissions, err := normalizePermissions(permissions)
+	if err != nil {
+		return models.Role{}, err
+	}
+
+	role := models.Role{Name: name, Description: description, Permissions: permissions}
+	if role.ID, err = s.db.CreateRole(ctx, role); err != nil {
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("role created", slog.Int("role_id", role.ID), slog.Any("permissions", permissions))
+	return role, nil
+}
+
+// SetRolePermissions заменяет набор прав роли.
+// Изменения попадают в токены при следующем входе или обновлении токена.
+func (s *AdminService) SetRolePermissions(
+	ctx context.Context,
+	roleID int,
+	permissions []string,
+) (models.Role, error) {
+	const op = rolesOp + "SetRolePermissions"
+
+	log := s.logger.With(slog.String("op", op), slog.Int("role_id", roleID))
+
+	permissions, err := normalizePermissions(permissions)
+	if err != nil {
+		return models.Role{}, err
+	}
+
+	if err := s.db.SetRolePermissions(ctx, roleID, permissions); err != nil {
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("role permissions changed", slog.Any("permissions", permissions))
+	return s.GetRole(ctx, roleID)
+}
+
+// DeleteRole удаляет пользовательскую роль
+func (s *AdminService) DeleteRole(ctx context.Context, roleID int) error {
+	const op = rolesOp + "DeleteRole"
+
+	log := s.logger.With(slog.String("op", op), slog.Int("role_id", roleID))
+
+	role := models.Role{ID: roleID}
+	if role.IsBuiltin() {
+		return ErrBuiltinRole
+	}
+
+	if err := s.db.DeleteRole(ctx, roleID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("role deleted")
+	return nil
+}
+
+// ListPermissions возвращает все зарегистрированные права
+func (s *AdminService) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	const op = rolesOp + "ListPermissions"
+
+	permissions, err := s.db.ListPermissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return permissions, nil
+}
+
+// CreatePermission регистрирует право
+func (s *AdminService) CreatePermission(
+	ctx context.Context,
+	name, description string,
+) (models.Permission, error) {
+	const op = rolesOp + "CreatePermission"
+
+	log := s.logger.With(slog.String("op", op), slog.String("permission", name))
+
+	if err := models.ValidatePermissionName(name); err != nil {
+		return models.Permission{}, err
+	}
+
+	permission := models.Permission{Name: name, Description: description}
+
+	var err error
+	if permission.ID, err = s.db.CreatePermission(ctx, permission); err != nil {
+		return models.Permission{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("permission created", slog.Int("permission_id", permission.ID))
+	return permission, nil
+}
+
+// DeletePermission удаляет право и отзывает его у всех ролей
+func (s *AdminService) DeletePermission(ctx context.Context, permissionID int) error {
+	const op = rolesOp + "DeletePermission"
+
+	log := s.logger.With(slog.String("op", op), slog.Int("permission_id", permissionID))
+
+	permissions, err := s.db.ListPermissions(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	for _, permission := range permissions {
+		if permission.ID == permissionID && permission.Name == models.PermissionAdmin {
+			return ErrBuiltinPermission
+		}
+	}
+
+	if err := s.db.DeletePermission(ctx, permissionID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("permission deleted")
+	return nil
+}
+
+// SetUserAppRole назначает пользователю роль в приложении, roleID = 0 снимает назначение.
+// Встроенные роли выше собственной actor назначить не может.
+func (s *AdminService) SetUserAppRole(
+	ctx context.Context,
+	actor Actor,
+	userID uint64,
+	appID uint32,
+	roleID int,
+) error {
+	const op = rolesOp + "SetUserAppRole"
+
+	log := s.logger.With(
+		slog.String("op", op),
+		slog.Uint64("user_id", userID),
+		slog.Any("app_id", appID),
+		slog.Int("role_id", roleID),
+	)
+
+	if _, err := s.manageableUser(ctx, actor, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := s.db.GetApp(ctx, appID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if roleID != 0 {
+		role, err := s.db.GetRole(ctx, roleID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if role.IsBuiltin() && !actor.canManage(role.ID) {
+			return ErrForbidden
+		}
+	}
+
+	if err := s.db.SetUserAppRole(ctx, userID, appID, roleID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user app role changed")
+	return nil
+}
+
+// GetUserAccess возвращает роли и права пользователя в приложении
+func (s *AdminService) GetUserAccess(
+	ctx context.Context,
+	userID uint64,
+	appID uint32,
+) (models.Access, error) {
+	const op = rolesOp + "GetUserAccess"
+
+	if _, err := s.db.GetUserByID(ctx, userID); err != nil {
+		return models.Access{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := s.db.GetApp(ctx, appID); err != nil {
+		return models.Access{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	roles, err := s.db.GetUserRoles(ctx, userID, appID)
+	if err != nil {
+		return models.Access{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return models.NewAccess(roles), nil
+}
+
+// normalizePermissions проверяет имена прав и убирает повторы
+func normalizePermissions(permissions []string) ([]string, error) {
+	result := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		if err := models.ValidatePermissionName(permission); err != nil {
+			return nil, err
+		}
+		if !slices.Contains(result, permission) {
+			result = append(result, permission)
+		}
+	}
+	slices.Sort(result)
+	return result, nil
+}
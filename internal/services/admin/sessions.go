@@ -0,0 +1,123 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/storage"
+)
+
+const sessionsOp = adminOp + "sessions."
+
+// ListUserSessions возвращает действующие сеансы пользователя во всех приложениях.
+// offlineOnly — только сеансы, выданные по запросу offline_access.
+func (s *AdminService) ListUserSessions(ctx context.Context, userID uint64, offlineOnly bool) ([]models.Session, error) {
+	const op = sessionsOp + "ListUserSessions"
+
+	if _, err := s.db.GetUserByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	filter := models.SessionFilter{UserID: userID}
+	if offlineOnly {
+		filter.Offline = &offlineOnly
+	}
+	sessions, err := s.db.ListSessions(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return sessions, nil
+}
+
+// GetUserSession возвращает действующий сеанс пользователя.
+// Отозванный или истекший сеанс не найден (storage.ErrSessionNotFound).
+func (s *AdminService) GetUserSession(ctx context.Context, userID uint64, sessionID string) (models.Session, error) {
+	const op = sessionsOp + "GetUserSession"
+
+	sessions, err := s.db.ListSessions(ctx, models.SessionFilter{UserID: userID, SessionID: sessionID})
+	if err != nil {
+		return models.Session{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(sessions) == 0 {
+		return models.Session{}, fmt.Errorf("%s: %w", op, storage.ErrSessionNotFound)
+	}
+	return sessions[0], nil
+}
+
+// RevokeUserSession отзывает сеанс пользователя
+func (s *AdminService) RevokeUserSession(
+	ctx context.Context,
+	actor Actor,
+	userID uint64,
+	sessionID string,
+) error {
+	const op = sessionsOp + "RevokeUserSession"
+
+	if err := models.ValidateSessionID(sessionID); err != nil {
+		return err
+	}
+	if _, err := s.manageableUser(ctx, actor, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.DeleteSession(ctx, userID, sessionID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.logger.Info(
+		"user session revoked",
+		slog.String("op", op),
+		slog.Uint64("user_id", userID),
+		slog.String("session_id", sessionID),
+		slog.String("actor", actor.Username),
+	)
+	return nil
+}
+
+// RevokeUserSessions отзывает все сеансы пользователя и возвращает их число
+func (s *AdminService) RevokeUserSessions(ctx context.Context, actor Actor, userID uint64) (int64, error) {
+	const op = sessionsOp + "RevokeUserSessions"
+
+	if _, err := s.manageableUser(ctx, actor, userID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	revoked, err := s.db.DeleteUserSessions(ctx, userID, "")
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.logger.Info(
+		"user sessions revoked",
+		slog.String("op", op),
+		slog.Uint64("user_id", userID),
+		slog.Int64("count", revoked),
+		slog.String("actor", actor.Username),
+	)
+	return revoked, nil
+}
+
+// RevokeUserOfflineGrants отзывает offline сеансы пользователя, обычные сеансы сохраняются
+func (s *AdminService) RevokeUserOfflineGrants(ctx context.Context, actor Actor, userID uint64) (int64, error) {
+	const op = sessionsOp + "RevokeUserOfflineGrants"
+
+	if _, err := s.manageableUser(ctx, actor, userID); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	revoked, err := s.db.DeleteOfflineSessions(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.logger.Info(
+		"user offline grants revoked",
+		slog.String("op", op),
+		slog.Uint64("user_id", userID),
+		slog.Int64("count", revoked),
+		slog.String("actor", actor.Username),
+	)
+	return revoked, nil
+}
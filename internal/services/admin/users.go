@@ -0,0 +1,422 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/generator"
+	"github.com/Grino777/sso/internal/lib/logger"
+)
+
+const usersOp = adminOp + "users."
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+
+	tempPasswordLength = 16
+	// Число попыток создать временный пароль, соответствующий политике паролей
+	tempPasswordAttempts = 10
+)
+
+var (
+	ErrForbidden   = errors.New("insufficient privileges")
+	ErrSelfAction  = errors.New("operation is not allowed on own account")
+	ErrInvalidRole = errors.New("invalid role")
+)
+
+// Actor администратор, выполняющий операцию
+type Actor struct {
+	UserID   uint64
+	Username string
+	RoleID   int
+}
+
+// canManage проверяет, может ли actor управлять пользователями с ролью roleID.
+// superadmin управляет всеми, остальные — только пользователями с ролью ниже своей.
+func (a Actor) canManage(roleID int) bool {
+	return a.RoleID == models.RoleSuperAdmin || roleID < a.RoleID
+}
+
+// UserUpdate изменяемые поля пользователя, nil — поле не меняется
+type UserUpdate struct {
+	Username *string
+	RoleID   *int
+}
+
+// ListUsers возвращает страницу пользователей и общее число найденных
+func (s *AdminService) ListUsers(
+	ctx context.Context,
+	filter models.UserFilter,
+) ([]models.User, int, error) {
+	const op = usersOp + "ListUsers"
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit > MaxPageSize {
+		filter.Limit = MaxPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	users, total, err := s.db.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return users, total, nil
+}
+
+// GetUser возвращает пользователя по id
+func (s *AdminService) GetUser(
+	ctx context.Context,
+	userID uint64,
+) (models.User, error) {
+	const op = usersOp + "GetUser"
+
+	user, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return user, nil
+}
+
+// CreateUser создает пользователя с указанной ролью (по умолчанию user)
+func (s *AdminService) CreateUser(
+	ctx context.Context,
+	actor Actor,
+	username, password string,
+	roleID int,
+) (models.User, error) {
+	const op = usersOp + "CreateUser"
+
+	username = s.usernames.Normalize(username)
+	log := s.logger.With(slog.String("op", op), slog.String("username", username))
+
+	if err := s.usernames.Validate(username); err != nil {
+		return models.User{}, err
+	}
+
+	if roleID == 0 {
+		roleID = models.RoleUser
+	}
+	if err := validateRole(roleID); err != nil {
+		return models.User{}, err
+	}
+	if !actor.canManage(roleID) {
+		return models.User{}, ErrForbidden
+	}
+
+	user := models.User{Username: username, Password: password, Role_id: roleID}
+	if err := s.validatePassword(ctx, user, password); err != nil {
+		return models.User{}, err
+	}
+
+	passHash, err := s.hasher.Hash(password)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	user.PassHash = []byte(passHash)
+
+	user.ID, err = s.db.CreateUser(ctx, user)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user created", slog.Uint64("user_id", user.ID), slog.Int("role_id", roleID))
+	return s.GetUser(ctx, user.ID)
+}
+
+// UpdateUser изменяет имя и/или роль пользователя
+func (s *AdminService) UpdateUser(
+	ctx context.Context,
+	actor Actor,
+	userID uint64,
+	update UserUpdate,
+) (models.User, error) {
+	const op = usersOp + "UpdateUser"
+
+	log := s.logger.With(slog.String("op", op), slog.Uint64("user_id", userID))
+
+	user, err := s.manageableUser(ctx, actor, userID)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	oldUsername := user.Username
+
+	if update.Username != nil {
+		username := s.usernames.Normalize(*update.Username)
+		if err := s.usernames.Validate(username); err != nil {
+			return models.User{}, err
+		}
+		user.Username = username
+	}
+	if update.RoleID != nil {
+		if err := validateRole(*update.RoleID); err != nil {
+			return models.User{}, err
+		}
+		if !actor.canManage(*update.RoleID) {
+			return models.User{}, ErrForbidden
+		}
+		if userID == actor.UserID && *update.RoleID != user.Role_id {
+			return models.User{}, ErrSelfAction
+		}
+		user.Role_id = *update.RoleID
+	}
+
+	if err := s.db.UpdateUser(ctx, user); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateUser(ctx, log, oldUsername)
+
+	log.Info("user updated", slog.String("username", user.Username), slog.Int("role_id", user.Role_id))
+	return user, nil
+}
+
+// SetUserDisabled отключает или включает учетную запись.
+// При отключении отзываются все refresh токены пользователя.
+func (s *AdminService) SetUserDisabled(
+	ctx context.Context,
+	actor Actor,
+	userID uint64,
+	disabled bool,
+) error {
+	const op = usersOp + "SetUserDisabled"
+
+	log := s.logger.With(slog.String("op", op), slog.Uint64("user_id", userID))
+
+	if userID == actor.UserID {
+		return ErrSelfAction
+	}
+
+	user, err := s.manageableUser(ctx, actor, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.SetUserDisabled(ctx, userID, disabled); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if disabled {
+		if err := s.db.DeleteUserRefreshTokens(ctx, userID); err != nil {
+			log.Error("failed to revoke refresh tokens", logger.Error(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	s.invalidateUser(ctx, log, user.Username)
+
+	log.Info("user status changed", slog.Bool("disabled", disabled))
+	return nil
+}
+
+// UnlockUser снимает блокировку входа пользователя, в том числе
+// временную блокировку после серии неудачных попыток
+func (s *AdminService) UnlockUser(
+	ctx context.Context,
+	actor Actor,
+	userID uint64,
+) error {
+	const op = usersOp + "UnlockUser"
+
+	log := s.logger.With(slog.String("op", op), slog.Uint64("user_id", userID))
+
+	user, err := s.manageableUser(ctx, actor, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.UnlockUser(ctx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.cache.ClearLockout(ctx, models.UserLockoutSubject(user.Username)); err != nil {
+		log.Warn("failed to clear login lockout", logger.Error(err))
+	}
+
+	s.invalidateUser(ctx, log, user.Username)
+
+	log.Info("user unlocked")
+	return nil
+}
+
+// ResetUserMFA отключает второй фактор пользователя, например при утере устройства.
+// Секрет TOTP и коды восстановления удаляются.
+func (s *AdminService) ResetUserMFA(
+	ctx context.Context,
+	actor Actor,
+	userID uint64,
+) error {
+	const op = usersOp + "ResetUserMFA"
+
+	log := s.logger.With(slog.String("op", op), slog.Uint64("user_id", userID))
+
+	if _, err := s.manageableUser(ctx, actor, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.DeleteUserMFA(ctx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user mfa reset", slog.String("actor", actor.Username))
+	return nil
+}
+
+// ResetPassword устанавливает пользователю новый пароль и отзывает его refresh токены.
+// Если password пуст, генерируется временный пароль, который возвращается вызывающему.
+func (s *AdminService) ResetPassword(
+	ctx context.Context,
+	actor Actor,
+	userID uint64,
+	password string,
+) (string, error) {
+	const op = usersOp + "ResetPassword"
+
+	log := s.logger.With(slog.String("op", op), slog.Uint64("user_id", userID))
+
+	user, err := s.manageableUser(ctx, actor, userID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	generated := ""
+	if password == "" {
+		if password, err = s.tempPassword(ctx, user); err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		generated = password
+	} else if err := s.validatePassword(ctx, user, password); err != nil {
+		return "", err
+	}
+
+	passHash, err := s.hasher.Hash(password)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.UpdateUserPassword(ctx, userID, []byte(passHash)); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.db.DeleteUserRefreshTokens(ctx, userID); err != nil {
+		log.Error("failed to revoke refresh tokens", logger.Error(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateUser(ctx, log, user.Username)
+
+	log.Info("user password reset")
+	return generated, nil
+}
+
+// DeleteUser удаляет пользователя
+func (s *AdminService) DeleteUser(
+	ctx context.Context,
+	actor Actor,
+	userID uint64,
+) error {
+	const op = usersOp + "DeleteUser"
+
+	log := s.logger.With(slog.String("op", op), slog.Uint64("user_id", userID))
+
+	if userID == actor.UserID {
+		return ErrSelfAction
+	}
+
+	user, err := s.manageableUser(ctx, actor, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.DeleteUser(ctx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.invalidateUser(ctx, log, user.Username)
+
+	log.Info("user deleted", slog.String("username", user.Username))
+	return nil
+}
+
+// ListUserLogins возвращает последние попытки входа пользователя
+func (s *AdminService) ListUserLogins(
+	ctx context.Context,
+	userID uint64,
+	limit int,
+) ([]models.LoginRecord, error) {
+	const op = usersOp + "ListUserLogins"
+
+	if _, err := s.db.GetUserByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	records, err := s.db.ListLoginRecords(ctx, userID, models.ClampLoginsLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return records, nil
+}
+
+// manageableUser возвращает пользователя, если actor имеет право им управлять
+func (s *AdminService) manageableUser(
+	ctx context.Context,
+	actor Actor,
+	userID uint64,
+) (models.User, error) {
+	user, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.User{}, err
+	}
+	if userID != actor.UserID && !actor.canManage(user.Role_id) {
+		return models.User{}, ErrForbidden
+	}
+	return user, nil
+}
+
+// validatePassword проверяет новый пароль пользователя по политике паролей
+func (s *AdminService) validatePassword(ctx context.Context, user models.User, password string) error {
+	if s.passwords == nil {
+		check := models.User{Username: user.Username, Password: password}
+		return check.IsValid()
+	}
+	if err := models.ValidateUsername(user.Username); err != nil {
+		return err
+	}
+	return s.passwords.Validate(ctx, user, password)
+}
+
+// tempPassword создает случайный временный пароль. Пароль может не содержать символов
+// классов, которых требует политика, поэтому он создается заново, пока не пройдет проверку.
+func (s *AdminService) tempPassword(ctx context.Context, user models.User) (string, error) {
+	var err error
+	for range tempPasswordAttempts {
+		var password string
+		if password, err = generator.GenerateRandomString(tempPasswordLength); err != nil {
+			return "", err
+		}
+		if err = s.validatePassword(ctx, user, password); err == nil {
+			return password, nil
+		}
+	}
+	return "", err
+}
+
+// invalidateUser удаляет пользователя из кэша всех приложений
+func (s *AdminService) invalidateUser(ctx context.Context, log *slog.Logger, username string) {
+	if err := s.cache.DeleteUser(ctx, username); err != nil {
+		log.Warn("failed to invalidate cached user", logger.Error(err))
+	}
+}
+
+func validateRole(roleID int) error {
+	switch roleID {
+	case models.RoleUser, models.RoleAdmin, models.RoleSuperAdmin:
+		return nil
+	default:
+		return ErrInvalidRole
+	}
+}
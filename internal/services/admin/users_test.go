@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/storage"
+	redisStorage "github.com/Grino777/sso/internal/storage/redis"
+)
+
+var testAdmin = Actor{UserID: rootUserID, Username: "root", RoleID: models.RoleSuperAdmin}
+
+func TestUsers__Lifecycle(t *testing.T) {
+	s, db, cache := newTestService(t)
+	ctx := context.Background()
+
+	user, err := s.CreateUser(ctx, testAdmin, "alice", "password1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role_id != models.RoleUser || string(user.PassHash) != "plain:password1" {
+		t.Fatalf("created user = %+v", user)
+	}
+	if _, err := s.CreateUser(ctx, testAdmin, "alice", "password1", 0); !errors.Is(err, storage.ErrUserExist) {
+		t.Fatalf("duplicate user: err = %v, want ErrUserExist", err)
+	}
+
+	users, total, err := s.ListUsers(ctx, models.UserFilter{Username: "ali"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(users) != 1 || users[0].ID != user.ID {
+		t.Fatalf("list: total = %d, users = %+v", total, users)
+	}
+
+	// Изменение пользователя удаляет его из кэша
+	if _, err := cache.SaveUser(ctx, user, 1); err != nil {
+		t.Fatal(err)
+	}
+	username := "alice2"
+	if _, err := s.UpdateUser(ctx, testAdmin, user.ID, UserUpdate{Username: &username}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.GetUser(ctx, "alice", 1); !errors.Is(err, redisStorage.ErrCacheNotFound) {
+		t.Fatalf("cached user after update: err = %v, want ErrCacheNotFound", err)
+	}
+
+	if err := s.SetUserDisabled(ctx, testAdmin, user.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	disabled := true
+	if _, total, err := s.ListUsers(ctx, models.UserFilter{Disabled: &disabled}); err != nil || total != 1 {
+		t.Fatalf("disabled users: total = %d, err = %v", total, err)
+	}
+
+	password, err := s.ResetPassword(ctx, testAdmin, user.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := db.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password == "" || string(stored.PassHash) != "plain:"+password {
+		t.Fatalf("temporary password %q does not match hash %q", password, stored.PassHash)
+	}
+
+	if err := s.DeleteUser(ctx, testAdmin, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetUser(ctx, user.ID); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Fatalf("deleted user: err = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestUsers__Privileges(t *testing.T) {
+	s, _, _ := newTestService(t)
+	ctx := context.Background()
+
+	admin, err := s.CreateUser(ctx, testAdmin, "admin", "password1", models.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actor := Actor{UserID: admin.ID, Username: admin.Username, RoleID: admin.Role_id}
+
+	// Администратор управляет только пользователями с ролью ниже своей
+	if _, err := s.CreateUser(ctx, actor, "admin2", "password1", models.RoleAdmin); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("create admin: err = %v, want ErrForbidden", err)
+	}
+	if err := s.SetUserDisabled(ctx, actor, rootUserID, true); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("disable superadmin: err = %v, want ErrForbidden", err)
+	}
+	if err := s.DeleteUser(ctx, actor, admin.ID); !errors.Is(err, ErrSelfAction) {
+		t.Fatalf("delete self: err = %v, want ErrSelfAction", err)
+	}
+
+	user, err := s.CreateUser(ctx, actor, "bob", "password1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetUserDisabled(ctx, actor, user.ID, true); err != nil {
+		t.Fatal(err)
+	}
+}
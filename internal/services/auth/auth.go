@@ -6,18 +6,32 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/Grino777/sso/internal/config"
 	"github.com/Grino777/sso/internal/domain/models"
 	interfaces "github.com/Grino777/sso/internal/interfaces/storage"
+	"github.com/Grino777/sso/internal/lib/dpop"
 	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/lib/notify"
+	"github.com/Grino777/sso/internal/lib/passhash"
+	"github.com/Grino777/sso/internal/lib/reqctx"
 	keysModels "github.com/Grino777/sso/internal/services/keys/models"
+	"github.com/Grino777/sso/internal/services/mfa"
+	"github.com/Grino777/sso/internal/services/passkeys"
+	"github.com/Grino777/sso/internal/services/password"
 	"github.com/Grino777/sso/internal/storage"
-	authU "github.com/Grino777/sso/internal/utils/auth"
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrInvalidDPoPProof    = errors.New("invalid DPoP proof")
+	ErrCertificateMismatch = errors.New("client certificate does not match bound token")
+	ErrUserDisabled        = errors.New("user is disabled")
+	ErrUserLocked          = errors.New("user is locked")
+	ErrAppAccessDenied     = errors.New("user is not allowed to access the app")
+	ErrPasswordExpired     = errors.New("password has expired")
 )
 
 type KeysStore interface {
@@ -30,7 +44,30 @@ type AuthService struct {
 	DB        interfaces.Storage
 	Cache     interfaces.CacheStorage
 	Tokens    config.TTLConfig
+	DPoP      config.DPoPConfig
+	Lockout   config.LockoutConfig
+	MFAConfig config.MFAConfig
 	KeysStore KeysStore
+	Hasher    passhash.PasswordHasher
+	// Второй фактор, nil — вход только по паролю
+	MFA *mfa.MFAService
+	// Вход по passkey, nil — отключен
+	Passkeys *passkeys.PasskeyService
+	// Журнал попыток входа, nil — попытки не записываются
+	Logins *LoginRecorder
+	// Политика паролей, nil — проверяется только минимальная длина
+	Passwords *password.PasswordService
+	// Требования к именам новых пользователей
+	Usernames models.UsernamePolicy
+	// Ограничение числа сеансов пользователя в приложении
+	Sessions config.SessionsConfig
+	// Доставка кодов входа без пароля, nil — вход без пароля отключен
+	Notifier notify.Notifier
+	// Отправлять письма на имя пользователя, если оно является адресом, а подтвержденного email нет
+	UsernameAsAddress bool
+	Passwordless      config.PasswordlessConfig
+	PasswordReset     config.PasswordResetConfig
+	dpop              *dpop.Validator
 }
 
 func NewAuthService(
@@ -40,20 +77,49 @@ func NewAuthService(
 	authConfigs.Logger.Debug("auth service successfully initialized")
 
 	return &AuthService{
-		Logger:    authConfigs.Logger,
-		DB:        authConfigs.DB,
-		Cache:     authConfigs.Cache,
-		Tokens:    authConfigs.Tokens,
-		KeysStore: keysStore,
+		Logger:            authConfigs.Logger,
+		DB:                authConfigs.DB,
+		Cache:             authConfigs.Cache,
+		Tokens:            authConfigs.Tokens,
+		DPoP:              authConfigs.DPoP,
+		Lockout:           authConfigs.Lockout,
+		MFAConfig:         authConfigs.MFAConfig,
+		KeysStore:         keysStore,
+		Hasher:            authConfigs.Hasher,
+		MFA:               authConfigs.MFA,
+		Passkeys:          authConfigs.Passkeys,
+		Logins:            authConfigs.Logins,
+		Passwords:         authConfigs.Passwords,
+		Usernames:         authConfigs.Usernames,
+		Sessions:          authConfigs.Sessions,
+		Notifier:          authConfigs.Notifier,
+		UsernameAsAddress: authConfigs.UsernameAsAddress,
+		Passwordless:      authConfigs.Passwordless,
+		PasswordReset:     authConfigs.PasswordReset,
+		dpop:              dpop.NewValidator(authConfigs.DPoP.ProofTTL, authConfigs.DPoP.ClockSkew),
 	}
 }
 
+// Login проверяет учетные данные и выпускает токены для приложения.
+// Каждая попытка входа записывается в журнал асинхронно.
 func (s *AuthService) Login(
 	ctx context.Context,
 	username string,
 	password string,
 	appID uint32,
 ) (models.Tokens, error) {
+	tokens, userID, err := s.login(ctx, username, password, appID)
+	s.recordLogin(ctx, userID, username, appID, err)
+	return tokens, err
+}
+
+// login выполняет вход и возвращает id пользователя (0, если он не найден)
+func (s *AuthService) login(
+	ctx context.Context,
+	username string,
+	password string,
+	appID uint32,
+) (models.Tokens, uint64, error) {
 	const op = "services.auth.Login"
 
 	log := s.Logger.With(
@@ -64,40 +130,167 @@ func (s *AuthService) Login(
 	err := ValidateData(ctx, username, password, appID)
 	if err != nil {
 		log.Error("%s:%w", op, err)
-		return models.Tokens{}, err
+		return models.Tokens{}, 0, err
+	}
+
+	username, err = s.resolveUsername(ctx, username)
+	if err != nil {
+		return models.Tokens{}, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.checkLockout(ctx, username); err != nil {
+		return models.Tokens{}, 0, err
 	}
 
 	user, err := s.GetCachedUser(ctx, username, appID)
 	if err != nil {
 		log.Error("%s: %w", op, err)
-		return models.Tokens{}, err
+		if errors.Is(err, ErrInvalidCredentials) {
+			s.registerLoginFailure(ctx, username)
+		}
+		return models.Tokens{}, 0, err
 	}
 
-	if err := s.validatePassword(user.PassHash, password); err != nil {
-		return models.Tokens{}, err
+	if err := s.validatePassword(ctx, user, password); err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			s.registerLoginFailure(ctx, username)
+		}
+		return models.Tokens{}, user.ID, err
+	}
+
+	if err := checkUserStatus(user); err != nil {
+		log.Warn("login rejected", logger.Error(err))
+		return models.Tokens{}, user.ID, err
 	}
 
 	app, err := s.GetCachedApp(ctx, appID)
 	if err != nil {
 		log.Error("%s: %w", op, err)
-		return models.Tokens{}, err
+		return models.Tokens{}, user.ID, err
 	}
 
-	user, err = s.generateUserTokens(ctx, user, app)
+	if s.Passwords != nil && s.Passwords.Expired(user) {
+		log.Warn("login rejected", logger.Error(ErrPasswordExpired))
+		return models.Tokens{}, user.ID, s.requirePasswordChange(ctx, user, app.ID)
+	}
+
+	if err := s.checkAppAccess(ctx, user, app); err != nil {
+		log.Warn("login rejected", logger.Error(err))
+		return models.Tokens{}, user.ID, err
+	}
+
+	cnf, err := s.confirmDPoP(ctx, nil)
 	if err != nil {
-		return models.Tokens{}, err
+		log.Warn("dpop proof rejected", logger.Error(err))
+		return models.Tokens{}, user.ID, err
+	}
+
+	cnf, err = s.confirmCertificate(ctx, app, nil, cnf)
+	if err != nil {
+		return models.Tokens{}, user.ID, err
+	}
+
+	// Счетчики неудачных попыток сбрасываются только после второго фактора
+	if err := s.requireMFA(ctx, user, app, cnf); err != nil {
+		return models.Tokens{}, user.ID, err
+	}
+	s.resetLoginFailures(ctx, username)
+
+	offline := s.offlineAccess(log, app, reqctx.FromContext(ctx).OfflineAccess)
+	user, err = s.generateUserTokens(ctx, user, app, cnf, offline)
+	if err != nil {
+		return models.Tokens{}, user.ID, err
 	}
 
 	_, err = s.Cache.SaveUser(ctx, user, appID)
 	if err != nil {
 		log.Error("%s: %w", op, err)
-		return models.Tokens{}, err
+		return models.Tokens{}, user.ID, err
 	}
 
-	// TODO loging user login
-
 	log.Info("logged is successfully")
-	return user.Tokens, nil
+	return user.Tokens, user.ID, nil
+}
+
+// resolveUsername возвращает имя пользователя, под которым выполняется вход.
+// Вместо имени можно указать подтвержденный email; совпадение с именем пользователя
+// имеет приоритет, чтобы чужой email не перехватывал вход по имени.
+func (s *AuthService) resolveUsername(ctx context.Context, login string) (string, error) {
+	username := s.Usernames.Normalize(login)
+	if models.ValidateEmail(models.NormalizeEmail(login)) != nil {
+		return username, nil
+	}
+
+	_, err := s.DB.GetUser(ctx, username)
+	if err == nil {
+		return username, nil
+	}
+	if !errors.Is(err, storage.ErrUserNotFound) {
+		return "", err
+	}
+
+	user, err := s.DB.GetUserByEmail(ctx, models.NormalizeEmail(login))
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return username, nil
+		}
+		return "", err
+	}
+	return user.Username, nil
+}
+
+// completeLogin завершает вход пользователя, личность которого уже подтверждена
+// (passkey, код из письма): проверяет статус учетной записи и доступ к приложению,
+// привязывает токены к ключу клиента и выпускает их. Если первый фактор не считается
+// достаточным (singleFactor), вход требует второй фактор, если он включен.
+func (s *AuthService) completeLogin(
+	ctx context.Context,
+	log *slog.Logger,
+	user models.User,
+	appID uint32,
+	singleFactor bool,
+) (models.User, uint32, error) {
+	if err := checkUserStatus(user); err != nil {
+		log.Warn("login rejected", logger.Error(err))
+		return user, appID, err
+	}
+
+	app, err := s.GetCachedApp(ctx, appID)
+	if err != nil {
+		return user, appID, err
+	}
+	if err := s.checkAppAccess(ctx, user, app); err != nil {
+		log.Warn("login rejected", logger.Error(err))
+		return user, app.ID, err
+	}
+
+	cnf, err := s.confirmDPoP(ctx, nil)
+	if err != nil {
+		log.Warn("dpop proof rejected", logger.Error(err))
+		return user, app.ID, err
+	}
+	cnf, err = s.confirmCertificate(ctx, app, nil, cnf)
+	if err != nil {
+		return user, app.ID, err
+	}
+
+	if singleFactor {
+		if err := s.requireMFA(ctx, user, app, cnf); err != nil {
+			return user, app.ID, err
+		}
+	}
+	s.resetLoginFailures(ctx, user.Username)
+
+	offline := s.offlineAccess(log, app, reqctx.FromContext(ctx).OfflineAccess)
+	user, err = s.generateUserTokens(ctx, user, app, cnf, offline)
+	if err != nil {
+		return user, app.ID, err
+	}
+
+	if _, err := s.Cache.SaveUser(ctx, user, app.ID); err != nil {
+		log.Error("failed to cache user", logger.Error(err))
+	}
+	return user, app.ID, nil
 }
 
 func (s *AuthService) Register(
@@ -107,11 +300,16 @@ func (s *AuthService) Register(
 ) error {
 	const op = "services.auth.Register"
 
+	username = s.Usernames.Normalize(username)
 	log := s.Logger.With(
 		slog.String("op", op),
 		slog.String("username", username),
 	)
 
+	if err := s.Usernames.Validate(username); err != nil {
+		return err
+	}
+
 	// First check if the user exists in the database.
 	_, err := s.DB.GetUser(ctx, username)
 	if err != nil {
@@ -124,13 +322,13 @@ func (s *AuthService) Register(
 		return storage.ErrUserExist
 	}
 
-	err = ValidateUser(username, password)
+	err = s.validateNewPassword(ctx, models.User{Username: username}, password)
 	if err != nil {
 		log.Error("%s:%w", op, err)
 		return err
 	}
 
-	passHash, err := authU.CreatePassHash(password)
+	passHash, err := s.Hasher.Hash(password)
 	if err != nil {
 		log.Error("failed to generate pass hash %w", logger.Error(err))
 		return fmt.Errorf("%s: %w", op, err)
@@ -153,20 +351,167 @@ func (s *AuthService) Logout(
 	panic("implement me")
 }
 
-// FIXME
+// IsAdmin проверяет, есть ли у пользователя право администратора в приложении
 func (s *AuthService) IsAdmin(
 	ctx context.Context,
 	username string,
+	appID uint32,
 ) (isAdmin bool, err error) {
-	panic("implement me")
+	return s.HasPermission(ctx, username, appID, models.PermissionAdmin)
 }
 
-// FIXME
+// HasPermission проверяет право пользователя в приложении с учетом
+// глобальной роли и роли, назначенной в приложении.
+// У отключенного пользователя прав нет.
+func (s *AuthService) HasPermission(
+	ctx context.Context,
+	username string,
+	appID uint32,
+	permission string,
+) (bool, error) {
+	const op = "services.auth.HasPermission"
+
+	log := s.Logger.With(
+		slog.String("op", op),
+		slog.String("username", username),
+		slog.Any("app_id", appID),
+		slog.String("permission", permission),
+	)
+
+	if err := models.ValidateUsername(username); err != nil {
+		return false, err
+	}
+	if err := ValidateApp(appID); err != nil {
+		return false, err
+	}
+	if err := models.ValidatePermissionName(permission); err != nil {
+		return false, err
+	}
+
+	user, err := s.DB.GetUser(ctx, username)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return false, err
+		}
+		log.Error("failed to get user", logger.Error(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.Disabled {
+		return false, nil
+	}
+
+	roles, err := s.DB.GetUserRoles(ctx, user.ID, appID)
+	if err != nil {
+		log.Error("failed to get user roles", logger.Error(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return models.NewAccess(roles).HasPermission(permission), nil
+}
+
+// RefreshToken выпускает новую пару токенов по refresh токену.
+// Использованный refresh токен заменяется новым в том же сеансе.
+// Сеанс, простаивавший дольше idle timeout или превысивший максимальный срок приложения, завершается,
+// как и offline сеанс, если приложению больше не разрешен offline доступ.
+// Если токен привязан к ключу DPoP, требуется proof тем же ключом.
 func (s *AuthService) RefreshToken(
 	ctx context.Context,
-	token, expired_at string,
-) (*models.Token, error) {
-	panic("implement me")
+	token string,
+	appID uint32,
+) (models.Tokens, error) {
+	const op = "services.auth.RefreshToken"
+
+	log := s.Logger.With(
+		slog.String("op", op),
+		slog.Any("app_id", appID),
+	)
+
+	if token == "" {
+		return models.Tokens{}, &models.ValidationError{Field: "refresh_token", Message: models.EmptyField}
+	}
+	if err := ValidateApp(appID); err != nil {
+		return models.Tokens{}, err
+	}
+
+	info, err := s.DB.GetRefreshToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			log.Warn("refresh token not found")
+			return models.Tokens{}, ErrInvalidRefreshToken
+		}
+		log.Error("failed to get refresh token", logger.Error(err))
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if info.AppID != appID {
+		log.Warn("refresh token issued for another app")
+		return models.Tokens{}, ErrInvalidRefreshToken
+	}
+	if time.Now().UTC().Unix() >= info.Token.Expire_at {
+		log.Debug("refresh token expired")
+		if err := s.DB.DeleteRefreshToken(ctx, info.UserID, info.AppID, info.Token); err != nil {
+			log.Error("failed to delete expired refresh token", logger.Error(err))
+		}
+		return models.Tokens{}, ErrInvalidRefreshToken
+	}
+
+	cnf, err := s.confirmDPoP(ctx, info.Token.Cnf)
+	if err != nil {
+		log.Warn("dpop proof rejected", logger.Error(err))
+		return models.Tokens{}, err
+	}
+
+	user, err := s.DB.GetUserByID(ctx, info.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return models.Tokens{}, ErrInvalidRefreshToken
+		}
+		return models.Tokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := checkUserStatus(user); err != nil {
+		log.Warn("refresh rejected", logger.Error(err))
+		return models.Tokens{}, err
+	}
+
+	app, err := s.GetCachedApp(ctx, appID)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	// Сроки приложения могли сократиться, а offline доступ — быть запрещен после выпуска токена,
+	// поэтому они проверяются здесь, а не только по сроку действия refresh токена
+	expired := s.lifetimes(app).For(info.Session).Expired(info.Session, time.Now().UTC())
+	if expired || (info.Session.Offline && !app.OfflineAccess) {
+		log.Info("session expired", slog.String("session_id", info.Session.ID), slog.Bool("offline", info.Session.Offline))
+		if err := s.DB.DeleteSession(ctx, info.UserID, info.Session.ID); err != nil && !errors.Is(err, storage.ErrSessionNotFound) {
+			log.Error("failed to delete expired session", logger.Error(err))
+		}
+		return models.Tokens{}, ErrInvalidRefreshToken
+	}
+
+	if err := s.checkAppAccess(ctx, user, app); err != nil {
+		log.Warn("refresh rejected", logger.Error(err))
+		return models.Tokens{}, err
+	}
+
+	cnf, err = s.confirmCertificate(ctx, app, info.Token.Cnf, cnf)
+	if err != nil {
+		log.Warn("client certificate rejected", logger.Error(err))
+		return models.Tokens{}, err
+	}
+
+	user, err = s.refreshUserTokens(ctx, user, app, info, cnf)
+	if err != nil {
+		return models.Tokens{}, err
+	}
+
+	if _, err := s.Cache.SaveUser(ctx, user, appID); err != nil {
+		log.Error("failed to cache user", logger.Error(err))
+	}
+
+	log.Info("tokens refreshed", slog.String("username", user.Username))
+	return user.Tokens, nil
 }
 
 // func (s *AuthService) GetApp(
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/secretbox"
+	keysModels "github.com/Grino777/sso/internal/services/keys/models"
+	redisStorage "github.com/Grino777/sso/internal/storage/redis"
+	"github.com/Grino777/sso/internal/storage/sqlite"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-jose/go-jose/v4"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newTestCache возвращает кэш на miniredis; время miniredis сдвигается через FastForward
+func newTestCache(t *testing.T) (*redisStorage.RedisStorage, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	cache := redisStorage.NewRedisStorage(testLogger(), config.RedisConfig{
+		Addr:        mr.Addr(),
+		MaxRetries:  1,
+		DialTimeout: time.Second,
+		Timeout:     time.Second,
+	}, testSecrets(t), nil)
+	if err := cache.Connect(context.Background(), make(chan error, 1)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cache.Close(context.Background()) })
+	return cache, mr
+}
+
+// testSecrets шифрование секретов приложений на тестовом ключе
+func testSecrets(t *testing.T) *secretbox.Box {
+	t.Helper()
+
+	box, err := secretbox.New("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return box
+}
+
+func TestGetCachedApp__SecretsEncrypted(t *testing.T) {
+	cache, mr := newTestCache(t)
+	s := NewAuthService(AuthService{Logger: testLogger(), Cache: cache}, nil)
+	ctx := context.Background()
+
+	app := models.App{ID: 1, Name: "app", Secret: "app-secret", PreviousSecret: "old-secret"}
+	if err := cache.SaveApp(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	// Секреты приложения не попадают в кэш в открытом виде
+	raw, err := mr.Get("apps:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(raw, "app-secret") || strings.Contains(raw, "old-secret") {
+		t.Fatalf("cached app exposes secrets: %s", raw)
+	}
+
+	cached, err := s.GetCachedApp(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.Secret != "app-secret" || cached.PreviousSecret != "old-secret" {
+		t.Fatalf("secrets = %q, %q", cached.Secret, cached.PreviousSecret)
+	}
+}
+
+// testKeysStore один ключ подписи токенов
+type testKeysStore struct{ key *keysModels.PrivateKey }
+
+func (ks testKeysStore) GetLatestPrivateKey() (*keysModels.PrivateKey, error) { return ks.key, nil }
+
+func (ks testKeysStore) GenerateNewKeys() (*keysModels.PrivateKey, error) { return ks.key, nil }
+
+func newTestKeysStore(t *testing.T) testKeysStore {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKeysStore{key: &keysModels.PrivateKey{ID: "test-key", Key: key, CreatedAt: time.Now()}}
+}
+
+const (
+	testUsername = "alice"
+	testPassword = "password1"
+)
+
+// newLoginService возвращает сервис на sqlite во временном файле и miniredis
+// с пользователем testUsername и id этого пользователя
+func newLoginService(t *testing.T) (*AuthService, *sqlite.SQLiteStorage, uint64) {
+	t.Helper()
+
+	db := sqlite.New(
+		"sqlite3",
+		filepath.Join(t.TempDir(), "sso.sqlite3"),
+		config.SuperUser{Username: "root", Password: "root-password"},
+		testHasher{},
+		testSecrets(t),
+		testLogger(),
+	)
+	if err := db.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close(context.Background()) })
+
+	if err := db.SaveUser(context.Background(), testUsername, "plain:"+testPassword); err != nil {
+		t.Fatal(err)
+	}
+	user, err := db.GetUser(context.Background(), testUsername)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache, _ := newTestCache(t)
+	lockout := false
+
+	return NewAuthService(AuthService{
+		Logger:  testLogger(),
+		DB:      db,
+		Cache:   cache,
+		Hasher:  testHasher{},
+		Lockout: config.LockoutConfig{Enabled: &lockout},
+		Tokens: config.TTLConfig{
+			TokenTTL:        time.Minute,
+			RefreshTokenTTL: time.Hour,
+		},
+	}, newTestKeysStore(t)), db, user.ID
+}
+
+// createTestApp создает приложение и возвращает его id
+func createTestApp(t *testing.T, db *sqlite.SQLiteStorage, app models.App) uint32 {
+	t.Helper()
+
+	app.Secret, app.CreatedAt = "app-secret", time.Now()
+	appID, err := db.CreateApp(context.Background(), app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return appID
+}
+
+func TestLogin__EncryptedAccessToken(t *testing.T) {
+	s, db, _ := newLoginService(t)
+	ctx := context.Background()
+
+	plainApp := createTestApp(t, db, models.App{Name: "plain"})
+	encryptedApp := createTestApp(t, db, models.App{Name: "encrypted"})
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveAppEncryptionKey(ctx, models.EncryptionKey{
+		ID:        "app-key",
+		AppID:     encryptedApp,
+		Algorithm: models.KeyAlgECDHES,
+		PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		CreatedAt: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Приложение без ключа получает обычный JWS
+	tokens, err := s.Login(ctx, testUsername, testPassword, plainApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parts := strings.Count(tokens.AccessToken.Token, "."); parts != 2 {
+		t.Fatalf("plain app token has %d dots, want JWS", parts)
+	}
+
+	// Приложение с ключом получает JWE, внутри которого подписанный JWT
+	tokens, err = s.Login(ctx, testUsername, testPassword, encryptedApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object, err := jose.ParseEncrypted(
+		tokens.AccessToken.Token,
+		[]jose.KeyAlgorithm{jose.ECDH_ES},
+		[]jose.ContentEncryption{jose.A256GCM},
+	)
+	if err != nil {
+		t.Fatalf("encrypted app token is not a JWE: %v", err)
+	}
+	if object.Header.KeyID != "app-key" {
+		t.Fatalf("kid = %q, want app-key", object.Header.KeyID)
+	}
+	signed, err := object.Decrypt(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parts := strings.Count(string(signed), "."); parts != 2 {
+		t.Fatalf("decrypted token %q is not a JWS", signed)
+	}
+}
+
+func TestLogin__VerifiedEmail(t *testing.T) {
+	s, db, userID := newLoginService(t)
+	appID := createTestApp(t, db, models.App{Name: "app"})
+	ctx := context.Background()
+
+	if err := db.SaveProfile(ctx, models.Profile{UserID: userID, Email: "alice@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Вход по email возможен только после его подтверждения
+	if _, err := s.Login(ctx, "Alice@Example.com", testPassword, appID); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("unverified email: err = %v, want ErrInvalidCredentials", err)
+	}
+	if err := db.VerifyProfileEmail(ctx, userID, "alice@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Login(ctx, "Alice@Example.com", testPassword, appID); err != nil {
+		t.Fatal(err)
+	}
+}
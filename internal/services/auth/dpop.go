@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/dpop"
+	"github.com/Grino777/sso/internal/lib/reqctx"
+)
+
+const dpopOp = "services.auth.dpop."
+
+// confirmDPoP проверяет DPoP proof из запроса и возвращает привязку для новых токенов.
+// Если bound не nil, proof обязателен и должен быть подписан тем же ключом.
+// Без proof и без привязки, а также при выключенном DPoP возвращается nil (обычные bearer токены).
+func (s *AuthService) confirmDPoP(
+	ctx context.Context,
+	bound *models.Confirmation,
+) (*models.Confirmation, error) {
+	const op = dpopOp + "confirmDPoP"
+
+	boundJKT := ""
+	if bound != nil {
+		boundJKT = bound.JKT
+	}
+
+	info := reqctx.FromContext(ctx)
+	if info.DPoP == "" || !s.DPoP.IsEnabled() {
+		if boundJKT != "" {
+			return nil, fmt.Errorf("%s: %w: proof required for bound token", op, ErrInvalidDPoPProof)
+		}
+		return nil, nil
+	}
+
+	// Access токен в запросах к SSO не передается, поэтому ath не проверяется
+	proof, err := s.dpop.Validate(info.DPoP, info.Method, info.URL, "")
+	if err != nil {
+		if errors.Is(err, dpop.ErrInvalidProof) {
+			return nil, fmt.Errorf("%s: %w: %v", op, ErrInvalidDPoPProof, err)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if boundJKT != "" && proof.JKT != boundJKT {
+		return nil, fmt.Errorf("%s: %w: key does not match bound token", op, ErrInvalidDPoPProof)
+	}
+
+	fresh, err := s.Cache.SaveNonce(ctx, "dpop:"+proof.JKT, proof.JTI, s.dpop.TTL())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !fresh {
+		return nil, fmt.Errorf("%s: %w: jti already used", op, ErrInvalidDPoPProof)
+	}
+
+	return &models.Confirmation{JKT: proof.JKT}, nil
+}
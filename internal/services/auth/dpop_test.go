@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/reqctx"
+	"github.com/go-jose/go-jose/v4"
+)
+
+const dpopTestURL = "/auth.Auth/RefreshToken"
+
+func newDPoPService(t *testing.T) *AuthService {
+	t.Helper()
+	cache, _ := newTestCache(t)
+	return NewAuthService(AuthService{
+		Logger: testLogger(),
+		Cache:  cache,
+		DPoP:   config.DPoPConfig{ProofTTL: time.Minute, ClockSkew: 5 * time.Second},
+	}, nil)
+}
+
+func dpopProof(t *testing.T, key *ecdsa.PrivateKey, jti string) string {
+	t.Helper()
+
+	opts := (&jose.SignerOptions{EmbedJWK: true}).WithType("dpop+jwt")
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := json.Marshal(map[string]any{
+		"jti": jti,
+		"htm": http.MethodPost,
+		"htu": dpopTestURL,
+		"iat": time.Now().Unix(),
+	})
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := jws.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+func dpopContext(proof string) context.Context {
+	return reqctx.WithInfo(context.Background(), reqctx.Info{
+		DPoP:   proof,
+		Method: http.MethodPost,
+		URL:    dpopTestURL,
+	})
+}
+
+func TestConfirmDPoP(t *testing.T) {
+	s := newDPoPService(t)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	// Без proof и без привязки выпускаются обычные bearer токены
+	cnf, err := s.confirmDPoP(context.Background(), nil)
+	if err != nil || cnf != nil {
+		t.Fatalf("no proof: cnf = %v, err = %v", cnf, err)
+	}
+
+	cnf, err = s.confirmDPoP(dpopContext(dpopProof(t, key, "jti-login-1")), nil)
+	if err != nil || cnf == nil || cnf.JKT == "" {
+		t.Fatalf("valid proof: cnf = %v, err = %v", cnf, err)
+	}
+	bound := cnf
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		bound *models.Confirmation
+	}{
+		{"bound token without proof", context.Background(), bound},
+		{"proof signed by another key", dpopContext(dpopProof(t, otherKey, "jti-other-1")), bound},
+		{"replayed jti", dpopContext(dpopProof(t, key, "jti-login-1")), bound},
+		{"invalid proof", dpopContext("not-a-jwt"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.confirmDPoP(tt.ctx, tt.bound)
+			if !errors.Is(err, ErrInvalidDPoPProof) {
+				t.Fatalf("err = %v, want ErrInvalidDPoPProof", err)
+			}
+		})
+	}
+
+	cnf, err = s.confirmDPoP(dpopContext(dpopProof(t, key, "jti-refresh-1")), bound)
+	if err != nil || cnf.JKT != bound.JKT {
+		t.Fatalf("proof from the bound key: cnf = %v, err = %v", cnf, err)
+	}
+}
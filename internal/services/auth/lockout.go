@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/lib/reqctx"
+)
+
+const lockoutOp = "services.auth.lockout."
+
+var ErrTooManyAttempts = errors.New("too many failed login attempts")
+
+// LockoutError возвращается, пока вход заблокирован после серии неудачных попыток
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return ErrTooManyAttempts.Error()
+}
+
+func (e *LockoutError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
+// lockoutSubjects возвращает субъекты счетчиков попытки входа и их пороги
+func (s *AuthService) lockoutSubjects(ctx context.Context, username string) map[string]int64 {
+	subjects := map[string]int64{
+		models.UserLockoutSubject(username): s.Lockout.MaxUserAttempts,
+	}
+	if ip := reqctx.FromContext(ctx).ClientIP; ip != "" {
+		if parsed := net.ParseIP(ip); parsed != nil {
+			ip = parsed.String()
+		}
+		subjects[models.IPLockoutSubject(ip)] = s.Lockout.MaxIPAttempts
+	}
+	return subjects
+}
+
+// checkLockout возвращает LockoutError, если вход по имени пользователя
+// или с адреса клиента заблокирован. При недоступности Redis вход разрешается.
+func (s *AuthService) checkLockout(ctx context.Context, username string) error {
+	const op = lockoutOp + "checkLockout"
+
+	if !s.Lockout.IsEnabled() {
+		return nil
+	}
+
+	log := s.Logger.With(slog.String("op", op), slog.String("username", username))
+
+	now := time.Now()
+	var retryAfter time.Duration
+	for subject := range s.lockoutSubjects(ctx, username) {
+		status, err := s.Cache.GetLockout(ctx, subject)
+		if err != nil {
+			log.Warn("failed to get lockout status", logger.Error(err))
+			continue
+		}
+		retryAfter = max(retryAfter, status.RetryAfter(now))
+	}
+
+	if retryAfter > 0 {
+		log.Warn("login rejected: too many failed attempts", slog.Duration("retry_after", retryAfter))
+		return &LockoutError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// registerLoginFailure учитывает неудачную попытку входа. По достижении порога
+// вход блокируется на BaseLockout * 2^(strikes-1), но не больше MaxLockout.
+func (s *AuthService) registerLoginFailure(ctx context.Context, username string) {
+	const op = lockoutOp + "registerLoginFailure"
+
+	if !s.Lockout.IsEnabled() {
+		return
+	}
+
+	log := s.Logger.With(slog.String("op", op), slog.String("username", username))
+
+	for subject, limit := range s.lockoutSubjects(ctx, username) {
+		failures, err := s.Cache.IncrLoginFailures(ctx, subject, s.Lockout.Window)
+		if err != nil {
+			log.Warn("failed to count login failure", logger.Error(err))
+			continue
+		}
+		if limit <= 0 || failures < limit {
+			continue
+		}
+
+		strikes, err := s.Cache.IncrLockoutStrikes(ctx, subject, s.Lockout.StrikesTTL)
+		if err != nil {
+			log.Warn("failed to count lockout", logger.Error(err))
+			continue
+		}
+
+		duration := lockoutDuration(s.Lockout.BaseLockout, s.Lockout.MaxLockout, strikes)
+		if err := s.Cache.SetLockout(ctx, subject, time.Now().Add(duration)); err != nil {
+			log.Warn("failed to set lockout", logger.Error(err))
+			continue
+		}
+		log.Warn("login locked out",
+			slog.String("subject", subject),
+			slog.Int64("strikes", strikes),
+			slog.Duration("duration", duration),
+		)
+	}
+}
+
+// resetLoginFailures сбрасывает счетчики имени пользователя после успешного входа.
+// Счетчик адреса не сбрасывается, чтобы удачный вход в одну учетную запись
+// не открывал перебор остальных.
+func (s *AuthService) resetLoginFailures(ctx context.Context, username string) {
+	const op = lockoutOp + "resetLoginFailures"
+
+	if !s.Lockout.IsEnabled() {
+		return
+	}
+
+	if err := s.Cache.ClearLockout(ctx, models.UserLockoutSubject(username)); err != nil {
+		s.Logger.Warn("failed to reset login failures",
+			slog.String("op", op),
+			slog.String("username", username),
+			logger.Error(err),
+		)
+	}
+}
+
+// lockoutDuration возвращает длительность блокировки с номером strikes
+func lockoutDuration(base, maxDuration time.Duration, strikes int64) time.Duration {
+	duration := base
+	for i := int64(1); i < strikes && duration < maxDuration; i++ {
+		duration *= 2
+	}
+	return min(duration, maxDuration)
+}
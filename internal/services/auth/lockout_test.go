@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/reqctx"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newLockoutService(t *testing.T, cfg config.LockoutConfig) (*AuthService, *miniredis.Miniredis) {
+	t.Helper()
+	cache, mr := newTestCache(t)
+	return NewAuthService(AuthService{Logger: testLogger(), Cache: cache, Lockout: cfg}, nil), mr
+}
+
+func clientContext(ip string) context.Context {
+	return reqctx.WithInfo(context.Background(), reqctx.Info{ClientIP: ip})
+}
+
+// assertRetryAfter проверяет блокировку с точностью до секунды хранения срока в Redis
+func assertRetryAfter(t *testing.T, err error, want time.Duration) {
+	t.Helper()
+
+	var lockout *LockoutError
+	if !errors.As(err, &lockout) {
+		t.Fatalf("err = %v, want LockoutError", err)
+	}
+	if lockout.RetryAfter > want || lockout.RetryAfter < want-2*time.Second {
+		t.Fatalf("retry after = %s, want about %s", lockout.RetryAfter, want)
+	}
+}
+
+func TestLockout__Escalation(t *testing.T) {
+	s, mr := newLockoutService(t, config.LockoutConfig{
+		MaxUserAttempts: 3,
+		Window:          15 * time.Minute,
+		BaseLockout:     time.Minute,
+		MaxLockout:      3 * time.Minute,
+		StrikesTTL:      24 * time.Hour,
+	})
+	ctx := clientContext("203.0.113.7")
+
+	for _, want := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+		for i := 0; i < 2; i++ {
+			s.registerLoginFailure(ctx, "alice")
+			if err := s.checkLockout(ctx, "alice"); err != nil {
+				t.Fatalf("locked out after %d failures: %v", i+1, err)
+			}
+		}
+		s.registerLoginFailure(ctx, "alice")
+		assertRetryAfter(t, s.checkLockout(ctx, "Alice"), want)
+
+		mr.FastForward(want + time.Second)
+		if err := s.checkLockout(ctx, "alice"); err != nil {
+			t.Fatalf("lockout not lifted after %s: %v", want, err)
+		}
+	}
+
+	// Успешный вход сбрасывает счетчики имени пользователя вместе с числом блокировок
+	s.resetLoginFailures(ctx, "alice")
+	for i := 0; i < 3; i++ {
+		s.registerLoginFailure(ctx, "alice")
+	}
+	assertRetryAfter(t, s.checkLockout(ctx, "alice"), time.Minute)
+}
+
+func TestLockout__FailuresWindow(t *testing.T) {
+	s, mr := newLockoutService(t, config.LockoutConfig{
+		MaxUserAttempts: 3,
+		Window:          time.Minute,
+		BaseLockout:     time.Minute,
+		MaxLockout:      time.Hour,
+		StrikesTTL:      time.Hour,
+	})
+	ctx := clientContext("")
+
+	s.registerLoginFailure(ctx, "bob")
+	s.registerLoginFailure(ctx, "bob")
+	key := "lockout:" + models.UserLockoutSubject("bob") + ":failures"
+	if ttl := mr.TTL(key); ttl <= 0 || ttl > time.Minute {
+		t.Fatalf("failures ttl = %s, want window", ttl)
+	}
+
+	// Окно отсчитывается от первой неудачи и не продлевается следующими
+	mr.FastForward(time.Minute)
+	s.registerLoginFailure(ctx, "bob")
+	if err := s.checkLockout(ctx, "bob"); err != nil {
+		t.Fatalf("failures outside the window locked the user: %v", err)
+	}
+
+	// Счетчик, оставшийся без срока хранения, получает окно при следующей неудаче
+	mr.Set(key, "1")
+	s.registerLoginFailure(ctx, "bob")
+	if ttl := mr.TTL(key); ttl <= 0 {
+		t.Fatalf("failures counter without ttl was not repaired")
+	}
+}
+
+func TestLockout__ClientIP(t *testing.T) {
+	s, _ := newLockoutService(t, config.LockoutConfig{
+		MaxUserAttempts: 100,
+		MaxIPAttempts:   3,
+		Window:          15 * time.Minute,
+		BaseLockout:     time.Minute,
+		MaxLockout:      time.Hour,
+		StrikesTTL:      time.Hour,
+	})
+	attacker := clientContext("203.0.113.7")
+
+	for _, username := range []string{"alice", "bob", "carol"} {
+		s.registerLoginFailure(attacker, username)
+	}
+	assertRetryAfter(t, s.checkLockout(attacker, "dave"), time.Minute)
+
+	// Удачный вход не сбрасывает счетчик адреса, другие адреса не затронуты
+	s.resetLoginFailures(attacker, "alice")
+	assertRetryAfter(t, s.checkLockout(attacker, "alice"), time.Minute)
+	if err := s.checkLockout(clientContext("198.51.100.1"), "dave"); err != nil {
+		t.Fatalf("other address locked out: %v", err)
+	}
+}
+
+func TestLockout__Disabled(t *testing.T) {
+	disabled := false
+	s, _ := newLockoutService(t, config.LockoutConfig{
+		Enabled:         &disabled,
+		MaxUserAttempts: 1,
+		Window:          time.Minute,
+		BaseLockout:     time.Minute,
+		MaxLockout:      time.Hour,
+	})
+	ctx := clientContext("203.0.113.7")
+
+	s.registerLoginFailure(ctx, "alice")
+	if err := s.checkLockout(ctx, "alice"); err != nil {
+		t.Fatalf("disabled lockout blocked login: %v", err)
+	}
+}
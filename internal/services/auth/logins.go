@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	interfaces "github.com/Grino777/sso/internal/interfaces/storage"
+	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/lib/reqctx"
+	"github.com/Grino777/sso/internal/services/mfa"
+	"github.com/Grino777/sso/internal/services/passkeys"
+	"github.com/Grino777/sso/internal/storage"
+)
+
+const loginsOp = "services.auth.logins."
+
+// Размер очереди записей о входе, при переполнении записи отбрасываются
+const DefaultLoginQueueSize = 1024
+
+// LoginRecorder асинхронно сохраняет попытки входа в users_logs,
+// чтобы запись в БД не задерживала ответ Login
+type LoginRecorder struct {
+	logger *slog.Logger
+	db     interfaces.StorageLoginProvider
+	queue  chan models.LoginRecord
+	done   chan struct{}
+}
+
+func NewLoginRecorder(
+	log *slog.Logger,
+	db interfaces.StorageLoginProvider,
+	queueSize int,
+) *LoginRecorder {
+	return &LoginRecorder{
+		logger: log,
+		db:     db,
+		queue:  make(chan models.LoginRecord, queueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// Record ставит запись в очередь, не блокируя вызывающего
+func (r *LoginRecorder) Record(record models.LoginRecord) {
+	if r == nil {
+		return
+	}
+
+	select {
+	case r.queue <- record:
+	default:
+		r.logger.Warn("login record dropped: queue is full",
+			slog.String("username", record.Username),
+			slog.Any("app_id", record.AppID),
+		)
+	}
+}
+
+// Run сохраняет записи из очереди до отмены ctx, затем дописывает оставшиеся
+func (r *LoginRecorder) Run(ctx context.Context) {
+	defer close(r.done)
+
+	for {
+		select {
+		case record := <-r.queue:
+			r.save(context.Background(), record)
+		case <-ctx.Done():
+			for {
+				select {
+				case record := <-r.queue:
+					r.save(context.Background(), record)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Wait ждет, пока Run сохранит оставшиеся записи
+func (r *LoginRecorder) Wait(ctx context.Context) {
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+	}
+}
+
+func (r *LoginRecorder) save(ctx context.Context, record models.LoginRecord) {
+	const op = loginsOp + "save"
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := r.db.SaveLoginRecord(ctx, record); err != nil {
+		r.logger.Error("failed to save login record",
+			slog.String("op", op),
+			slog.String("username", record.Username),
+			logger.Error(err),
+		)
+	}
+}
+
+// recordLogin записывает результат попытки входа.
+// userID равен 0, если пользователь не был найден.
+func (s *AuthService) recordLogin(
+	ctx context.Context,
+	userID uint64,
+	username string,
+	appID uint32,
+	loginErr error,
+) {
+	info := reqctx.FromContext(ctx)
+
+	record := models.LoginRecord{
+		UserID:    userID,
+		Username:  username,
+		AppID:     appID,
+		IP:        info.ClientIP,
+		UserAgent: info.UserAgent,
+		Success:   loginErr == nil,
+		Reason:    loginFailureReason(loginErr),
+		CreatedAt: time.Now().UTC(),
+	}
+	if record.IP == "" {
+		record.IP = models.UnknownIP
+	}
+	// Для неизвестного пользователя клиенту возвращается ErrInvalidCredentials,
+	// в журнале причина уточняется
+	if userID == 0 && record.Reason == models.LoginReasonInvalidCredentials {
+		record.Reason = models.LoginReasonUserNotFound
+	}
+
+	s.Logins.Record(record)
+}
+
+// loginFailureReason возвращает причину отказа для журнала входов
+func loginFailureReason(err error) string {
+	var valErr *models.ValidationError
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &valErr):
+		return models.LoginReasonInvalidRequest
+	case errors.Is(err, storage.ErrUserNotFound):
+		return models.LoginReasonUserNotFound
+	case errors.Is(err, ErrInvalidCredentials):
+		return models.LoginReasonInvalidCredentials
+	case errors.Is(err, ErrUserDisabled):
+		return models.LoginReasonUserDisabled
+	case errors.Is(err, ErrUserLocked):
+		return models.LoginReasonUserLocked
+	case errors.Is(err, ErrTooManyAttempts):
+		return models.LoginReasonTooManyAttempts
+	case errors.Is(err, ErrMFARequired):
+		return models.LoginReasonMFARequired
+	case errors.Is(err, ErrMFAEnrollmentRequired):
+		return models.LoginReasonMFAEnrollment
+	case errors.Is(err, mfa.ErrInvalidMFACode):
+		return models.LoginReasonInvalidMFACode
+	case errors.Is(err, ErrInvalidMFAChallenge):
+		return models.LoginReasonInvalidMFAChallenge
+	case errors.Is(err, passkeys.ErrInvalidPasskey):
+		return models.LoginReasonInvalidPasskey
+	case errors.Is(err, storage.ErrAppNotFound):
+		return models.LoginReasonAppNotFound
+	case errors.Is(err, ErrAppAccessDenied):
+		return models.LoginReasonAppAccessDenied
+	case errors.Is(err, ErrInvalidDPoPProof):
+		return models.LoginReasonInvalidDPoPProof
+	case errors.Is(err, ErrCertificateMismatch):
+		return models.LoginReasonCertificateMismatch
+	default:
+		return models.LoginReasonInternalError
+	}
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/reqctx"
+)
+
+func TestLogin__History(t *testing.T) {
+	s, db, userID := newLoginService(t)
+	appID := createTestApp(t, db, models.App{Name: "app"})
+
+	runCtx, stop := context.WithCancel(context.Background())
+	s.Logins = NewLoginRecorder(testLogger(), db, DefaultLoginQueueSize)
+	go s.Logins.Run(runCtx)
+
+	ctx := reqctx.WithInfo(context.Background(), reqctx.Info{ClientIP: "203.0.113.7", UserAgent: "test-agent"})
+	if _, err := s.Login(ctx, testUsername, "wrong-password", appID); err == nil {
+		t.Fatal("login with wrong password succeeded")
+	}
+	if _, err := s.Login(ctx, testUsername, testPassword, appID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Login(context.Background(), "bob", testPassword, appID); err == nil {
+		t.Fatal("login of unknown user succeeded")
+	}
+
+	// Записи сохраняются асинхронно, остаток очереди дописывается после остановки
+	stop()
+	s.Logins.Wait(context.Background())
+
+	records, err := db.ListLoginRecords(context.Background(), userID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("records = %+v, want 2", records)
+	}
+	success, failure := records[0], records[1]
+	if !success.Success || success.Reason != "" || success.AppID != appID {
+		t.Fatalf("successful login record = %+v", success)
+	}
+	if failure.Success || failure.Reason != models.LoginReasonInvalidCredentials {
+		t.Fatalf("failed login record = %+v", failure)
+	}
+	for _, record := range records {
+		if record.IP != "203.0.113.7" || record.UserAgent != "test-agent" || record.Username != testUsername {
+			t.Fatalf("record client = %+v", record)
+		}
+	}
+
+	unknown, err := db.ListLoginRecords(context.Background(), 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unknown) != 1 || unknown[0].Reason != models.LoginReasonUserNotFound || unknown[0].IP != models.UnknownIP {
+		t.Fatalf("unknown user records = %+v", unknown)
+	}
+}
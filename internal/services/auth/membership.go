@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/storage"
+)
+
+const membershipOp = "services.auth.membership."
+
+// checkAppAccess проверяет, может ли пользователь войти в приложение.
+// Заблокированным в приложении вход запрещен при любой политике,
+// для invite_only требуется членство, при auto_enroll оно создается при первом входе.
+func (s *AuthService) checkAppAccess(
+	ctx context.Context,
+	user models.User,
+	app models.App,
+) error {
+	const op = membershipOp + "checkAppAccess"
+
+	log := s.Logger.With(
+		slog.String("op", op),
+		slog.Uint64("user_id", user.ID),
+		slog.Any("app_id", app.ID),
+	)
+
+	member, err := s.DB.GetAppMember(ctx, user.ID, app.ID)
+	if err != nil && !errors.Is(err, storage.ErrMemberNotFound) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	isMember := err == nil
+
+	if isMember && member.Blocked {
+		return ErrAppAccessDenied
+	}
+	if isMember {
+		return nil
+	}
+
+	switch app.Policy() {
+	case models.MembershipInviteOnly:
+		return ErrAppAccessDenied
+	case models.MembershipAutoEnroll:
+		if err := s.DB.AddAppMember(ctx, user.ID, app.ID); err != nil && !errors.Is(err, storage.ErrMemberExist) {
+			log.Error("failed to enroll user", logger.Error(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		log.Info("user enrolled to app")
+	}
+	return nil
+}
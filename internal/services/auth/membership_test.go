@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Grino777/sso/internal/domain/models"
+)
+
+func TestLogin__Membership(t *testing.T) {
+	s, db, userID := newLoginService(t)
+	ctx := context.Background()
+
+	login := func(appID uint32) error {
+		_, err := s.Login(ctx, testUsername, testPassword, appID)
+		return err
+	}
+
+	t.Run("open", func(t *testing.T) {
+		appID := createTestApp(t, db, models.App{Name: "open"})
+		if err := login(appID); err != nil {
+			t.Fatal(err)
+		}
+		// Открытое приложение не добавляет пользователя в участники, но блокировка действует
+		if _, err := db.GetAppMember(ctx, userID, appID); err == nil {
+			t.Fatal("open app enrolled the user")
+		}
+		if err := db.AddAppMember(ctx, userID, appID); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SetAppMemberBlocked(ctx, userID, appID, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := login(appID); !errors.Is(err, ErrAppAccessDenied) {
+			t.Fatalf("blocked member: err = %v, want ErrAppAccessDenied", err)
+		}
+	})
+
+	t.Run("invite only", func(t *testing.T) {
+		appID := createTestApp(t, db, models.App{Name: "invite", MembershipPolicy: models.MembershipInviteOnly})
+		if err := login(appID); !errors.Is(err, ErrAppAccessDenied) {
+			t.Fatalf("not a member: err = %v, want ErrAppAccessDenied", err)
+		}
+		if err := db.AddAppMember(ctx, userID, appID); err != nil {
+			t.Fatal(err)
+		}
+		if err := login(appID); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SetAppMemberBlocked(ctx, userID, appID, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := login(appID); !errors.Is(err, ErrAppAccessDenied) {
+			t.Fatalf("blocked member: err = %v, want ErrAppAccessDenied", err)
+		}
+	})
+
+	t.Run("auto enroll", func(t *testing.T) {
+		appID := createTestApp(t, db, models.App{Name: "auto", MembershipPolicy: models.MembershipAutoEnroll})
+		if err := login(appID); err != nil {
+			t.Fatal(err)
+		}
+		member, err := db.GetAppMember(ctx, userID, appID)
+		if err != nil {
+			t.Fatalf("user is not enrolled: %v", err)
+		}
+		if member.Blocked {
+			t.Fatal("enrolled member is blocked")
+		}
+		if err := login(appID); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/generator"
+	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/lib/reqctx"
+	"github.com/Grino777/sso/internal/services/mfa"
+	"github.com/Grino777/sso/internal/storage"
+	"github.com/Grino777/sso/internal/storage/redis"
+)
+
+const mfaOp = "services.auth.mfa."
+
+// Длина токена незавершенного входа
+const challengeTokenLength = 43
+
+var (
+	ErrMFARequired           = errors.New("mfa required")
+	ErrMFAEnrollmentRequired = errors.New("app requires mfa, enroll an authenticator first")
+	ErrInvalidMFAChallenge   = errors.New("invalid or expired mfa challenge")
+)
+
+// MFARequiredError возвращается Login, если пароль верен, но нужен второй фактор.
+// Вход завершается CompleteMFALogin с токеном ChallengeToken.
+type MFARequiredError struct {
+	ChallengeToken string
+	ExpiresAt      time.Time
+}
+
+func (e *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (e *MFARequiredError) Is(target error) bool {
+	return target == ErrMFARequired
+}
+
+// requireMFA возвращает MFARequiredError, если у пользователя включен второй фактор,
+// и ErrMFAEnrollmentRequired, если приложение требует MFA, а пользователь его не настроил.
+// Ключ клиента (cnf) сохраняется в challenge, чтобы токены были привязаны к нему же.
+func (s *AuthService) requireMFA(
+	ctx context.Context,
+	user models.User,
+	app models.App,
+	cnf *models.Confirmation,
+) error {
+	const op = mfaOp + "requireMFA"
+
+	if s.MFA == nil {
+		return nil
+	}
+
+	enabled, err := s.MFA.Enabled(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !enabled {
+		if app.RequireMFA {
+			return ErrMFAEnrollmentRequired
+		}
+		return nil
+	}
+
+	token, err := generator.GenerateRandomString(challengeTokenLength)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	challenge := models.MFAChallenge{
+		UserID:    user.ID,
+		AppID:     app.ID,
+		Cnf:       cnf,
+		Offline:   reqctx.FromContext(ctx).OfflineAccess,
+		ExpiresAt: time.Now().Add(s.MFAConfig.ChallengeTTL).UTC(),
+	}
+	if err := s.Cache.SaveMFAChallenge(ctx, token, challenge, s.MFAConfig.ChallengeTTL); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &MFARequiredError{ChallengeToken: token, ExpiresAt: challenge.ExpiresAt}
+}
+
+// CompleteMFALogin завершает вход кодом TOTP или кодом восстановления
+// и выпускает токены для приложения, в которое выполнялся вход.
+// Завершить вход может только то же приложение (appID), которое его начало.
+func (s *AuthService) CompleteMFALogin(
+	ctx context.Context,
+	challengeToken string,
+	code string,
+	appID uint32,
+) (models.Tokens, error) {
+	tokens, user, appID, err := s.completeMFALogin(ctx, challengeToken, code, appID)
+	if user.ID != 0 {
+		s.recordLogin(ctx, user.ID, user.Username, appID, err)
+	}
+	return tokens, err
+}
+
+func (s *AuthService) completeMFALogin(
+	ctx context.Context,
+	challengeToken string,
+	code string,
+	appID uint32,
+) (models.Tokens, models.User, uint32, error) {
+	const op = mfaOp + "CompleteMFALogin"
+
+	log := s.Logger.With(slog.String("op", op))
+
+	if challengeToken == "" {
+		return models.Tokens{}, models.User{}, 0, &models.ValidationError{Field: "challenge_token", Message: models.EmptyField}
+	}
+	if code == "" {
+		return models.Tokens{}, models.User{}, 0, &models.ValidationError{Field: "code", Message: models.EmptyField}
+	}
+	if err := ValidateApp(appID); err != nil {
+		return models.Tokens{}, models.User{}, 0, err
+	}
+	if s.MFA == nil {
+		return models.Tokens{}, models.User{}, 0, ErrInvalidMFAChallenge
+	}
+
+	challenge, err := s.Cache.TakeMFAChallenge(ctx, challengeToken)
+	if err != nil {
+		if errors.Is(err, redis.ErrCacheNotFound) {
+			return models.Tokens{}, models.User{}, 0, ErrInvalidMFAChallenge
+		}
+		return models.Tokens{}, models.User{}, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if !time.Now().Before(challenge.ExpiresAt) {
+		return models.Tokens{}, models.User{}, 0, ErrInvalidMFAChallenge
+	}
+	// Challenge удален и не возвращается: токен попал к другому приложению
+	if challenge.AppID != appID {
+		log.Warn("mfa challenge issued for another app", slog.Any("app_id", appID))
+		return models.Tokens{}, models.User{}, 0, ErrInvalidCredentials
+	}
+
+	user, err := s.DB.GetUserByID(ctx, challenge.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return models.Tokens{}, models.User{}, 0, ErrInvalidMFAChallenge
+		}
+		return models.Tokens{}, models.User{}, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.String("username", user.Username))
+
+	if err := s.checkLockout(ctx, user.Username); err != nil {
+		return models.Tokens{}, user, challenge.AppID, err
+	}
+	if err := checkUserStatus(user); err != nil {
+		log.Warn("login rejected", logger.Error(err))
+		return models.Tokens{}, user, challenge.AppID, err
+	}
+
+	if err := s.MFA.Verify(ctx, user.ID, code); err != nil {
+		if errors.Is(err, mfa.ErrInvalidMFACode) {
+			log.Warn("invalid mfa code")
+			s.registerLoginFailure(ctx, user.Username)
+			s.retryChallenge(ctx, log, challengeToken, challenge)
+		}
+		return models.Tokens{}, user, challenge.AppID, err
+	}
+
+	app, err := s.GetCachedApp(ctx, challenge.AppID)
+	if err != nil {
+		return models.Tokens{}, user, challenge.AppID, err
+	}
+	if err := s.checkAppAccess(ctx, user, app); err != nil {
+		log.Warn("login rejected", logger.Error(err))
+		return models.Tokens{}, user, app.ID, err
+	}
+
+	// Offline доступ запрашивается при входе, разрешение приложения проверяется заново
+	offline := s.offlineAccess(log, app, challenge.Offline)
+	user, err = s.generateUserTokens(ctx, user, app, challenge.Cnf, offline)
+	if err != nil {
+		return models.Tokens{}, user, app.ID, err
+	}
+
+	if _, err := s.Cache.SaveUser(ctx, user, app.ID); err != nil {
+		log.Error("failed to cache user", logger.Error(err))
+	}
+	s.resetLoginFailures(ctx, user.Username)
+
+	log.Info("mfa login completed")
+	return user.Tokens, user, app.ID, nil
+}
+
+// retryChallenge возвращает challenge после неверного кода, пока не исчерпаны попытки
+func (s *AuthService) retryChallenge(
+	ctx context.Context,
+	log *slog.Logger,
+	token string,
+	challenge models.MFAChallenge,
+) {
+	challenge.Attempts++
+	if challenge.Attempts >= s.MFAConfig.MaxAttempts {
+		log.Warn("mfa challenge attempts exhausted")
+		return
+	}
+
+	ttl := time.Until(challenge.ExpiresAt)
+	if ttl <= 0 {
+		return
+	}
+	if err := s.Cache.SaveMFAChallenge(ctx, token, challenge, ttl); err != nil {
+		log.Warn("failed to save mfa challenge", logger.Error(err))
+	}
+}
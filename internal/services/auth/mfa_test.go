@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	interfaces "github.com/Grino777/sso/internal/interfaces/storage"
+	"github.com/Grino777/sso/internal/lib/totp"
+	"github.com/Grino777/sso/internal/services/mfa"
+	"github.com/Grino777/sso/internal/storage"
+)
+
+const testMFASecret = "JBSWY3DPEHPK3PXP"
+
+// testMFAStore пользователь с включенным TOTP
+type testMFAStore struct {
+	interfaces.Storage
+	user         models.User
+	lastUsedStep int64
+}
+
+func (s *testMFAStore) GetUserByID(_ context.Context, userID uint64) (models.User, error) {
+	if userID != s.user.ID {
+		return models.User{}, storage.ErrUserNotFound
+	}
+	return s.user, nil
+}
+
+func (s *testMFAStore) GetUserMFA(_ context.Context, userID uint64) (models.UserMFA, error) {
+	return models.UserMFA{UserID: userID, TOTPSecret: testMFASecret, Enabled: true, LastUsedStep: s.lastUsedStep}, nil
+}
+
+func (s *testMFAStore) UseTOTPStep(_ context.Context, _ uint64, step int64) (bool, error) {
+	if step <= s.lastUsedStep {
+		return false, nil
+	}
+	s.lastUsedStep = step
+	return true, nil
+}
+
+func (s *testMFAStore) UseRecoveryCode(context.Context, uint64, string) (bool, error) {
+	return false, nil
+}
+
+func newMFATestService(t *testing.T, maxAttempts int) (*AuthService, *testMFAStore) {
+	t.Helper()
+
+	cache, _ := newTestCache(t)
+	db := &testMFAStore{user: models.User{ID: 7, Username: "alice"}}
+	lockout := false
+
+	return NewAuthService(AuthService{
+		Logger:    testLogger(),
+		DB:        db,
+		Cache:     cache,
+		Lockout:   config.LockoutConfig{Enabled: &lockout},
+		MFAConfig: config.MFAConfig{MaxAttempts: maxAttempts, Skew: 1},
+		MFA:       mfa.NewMFAService(testLogger(), db, config.MFAConfig{Skew: 1}),
+	}, nil), db
+}
+
+func saveChallenge(t *testing.T, s *AuthService, token string, challenge models.MFAChallenge) {
+	t.Helper()
+
+	if challenge.ExpiresAt.IsZero() {
+		challenge.ExpiresAt = time.Now().Add(5 * time.Minute)
+	}
+	if err := s.Cache.SaveMFAChallenge(context.Background(), token, challenge, time.Until(challenge.ExpiresAt)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// totpCode возвращает код шага, отстоящего от текущего на offset
+func totpCode(t *testing.T, offset int64) string {
+	t.Helper()
+
+	code, err := totp.Code(testMFASecret, totp.Step(time.Now())+offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestCompleteMFALogin__ChallengeExhausted(t *testing.T) {
+	s, _ := newMFATestService(t, 3)
+	ctx := context.Background()
+	saveChallenge(t, s, "challenge", models.MFAChallenge{UserID: 7, AppID: 1})
+
+	// Код вне допустимого окна
+	wrong := totpCode(t, 5)
+	for i := range 3 {
+		if _, _, _, err := s.completeMFALogin(ctx, "challenge", wrong, 1); !errors.Is(err, mfa.ErrInvalidMFACode) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidMFACode", i+1, err)
+		}
+	}
+
+	if _, _, _, err := s.completeMFALogin(ctx, "challenge", totpCode(t, 0), 1); !errors.Is(err, ErrInvalidMFAChallenge) {
+		t.Fatalf("after max attempts: err = %v, want ErrInvalidMFAChallenge", err)
+	}
+}
+
+func TestCompleteMFALogin__ReusedStep(t *testing.T) {
+	s, db := newMFATestService(t, 3)
+	ctx := context.Background()
+	saveChallenge(t, s, "challenge", models.MFAChallenge{UserID: 7, AppID: 1})
+
+	// Код текущего шага уже использован при предыдущем входе
+	db.lastUsedStep = totp.Step(time.Now())
+	if _, _, _, err := s.completeMFALogin(ctx, "challenge", totpCode(t, 0), 1); !errors.Is(err, mfa.ErrInvalidMFACode) {
+		t.Fatalf("err = %v, want ErrInvalidMFACode", err)
+	}
+
+	challenge, err := s.Cache.TakeMFAChallenge(ctx, "challenge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if challenge.Attempts != 1 {
+		t.Fatalf("attempts = %d, want 1", challenge.Attempts)
+	}
+}
+
+func TestCompleteMFALogin__OtherApp(t *testing.T) {
+	s, db := newMFATestService(t, 3)
+	ctx := context.Background()
+	saveChallenge(t, s, "challenge", models.MFAChallenge{UserID: 7, AppID: 1})
+
+	if _, _, _, err := s.completeMFALogin(ctx, "challenge", totpCode(t, 0), 2); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("err = %v, want ErrInvalidCredentials", err)
+	}
+	if db.lastUsedStep != 0 {
+		t.Fatal("code is consumed by another app")
+	}
+	if _, _, _, err := s.completeMFALogin(ctx, "challenge", totpCode(t, 0), 1); !errors.Is(err, ErrInvalidMFAChallenge) {
+		t.Fatalf("after another app: err = %v, want ErrInvalidMFAChallenge", err)
+	}
+}
+
+func TestCompleteMFALogin__InvalidChallenge(t *testing.T) {
+	s, _ := newMFATestService(t, 3)
+	ctx := context.Background()
+	saveChallenge(t, s, "deleted-user", models.MFAChallenge{UserID: 8, AppID: 1})
+
+	// Срок challenge проверяется и при оставшемся ключе в Redis
+	expired := models.MFAChallenge{UserID: 7, AppID: 1, ExpiresAt: time.Now().Add(-time.Second)}
+	if err := s.Cache.SaveMFAChallenge(ctx, "expired", expired, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, token := range []string{"unknown", "expired", "deleted-user"} {
+		if _, _, _, err := s.completeMFALogin(ctx, token, totpCode(t, 0), 1); !errors.Is(err, ErrInvalidMFAChallenge) {
+			t.Fatalf("%s: err = %v, want ErrInvalidMFAChallenge", token, err)
+		}
+	}
+
+	var valErr *models.ValidationError
+	if _, _, _, err := s.completeMFALogin(ctx, "", "123456", 1); !errors.As(err, &valErr) {
+		t.Fatalf("empty token: err = %v, want ValidationError", err)
+	}
+}
+
+func TestRetryChallenge(t *testing.T) {
+	s, _ := newMFATestService(t, 3)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Minute).Truncate(time.Second)
+
+	s.retryChallenge(ctx, s.Logger, "retry", models.MFAChallenge{UserID: 7, Attempts: 1, ExpiresAt: expiresAt})
+	challenge, err := s.Cache.TakeMFAChallenge(ctx, "retry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if challenge.Attempts != 2 || !challenge.ExpiresAt.Equal(expiresAt) {
+		t.Fatalf("challenge = %+v, want attempts 2 and the same expiry", challenge)
+	}
+
+	s.retryChallenge(ctx, s.Logger, "exhausted", models.MFAChallenge{UserID: 7, Attempts: 2, ExpiresAt: expiresAt})
+	if _, err := s.Cache.TakeMFAChallenge(ctx, "exhausted"); err == nil {
+		t.Fatal("exhausted challenge is saved")
+	}
+
+	s.retryChallenge(ctx, s.Logger, "expired", models.MFAChallenge{UserID: 7, ExpiresAt: time.Now().Add(-time.Second)})
+	if _, err := s.Cache.TakeMFAChallenge(ctx, "expired"); err == nil {
+		t.Fatal("expired challenge is saved")
+	}
+}
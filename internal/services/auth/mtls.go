@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/reqctx"
+)
+
+const mtlsOp = "services.auth.mtls."
+
+// confirmCertificate добавляет к привязке cnf отпечаток клиентского сертификата (x5t#S256),
+// если запрос пришел по mTLS с сертификатом, зарегистрированным за приложением.
+// Если bound содержит x5t#S256, запрос должен быть выполнен с тем же сертификатом.
+func (s *AuthService) confirmCertificate(
+	ctx context.Context,
+	app models.App,
+	bound *models.Confirmation,
+	cnf *models.Confirmation,
+) (*models.Confirmation, error) {
+	const op = mtlsOp + "confirmCertificate"
+
+	thumbprint := reqctx.FromContext(ctx).CertThumbprint
+
+	if bound != nil && bound.X5TS256 != "" && bound.X5TS256 != thumbprint {
+		return nil, fmt.Errorf("%s: %w", op, ErrCertificateMismatch)
+	}
+
+	if thumbprint == "" || !app.HasCertificate(thumbprint) {
+		return cnf, nil
+	}
+
+	if cnf == nil {
+		cnf = &models.Confirmation{}
+	}
+	cnf.X5TS256 = thumbprint
+	return cnf, nil
+}
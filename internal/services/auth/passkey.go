@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/webauthn"
+	"github.com/Grino777/sso/internal/services/passkeys"
+)
+
+const passkeyOp = "services.auth.passkey."
+
+var ErrPasskeysDisabled = errors.New("passkey login is not configured")
+
+// BeginPasskeyLogin возвращает параметры navigator.credentials.get для входа в приложение.
+// username необязателен: без него вход выполняется discoverable passkey.
+func (s *AuthService) BeginPasskeyLogin(
+	ctx context.Context,
+	username string,
+	appID uint32,
+) (webauthn.RequestOptions, error) {
+	const op = passkeyOp + "BeginPasskeyLogin"
+
+	if s.Passkeys == nil {
+		return webauthn.RequestOptions{}, ErrPasskeysDisabled
+	}
+	if err := ValidateApp(appID); err != nil {
+		return webauthn.RequestOptions{}, err
+	}
+	if _, err := s.GetCachedApp(ctx, appID); err != nil {
+		return webauthn.RequestOptions{}, err
+	}
+	if username != "" {
+		resolved, err := s.resolveUsername(ctx, username)
+		if err != nil {
+			return webauthn.RequestOptions{}, fmt.Errorf("%s: %w", op, err)
+		}
+		username = resolved
+	}
+
+	options, err := s.Passkeys.BeginLogin(ctx, username, appID)
+	if err != nil {
+		return webauthn.RequestOptions{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return options, nil
+}
+
+// PasskeyLogin проверяет подпись passkey и выпускает токены для приложения,
+// в которое был начат вход; завершить вход может только это же приложение (appID). Вход без проверки пользователя на аутентификаторе (флаг UV)
+// считается однофакторным и требует второй фактор, если он включен.
+func (s *AuthService) PasskeyLogin(
+	ctx context.Context,
+	resp webauthn.AssertionResponse,
+	appID uint32,
+) (models.Tokens, error) {
+	tokens, user, appID, err := s.passkeyLogin(ctx, resp, appID)
+	if user.ID != 0 {
+		s.recordLogin(ctx, user.ID, user.Username, appID, err)
+	}
+	return tokens, err
+}
+
+func (s *AuthService) passkeyLogin(
+	ctx context.Context,
+	resp webauthn.AssertionResponse,
+	appID uint32,
+) (models.Tokens, models.User, uint32, error) {
+	const op = passkeyOp + "PasskeyLogin"
+
+	log := s.Logger.With(slog.String("op", op))
+
+	if s.Passkeys == nil {
+		return models.Tokens{}, models.User{}, 0, ErrPasskeysDisabled
+	}
+	if err := ValidateApp(appID); err != nil {
+		return models.Tokens{}, models.User{}, 0, err
+	}
+
+	user, session, assertion, err := s.Passkeys.FinishLogin(ctx, resp, appID)
+	if err != nil {
+		if user.ID != 0 && errors.Is(err, passkeys.ErrInvalidPasskey) {
+			s.registerLoginFailure(ctx, user.Username)
+		}
+		return models.Tokens{}, user, session.AppID, err
+	}
+	log = log.With(slog.String("username", user.Username))
+
+	if err := s.checkLockout(ctx, user.Username); err != nil {
+		return models.Tokens{}, user, session.AppID, err
+	}
+
+	user, appID, err = s.completeLogin(ctx, log, user, session.AppID, !assertion.UserVerified)
+	if err != nil {
+		return models.Tokens{}, user, appID, err
+	}
+
+	log.Info("passkey login completed")
+	return user.Tokens, user, appID, nil
+}
@@ -0,0 +1,420 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/generator"
+	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/lib/notify"
+	"github.com/Grino777/sso/internal/storage"
+	"github.com/Grino777/sso/internal/storage/redis"
+)
+
+const passwordOp = "services.auth.password."
+
+var (
+	ErrPasswordResetDisabled = errors.New("password reset is not configured")
+	ErrInvalidResetToken     = errors.New("invalid or expired password reset token")
+	ErrInvalidChangeToken    = errors.New("invalid or expired password change token")
+)
+
+// PasswordExpiredError возвращается Login, если пароль верен, но срок его действия истек.
+// Пароль меняется ChangeExpiredPassword с токеном ChangeToken, после чего нужно войти заново.
+type PasswordExpiredError struct {
+	ChangeToken string
+	ExpiresAt   time.Time
+}
+
+func (e *PasswordExpiredError) Error() string {
+	return ErrPasswordExpired.Error()
+}
+
+func (e *PasswordExpiredError) Is(target error) bool {
+	return target == ErrPasswordExpired
+}
+
+// ChangePassword меняет пароль пользователя после проверки текущего.
+// Неверный текущий пароль учитывается как неудачная попытка входа.
+func (s *AuthService) ChangePassword(
+	ctx context.Context,
+	userID uint64,
+	current string,
+	password string,
+) error {
+	const op = passwordOp + "ChangePassword"
+
+	log := s.Logger.With(slog.String("op", op), slog.Uint64("user_id", userID))
+
+	if current == "" {
+		return &models.ValidationError{Field: "current_password", Message: models.EmptyField}
+	}
+	if password == current {
+		return &models.ValidationError{Field: "password", Message: "must differ from the current password"}
+	}
+
+	user, err := s.DB.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.checkLockout(ctx, user.Username); err != nil {
+		return err
+	}
+	if err := s.validatePassword(ctx, user, current); err != nil {
+		if errors.Is(err, ErrInvalidCredentials) {
+			s.registerLoginFailure(ctx, user.Username)
+		}
+		return err
+	}
+
+	if err := s.validateNewPassword(ctx, user, password); err != nil {
+		return err
+	}
+	if err := s.setPassword(ctx, log, user, password); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("password changed")
+	return nil
+}
+
+// StartPasswordReset отправляет письмо со ссылкой для сброса пароля и возвращает срок ее действия.
+// Вместо имени пользователя можно указать подтвержденный email.
+// Ответ не зависит от того, существует ли пользователь и было ли письмо отправлено.
+func (s *AuthService) StartPasswordReset(
+	ctx context.Context,
+	username string,
+) (time.Time, error) {
+	const op = passwordOp + "StartPasswordReset"
+
+	log := s.Logger.With(slog.String("op", op), slog.String("username", username))
+
+	if !s.passwordResetEnabled() {
+		return time.Time{}, ErrPasswordResetDisabled
+	}
+	if err := models.ValidateUsername(username); err != nil {
+		return time.Time{}, err
+	}
+	username, err := s.resolveUsername(ctx, username)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	ttl := s.PasswordReset.TokenTTL
+	expiresAt := time.Now().Add(ttl).UTC()
+
+	user, err := s.DB.GetUser(ctx, username)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Debug("password reset requested for unknown user")
+			return expiresAt, nil
+		}
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.Disabled {
+		log.Warn("password reset rejected", logger.Error(ErrUserDisabled))
+		return expiresAt, nil
+	}
+
+	address, err := s.deliveryAddress(ctx, user)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if address == "" {
+		log.Warn("password reset rejected: user has no email address")
+		return expiresAt, nil
+	}
+
+	// Пока действует интервал, прежняя ссылка остается в силе, а новое письмо не отправляется
+	allowed, err := s.allowEmail(ctx, "password_reset", user.ID, s.PasswordReset.ResendInterval)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !allowed {
+		log.Warn("password reset throttled")
+		return expiresAt, nil
+	}
+
+	token, err := generator.GenerateRandomString(challengeTokenLength)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	code := models.LoginCode{
+		UserID:    user.ID,
+		Hash:      hashLoginSecret(token),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.Cache.SaveLoginCode(ctx, resetTokenKey(token), code, ttl); err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.Notifier.Send(ctx, resetLinkMessage(address, s.resetLinkURL(token), ttl)); err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("password reset started")
+	return expiresAt, nil
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма.
+// Токен принимается один раз; если новый пароль не прошел проверку, токен остается действительным.
+func (s *AuthService) ResetPassword(
+	ctx context.Context,
+	token string,
+	password string,
+) error {
+	const op = passwordOp + "ResetPassword"
+
+	log := s.Logger.With(slog.String("op", op))
+
+	if !s.passwordResetEnabled() {
+		return ErrPasswordResetDisabled
+	}
+	if token == "" {
+		return &models.ValidationError{Field: "token", Message: models.EmptyField}
+	}
+
+	key := resetTokenKey(token)
+	code, err := s.Cache.TakeLoginCode(ctx, key)
+	if err != nil {
+		if errors.Is(err, redis.ErrCacheNotFound) {
+			return ErrInvalidResetToken
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !time.Now().Before(code.ExpiresAt) || !checkLoginSecret(code.Hash, token) {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.DB.GetUserByID(ctx, code.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return ErrInvalidResetToken
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.String("username", user.Username))
+
+	if user.Disabled {
+		return ErrUserDisabled
+	}
+	if err := s.validateNewPassword(ctx, user, password); err != nil {
+		if err := s.Cache.SaveLoginCode(ctx, key, code, time.Until(code.ExpiresAt)); err != nil {
+			log.Warn("failed to restore password reset token", logger.Error(err))
+		}
+		return err
+	}
+
+	if err := s.setPassword(ctx, log, user, password); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	// Владелец почты подтвердил себя, накопленные неудачные попытки входа больше не нужны
+	s.resetLoginFailures(ctx, user.Username)
+
+	log.Info("password reset completed")
+	return nil
+}
+
+// requirePasswordChange возвращает PasswordExpiredError с токеном, который позволяет
+// только сменить пароль в том же приложении. Токены приложения не выпускаются.
+func (s *AuthService) requirePasswordChange(ctx context.Context, user models.User, appID uint32) error {
+	const op = passwordOp + "requirePasswordChange"
+
+	token, err := generator.GenerateRandomString(challengeTokenLength)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	ttl := s.Passwords.ChangeTokenTTL()
+	code := models.LoginCode{
+		UserID:    user.ID,
+		AppID:     appID,
+		Hash:      hashLoginSecret(token),
+		ExpiresAt: time.Now().Add(ttl).UTC(),
+	}
+	if err := s.Cache.SaveLoginCode(ctx, changeTokenKey(token), code, ttl); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &PasswordExpiredError{ChangeToken: token, ExpiresAt: code.ExpiresAt}
+}
+
+// ChangeExpiredPassword устанавливает новый пароль по токену из ошибки PASSWORD_EXPIRED.
+// Токен принимается один раз и только от приложения (appID), при входе в которое он выдан;
+// если новый пароль не прошел проверку, токен остается действительным. Новый пароль
+// должен отличаться от истекшего. Токены приложения не выпускаются: после смены нужно войти заново.
+func (s *AuthService) ChangeExpiredPassword(
+	ctx context.Context,
+	token string,
+	password string,
+	appID uint32,
+) error {
+	const op = passwordOp + "ChangeExpiredPassword"
+
+	log := s.Logger.With(slog.String("op", op))
+
+	if token == "" {
+		return &models.ValidationError{Field: "change_token", Message: models.EmptyField}
+	}
+	if err := ValidateApp(appID); err != nil {
+		return err
+	}
+
+	key := changeTokenKey(token)
+	code, err := s.Cache.TakeLoginCode(ctx, key)
+	if err != nil {
+		if errors.Is(err, redis.ErrCacheNotFound) {
+			return ErrInvalidChangeToken
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !time.Now().Before(code.ExpiresAt) || !checkLoginSecret(code.Hash, token) {
+		return ErrInvalidChangeToken
+	}
+	// Токен удален и не возвращается: он попал к другому приложению
+	if code.AppID != appID {
+		log.Warn("password change token issued for another app", slog.Any("app_id", appID))
+		return ErrInvalidChangeToken
+	}
+
+	user, err := s.DB.GetUserByID(ctx, code.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return ErrInvalidChangeToken
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.String("username", user.Username))
+
+	if user.Disabled {
+		return ErrUserDisabled
+	}
+	if err := s.validateExpiredPasswordChange(ctx, user, password); err != nil {
+		if err := s.Cache.SaveLoginCode(ctx, key, code, time.Until(code.ExpiresAt)); err != nil {
+			log.Warn("failed to restore password change token", logger.Error(err))
+		}
+		return err
+	}
+
+	if err := s.setPassword(ctx, log, user, password); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("expired password changed")
+	return nil
+}
+
+// validateExpiredPasswordChange проверяет новый пароль вместо истекшего: он должен
+// отличаться от текущего, даже если история паролей не ведется
+func (s *AuthService) validateExpiredPasswordChange(ctx context.Context, user models.User, password string) error {
+	if password != "" {
+		same, _, err := s.Hasher.Verify(user.PassHash, password)
+		if err != nil {
+			return err
+		}
+		if same {
+			return &models.ValidationError{Field: "password", Message: "must differ from the current password"}
+		}
+	}
+	return s.validateNewPassword(ctx, user, password)
+}
+
+// validateNewPassword проверяет новый пароль пользователя по политике паролей
+func (s *AuthService) validateNewPassword(ctx context.Context, user models.User, password string) error {
+	if s.Passwords == nil {
+		return ValidateUser(user.Username, password)
+	}
+	if err := models.ValidateUsername(user.Username); err != nil {
+		return err
+	}
+	return s.Passwords.Validate(ctx, user, password)
+}
+
+// setPassword сохраняет проверенный новый пароль, отзывает все refresh токены пользователя
+// и удаляет его из кэша, чтобы вход по старому хэшу стал невозможен
+func (s *AuthService) setPassword(
+	ctx context.Context,
+	log *slog.Logger,
+	user models.User,
+	password string,
+) error {
+	passHash, err := s.Hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	if err := s.DB.UpdateUserPassword(ctx, user.ID, []byte(passHash)); err != nil {
+		return err
+	}
+	if err := s.DB.DeleteUserRefreshTokens(ctx, user.ID); err != nil {
+		log.Error("failed to revoke refresh tokens", logger.Error(err))
+		return err
+	}
+	if err := s.Cache.DeleteUser(ctx, user.Username); err != nil {
+		log.Warn("failed to invalidate cached user", logger.Error(err))
+	}
+
+	s.notifyPasswordChanged(ctx, log, user)
+	return nil
+}
+
+// notifyPasswordChanged сообщает пользователю о смене пароля, если есть куда отправить письмо
+func (s *AuthService) notifyPasswordChanged(ctx context.Context, log *slog.Logger, user models.User) {
+	if s.Notifier == nil {
+		return
+	}
+
+	address, err := s.deliveryAddress(ctx, user)
+	if err != nil || address == "" {
+		return
+	}
+	if err := s.Notifier.Send(ctx, passwordChangedMessage(address)); err != nil {
+		log.Warn("failed to send password change notification", logger.Error(err))
+	}
+}
+
+func (s *AuthService) passwordResetEnabled() bool {
+	return s.PasswordReset.Enabled && s.Notifier != nil
+}
+
+func (s *AuthService) resetLinkURL(token string) string {
+	link, err := url.Parse(s.PasswordReset.LinkURL)
+	if err != nil {
+		return s.PasswordReset.LinkURL + "?token=" + url.QueryEscape(token)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// В кэше хранится только хэш токена, сам токен есть лишь в письме
+func resetTokenKey(token string) string {
+	return "reset:" + hashLoginSecret(token)
+}
+
+// Токен смены истекшего пароля хранится так же, как токен сброса
+func changeTokenKey(token string) string {
+	return "password_change:" + hashLoginSecret(token)
+}
+
+func resetLinkMessage(to, link string, ttl time.Duration) notify.Message {
+	return notify.Message{
+		To:      to,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Follow the link to set a new password:\n\n%s\n\nThe link expires in %s and can be used once. If you did not request a password reset, ignore this email.\n",
+			link, ttl,
+		),
+	}
+}
+
+func passwordChangedMessage(to string) notify.Message {
+	return notify.Message{
+		To:      to,
+		Subject: "Your password was changed",
+		Body:    "The password for your account was changed and all sessions were signed out. If you did not do this, reset your password immediately.\n",
+	}
+}
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/services/password"
+)
+
+func TestStartPasswordReset__ResendInterval(t *testing.T) {
+	s, _, notifier, mr := newPasswordlessService(t)
+	s.PasswordReset = config.PasswordResetConfig{
+		Enabled:        true,
+		LinkURL:        "https://app.example.com/reset-password",
+		TokenTTL:       time.Hour,
+		ResendInterval: time.Minute,
+	}
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := s.StartPasswordReset(ctx, testEmail); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(notifier.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(notifier.sent))
+	}
+
+	// Вход без пароля отправляет письма независимо от сброса пароля
+	startLogin(t, s, notifier, models.PasswordlessCode)
+
+	mr.FastForward(time.Minute)
+	if _, err := s.StartPasswordReset(ctx, testEmail); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.sent) != 3 {
+		t.Fatalf("sent %d emails, want 3", len(notifier.sent))
+	}
+}
+
+// testHasher хранит пароль открытым текстом, чтобы тесты не тратили время на argon2id
+type testHasher struct{}
+
+func (testHasher) Hash(password string) (string, error) { return "plain:" + password, nil }
+
+func (testHasher) Verify(hash []byte, password string) (bool, bool, error) {
+	return string(hash) == "plain:"+password, false, nil
+}
+
+// testPasswordStore пользователь, пароль которого можно сменить
+type testPasswordStore struct {
+	testUserStore
+}
+
+func (s *testPasswordStore) UpdateUserPassword(_ context.Context, _ uint64, passHash []byte) error {
+	s.user.PassHash = passHash
+	s.user.PasswordChangedAt = time.Now()
+	return nil
+}
+
+func (s *testPasswordStore) DeleteUserRefreshTokens(context.Context, uint64) error {
+	return nil
+}
+
+// newExpiredPasswordService возвращает сервис с пользователем, пароль которого истек
+func newExpiredPasswordService(t *testing.T) (*AuthService, *testPasswordStore) {
+	t.Helper()
+
+	s, _, _, _ := newPasswordlessService(t)
+	db := &testPasswordStore{testUserStore{user: models.User{
+		ID:                7,
+		Username:          testEmail,
+		PassHash:          []byte("plain:old-password1"),
+		PasswordChangedAt: time.Now().Add(-2 * time.Hour),
+	}}}
+	passwords, err := password.NewPasswordService(testLogger(), db, testHasher{}, config.PasswordPolicyConfig{
+		MinLength:      8,
+		MaxLength:      64,
+		MaxAge:         time.Hour,
+		ChangeTokenTTL: 10 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.DB = db
+	s.Hasher = testHasher{}
+	s.Passwords = passwords
+	return s, db
+}
+
+// expiredLogin входит с истекшим паролем и возвращает токен смены пароля
+func expiredLogin(t *testing.T, s *AuthService) string {
+	t.Helper()
+
+	_, err := s.Login(context.Background(), testEmail, "old-password1", 1)
+	var expiredErr *PasswordExpiredError
+	if !errors.As(err, &expiredErr) || !errors.Is(err, ErrPasswordExpired) {
+		t.Fatalf("login: err = %v, want PasswordExpiredError", err)
+	}
+	return expiredErr.ChangeToken
+}
+
+func TestChangeExpiredPassword(t *testing.T) {
+	s, db := newExpiredPasswordService(t)
+	ctx := context.Background()
+	token := expiredLogin(t, s)
+
+	// Истекший пароль не принимается, токен остается действительным
+	var valErr *models.ValidationError
+	if err := s.ChangeExpiredPassword(ctx, token, "old-password1", 1); !errors.As(err, &valErr) {
+		t.Fatalf("same password: err = %v, want ValidationError", err)
+	}
+
+	if err := s.ChangeExpiredPassword(ctx, token, "new-password2", 1); err != nil {
+		t.Fatal(err)
+	}
+	if string(db.user.PassHash) != "plain:new-password2" {
+		t.Fatalf("password hash = %q", db.user.PassHash)
+	}
+	if err := s.ChangeExpiredPassword(ctx, token, "new-password3", 1); !errors.Is(err, ErrInvalidChangeToken) {
+		t.Fatalf("used token: err = %v, want ErrInvalidChangeToken", err)
+	}
+}
+
+func TestChangeExpiredPassword__OtherApp(t *testing.T) {
+	s, db := newExpiredPasswordService(t)
+	ctx := context.Background()
+	token := expiredLogin(t, s)
+
+	if err := s.ChangeExpiredPassword(ctx, token, "new-password2", 2); !errors.Is(err, ErrInvalidChangeToken) {
+		t.Fatalf("another app: err = %v, want ErrInvalidChangeToken", err)
+	}
+	if err := s.ChangeExpiredPassword(ctx, token, "new-password2", 1); !errors.Is(err, ErrInvalidChangeToken) {
+		t.Fatalf("after another app: err = %v, want ErrInvalidChangeToken", err)
+	}
+	if string(db.user.PassHash) != "plain:old-password1" {
+		t.Fatal("password is changed by another app")
+	}
+}
@@ -0,0 +1,412 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/generator"
+	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/lib/notify"
+	"github.com/Grino777/sso/internal/storage"
+	"github.com/Grino777/sso/internal/storage/redis"
+)
+
+const passwordlessOp = "services.auth.passwordless."
+
+var (
+	ErrPasswordlessDisabled = errors.New("passwordless login is not configured")
+	ErrInvalidLoginCode     = errors.New("invalid or expired login code")
+)
+
+// StartPasswordlessLogin отправляет пользователю письмо с одноразовым кодом (PasswordlessCode)
+// или ссылкой (PasswordlessLink) для входа в приложение и возвращает срок их действия.
+// Ответ не зависит от того, существует ли пользователь и было ли письмо отправлено.
+func (s *AuthService) StartPasswordlessLogin(
+	ctx context.Context,
+	username string,
+	appID uint32,
+	method string,
+) (time.Time, error) {
+	const op = passwordlessOp + "StartPasswordlessLogin"
+
+	log := s.Logger.With(slog.String("op", op), slog.String("username", username))
+
+	if !s.passwordlessEnabled() {
+		return time.Time{}, ErrPasswordlessDisabled
+	}
+
+	var ttl time.Duration
+	switch method {
+	case models.PasswordlessCode:
+		ttl = s.Passwordless.CodeTTL
+	case models.PasswordlessLink:
+		ttl = s.Passwordless.LinkTTL
+	default:
+		return time.Time{}, &models.ValidationError{Field: "method", Message: "must be email_code or magic_link"}
+	}
+	if err := models.ValidateUsername(username); err != nil {
+		return time.Time{}, err
+	}
+	if err := ValidateApp(appID); err != nil {
+		return time.Time{}, err
+	}
+	if _, err := s.GetCachedApp(ctx, appID); err != nil {
+		return time.Time{}, err
+	}
+	username, err := s.resolveUsername(ctx, username)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.checkLockout(ctx, username); err != nil {
+		return time.Time{}, err
+	}
+	expiresAt := time.Now().Add(ttl).UTC()
+
+	user, err := s.DB.GetUser(ctx, username)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			log.Debug("passwordless login requested for unknown user")
+			return expiresAt, nil
+		}
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkUserStatus(user); err != nil {
+		log.Warn("passwordless login rejected", logger.Error(err))
+		return expiresAt, nil
+	}
+
+	address, err := s.deliveryAddress(ctx, user)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if address == "" {
+		log.Warn("passwordless login rejected: user has no email address")
+		return expiresAt, nil
+	}
+
+	// Пока действует интервал, прежний код остается в силе, а новое письмо не отправляется
+	allowed, err := s.allowEmail(ctx, "passwordless", user.ID, s.Passwordless.ResendInterval)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !allowed {
+		log.Warn("passwordless login throttled")
+		return expiresAt, nil
+	}
+
+	var key, secret string
+	var msg notify.Message
+	if method == models.PasswordlessCode {
+		if secret, err = randomDigits(s.Passwordless.CodeLength); err != nil {
+			return time.Time{}, fmt.Errorf("%s: %w", op, err)
+		}
+		key = loginCodeKey(appID, user.ID)
+		msg = loginCodeMessage(address, secret, ttl)
+	} else {
+		if secret, err = generator.GenerateRandomString(challengeTokenLength); err != nil {
+			return time.Time{}, fmt.Errorf("%s: %w", op, err)
+		}
+		key = loginLinkKey(secret)
+		msg = loginLinkMessage(address, s.loginLinkURL(secret), ttl)
+	}
+
+	code := models.LoginCode{
+		UserID:    user.ID,
+		AppID:     appID,
+		Hash:      hashLoginSecret(secret),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.Cache.SaveLoginCode(ctx, key, code, ttl); err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.Notifier.Send(ctx, msg); err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("passwordless login started", slog.String("method", method), slog.Uint64("app_id", uint64(appID)))
+	return expiresAt, nil
+}
+
+// LoginWithCode завершает вход кодом из письма
+func (s *AuthService) LoginWithCode(
+	ctx context.Context,
+	username string,
+	code string,
+	appID uint32,
+) (models.Tokens, error) {
+	tokens, user, err := s.loginWithCode(ctx, username, code, appID)
+	s.recordLogin(ctx, user.ID, username, appID, err)
+	return tokens, err
+}
+
+func (s *AuthService) loginWithCode(
+	ctx context.Context,
+	username string,
+	code string,
+	appID uint32,
+) (models.Tokens, models.User, error) {
+	const op = passwordlessOp + "LoginWithCode"
+
+	log := s.Logger.With(slog.String("op", op), slog.String("username", username))
+
+	if !s.passwordlessEnabled() {
+		return models.Tokens{}, models.User{}, ErrPasswordlessDisabled
+	}
+	if err := models.ValidateUsername(username); err != nil {
+		return models.Tokens{}, models.User{}, err
+	}
+	if code == "" {
+		return models.Tokens{}, models.User{}, &models.ValidationError{Field: "code", Message: models.EmptyField}
+	}
+	if err := ValidateApp(appID); err != nil {
+		return models.Tokens{}, models.User{}, err
+	}
+	username, err := s.resolveUsername(ctx, username)
+	if err != nil {
+		return models.Tokens{}, models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := s.checkLockout(ctx, username); err != nil {
+		return models.Tokens{}, models.User{}, err
+	}
+
+	user, err := s.DB.GetUser(ctx, username)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			s.registerLoginFailure(ctx, username)
+			return models.Tokens{}, models.User{}, ErrInvalidLoginCode
+		}
+		return models.Tokens{}, models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key := loginCodeKey(appID, user.ID)
+	loginCode, err := s.takeLoginCode(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrInvalidLoginCode) {
+			s.registerLoginFailure(ctx, username)
+		}
+		return models.Tokens{}, user, err
+	}
+
+	if !checkLoginSecret(loginCode.Hash, code) {
+		log.Warn("invalid login code")
+		s.registerLoginFailure(ctx, username)
+		s.retryLoginCode(ctx, log, key, loginCode)
+		return models.Tokens{}, user, ErrInvalidLoginCode
+	}
+
+	user, _, err = s.completeLogin(ctx, log, user, loginCode.AppID, true)
+	if err != nil {
+		return models.Tokens{}, user, err
+	}
+
+	log.Info("passwordless login completed", slog.String("method", models.PasswordlessCode))
+	return user.Tokens, user, nil
+}
+
+// LoginWithLink завершает вход по токену из ссылки в письме.
+// Ссылка действует только в приложении (appID), в котором она была отправлена.
+func (s *AuthService) LoginWithLink(
+	ctx context.Context,
+	token string,
+	appID uint32,
+) (models.Tokens, error) {
+	tokens, user, appID, err := s.loginWithLink(ctx, token, appID)
+	if user.ID != 0 {
+		s.recordLogin(ctx, user.ID, user.Username, appID, err)
+	}
+	return tokens, err
+}
+
+func (s *AuthService) loginWithLink(
+	ctx context.Context,
+	token string,
+	appID uint32,
+) (models.Tokens, models.User, uint32, error) {
+	const op = passwordlessOp + "LoginWithLink"
+
+	log := s.Logger.With(slog.String("op", op))
+
+	if !s.passwordlessEnabled() {
+		return models.Tokens{}, models.User{}, 0, ErrPasswordlessDisabled
+	}
+	if token == "" {
+		return models.Tokens{}, models.User{}, 0, &models.ValidationError{Field: "token", Message: models.EmptyField}
+	}
+	if err := ValidateApp(appID); err != nil {
+		return models.Tokens{}, models.User{}, 0, err
+	}
+
+	loginCode, err := s.takeLoginCode(ctx, loginLinkKey(token))
+	if err != nil {
+		return models.Tokens{}, models.User{}, 0, err
+	}
+	// Ссылка удалена и не возвращается: токен попал к другому приложению
+	if loginCode.AppID != appID {
+		log.Warn("login link issued for another app", slog.Any("app_id", appID))
+		return models.Tokens{}, models.User{}, 0, ErrInvalidLoginCode
+	}
+
+	user, err := s.DB.GetUserByID(ctx, loginCode.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return models.Tokens{}, models.User{}, loginCode.AppID, ErrInvalidLoginCode
+		}
+		return models.Tokens{}, models.User{}, loginCode.AppID, fmt.Errorf("%s: %w", op, err)
+	}
+	log = log.With(slog.String("username", user.Username))
+
+	if err := s.checkLockout(ctx, user.Username); err != nil {
+		return models.Tokens{}, user, loginCode.AppID, err
+	}
+
+	user, appID, err = s.completeLogin(ctx, log, user, loginCode.AppID, true)
+	if err != nil {
+		return models.Tokens{}, user, appID, err
+	}
+
+	log.Info("passwordless login completed", slog.String("method", models.PasswordlessLink))
+	return user.Tokens, user, appID, nil
+}
+
+func (s *AuthService) passwordlessEnabled() bool {
+	return s.Passwordless.Enabled && s.Notifier != nil
+}
+
+// takeLoginCode возвращает и удаляет код входа
+func (s *AuthService) takeLoginCode(ctx context.Context, key string) (models.LoginCode, error) {
+	const op = passwordlessOp + "takeLoginCode"
+
+	code, err := s.Cache.TakeLoginCode(ctx, key)
+	if err != nil {
+		if errors.Is(err, redis.ErrCacheNotFound) {
+			return models.LoginCode{}, ErrInvalidLoginCode
+		}
+		return models.LoginCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !time.Now().Before(code.ExpiresAt) {
+		return models.LoginCode{}, ErrInvalidLoginCode
+	}
+	return code, nil
+}
+
+// retryLoginCode возвращает код после неверной попытки, пока не исчерпаны попытки
+func (s *AuthService) retryLoginCode(
+	ctx context.Context,
+	log *slog.Logger,
+	key string,
+	code models.LoginCode,
+) {
+	code.Attempts++
+	if code.Attempts >= s.Passwordless.MaxAttempts {
+		log.Warn("login code attempts exhausted")
+		return
+	}
+
+	ttl := time.Until(code.ExpiresAt)
+	if ttl <= 0 {
+		return
+	}
+	if err := s.Cache.SaveLoginCode(ctx, key, code, ttl); err != nil {
+		log.Warn("failed to save login code", logger.Error(err))
+	}
+}
+
+// allowEmail возвращает false, если письмо вида kind уже отправлялось пользователю
+// в течение interval. Отметка об отправке ставится одной командой SET NX EX.
+func (s *AuthService) allowEmail(ctx context.Context, kind string, userID uint64, interval time.Duration) (bool, error) {
+	if interval <= 0 {
+		return true, nil
+	}
+	return s.Cache.SaveNonce(ctx, "email:"+kind, strconv.FormatUint(userID, 10), interval)
+}
+
+func (s *AuthService) loginLinkURL(token string) string {
+	link, err := url.Parse(s.Passwordless.LinkURL)
+	if err != nil {
+		return s.Passwordless.LinkURL + "?token=" + url.QueryEscape(token)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// deliveryAddress возвращает адрес, на который отправляются письма пользователю:
+// подтвержденный email из профиля или, если разрешено UsernameAsAddress, имя пользователя,
+// являющееся адресом. Пустая строка — писать некуда.
+func (s *AuthService) deliveryAddress(ctx context.Context, user models.User) (string, error) {
+	profile, err := s.DB.GetProfile(ctx, user.ID)
+	if err != nil && !errors.Is(err, storage.ErrProfileNotFound) {
+		return "", err
+	}
+	if profile.EmailVerified && profile.Email != "" {
+		return profile.Email, nil
+	}
+	if s.UsernameAsAddress && notify.IsAddress(user.Username) {
+		return user.Username, nil
+	}
+	return "", nil
+}
+
+// Код привязан к пользователю и приложению: новый запрос заменяет предыдущий код
+func loginCodeKey(appID uint32, userID uint64) string {
+	return "code:" + strconv.FormatUint(uint64(appID), 10) + ":" + strconv.FormatUint(userID, 10)
+}
+
+func loginLinkKey(token string) string {
+	return "link:" + hashLoginSecret(token)
+}
+
+func hashLoginSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func checkLoginSecret(hash, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(hashLoginSecret(secret))) == 1
+}
+
+func randomDigits(n int) (string, error) {
+	code := make([]byte, n)
+	for i := range code {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + d.Int64())
+	}
+	return string(code), nil
+}
+
+func loginCodeMessage(to, code string, ttl time.Duration) notify.Message {
+	return notify.Message{
+		To:      to,
+		Subject: "Your login code",
+		Body: fmt.Sprintf(
+			"Your login code is %s.\n\nThe code expires in %s. If you did not try to sign in, ignore this email.\n",
+			code, ttl,
+		),
+	}
+}
+
+func loginLinkMessage(to, link string, ttl time.Duration) notify.Message {
+	return notify.Message{
+		To:      to,
+		Subject: "Your login link",
+		Body: fmt.Sprintf(
+			"Follow the link to sign in:\n\n%s\n\nThe link expires in %s and can be used once. If you did not try to sign in, ignore this email.\n",
+			link, ttl,
+		),
+	}
+}
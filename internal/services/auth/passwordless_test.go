@@ -0,0 +1,272 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	interfaces "github.com/Grino777/sso/internal/interfaces/storage"
+	"github.com/Grino777/sso/internal/lib/notify"
+	"github.com/Grino777/sso/internal/storage"
+	"github.com/alicebob/miniredis/v2"
+)
+
+const testEmail = "alice@example.com"
+
+// testUserStore один пользователь и его профиль (без профиля, если profile.UserID = 0)
+type testUserStore struct {
+	interfaces.Storage
+	user    models.User
+	profile models.Profile
+}
+
+func (s *testUserStore) GetUser(_ context.Context, username string) (models.User, error) {
+	if username != s.user.Username {
+		return models.User{}, storage.ErrUserNotFound
+	}
+	return s.user, nil
+}
+
+func (s *testUserStore) GetUserByID(_ context.Context, userID uint64) (models.User, error) {
+	if userID != s.user.ID {
+		return models.User{}, storage.ErrUserNotFound
+	}
+	return s.user, nil
+}
+
+func (s *testUserStore) GetProfile(_ context.Context, userID uint64) (models.Profile, error) {
+	if s.profile.UserID == 0 || userID != s.profile.UserID {
+		return models.Profile{}, storage.ErrProfileNotFound
+	}
+	return s.profile, nil
+}
+
+// testNotifier запоминает отправленные письма
+type testNotifier struct{ sent []notify.Message }
+
+func (n *testNotifier) Send(_ context.Context, msg notify.Message) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+var loginCodePattern = regexp.MustCompile(`login code is (\d+)\.`)
+
+func newPasswordlessService(t *testing.T) (*AuthService, *testUserStore, *testNotifier, *miniredis.Miniredis) {
+	t.Helper()
+
+	cache, mr := newTestCache(t)
+	if err := cache.SaveApp(context.Background(), models.App{ID: 1, Name: "app", Secret: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	db := &testUserStore{
+		user:    models.User{ID: 7, Username: testEmail},
+		profile: models.Profile{UserID: 7, Email: testEmail, EmailVerified: true},
+	}
+	notifier := &testNotifier{}
+	lockout := false
+
+	return NewAuthService(AuthService{
+		Logger:   testLogger(),
+		DB:       db,
+		Cache:    cache,
+		Lockout:  config.LockoutConfig{Enabled: &lockout},
+		Notifier: notifier,
+		Passwordless: config.PasswordlessConfig{
+			Enabled:        true,
+			CodeLength:     6,
+			CodeTTL:        10 * time.Minute,
+			MaxAttempts:    3,
+			LinkURL:        "https://app.example.com/login/link",
+			LinkTTL:        15 * time.Minute,
+			ResendInterval: time.Minute,
+		},
+	}, nil), db, notifier, mr
+}
+
+// startLogin запрашивает письмо для входа и возвращает код или токен ссылки из него
+func startLogin(t *testing.T, s *AuthService, n *testNotifier, method string) string {
+	t.Helper()
+
+	sent := len(n.sent)
+	if _, err := s.StartPasswordlessLogin(context.Background(), testEmail, 1, method); err != nil {
+		t.Fatal(err)
+	}
+	if len(n.sent) != sent+1 {
+		t.Fatalf("sent %d emails, want 1", len(n.sent)-sent)
+	}
+
+	body := n.sent[len(n.sent)-1].Body
+	if method == models.PasswordlessCode {
+		return loginCodePattern.FindStringSubmatch(body)[1]
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if link, err := url.Parse(line); err == nil && link.Query().Has("token") {
+			return link.Query().Get("token")
+		}
+	}
+	t.Fatalf("no link in %q", body)
+	return ""
+}
+
+// wrongCode возвращает код той же длины, не совпадающий с code
+func wrongCode(code string) string {
+	if code[0] == '0' {
+		return "1" + code[1:]
+	}
+	return "0" + code[1:]
+}
+
+func TestLoginWithCode__Attempts(t *testing.T) {
+	s, db, notifier, _ := newPasswordlessService(t)
+	ctx := context.Background()
+
+	code := startLogin(t, s, notifier, models.PasswordlessCode)
+	for i := range 2 {
+		if _, _, err := s.loginWithCode(ctx, testEmail, wrongCode(code), 1); !errors.Is(err, ErrInvalidLoginCode) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidLoginCode", i+1, err)
+		}
+	}
+
+	// Верный код принят: вход останавливается только на проверке статуса пользователя
+	db.user.Disabled = true
+	if _, _, err := s.loginWithCode(ctx, testEmail, code, 1); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("valid code: err = %v, want ErrUserDisabled", err)
+	}
+	if _, _, err := s.loginWithCode(ctx, testEmail, code, 1); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Fatalf("used code: err = %v, want ErrInvalidLoginCode", err)
+	}
+}
+
+func TestLoginWithCode__AttemptsExhausted(t *testing.T) {
+	s, _, notifier, _ := newPasswordlessService(t)
+	ctx := context.Background()
+
+	code := startLogin(t, s, notifier, models.PasswordlessCode)
+	for range 3 {
+		if _, _, err := s.loginWithCode(ctx, testEmail, wrongCode(code), 1); !errors.Is(err, ErrInvalidLoginCode) {
+			t.Fatalf("err = %v, want ErrInvalidLoginCode", err)
+		}
+	}
+	if _, _, err := s.loginWithCode(ctx, testEmail, code, 1); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Fatalf("after max attempts: err = %v, want ErrInvalidLoginCode", err)
+	}
+}
+
+func TestLoginWithCode__OtherApp(t *testing.T) {
+	s, _, notifier, _ := newPasswordlessService(t)
+
+	code := startLogin(t, s, notifier, models.PasswordlessCode)
+	if _, _, err := s.loginWithCode(context.Background(), testEmail, code, 2); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Fatalf("err = %v, want ErrInvalidLoginCode", err)
+	}
+}
+
+func TestLoginWithLink__SingleUse(t *testing.T) {
+	s, db, notifier, _ := newPasswordlessService(t)
+	ctx := context.Background()
+
+	token := startLogin(t, s, notifier, models.PasswordlessLink)
+
+	db.user.Disabled = true
+	if _, _, _, err := s.loginWithLink(ctx, token, 1); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("valid link: err = %v, want ErrUserDisabled", err)
+	}
+	if _, _, _, err := s.loginWithLink(ctx, token, 1); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Fatalf("used link: err = %v, want ErrInvalidLoginCode", err)
+	}
+}
+
+func TestLoginWithLink__OtherApp(t *testing.T) {
+	s, _, notifier, _ := newPasswordlessService(t)
+	ctx := context.Background()
+
+	token := startLogin(t, s, notifier, models.PasswordlessLink)
+	if _, _, _, err := s.loginWithLink(ctx, token, 2); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Fatalf("another app: err = %v, want ErrInvalidLoginCode", err)
+	}
+	if _, _, _, err := s.loginWithLink(ctx, token, 1); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Fatalf("after another app: err = %v, want ErrInvalidLoginCode", err)
+	}
+}
+
+func TestStartPasswordlessLogin__ResendInterval(t *testing.T) {
+	s, db, notifier, mr := newPasswordlessService(t)
+	ctx := context.Background()
+
+	code := startLogin(t, s, notifier, models.PasswordlessCode)
+
+	// Повторный запрос в пределах интервала не отправляет письмо и не заменяет код
+	if _, err := s.StartPasswordlessLogin(ctx, testEmail, 1, models.PasswordlessCode); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.StartPasswordlessLogin(ctx, testEmail, 1, models.PasswordlessLink); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.sent) != 1 {
+		t.Fatalf("sent %d emails, want 1", len(notifier.sent))
+	}
+
+	mr.FastForward(time.Minute)
+	next := startLogin(t, s, notifier, models.PasswordlessCode)
+
+	db.user.Disabled = true
+	if code != next {
+		if _, _, err := s.loginWithCode(ctx, testEmail, code, 1); !errors.Is(err, ErrInvalidLoginCode) {
+			t.Fatalf("replaced code: err = %v, want ErrInvalidLoginCode", err)
+		}
+	}
+	if _, _, err := s.loginWithCode(ctx, testEmail, next, 1); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("new code: err = %v, want ErrUserDisabled", err)
+	}
+}
+
+func TestDeliveryAddress(t *testing.T) {
+	s, db, _, _ := newPasswordlessService(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name              string
+		profile           models.Profile
+		usernameAsAddress bool
+		want              string
+	}{
+		{"verified email", models.Profile{UserID: 7, Email: "bob@example.com", EmailVerified: true}, false, "bob@example.com"},
+		{"unverified email", models.Profile{UserID: 7, Email: "bob@example.com"}, false, ""},
+		{"no profile", models.Profile{}, false, ""},
+		{"username as address", models.Profile{}, true, testEmail},
+		{"verified email before username", models.Profile{UserID: 7, Email: "bob@example.com", EmailVerified: true}, true, "bob@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db.profile = tt.profile
+			s.UsernameAsAddress = tt.usernameAsAddress
+
+			got, err := s.deliveryAddress(ctx, db.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("address = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartPasswordlessLogin__UnverifiedAddress(t *testing.T) {
+	s, db, notifier, _ := newPasswordlessService(t)
+	db.profile = models.Profile{}
+
+	// Имя пользователя похоже на адрес, но не подтверждено: письмо не отправляется
+	if _, err := s.StartPasswordlessLogin(context.Background(), testEmail, 1, models.PasswordlessCode); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.sent) != 0 {
+		t.Fatalf("sent %d emails to an unverified address", len(notifier.sent))
+	}
+}
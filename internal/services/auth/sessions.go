@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/lib/reqctx"
+)
+
+// newSession создает сеанс пользователя в приложении по данным текущего запроса
+func newSession(ctx context.Context, userID uint64, appID uint32) (models.Session, error) {
+	id, err := models.NewSessionID()
+	if err != nil {
+		return models.Session{}, err
+	}
+
+	now := time.Now().UTC()
+	session := touchSession(ctx, models.Session{
+		ID:        id,
+		UserID:    userID,
+		AppID:     appID,
+		CreatedAt: now,
+	})
+	return session, nil
+}
+
+// touchSession обновляет время использования сеанса и данные клиента из текущего запроса.
+// Имя устройства сохраняется прежним, если клиент его не передал.
+func touchSession(ctx context.Context, session models.Session) models.Session {
+	info := reqctx.FromContext(ctx)
+
+	session.IP = info.ClientIP
+	if session.IP == "" {
+		session.IP = models.UnknownIP
+	}
+	session.UserAgent = info.UserAgent
+	if info.DeviceName != "" {
+		session.DeviceName = info.DeviceName
+	}
+	session.LastUsedAt = time.Now().UTC()
+	return session
+}
+
+// evictSessions отзывает самые старые сеансы пользователя в приложении сверх ограничения.
+// Ошибка не мешает входу: новый сеанс уже создан.
+func (s *AuthService) evictSessions(ctx context.Context, log *slog.Logger, userID uint64, appID uint32) {
+	limit := s.Sessions.PerAppLimit()
+	if limit <= 0 {
+		return
+	}
+
+	evicted, err := s.DB.TrimSessions(ctx, userID, appID, limit)
+	if err != nil {
+		log.Warn("failed to evict old sessions", logger.Error(err))
+		return
+	}
+	if evicted > 0 {
+		log.Info("old sessions evicted", slog.Int64("count", evicted), slog.Any("app_id", appID))
+	}
+}
+
+// offlineAccess проверяет, выдать ли offline сеанс: клиент его запросил и приложению это разрешено.
+// Если приложению offline доступ не разрешен, выдается обычный сеанс.
+func (s *AuthService) offlineAccess(log *slog.Logger, app models.App, requested bool) bool {
+	if !requested {
+		return false
+	}
+	if !app.OfflineAccess {
+		log.Warn("offline access is not allowed for the app, issuing a regular session", slog.Any("app_id", app.ID))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/reqctx"
+)
+
+func TestLogin__OfflineAccess(t *testing.T) {
+	s, db, userID := newLoginService(t)
+	s.Tokens.OfflineTokenTTL = 30 * 24 * time.Hour
+	ctx := context.Background()
+
+	regularApp := createTestApp(t, db, models.App{Name: "regular"})
+	offlineApp := createTestApp(t, db, models.App{Name: "offline"})
+	app, err := db.GetApp(ctx, offlineApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.OfflineAccess = true
+	if err := db.UpdateApp(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+
+	offlineCtx := reqctx.WithInfo(ctx, reqctx.Info{OfflineAccess: true})
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		appID   uint32
+		offline bool
+		ttl     time.Duration
+	}{
+		{"not requested", ctx, offlineApp, false, s.Tokens.RefreshTokenTTL},
+		{"not allowed for app", offlineCtx, regularApp, false, s.Tokens.RefreshTokenTTL},
+		{"allowed for app", offlineCtx, offlineApp, true, s.Tokens.OfflineTokenTTL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := s.Login(tt.ctx, testUsername, testPassword, tt.appID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			info, err := db.GetRefreshToken(ctx, tokens.RefreshToken.Token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Session.Offline != tt.offline {
+				t.Fatalf("offline = %v, want %v", info.Session.Offline, tt.offline)
+			}
+			expiresIn := time.Until(time.Unix(tokens.RefreshToken.Expire_at, 0))
+			if expiresIn < tt.ttl-time.Minute || expiresIn > tt.ttl {
+				t.Fatalf("refresh token expires in %s, want %s", expiresIn, tt.ttl)
+			}
+		})
+	}
+
+	offline := true
+	sessions, err := db.ListSessions(ctx, models.SessionFilter{UserID: userID, Offline: &offline})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].AppID != offlineApp {
+		t.Fatalf("offline sessions = %+v", sessions)
+	}
+
+	// Offline сеанс отклоняется при обновлении, если приложению запретили offline доступ
+	tokens, err := s.Login(offlineCtx, testUsername, testPassword, offlineApp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens, err = s.RefreshToken(ctx, tokens.RefreshToken.Token, offlineApp); err != nil {
+		t.Fatal(err)
+	}
+	app.OfflineAccess = false
+	if err := db.UpdateApp(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Cache.DeleteApp(ctx, offlineApp); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RefreshToken(ctx, tokens.RefreshToken.Token, offlineApp); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("refresh after offline access revoked: err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
@@ -5,12 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
+	"github.com/Grino777/sso/internal/config"
 	"github.com/Grino777/sso/internal/domain/models"
 	"github.com/Grino777/sso/internal/lib/jwt"
 	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/storage"
 	"github.com/Grino777/sso/internal/storage/sqlite"
-	"golang.org/x/crypto/bcrypt"
 )
 
 const authUOp = "services.auth.utils."
@@ -27,15 +29,20 @@ func ValidateApp(appID uint32) error {
 	return err
 }
 
+// ValidateData проверяет данные входа. Требования к длине и сложности относятся
+// только к новым паролям, поэтому пароль здесь проверяется лишь на пустоту.
 func ValidateData(
 	ctx context.Context,
 	username, password string,
 	appID uint32,
 ) error {
-	err := ValidateUser(username, password)
+	err := models.ValidateUsername(username)
 	if err != nil {
 		return err
 	}
+	if password == "" {
+		return &models.ValidationError{Field: "password", Message: models.EmptyField}
+	}
 
 	err = ValidateApp(appID)
 	if err != nil {
@@ -45,59 +52,217 @@ func ValidateData(
 	return nil
 }
 
-func (s *AuthService) generateUserTokens(ctx context.Context, user models.User, app models.App) (models.User, error) {
+// checkUserStatus проверяет, что учетная запись не отключена и не заблокирована
+func checkUserStatus(user models.User) error {
+	if user.Disabled {
+		return ErrUserDisabled
+	}
+	if user.IsLocked(time.Now()) {
+		return ErrUserLocked
+	}
+	return nil
+}
+
+// generateUserTokens выпускает токены для нового сеанса пользователя в приложении.
+// offline сеанс получает refresh токен со сроком offline доступа приложения.
+// Сеансы сверх ограничения на приложение отзываются, начиная с самых старых.
+func (s *AuthService) generateUserTokens(
+	ctx context.Context,
+	user models.User,
+	app models.App,
+	cnf *models.Confirmation,
+	offline bool,
+) (models.User, error) {
 	const op = authUOp + "generateUserTokens"
 
 	log := s.Logger.With(slog.String("op", op), slog.String("username", user.Username))
 
-	privateKey, err := s.KeysStore.GetLatestPrivateKey()
+	session, err := newSession(ctx, user.ID, app.ID)
 	if err != nil {
-		return models.User{}, err
+		log.Error("failed to create session", logger.Error(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
+	session.Offline = offline
 
-	tokens, err := jwt.CreateNewTokens(user, app, privateKey, s.Tokens)
+	user, err = s.issueTokens(ctx, log, user, app, session, cnf)
 	if err != nil {
-		log.Error("failed to create new tokens", logger.Error(err))
 		return models.User{}, err
 	}
 
 	for attempts := 0; attempts < 10; attempts++ {
-		if err := s.DB.SaveRefreshToken(ctx, user.ID, app.ID, tokens.RefreshToken); err != nil {
+		if err := s.DB.SaveRefreshToken(ctx, session, user.Tokens.RefreshToken); err != nil {
 			if errors.Is(err, sqlite.ErrRefreshTokenExist) {
 				log.Debug("refresh token already exists, generating new token")
-				refreshToken, err := jwt.NewRefreshToken(s.Tokens.RefreshTokenTTL)
-				if err != nil {
+				if err := s.regenerateRefreshToken(&user.Tokens, app, session, cnf); err != nil {
 					log.Error("failed to generate new refresh token", logger.Error(err))
 					return models.User{}, fmt.Errorf("%s: failed to generate new refresh token: %w", op, err)
 				}
-				tokens.RefreshToken = refreshToken
 				continue // Повторяем попытку с новым токеном
 			}
 			log.Error("failed to save refresh token", logger.Error(err))
 			return models.User{}, fmt.Errorf("%s: failed to save refresh token: %w", op, err)
 		}
+		log.Debug("session created", slog.String("session_id", session.ID), slog.Bool("offline", offline))
+		break
+	}
+
+	s.evictSessions(ctx, log, user.ID, app.ID)
+	return user, nil
+}
+
+// refreshUserTokens выпускает новые токены для сеанса info и заменяет его refresh токен.
+// Если сеанс отозван или токен уже использован параллельным запросом, возвращается ErrInvalidRefreshToken.
+func (s *AuthService) refreshUserTokens(
+	ctx context.Context,
+	user models.User,
+	app models.App,
+	info models.RefreshTokenInfo,
+	cnf *models.Confirmation,
+) (models.User, error) {
+	const op = authUOp + "refreshUserTokens"
+
+	log := s.Logger.With(
+		slog.String("op", op),
+		slog.String("username", user.Username),
+		slog.String("session_id", info.Session.ID),
+	)
+
+	session := touchSession(ctx, info.Session)
+
+	user, err := s.issueTokens(ctx, log, user, app, session, cnf)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	for attempts := 0; attempts < 10; attempts++ {
+		if err := s.DB.RotateRefreshToken(ctx, session, info.Token.Token, user.Tokens.RefreshToken); err != nil {
+			if errors.Is(err, sqlite.ErrRefreshTokenExist) {
+				log.Debug("refresh token already exists, generating new token")
+				if err := s.regenerateRefreshToken(&user.Tokens, app, session, cnf); err != nil {
+					log.Error("failed to generate new refresh token", logger.Error(err))
+					return models.User{}, fmt.Errorf("%s: failed to generate new refresh token: %w", op, err)
+				}
+				continue // Повторяем попытку с новым токеном
+			}
+			if errors.Is(err, storage.ErrTokenNotFound) {
+				log.Warn("session revoked or refresh token already used")
+				return models.User{}, ErrInvalidRefreshToken
+			}
+			log.Error("failed to rotate refresh token", logger.Error(err))
+			return models.User{}, fmt.Errorf("%s: failed to rotate refresh token: %w", op, err)
+		}
 		log.Debug("refresh token updated")
 		break
 	}
+	return user, nil
+}
+
+// issueTokens выпускает access и refresh токены пользователя для сеанса session
+// со сроками действия, заданными для приложения
+func (s *AuthService) issueTokens(
+	ctx context.Context,
+	log *slog.Logger,
+	user models.User,
+	app models.App,
+	session models.Session,
+	cnf *models.Confirmation,
+) (models.User, error) {
+	const op = authUOp + "issueTokens"
+
+	// Роли читаются из БД при каждом выпуске, чтобы изменения прав применялись без сброса кэша
+	roles, err := s.DB.GetUserRoles(ctx, user.ID, app.ID)
+	if err != nil {
+		log.Error("failed to get user roles", logger.Error(err))
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	user.Access = models.NewAccess(roles)
+
+	privateKey, err := s.KeysStore.GetLatestPrivateKey()
+	if err != nil {
+		return models.User{}, err
+	}
+
+	lifetimes := s.lifetimes(app).For(session)
+	ttl := config.TTLConfig{TokenTTL: lifetimes.AccessTTL, RefreshTokenTTL: lifetimes.RefreshTTL}
+
+	tokens, err := jwt.CreateNewTokens(user, app, privateKey, ttl, session.ID, cnf)
+	if err != nil {
+		log.Error("failed to create new tokens", logger.Error(err))
+		return models.User{}, err
+	}
+	tokens.RefreshToken.Expire_at = lifetimes.RefreshExpiry(session, time.Now().UTC()).Unix()
 	user.Tokens = tokens
 
 	return user, nil
 }
 
-func (s *AuthService) validatePassword(passHash []byte, password string) error {
+// regenerateRefreshToken заменяет refresh токен, совпавший с уже выданным
+func (s *AuthService) regenerateRefreshToken(
+	tokens *models.Tokens,
+	app models.App,
+	session models.Session,
+	cnf *models.Confirmation,
+) error {
+	lifetimes := s.lifetimes(app).For(session)
+
+	refreshToken, err := jwt.NewRefreshToken(lifetimes.RefreshTTL)
+	if err != nil {
+		return err
+	}
+	refreshToken.Expire_at = lifetimes.RefreshExpiry(session, time.Now().UTC()).Unix()
+	refreshToken.Cnf = cnf
+	tokens.RefreshToken = refreshToken
+	return nil
+}
+
+// lifetimes возвращает сроки токенов и сеансов приложения с глобальными значениями по умолчанию
+func (s *AuthService) lifetimes(app models.App) models.SessionLifetimes {
+	return app.Lifetimes.Or(models.SessionLifetimes{
+		AccessTTL:   s.Tokens.TokenTTL,
+		RefreshTTL:  s.Tokens.RefreshTokenTTL,
+		IdleTimeout: s.Tokens.IdleTimeout,
+		MaxAge:      s.Tokens.MaxSessionAge,
+		OfflineTTL:  s.Tokens.OfflineTokenTTL,
+	})
+}
+
+// validatePassword проверяет пароль пользователя. Если хэш создан устаревшим алгоритмом
+// или параметрами, он пересоздается текущими настройками; ошибка пересоздания не мешает входу.
+func (s *AuthService) validatePassword(ctx context.Context, user models.User, password string) error {
 	const op = authUOp + "validatePassword"
 
 	log := s.Logger.With(slog.String("op", op))
 
-	if err := bcrypt.CompareHashAndPassword(passHash, []byte(password)); err != nil {
-		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
-			log.Error("invalid credentials", logger.Error(err))
-			return ErrInvalidCredentials
-		}
-		log.Error("%s:%w", op, err)
-		return err
+	match, rehash, err := s.Hasher.Verify(user.PassHash, password)
+	if err != nil {
+		log.Error("failed to verify password", logger.Error(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !match {
+		log.Error("invalid credentials", logger.Error(ErrInvalidCredentials))
+		return ErrInvalidCredentials
+	}
+	if rehash {
+		s.rehashPassword(ctx, log, user, password)
 	}
 	return nil
 }
 
-func (s *AuthService) loggingUserLogin() {}
+// rehashPassword сохраняет хэш пароля, созданный текущими настройками хэширования
+func (s *AuthService) rehashPassword(ctx context.Context, log *slog.Logger, user models.User, password string) {
+	log = log.With(slog.String("username", user.Username))
+
+	passHash, err := s.Hasher.Hash(password)
+	if err != nil {
+		log.Warn("failed to rehash password", logger.Error(err))
+		return
+	}
+	if err := s.DB.RehashUserPassword(ctx, user.ID, user.PassHash, []byte(passHash)); err != nil {
+		log.Warn("failed to save rehashed password", logger.Error(err))
+		return
+	}
+	if err := s.Cache.DeleteUser(ctx, user.Username); err != nil {
+		log.Warn("failed to invalidate cached user", logger.Error(err))
+	}
+	log.Info("password rehashed")
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -15,6 +16,8 @@ const (
 	opStore = "keys.store."
 )
 
+var ErrKeyNotFound = errors.New("key not found")
+
 // KeysStore represents the keys store
 type KeysStore struct {
 	mu          sync.RWMutex
@@ -141,6 +144,18 @@ func (ks *KeysStore) GetPublicKeys() ([]*models.JWKSToken, error) {
 	return data, nil
 }
 
+// GetPublicKey returns an unexpired public key by its ID
+func (ks *KeysStore) GetPublicKey(kid string) (*models.PublicKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	publicKey, ok := ks.PublicKeys[kid]
+	if !ok || publicKey.IsExpired() {
+		return nil, ErrKeyNotFound
+	}
+	return publicKey, nil
+}
+
 // EnsurePublicKeys ensures that at least one public key is present.
 // If keys are missing, it generates a new pair.
 func (ks *KeysStore) EnsurePublicKeys() error {
@@ -0,0 +1,288 @@
+// Пакет бизнес-логики второго фактора аутентификации (TOTP и коды восстановления)
+package mfa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	interfaces "github.com/Grino777/sso/internal/interfaces/storage"
+	"github.com/Grino777/sso/internal/lib/totp"
+	"github.com/Grino777/sso/internal/storage"
+)
+
+const mfaOp = "services.mfa."
+
+// Коды восстановления: 10 символов без похожих друг на друга (0/o, 1/l/i)
+const (
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+var (
+	ErrMFANotEnrolled    = errors.New("mfa is not enrolled")
+	ErrMFAAlreadyEnabled = errors.New("mfa is already enabled")
+	ErrInvalidMFACode    = errors.New("invalid mfa code")
+)
+
+type MFAService struct {
+	logger *slog.Logger
+	db     interfaces.Storage
+	cfg    config.MFAConfig
+}
+
+func NewMFAService(
+	log *slog.Logger,
+	db interfaces.Storage,
+	cfg config.MFAConfig,
+) *MFAService {
+	log.Debug("mfa service successfully initialized")
+
+	return &MFAService{
+		logger: log,
+		db:     db,
+		cfg:    cfg,
+	}
+}
+
+// Status возвращает настройки MFA пользователя
+func (s *MFAService) Status(ctx context.Context, userID uint64) (models.UserMFA, error) {
+	const op = mfaOp + "Status"
+
+	mfa, err := s.db.GetUserMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFANotFound) {
+			return models.UserMFA{UserID: userID}, nil
+		}
+		return models.UserMFA{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return mfa, nil
+}
+
+// Enabled возвращает true, если пользователь подтвердил привязку TOTP
+func (s *MFAService) Enabled(ctx context.Context, userID uint64) (bool, error) {
+	mfa, err := s.Status(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return mfa.Enabled, nil
+}
+
+// Enroll создает новый секрет TOTP. MFA включается только после Confirm,
+// повторный вызов до подтверждения заменяет секрет.
+func (s *MFAService) Enroll(ctx context.Context, user models.User) (models.TOTPEnrollment, error) {
+	const op = mfaOp + "Enroll"
+
+	enabled, err := s.Enabled(ctx, user.ID)
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if enabled {
+		return models.TOTPEnrollment{}, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	mfa := models.UserMFA{UserID: user.ID, TOTPSecret: secret, CreatedAt: time.Now().UTC()}
+	if err := s.db.SaveUserMFA(ctx, mfa); err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.logger.Info("totp enrollment started", slog.String("op", op), slog.Uint64("user_id", user.ID))
+	return models.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.ProvisioningURI(secret, s.cfg.Issuer, user.Username),
+	}, nil
+}
+
+// Confirm включает MFA, если code совпадает с новым секретом,
+// и возвращает коды восстановления. Коды показываются один раз.
+func (s *MFAService) Confirm(ctx context.Context, userID uint64, code string) ([]string, error) {
+	const op = mfaOp + "Confirm"
+
+	mfa, err := s.db.GetUserMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFANotFound) {
+			return nil, ErrMFANotEnrolled
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if mfa.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, ok, err := totp.Validate(mfa.TOTPSecret, normalizeCode(code), time.Now(), s.cfg.Skew)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := s.newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.EnableUserMFA(ctx, userID, step, hashes); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.logger.Info("mfa enabled", slog.String("op", op), slog.Uint64("user_id", userID))
+	return codes, nil
+}
+
+// Verify проверяет код TOTP или код восстановления пользователя.
+// Каждый код принимается один раз.
+func (s *MFAService) Verify(ctx context.Context, userID uint64, code string) error {
+	const op = mfaOp + "Verify"
+
+	log := s.logger.With(slog.String("op", op), slog.Uint64("user_id", userID))
+
+	mfa, err := s.db.GetUserMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrMFANotFound) {
+			return ErrMFANotEnrolled
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !mfa.Enabled {
+		return ErrMFANotEnrolled
+	}
+
+	code = normalizeCode(code)
+
+	if len(code) == totp.Digits {
+		step, ok, err := totp.Validate(mfa.TOTPSecret, code, time.Now(), s.cfg.Skew)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if !ok {
+			return ErrInvalidMFACode
+		}
+
+		fresh, err := s.db.UseTOTPStep(ctx, userID, step)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if !fresh {
+			log.Warn("totp code reused")
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.db.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+
+	log.Info("recovery code used", slog.Int("recovery_codes_left", mfa.RecoveryCodesLeft-1))
+	return nil
+}
+
+// Disable отключает MFA после проверки кода
+func (s *MFAService) Disable(ctx context.Context, userID uint64, code string) error {
+	const op = mfaOp + "Disable"
+
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+
+	if err := s.Reset(ctx, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления после проверки кода
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID uint64, code string) ([]string, error) {
+	const op = mfaOp + "RegenerateRecoveryCodes"
+
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := s.newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.db.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.logger.Info("recovery codes regenerated", slog.String("op", op), slog.Uint64("user_id", userID))
+	return codes, nil
+}
+
+// Reset удаляет настройки MFA пользователя без проверки кода
+func (s *MFAService) Reset(ctx context.Context, userID uint64) error {
+	const op = mfaOp + "Reset"
+
+	if err := s.db.DeleteUserMFA(ctx, userID); err != nil {
+		if errors.Is(err, storage.ErrMFANotFound) {
+			return ErrMFANotEnrolled
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.logger.Info("mfa disabled", slog.String("op", op), slog.Uint64("user_id", userID))
+	return nil
+}
+
+// newRecoveryCodes возвращает коды восстановления вида xxxxx-xxxxx и их хэши
+func (s *MFAService) newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, s.cfg.RecoveryCodes)
+	hashes := make([]string, 0, s.cfg.RecoveryCodes)
+
+	for range s.cfg.RecoveryCodes {
+		raw, err := randomCode(recoveryCodeLength)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, raw[:recoveryCodeLength/2]+"-"+raw[recoveryCodeLength/2:])
+		hashes = append(hashes, hashRecoveryCode(raw))
+	}
+	return codes, hashes, nil
+}
+
+func randomCode(n int) (string, error) {
+	code := make([]byte, n)
+	for i := range code {
+		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = recoveryCodeAlphabet[idx.Int64()]
+	}
+	return string(code), nil
+}
+
+// normalizeCode убирает пробелы и дефисы и приводит код к нижнему регистру
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+// hashRecoveryCode возвращает хэш кода восстановления. Коды случайные и длинные,
+// поэтому медленная функция хэширования не требуется.
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
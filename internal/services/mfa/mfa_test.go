@@ -0,0 +1,224 @@
+package mfa
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	interfaces "github.com/Grino777/sso/internal/interfaces/storage"
+	"github.com/Grino777/sso/internal/lib/totp"
+	"github.com/Grino777/sso/internal/storage"
+)
+
+const testUserID = 7
+
+// testStore хранит настройки MFA одного пользователя в памяти
+type testStore struct {
+	interfaces.Storage
+	mfa      *models.UserMFA
+	recovery map[string]bool // хэш кода -> использован
+}
+
+func (s *testStore) GetUserMFA(_ context.Context, userID uint64) (models.UserMFA, error) {
+	if s.mfa == nil || userID != s.mfa.UserID {
+		return models.UserMFA{}, storage.ErrMFANotFound
+	}
+	mfa := *s.mfa
+	for _, used := range s.recovery {
+		if !used {
+			mfa.RecoveryCodesLeft++
+		}
+	}
+	return mfa, nil
+}
+
+func (s *testStore) SaveUserMFA(_ context.Context, mfa models.UserMFA) error {
+	s.mfa = &mfa
+	s.recovery = nil
+	return nil
+}
+
+func (s *testStore) EnableUserMFA(_ context.Context, _ uint64, step int64, codeHashes []string) error {
+	s.mfa.Enabled = true
+	s.mfa.LastUsedStep = step
+	s.recovery = map[string]bool{}
+	for _, hash := range codeHashes {
+		s.recovery[hash] = false
+	}
+	return nil
+}
+
+func (s *testStore) UseTOTPStep(_ context.Context, _ uint64, step int64) (bool, error) {
+	if step <= s.mfa.LastUsedStep {
+		return false, nil
+	}
+	s.mfa.LastUsedStep = step
+	return true, nil
+}
+
+func (s *testStore) UseRecoveryCode(_ context.Context, _ uint64, codeHash string) (bool, error) {
+	used, ok := s.recovery[codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	s.recovery[codeHash] = true
+	return true, nil
+}
+
+func newTestService(skew int) (*MFAService, *testStore) {
+	db := &testStore{}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewMFAService(log, db, config.MFAConfig{Issuer: "SSO", Skew: skew, RecoveryCodes: 3}), db
+}
+
+// codeAt возвращает код TOTP для шага, отстоящего от текущего на offset
+func codeAt(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+
+	code, err := totp.Code(secret, totp.Step(time.Now())+offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// enroll привязывает TOTP к пользователю кодом самого раннего допустимого шага
+// и возвращает секрет и коды восстановления
+func enroll(t *testing.T, s *MFAService) (string, []string) {
+	t.Helper()
+
+	ctx := context.Background()
+	enrollment, err := s.Enroll(ctx, models.User{ID: testUserID, Username: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, err := s.Confirm(ctx, testUserID, codeAt(t, enrollment.Secret, -int64(s.cfg.Skew)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enrollment.Secret, codes
+}
+
+func TestConfirm(t *testing.T) {
+	s, _ := newTestService(1)
+	ctx := context.Background()
+
+	if _, err := s.Confirm(ctx, testUserID, "123456"); !errors.Is(err, ErrMFANotEnrolled) {
+		t.Fatalf("confirm without enrollment: err = %v", err)
+	}
+
+	enrollment, err := s.Enroll(ctx, models.User{ID: testUserID, Username: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Confirm(ctx, testUserID, codeAt(t, enrollment.Secret, 2)); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("code outside skew: err = %v", err)
+	}
+
+	codes, err := s.Confirm(ctx, testUserID, codeAt(t, enrollment.Secret, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != 3 {
+		t.Fatalf("recovery codes = %d, want 3", len(codes))
+	}
+	if _, err := s.Confirm(ctx, testUserID, codeAt(t, enrollment.Secret, 0)); !errors.Is(err, ErrMFAAlreadyEnabled) {
+		t.Fatalf("second confirm: err = %v", err)
+	}
+}
+
+func TestVerify__TOTPSkew(t *testing.T) {
+	tests := []struct {
+		name   string
+		skew   int
+		offset int64
+		want   error
+	}{
+		{"current step", 1, 0, nil},
+		{"next step within skew", 1, 1, nil},
+		{"step after skew", 1, 2, ErrInvalidMFACode},
+		{"step before skew", 1, -2, ErrInvalidMFACode},
+		{"next step without skew", 0, 1, ErrInvalidMFACode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(tt.skew)
+			secret, _ := enroll(t, s)
+
+			err := s.Verify(context.Background(), testUserID, codeAt(t, secret, tt.offset))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify__ReusedStep(t *testing.T) {
+	s, _ := newTestService(1)
+	secret, _ := enroll(t, s)
+	ctx := context.Background()
+
+	// Код шага, которым подтверждена привязка, уже использован
+	if err := s.Verify(ctx, testUserID, codeAt(t, secret, -1)); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("confirmation code: err = %v", err)
+	}
+
+	code := codeAt(t, secret, 0)
+	if err := s.Verify(ctx, testUserID, code); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Verify(ctx, testUserID, code); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("reused code: err = %v", err)
+	}
+}
+
+func TestVerify__RecoveryCode(t *testing.T) {
+	s, db := newTestService(1)
+	_, codes := enroll(t, s)
+	ctx := context.Background()
+
+	// Код принимается в любом регистре и с пробелами
+	if err := s.Verify(ctx, testUserID, " "+strings.ToUpper(codes[0])+" "); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Verify(ctx, testUserID, codes[0]); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("used recovery code: err = %v", err)
+	}
+	if err := s.Verify(ctx, testUserID, "ABCDE-FGHJK"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("unknown recovery code: err = %v", err)
+	}
+
+	status, err := s.Status(ctx, testUserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.RecoveryCodesLeft != len(codes)-1 {
+		t.Fatalf("recovery codes left = %d, want %d", status.RecoveryCodesLeft, len(codes)-1)
+	}
+	if len(db.recovery) != len(codes) {
+		t.Fatalf("stored recovery codes = %d, want %d", len(db.recovery), len(codes))
+	}
+}
+
+func TestVerify__NotEnabled(t *testing.T) {
+	s, _ := newTestService(1)
+	ctx := context.Background()
+
+	if err := s.Verify(ctx, testUserID, "123456"); !errors.Is(err, ErrMFANotEnrolled) {
+		t.Fatalf("without enrollment: err = %v", err)
+	}
+
+	enrollment, err := s.Enroll(ctx, models.User{ID: testUserID, Username: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Verify(ctx, testUserID, codeAt(t, enrollment.Secret, 0)); !errors.Is(err, ErrMFANotEnrolled) {
+		t.Fatalf("before confirm: err = %v", err)
+	}
+}
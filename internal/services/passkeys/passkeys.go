@@ -0,0 +1,321 @@
+// Пакет бизнес-логики входа по passkey (WebAuthn)
+package passkeys
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	interfaces "github.com/Grino777/sso/internal/interfaces/storage"
+	"github.com/Grino777/sso/internal/lib/webauthn"
+	"github.com/Grino777/sso/internal/storage"
+	"github.com/Grino777/sso/internal/storage/redis"
+)
+
+const passkeysOp = "services.passkeys."
+
+const maxPasskeyNameLength = 64
+
+var (
+	ErrInvalidPasskey   = errors.New("invalid passkey response")
+	ErrPasskeyChallenge = errors.New("invalid or expired passkey challenge")
+	ErrTooManyPasskeys  = errors.New("passkey limit reached")
+)
+
+type PasskeyService struct {
+	logger *slog.Logger
+	db     interfaces.Storage
+	cache  interfaces.CacheStorage
+	rp     *webauthn.RelyingParty
+	cfg    config.WebAuthnConfig
+}
+
+func NewPasskeyService(
+	log *slog.Logger,
+	db interfaces.Storage,
+	cache interfaces.CacheStorage,
+	cfg config.WebAuthnConfig,
+) *PasskeyService {
+	log.Debug("passkey service successfully initialized")
+
+	return &PasskeyService{
+		logger: log,
+		db:     db,
+		cache:  cache,
+		rp: &webauthn.RelyingParty{
+			ID:               cfg.RPID,
+			Name:             cfg.RPName,
+			Origins:          cfg.Origins,
+			UserVerification: cfg.UserVerification,
+			Attestation:      cfg.Attestation,
+			Timeout:          cfg.ChallengeTTL,
+		},
+		cfg: cfg,
+	}
+}
+
+// BeginRegistration возвращает параметры navigator.credentials.create для нового ключа пользователя
+func (s *PasskeyService) BeginRegistration(
+	ctx context.Context,
+	user models.User,
+) (webauthn.CreationOptions, error) {
+	const op = passkeysOp + "BeginRegistration"
+
+	creds, err := s.db.ListWebAuthnCredentials(ctx, user.ID)
+	if err != nil {
+		return webauthn.CreationOptions{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if s.cfg.MaxCredentials > 0 && len(creds) >= s.cfg.MaxCredentials {
+		return webauthn.CreationOptions{}, ErrTooManyPasskeys
+	}
+
+	challenge, err := s.newSession(ctx, models.WebAuthnSession{
+		Ceremony: models.WebAuthnRegistration,
+		UserID:   user.ID,
+	})
+	if err != nil {
+		return webauthn.CreationOptions{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	owner := webauthn.User{
+		ID:          userHandle(user.ID),
+		Name:        user.Username,
+		DisplayName: user.Username,
+	}
+	return s.rp.CreationOptions(challenge, owner, descriptors(creds)), nil
+}
+
+// FinishRegistration проверяет ответ аутентификатора и сохраняет новый ключ
+func (s *PasskeyService) FinishRegistration(
+	ctx context.Context,
+	userID uint64,
+	name string,
+	resp webauthn.RegistrationResponse,
+) (models.WebAuthnCredential, error) {
+	const op = passkeysOp + "FinishRegistration"
+
+	log := s.logger.With(slog.String("op", op), slog.Uint64("user_id", userID))
+
+	if len(name) > maxPasskeyNameLength {
+		return models.WebAuthnCredential{}, &models.ValidationError{Field: "name", Message: "must be at most 64 characters"}
+	}
+
+	challenge, err := resp.Challenge()
+	if err != nil {
+		return models.WebAuthnCredential{}, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+	session, err := s.takeSession(ctx, challenge, models.WebAuthnRegistration)
+	if err != nil {
+		return models.WebAuthnCredential{}, err
+	}
+	if session.UserID != userID {
+		return models.WebAuthnCredential{}, ErrPasskeyChallenge
+	}
+
+	verified, err := s.rp.VerifyRegistration(challenge, resp)
+	if err != nil {
+		log.Warn("passkey registration rejected", slog.String("reason", err.Error()))
+		return models.WebAuthnCredential{}, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+
+	cred := models.WebAuthnCredential{
+		UserID:            userID,
+		CredentialID:      verified.ID,
+		PublicKey:         verified.PublicKey,
+		Algorithm:         verified.Algorithm,
+		SignCount:         verified.SignCount,
+		AAGUID:            verified.AAGUID,
+		AttestationFormat: verified.AttestationFormat,
+		Transports:        resp.Response.Transports,
+		Name:              name,
+		CreatedAt:         time.Now().UTC(),
+	}
+	cred.ID, err = s.db.SaveWebAuthnCredential(ctx, cred)
+	if err != nil {
+		return models.WebAuthnCredential{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("passkey registered", slog.Uint64("passkey_id", cred.ID), slog.String("format", cred.AttestationFormat))
+	return cred, nil
+}
+
+// ListPasskeys возвращает ключи пользователя
+func (s *PasskeyService) ListPasskeys(
+	ctx context.Context,
+	userID uint64,
+) ([]models.WebAuthnCredential, error) {
+	const op = passkeysOp + "ListPasskeys"
+
+	creds, err := s.db.ListWebAuthnCredentials(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return creds, nil
+}
+
+// DeletePasskey удаляет ключ пользователя
+func (s *PasskeyService) DeletePasskey(
+	ctx context.Context,
+	userID uint64,
+	id uint64,
+) error {
+	const op = passkeysOp + "DeletePasskey"
+
+	if err := s.db.DeleteWebAuthnCredential(ctx, userID, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.logger.Info("passkey deleted", slog.String("op", op), slog.Uint64("user_id", userID), slog.Uint64("passkey_id", id))
+	return nil
+}
+
+// BeginLogin возвращает параметры navigator.credentials.get для входа в приложение.
+// Если username пуст или пользователь не найден, allowCredentials пуст
+// и вход возможен ключом, сохраненным в аутентификаторе (discoverable credential).
+func (s *PasskeyService) BeginLogin(
+	ctx context.Context,
+	username string,
+	appID uint32,
+) (webauthn.RequestOptions, error) {
+	const op = passkeysOp + "BeginLogin"
+
+	session := models.WebAuthnSession{Ceremony: models.WebAuthnLogin, AppID: appID}
+	var creds []models.WebAuthnCredential
+
+	if username != "" {
+		user, err := s.db.GetUser(ctx, username)
+		switch {
+		case err == nil:
+			if creds, err = s.db.ListWebAuthnCredentials(ctx, user.ID); err != nil {
+				return webauthn.RequestOptions{}, fmt.Errorf("%s: %w", op, err)
+			}
+			session.UserID = user.ID
+		case errors.Is(err, storage.ErrUserNotFound):
+			// Ответ не должен раскрывать, существует ли пользователь
+		default:
+			return webauthn.RequestOptions{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	challenge, err := s.newSession(ctx, session)
+	if err != nil {
+		return webauthn.RequestOptions{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return s.rp.RequestOptions(challenge, descriptors(creds)), nil
+}
+
+// FinishLogin проверяет подпись ключа и возвращает его владельца
+// вместе с сессией, в которой был выдан challenge.
+// Challenge, выданный другому приложению, отклоняется до проверки подписи.
+func (s *PasskeyService) FinishLogin(
+	ctx context.Context,
+	resp webauthn.AssertionResponse,
+	appID uint32,
+) (models.User, models.WebAuthnSession, webauthn.Assertion, error) {
+	const op = passkeysOp + "FinishLogin"
+
+	challenge, err := resp.Challenge()
+	if err != nil {
+		return models.User{}, models.WebAuthnSession{}, webauthn.Assertion{}, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+	session, err := s.takeSession(ctx, challenge, models.WebAuthnLogin)
+	if err != nil {
+		return models.User{}, models.WebAuthnSession{}, webauthn.Assertion{}, err
+	}
+	if session.AppID != appID {
+		s.logger.Warn("passkey challenge issued for another app", slog.String("op", op), slog.Any("app_id", appID))
+		return models.User{}, session, webauthn.Assertion{}, ErrPasskeyChallenge
+	}
+
+	credentialID, err := resp.CredentialID()
+	if err != nil {
+		return models.User{}, session, webauthn.Assertion{}, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+	cred, err := s.db.GetWebAuthnCredential(ctx, credentialID)
+	if err != nil {
+		if errors.Is(err, storage.ErrCredentialNotFound) {
+			return models.User{}, session, webauthn.Assertion{}, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+		}
+		return models.User{}, session, webauthn.Assertion{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.db.GetUserByID(ctx, cred.UserID)
+	if err != nil {
+		return models.User{}, session, webauthn.Assertion{}, fmt.Errorf("%s: %w", op, err)
+	}
+	log := s.logger.With(slog.String("op", op), slog.String("username", user.Username))
+
+	if session.UserID != 0 && session.UserID != cred.UserID {
+		return user, session, webauthn.Assertion{}, fmt.Errorf("%w: passkey belongs to another user", ErrInvalidPasskey)
+	}
+	handle, err := resp.UserHandle()
+	if err != nil {
+		return user, session, webauthn.Assertion{}, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+	if handle != nil && string(handle) != string(userHandle(cred.UserID)) {
+		return user, session, webauthn.Assertion{}, fmt.Errorf("%w: passkey belongs to another user", ErrInvalidPasskey)
+	}
+
+	assertion, err := s.rp.VerifyAssertion(challenge, cred.PublicKey, cred.SignCount, resp)
+	if err != nil {
+		if errors.Is(err, webauthn.ErrSignCount) {
+			log.Warn("passkey sign counter did not increase, possible cloned authenticator",
+				slog.Uint64("passkey_id", cred.ID))
+		}
+		return user, session, webauthn.Assertion{}, fmt.Errorf("%w: %w", ErrInvalidPasskey, err)
+	}
+
+	if err := s.db.UpdateWebAuthnSignCount(ctx, cred.ID, assertion.SignCount); err != nil {
+		return user, session, webauthn.Assertion{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return user, session, assertion, nil
+}
+
+// newSession создает challenge и сохраняет сессию церемонии
+func (s *PasskeyService) newSession(ctx context.Context, session models.WebAuthnSession) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", err
+	}
+
+	session.ExpiresAt = time.Now().Add(s.cfg.ChallengeTTL).UTC()
+	if err := s.cache.SaveWebAuthnSession(ctx, challenge, session, s.cfg.ChallengeTTL); err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// takeSession возвращает и удаляет сессию церемонии по challenge
+func (s *PasskeyService) takeSession(ctx context.Context, challenge, ceremony string) (models.WebAuthnSession, error) {
+	const op = passkeysOp + "takeSession"
+
+	session, err := s.cache.TakeWebAuthnSession(ctx, challenge)
+	if err != nil {
+		if errors.Is(err, redis.ErrCacheNotFound) {
+			return models.WebAuthnSession{}, ErrPasskeyChallenge
+		}
+		return models.WebAuthnSession{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if session.Ceremony != ceremony || !time.Now().Before(session.ExpiresAt) {
+		return models.WebAuthnSession{}, ErrPasskeyChallenge
+	}
+	return session, nil
+}
+
+// userHandle идентификатор пользователя для аутентификатора (user.id в WebAuthn)
+func userHandle(userID uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, userID)
+}
+
+func descriptors(creds []models.WebAuthnCredential) []webauthn.CredentialDescriptor {
+	result := make([]webauthn.CredentialDescriptor, 0, len(creds))
+	for _, cred := range creds {
+		result = append(result, webauthn.Descriptor(cred.CredentialID, cred.Transports))
+	}
+	return result
+}
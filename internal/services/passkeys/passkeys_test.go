@@ -0,0 +1,79 @@
+package passkeys
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/lib/secretbox"
+	"github.com/Grino777/sso/internal/lib/webauthn"
+	redisStorage "github.com/Grino777/sso/internal/storage/redis"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestService(t *testing.T) *PasskeyService {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mr := miniredis.RunT(t)
+	cache := redisStorage.NewRedisStorage(log, config.RedisConfig{
+		Addr:        mr.Addr(),
+		MaxRetries:  1,
+		DialTimeout: time.Second,
+		Timeout:     time.Second,
+	}, testSecrets(t), nil)
+	if err := cache.Connect(context.Background(), make(chan error, 1)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cache.Close(context.Background()) })
+
+	return NewPasskeyService(log, nil, cache, config.WebAuthnConfig{
+		RPID:         "localhost",
+		Origins:      []string{"https://localhost"},
+		ChallengeTTL: time.Minute,
+	})
+}
+
+// assertionFor возвращает ответ аутентификатора на challenge без подписи
+func assertionFor(challenge string) webauthn.AssertionResponse {
+	var resp webauthn.AssertionResponse
+	resp.Type = "public-key"
+	resp.Response.ClientDataJSON = webauthn.EncodeID([]byte(
+		`{"type":"webauthn.get","challenge":"` + challenge + `","origin":"https://localhost"}`,
+	))
+	return resp
+}
+
+func TestFinishLogin__OtherApp(t *testing.T) {
+	s := newTestService(t)
+	ctx := context.Background()
+
+	options, err := s.BeginLogin(ctx, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Challenge приложения 1 отклоняется другим приложением и больше не действует
+	resp := assertionFor(options.Challenge)
+	if _, _, _, err := s.FinishLogin(ctx, resp, 2); !errors.Is(err, ErrPasskeyChallenge) {
+		t.Fatalf("another app: err = %v, want ErrPasskeyChallenge", err)
+	}
+	if _, _, _, err := s.FinishLogin(ctx, resp, 1); !errors.Is(err, ErrPasskeyChallenge) {
+		t.Fatalf("after another app: err = %v, want ErrPasskeyChallenge", err)
+	}
+}
+
+// testSecrets шифрование секретов приложений на тестовом ключе
+func testSecrets(t *testing.T) *secretbox.Box {
+	t.Helper()
+
+	box, err := secretbox.New("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return box
+}
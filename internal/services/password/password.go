@@ -0,0 +1,147 @@
+// Пакет проверки паролей по политике: сложность, повторное использование и срок действия
+package password
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	interfaces "github.com/Grino777/sso/internal/interfaces/storage"
+	"github.com/Grino777/sso/internal/lib/breached"
+	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/lib/passhash"
+)
+
+const passwordOp = "services.password."
+
+type PasswordService struct {
+	logger *slog.Logger
+	db     interfaces.Storage
+	hasher passhash.PasswordHasher
+	policy models.PasswordPolicy
+	// Срок действия токена смены истекшего пароля
+	changeTokenTTL time.Duration
+	// Список утекших паролей, nil — проверка отключена
+	breached *breached.List
+}
+
+func NewPasswordService(
+	log *slog.Logger,
+	db interfaces.Storage,
+	hasher passhash.PasswordHasher,
+	cfg config.PasswordPolicyConfig,
+) (*PasswordService, error) {
+	const op = passwordOp + "NewPasswordService"
+
+	var list *breached.List
+	if cfg.Breached.Enabled {
+		var err error
+		if list, err = breached.Open(cfg.Breached.Path, cfg.Breached.MinCount); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Debug("password service successfully initialized")
+
+	return &PasswordService{
+		logger:         log,
+		db:             db,
+		hasher:         hasher,
+		breached:       list,
+		changeTokenTTL: cfg.ChangeTokenTTL,
+		policy: models.PasswordPolicy{
+			MinLength:        cfg.MinLength,
+			MaxLength:        cfg.MaxLength,
+			RequireUpper:     cfg.RequireUpper,
+			RequireLower:     cfg.RequireLower,
+			RequireDigit:     cfg.RequireDigit,
+			RequireSymbol:    cfg.RequireSymbol,
+			DisallowUsername: cfg.UsernameDisallowed(),
+			History:          min(cfg.History, models.MaxPasswordHistory),
+			MaxAge:           cfg.MaxAge,
+		},
+	}, nil
+}
+
+// Validate проверяет новый пароль пользователя по политике. Для существующего пользователя
+// (user.ID != 0) пароль также сравнивается с текущим и предыдущими паролями.
+// Ошибка *models.ValidationError перечисляет все нарушенные правила.
+func (s *PasswordService) Validate(ctx context.Context, user models.User, password string) error {
+	const op = passwordOp + "Validate"
+
+	if password == "" {
+		return &models.ValidationError{Field: "password", Message: models.EmptyField}
+	}
+
+	violations := s.policy.Violations(user.Username, password)
+	if user.ID != 0 && s.policy.History > 0 {
+		reused, err := s.reused(ctx, user, password)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if reused {
+			violations = append(violations, fmt.Sprintf("must not match any of the last %d passwords", s.policy.History))
+		}
+	}
+
+	if s.isBreached(password) {
+		violations = append(violations, "must not be a password exposed in a known data breach")
+	}
+
+	if len(violations) > 0 {
+		return &models.ValidationError{Field: "password", Message: models.PasswordPolicyViolation, Details: violations}
+	}
+	return nil
+}
+
+// Expired проверяет, истек ли срок действия пароля пользователя
+func (s *PasswordService) Expired(user models.User) bool {
+	return s.policy.Expired(user, time.Now())
+}
+
+// ChangeTokenTTL возвращает срок действия токена смены истекшего пароля
+func (s *PasswordService) ChangeTokenTTL() time.Duration {
+	return s.changeTokenTTL
+}
+
+// isBreached проверяет пароль по списку утечек. Если список недоступен, проверка пропускается,
+// чтобы ошибка чтения файла не блокировала регистрацию и смену паролей.
+func (s *PasswordService) isBreached(password string) bool {
+	const op = passwordOp + "isBreached"
+
+	if s.breached == nil {
+		return false
+	}
+	found, err := s.breached.Contains(password)
+	if err != nil {
+		s.logger.Error("failed to check breached passwords", slog.String("op", op), logger.Error(err))
+		return false
+	}
+	return found
+}
+
+// reused проверяет, совпадает ли пароль с одним из последних policy.History паролей, включая текущий
+func (s *PasswordService) reused(ctx context.Context, user models.User, password string) (bool, error) {
+	hashes, err := s.db.ListPasswordHistory(ctx, user.ID, s.policy.History-1)
+	if err != nil {
+		return false, err
+	}
+	if len(user.PassHash) > 0 {
+		hashes = append([][]byte{user.PassHash}, hashes...)
+	}
+
+	for _, hash := range hashes {
+		match, _, err := s.hasher.Verify(hash, password)
+		if err != nil {
+			s.logger.Warn("failed to check password history", logger.Error(err))
+			continue
+		}
+		if match {
+			return true, nil
+		}
+	}
+	return false, nil
+}
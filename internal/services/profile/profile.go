@@ -0,0 +1,228 @@
+// Пакет бизнес-логики профиля пользователя и подтверждения email
+package profile
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	interfaces "github.com/Grino777/sso/internal/interfaces/storage"
+	"github.com/Grino777/sso/internal/lib/generator"
+	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/internal/lib/notify"
+	"github.com/Grino777/sso/internal/lib/signedtoken"
+	"github.com/Grino777/sso/internal/storage"
+)
+
+const profileOp = "services.profile."
+
+// Область одноразовых значений кэша для токенов подтверждения email
+const verificationNonceScope = "email_verification"
+
+var (
+	ErrEmailNotSet               = errors.New("profile has no email")
+	ErrEmailAlreadyVerified      = errors.New("email is already verified")
+	ErrInvalidVerificationToken  = errors.New("invalid or expired email verification token")
+	ErrEmailVerificationDisabled = errors.New("email delivery is not configured")
+)
+
+// verificationClaims данные токена подтверждения email
+type verificationClaims struct {
+	UserID    uint64 `json:"uid"`
+	Email     string `json:"email"`
+	ExpiresAt int64  `json:"exp"`
+	ID        string `json:"jti"`
+}
+
+type ProfileService struct {
+	logger   *slog.Logger
+	db       interfaces.Storage
+	cache    interfaces.CacheStorage
+	notifier notify.Notifier
+	cfg      config.EmailVerificationConfig
+	secret   []byte
+}
+
+func NewProfileService(
+	log *slog.Logger,
+	db interfaces.Storage,
+	cache interfaces.CacheStorage,
+	notifier notify.Notifier,
+	cfg config.EmailVerificationConfig,
+) (*ProfileService, error) {
+	const op = profileOp + "NewProfileService"
+
+	secret := []byte(cfg.Secret)
+	if len(secret) == 0 {
+		log.Warn("email verification secret is not set, verification links will not survive restart")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Debug("profile service successfully initialized")
+
+	return &ProfileService{
+		logger:   log,
+		db:       db,
+		cache:    cache,
+		notifier: notifier,
+		cfg:      cfg,
+		secret:   secret,
+	}, nil
+}
+
+// Get возвращает профиль пользователя. Если профиль не заполнялся, возвращается пустой.
+func (s *ProfileService) Get(ctx context.Context, userID uint64) (models.Profile, error) {
+	const op = profileOp + "Get"
+
+	profile, err := s.db.GetProfile(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrProfileNotFound) {
+			return models.Profile{UserID: userID, Attributes: map[string]any{}}, nil
+		}
+		return models.Profile{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return profile, nil
+}
+
+// Update изменяет профиль пользователя. При смене email отправляется письмо для его подтверждения.
+func (s *ProfileService) Update(
+	ctx context.Context,
+	userID uint64,
+	update models.ProfileUpdate,
+) (models.Profile, error) {
+	const op = profileOp + "Update"
+
+	log := s.logger.With(slog.String("op", op), slog.Uint64("user_id", userID))
+
+	profile, err := s.Get(ctx, userID)
+	if err != nil {
+		return models.Profile{}, fmt.Errorf("%s: %w", op, err)
+	}
+	previousEmail := profile.Email
+
+	profile.Apply(update)
+	if err := profile.Validate(); err != nil {
+		return models.Profile{}, err
+	}
+	if err := s.db.SaveProfile(ctx, profile); err != nil {
+		return models.Profile{}, fmt.Errorf("%s: %w", op, err)
+	}
+	profile.UpdatedAt = time.Now().UTC()
+
+	if profile.Email != "" && profile.Email != previousEmail {
+		if err := s.sendVerification(ctx, profile); err != nil {
+			log.Warn("failed to send email verification", logger.Error(err))
+		}
+	}
+
+	log.Info("profile updated")
+	return profile, nil
+}
+
+// SendVerification повторно отправляет письмо для подтверждения email
+func (s *ProfileService) SendVerification(ctx context.Context, userID uint64) error {
+	const op = profileOp + "SendVerification"
+
+	profile, err := s.Get(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if profile.Email == "" {
+		return ErrEmailNotSet
+	}
+	if profile.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+
+	if err := s.sendVerification(ctx, profile); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// VerifyEmail подтверждает email по токену из письма. Токен принимается один раз
+// и только пока email в профиле не изменился.
+func (s *ProfileService) VerifyEmail(ctx context.Context, token string) (models.Profile, error) {
+	const op = profileOp + "VerifyEmail"
+
+	if token == "" {
+		return models.Profile{}, &models.ValidationError{Field: "token", Message: models.EmptyField}
+	}
+
+	var claims verificationClaims
+	if err := signedtoken.Verify(s.secret, token, &claims); err != nil {
+		return models.Profile{}, ErrInvalidVerificationToken
+	}
+	expiresAt := time.Unix(claims.ExpiresAt, 0)
+	if !time.Now().Before(expiresAt) || claims.ID == "" {
+		return models.Profile{}, ErrInvalidVerificationToken
+	}
+
+	fresh, err := s.cache.SaveNonce(ctx, verificationNonceScope, claims.ID, time.Until(expiresAt))
+	if err != nil {
+		return models.Profile{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !fresh {
+		return models.Profile{}, ErrInvalidVerificationToken
+	}
+
+	if err := s.db.VerifyProfileEmail(ctx, claims.UserID, claims.Email); err != nil {
+		if errors.Is(err, storage.ErrProfileNotFound) {
+			return models.Profile{}, ErrInvalidVerificationToken
+		}
+		return models.Profile{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.logger.Info("email verified", slog.String("op", op), slog.Uint64("user_id", claims.UserID))
+	return s.Get(ctx, claims.UserID)
+}
+
+// sendVerification отправляет письмо со ссылкой подтверждения email
+func (s *ProfileService) sendVerification(ctx context.Context, profile models.Profile) error {
+	if s.notifier == nil {
+		return ErrEmailVerificationDisabled
+	}
+
+	id, err := generator.GenerateRandomString(22)
+	if err != nil {
+		return err
+	}
+	token, err := signedtoken.Sign(s.secret, verificationClaims{
+		UserID:    profile.UserID,
+		Email:     profile.Email,
+		ExpiresAt: time.Now().Add(s.cfg.TokenTTL).Unix(),
+		ID:        id,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.Send(ctx, notify.Message{
+		To:      profile.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf(
+			"Follow the link to confirm your email address:\n\n%s\n\nThe link expires in %s. If you did not add this address, ignore this email.\n",
+			s.verificationURL(token), s.cfg.TokenTTL,
+		),
+	})
+}
+
+func (s *ProfileService) verificationURL(token string) string {
+	link, err := url.Parse(s.cfg.LinkURL)
+	if err != nil {
+		return s.cfg.LinkURL + "?token=" + url.QueryEscape(token)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
@@ -0,0 +1,187 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/notify"
+	"github.com/Grino777/sso/internal/lib/secretbox"
+	"github.com/Grino777/sso/internal/storage"
+	redisStorage "github.com/Grino777/sso/internal/storage/redis"
+	"github.com/Grino777/sso/internal/storage/sqlite"
+	"github.com/alicebob/miniredis/v2"
+)
+
+// testHasher хранит пароль открытым текстом, чтобы тесты не тратили время на argon2id
+type testHasher struct{}
+
+func (testHasher) Hash(password string) (string, error) { return "plain:" + password, nil }
+
+func (testHasher) Verify(hash []byte, password string) (bool, bool, error) {
+	return string(hash) == "plain:"+password, false, nil
+}
+
+// testNotifier запоминает отправленные письма
+type testNotifier struct{ sent []notify.Message }
+
+func (n *testNotifier) Send(_ context.Context, msg notify.Message) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+// newTestService возвращает сервис на sqlite во временном файле и miniredis
+// с пользователем alice и id этого пользователя
+func newTestService(t *testing.T) (*ProfileService, *sqlite.SQLiteStorage, *testNotifier, uint64) {
+	t.Helper()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	box, err := secretbox.New("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := sqlite.New(
+		"sqlite3",
+		filepath.Join(t.TempDir(), "sso.sqlite3"),
+		config.SuperUser{Username: "root", Password: "root-password"},
+		testHasher{},
+		box,
+		log,
+	)
+	if err := db.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close(context.Background()) })
+
+	if err := db.SaveUser(context.Background(), "alice", "plain:password1"); err != nil {
+		t.Fatal(err)
+	}
+	user, err := db.GetUser(context.Background(), "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mr := miniredis.RunT(t)
+	cache := redisStorage.NewRedisStorage(log, config.RedisConfig{
+		Addr:        mr.Addr(),
+		MaxRetries:  1,
+		DialTimeout: time.Second,
+		Timeout:     time.Second,
+	}, box, nil)
+	if err := cache.Connect(context.Background(), make(chan error, 1)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = cache.Close(context.Background()) })
+
+	notifier := &testNotifier{}
+	s, err := NewProfileService(log, db, cache, notifier, config.EmailVerificationConfig{
+		LinkURL:  "https://app.example.com/verify-email",
+		TokenTTL: time.Hour,
+		Secret:   "verification-secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, db, notifier, user.ID
+}
+
+// verificationToken возвращает токен из последнего письма, отправленного на email
+func verificationToken(t *testing.T, n *testNotifier, email string) string {
+	t.Helper()
+
+	if len(n.sent) == 0 {
+		t.Fatal("no emails sent")
+	}
+	msg := n.sent[len(n.sent)-1]
+	if msg.To != email {
+		t.Fatalf("email sent to %q, want %q", msg.To, email)
+	}
+	for _, line := range strings.Split(msg.Body, "\n") {
+		if link, err := url.Parse(line); err == nil && link.Query().Has("token") {
+			return link.Query().Get("token")
+		}
+	}
+	t.Fatalf("no link in %q", msg.Body)
+	return ""
+}
+
+func TestVerifyEmail(t *testing.T) {
+	s, db, notifier, userID := newTestService(t)
+	ctx := context.Background()
+
+	email := "Alice@Example.com"
+	profile, err := s.Update(ctx, userID, models.ProfileUpdate{Email: &email})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Email != "alice@example.com" || profile.EmailVerified {
+		t.Fatalf("updated profile = %+v", profile)
+	}
+	token := verificationToken(t, notifier, "alice@example.com")
+
+	// По неподтвержденному email пользователь не находится
+	if _, err := db.GetUserByEmail(ctx, "alice@example.com"); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Fatalf("unverified email: err = %v, want ErrUserNotFound", err)
+	}
+
+	profile, err = s.VerifyEmail(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !profile.EmailVerified {
+		t.Fatalf("verified profile = %+v", profile)
+	}
+	user, err := db.GetUserByEmail(ctx, "alice@example.com")
+	if err != nil || user.ID != userID {
+		t.Fatalf("user by verified email = %+v, err = %v", user, err)
+	}
+
+	if _, err := s.VerifyEmail(ctx, token); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Fatalf("reused token: err = %v, want ErrInvalidVerificationToken", err)
+	}
+	if err := s.SendVerification(ctx, userID); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Fatalf("resend for verified email: err = %v, want ErrEmailAlreadyVerified", err)
+	}
+}
+
+func TestVerifyEmail__EmailChanged(t *testing.T) {
+	s, _, notifier, userID := newTestService(t)
+	ctx := context.Background()
+
+	first, second := "alice@example.com", "alice@example.org"
+	if _, err := s.Update(ctx, userID, models.ProfileUpdate{Email: &first}); err != nil {
+		t.Fatal(err)
+	}
+	token := verificationToken(t, notifier, first)
+
+	// Ссылка на прежний адрес не подтверждает новый
+	if _, err := s.Update(ctx, userID, models.ProfileUpdate{Email: &second}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.VerifyEmail(ctx, token); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Fatalf("token for previous email: err = %v, want ErrInvalidVerificationToken", err)
+	}
+	profile, err := s.Get(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Email != second || profile.EmailVerified {
+		t.Fatalf("profile = %+v", profile)
+	}
+
+	if _, err := s.VerifyEmail(ctx, token[:len(token)-2]+"xx"); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Fatalf("tampered token: err = %v, want ErrInvalidVerificationToken", err)
+	}
+	if _, err := s.VerifyEmail(ctx, verificationToken(t, notifier, second)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,7 +3,24 @@ package storage
 import "errors"
 
 var (
-	ErrUserExist    = errors.New("user already exist")
-	ErrUserNotFound = errors.New("user not found")
-	ErrAppNotFound  = errors.New("app not found")
+	ErrUserExist             = errors.New("user already exist")
+	ErrUserNotFound          = errors.New("user not found")
+	ErrAppNotFound           = errors.New("app not found")
+	ErrEncryptionKeyNotFound = errors.New("encryption key not found")
+	ErrTokenNotFound         = errors.New("token not found")
+	ErrSessionNotFound       = errors.New("session not found")
+	ErrCertificateExist      = errors.New("certificate already registered")
+	ErrCertificateNotFound   = errors.New("certificate not found")
+	ErrRoleExist             = errors.New("role already exist")
+	ErrRoleNotFound          = errors.New("role not found")
+	ErrRoleInUse             = errors.New("role is assigned to users")
+	ErrPermissionExist       = errors.New("permission already exist")
+	ErrPermissionNotFound    = errors.New("permission not found")
+	ErrMemberExist           = errors.New("user is already a member of the app")
+	ErrMemberNotFound        = errors.New("user is not a member of the app")
+	ErrMFANotFound           = errors.New("mfa is not configured for user")
+	ErrCredentialExist       = errors.New("webauthn credential already registered")
+	ErrCredentialNotFound    = errors.New("webauthn credential not found")
+	ErrProfileNotFound       = errors.New("profile not found")
+	ErrEmailTaken            = errors.New("email is already used by another user")
 )
@@ -2,18 +2,33 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 
 	"github.com/Grino777/sso/internal/config"
 	"github.com/Grino777/sso/internal/domain/models"
-	"github.com/jackc/pgx/v5"
+	"github.com/Grino777/sso/internal/lib/logger"
+	"github.com/Grino777/sso/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
 const pgOp = "storage.postgres.postgres."
 
+const driverName = "postgres"
+
+// ErrNotImplemented возвращают методы, которые еще не реализованы для Postgres.
+// Пока они есть, приложение не запускается с -db postgres.
+var ErrNotImplemented = errors.New("not implemented for postgres storage")
+
+func notImplemented(method string) error {
+	return fmt.Errorf("%s%s: %w", pgOp, method, ErrNotImplemented)
+}
+
 type PostgresStorage struct {
-	client *pgx.Conn
+	// Пул соединений: pgx.Conn не допускает конкурентного использования
+	client *pgxpool.Pool
 	logger *slog.Logger
 	cfg    config.DatabaseConfig
 }
@@ -29,6 +44,7 @@ func NewPostgresStorage(
 }
 
 func (ps *PostgresStorage) Connect(ctx context.Context) error {
+	const op = pgOp + "Connect"
 
 	user := ps.cfg.DBUser
 	pass := ps.cfg.DBPass
@@ -37,45 +53,279 @@ func (ps *PostgresStorage) Connect(ctx context.Context) error {
 	db := ps.cfg.DBName
 
 	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", user, pass, host, port, db)
-	client, err := pgx.Connect(ctx, connStr)
+	client, err := pgxpool.New(ctx, connStr)
 	if err != nil {
 		return fmt.Errorf("failed to connect to Postgres: %w", err)
 	}
+	if err := client.Ping(ctx); err != nil {
+		client.Close()
+		return fmt.Errorf("failed to connect to Postgres: %w", err)
+	}
 
 	ps.client = client
 
+	sqlDB := stdlib.OpenDBFromPool(client)
+	defer sqlDB.Close()
+
+	if err := migrations.Migrate(sqlDB, driverName); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	ps.warnUsernameCollisions(ctx)
+
+	ps.logger.Debug("database connection successfully")
 	return nil
 }
 
-func (ps *PostgresStorage) Close(ctx context.Context) error {
-	const op = pgOp + "Close"
+// warnUsernameCollisions сообщает о пользователях, чьи имена совпали с чужими после нормализации.
+// Такие пользователи не могут войти, пока их не переименуют.
+func (ps *PostgresStorage) warnUsernameCollisions(ctx context.Context) {
+	const op = pgOp + "warnUsernameCollisions"
 
-	if err := ps.client.Close(ctx); err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	rows, err := ps.client.Query(ctx, "SELECT user_id, username, conflicts_with FROM username_collisions ORDER BY user_id")
+	if err != nil {
+		ps.logger.Error("failed to check username collisions", slog.String("op", op), logger.Error(err))
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID, conflictsWith uint64
+		var username string
+		if err := rows.Scan(&userID, &username, &conflictsWith); err != nil {
+			ps.logger.Error("failed to check username collisions", slog.String("op", op), logger.Error(err))
+			return
+		}
+		ps.logger.Warn("username collides with another user after normalization, the user cannot sign in until renamed",
+			slog.Uint64("user_id", userID),
+			slog.String("username", username),
+			slog.Uint64("conflicts_with", conflictsWith),
+		)
+	}
+}
+
+func (ps *PostgresStorage) Close(ctx context.Context) error {
+	if ps.client != nil {
+		ps.client.Close()
 	}
 	return nil
 }
 
 func (ps *PostgresStorage) SaveUser(ctx context.Context, user, passHash string) error {
-	panic("implement me!")
+	return notImplemented("SaveUser")
+}
+
+func (ps *PostgresStorage) ListRoles(ctx context.Context) ([]models.Role, error) {
+	return nil, notImplemented("ListRoles")
+}
+
+func (ps *PostgresStorage) GetRole(ctx context.Context, roleID int) (models.Role, error) {
+	return models.Role{}, notImplemented("GetRole")
 }
 
-func (ps *PostgresStorage) GetUser(ctx context.Context, username string) (models.User, error) {
-	panic("implement me!")
+func (ps *PostgresStorage) CreateRole(ctx context.Context, role models.Role) (int, error) {
+	return 0, notImplemented("CreateRole")
 }
 
-func (ps *PostgresStorage) IsAdmin(ctx context.Context, username string) (bool, error) {
-	panic("implement me!")
+func (ps *PostgresStorage) SetRolePermissions(ctx context.Context, roleID int, permissions []string) error {
+	return notImplemented("SetRolePermissions")
+}
+
+func (ps *PostgresStorage) DeleteRole(ctx context.Context, roleID int) error {
+	return notImplemented("DeleteRole")
+}
+
+func (ps *PostgresStorage) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	return nil, notImplemented("ListPermissions")
+}
+
+func (ps *PostgresStorage) CreatePermission(ctx context.Context, permission models.Permission) (int, error) {
+	return 0, notImplemented("CreatePermission")
+}
+
+func (ps *PostgresStorage) DeletePermission(ctx context.Context, permissionID int) error {
+	return notImplemented("DeletePermission")
+}
+
+func (ps *PostgresStorage) SetUserAppRole(ctx context.Context, userID uint64, appID uint32, roleID int) error {
+	return notImplemented("SetUserAppRole")
+}
+
+func (ps *PostgresStorage) GetUserRoles(ctx context.Context, userID uint64, appID uint32) ([]models.Role, error) {
+	return nil, notImplemented("GetUserRoles")
 }
 
 func (ps *PostgresStorage) GetApp(ctx context.Context, appID uint32) (models.App, error) {
-	panic("implement me!")
+	return models.App{}, notImplemented("GetApp")
+}
+
+func (ps *PostgresStorage) ListApps(ctx context.Context) ([]models.App, error) {
+	return nil, notImplemented("ListApps")
+}
+
+func (ps *PostgresStorage) CreateApp(ctx context.Context, app models.App) (uint32, error) {
+	return 0, notImplemented("CreateApp")
+}
+
+func (ps *PostgresStorage) UpdateApp(ctx context.Context, app models.App) error {
+	return notImplemented("UpdateApp")
+}
+
+func (ps *PostgresStorage) UpdateAppSecret(ctx context.Context, app models.App) error {
+	return notImplemented("UpdateAppSecret")
+}
+
+func (ps *PostgresStorage) DeleteApp(ctx context.Context, appID uint32) error {
+	return notImplemented("DeleteApp")
+}
+
+func (ps *PostgresStorage) GetAppMember(ctx context.Context, userID uint64, appID uint32) (models.AppMember, error) {
+	return models.AppMember{}, notImplemented("GetAppMember")
+}
+
+func (ps *PostgresStorage) ListAppMembers(ctx context.Context, appID uint32) ([]models.AppMember, error) {
+	return nil, notImplemented("ListAppMembers")
+}
+
+func (ps *PostgresStorage) AddAppMember(ctx context.Context, userID uint64, appID uint32) error {
+	return notImplemented("AddAppMember")
+}
+
+func (ps *PostgresStorage) RemoveAppMember(ctx context.Context, userID uint64, appID uint32) error {
+	return notImplemented("RemoveAppMember")
+}
+
+func (ps *PostgresStorage) SetAppMemberBlocked(ctx context.Context, userID uint64, appID uint32, blocked bool) error {
+	return notImplemented("SetAppMemberBlocked")
+}
+
+func (ps *PostgresStorage) SaveLoginRecord(ctx context.Context, record models.LoginRecord) error {
+	return notImplemented("SaveLoginRecord")
+}
+
+func (ps *PostgresStorage) ListLoginRecords(ctx context.Context, userID uint64, limit int) ([]models.LoginRecord, error) {
+	return nil, notImplemented("ListLoginRecords")
+}
+
+func (ps *PostgresStorage) GetUserMFA(ctx context.Context, userID uint64) (models.UserMFA, error) {
+	return models.UserMFA{}, notImplemented("GetUserMFA")
+}
+
+func (ps *PostgresStorage) SaveUserMFA(ctx context.Context, mfa models.UserMFA) error {
+	return notImplemented("SaveUserMFA")
+}
+
+func (ps *PostgresStorage) EnableUserMFA(ctx context.Context, userID uint64, step int64, codeHashes []string) error {
+	return notImplemented("EnableUserMFA")
+}
+
+func (ps *PostgresStorage) UseTOTPStep(ctx context.Context, userID uint64, step int64) (bool, error) {
+	return false, notImplemented("UseTOTPStep")
+}
+
+func (ps *PostgresStorage) UseRecoveryCode(ctx context.Context, userID uint64, codeHash string) (bool, error) {
+	return false, notImplemented("UseRecoveryCode")
+}
+
+func (ps *PostgresStorage) ReplaceRecoveryCodes(ctx context.Context, userID uint64, codeHashes []string) error {
+	return notImplemented("ReplaceRecoveryCodes")
+}
+
+func (ps *PostgresStorage) DeleteUserMFA(ctx context.Context, userID uint64) error {
+	return notImplemented("DeleteUserMFA")
+}
+
+func (ps *PostgresStorage) SaveWebAuthnCredential(ctx context.Context, cred models.WebAuthnCredential) (uint64, error) {
+	return 0, notImplemented("SaveWebAuthnCredential")
+}
+
+func (ps *PostgresStorage) ListWebAuthnCredentials(ctx context.Context, userID uint64) ([]models.WebAuthnCredential, error) {
+	return nil, notImplemented("ListWebAuthnCredentials")
+}
+
+func (ps *PostgresStorage) GetWebAuthnCredential(ctx context.Context, credentialID []byte) (models.WebAuthnCredential, error) {
+	return models.WebAuthnCredential{}, notImplemented("GetWebAuthnCredential")
+}
+
+func (ps *PostgresStorage) UpdateWebAuthnSignCount(ctx context.Context, id uint64, signCount uint32) error {
+	return notImplemented("UpdateWebAuthnSignCount")
+}
+
+func (ps *PostgresStorage) DeleteWebAuthnCredential(ctx context.Context, userID uint64, id uint64) error {
+	return notImplemented("DeleteWebAuthnCredential")
+}
+
+func (ps *PostgresStorage) GetProfile(ctx context.Context, userID uint64) (models.Profile, error) {
+	return models.Profile{}, notImplemented("GetProfile")
+}
+
+func (ps *PostgresStorage) SaveProfile(ctx context.Context, profile models.Profile) error {
+	return notImplemented("SaveProfile")
+}
+
+func (ps *PostgresStorage) VerifyProfileEmail(ctx context.Context, userID uint64, email string) error {
+	return notImplemented("VerifyProfileEmail")
+}
+
+func (ps *PostgresStorage) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
+	return models.User{}, notImplemented("GetUserByEmail")
 }
 
 func (ps *PostgresStorage) DeleteRefreshToken(ctx context.Context, userID uint64, appID uint32, token models.Token) error {
-	panic("implement me!")
+	return notImplemented("DeleteRefreshToken")
+}
+
+func (ps *PostgresStorage) SaveRefreshToken(ctx context.Context, session models.Session, token models.Token) error {
+	return notImplemented("SaveRefreshToken")
+}
+
+func (ps *PostgresStorage) RotateRefreshToken(ctx context.Context, session models.Session, old string, token models.Token) error {
+	return notImplemented("RotateRefreshToken")
+}
+
+func (ps *PostgresStorage) ListSessions(ctx context.Context, filter models.SessionFilter) ([]models.Session, error) {
+	return nil, notImplemented("ListSessions")
+}
+
+func (ps *PostgresStorage) DeleteSession(ctx context.Context, userID uint64, sessionID string) error {
+	return notImplemented("DeleteSession")
+}
+
+func (ps *PostgresStorage) DeleteUserSessions(ctx context.Context, userID uint64, except string) (int64, error) {
+	return 0, notImplemented("DeleteUserSessions")
+}
+
+func (ps *PostgresStorage) DeleteOfflineSessions(ctx context.Context, userID uint64) (int64, error) {
+	return 0, notImplemented("DeleteOfflineSessions")
+}
+
+func (ps *PostgresStorage) TrimSessions(ctx context.Context, userID uint64, appID uint32, keep int) (int64, error) {
+	return 0, notImplemented("TrimSessions")
+}
+
+func (ps *PostgresStorage) GetRefreshToken(ctx context.Context, token string) (models.RefreshTokenInfo, error) {
+	return models.RefreshTokenInfo{}, notImplemented("GetRefreshToken")
+}
+
+func (ps *PostgresStorage) GetAppEncryptionKey(ctx context.Context, appID uint32) (models.EncryptionKey, error) {
+	return models.EncryptionKey{}, notImplemented("GetAppEncryptionKey")
+}
+
+func (ps *PostgresStorage) SaveAppEncryptionKey(ctx context.Context, key models.EncryptionKey) error {
+	return notImplemented("SaveAppEncryptionKey")
+}
+
+func (ps *PostgresStorage) DeleteAppEncryptionKey(ctx context.Context, appID uint32) error {
+	return notImplemented("DeleteAppEncryptionKey")
+}
+
+func (ps *PostgresStorage) GetAppCertificates(ctx context.Context, appID uint32) ([]models.AppCertificate, error) {
+	return nil, notImplemented("GetAppCertificates")
+}
+
+func (ps *PostgresStorage) SaveAppCertificate(ctx context.Context, cert models.AppCertificate) error {
+	return notImplemented("SaveAppCertificate")
 }
 
-func (ps *PostgresStorage) SaveRefreshToken(ctx context.Context, userID uint64, appID uint32, token models.Token) error {
-	panic("implement me!")
+func (ps *PostgresStorage) DeleteAppCertificate(ctx context.Context, appID uint32, thumbprint string) error {
+	return notImplemented("DeleteAppCertificate")
 }
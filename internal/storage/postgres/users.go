@@ -0,0 +1,357 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/storage"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const usersOp = pgOp + "users."
+
+// Код ошибки unique_violation
+const uniqueViolation = "23505"
+
+const userColumns = "id, username, pass_hash, role_id, is_disabled, locked_until, created_at, password_changed_at"
+
+// scanUser читает пользователя, выбранного по userColumns
+func scanUser(row pgx.Row) (models.User, error) {
+	var user models.User
+	var passHash string
+	var lockedUntil, createdAt, passwordChangedAt *time.Time
+
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&passHash,
+		&user.Role_id,
+		&user.Disabled,
+		&lockedUntil,
+		&createdAt,
+		&passwordChangedAt,
+	)
+	if err != nil {
+		return user, err
+	}
+
+	user.PassHash = []byte(passHash)
+	if lockedUntil != nil {
+		user.LockedUntil = lockedUntil.UTC()
+	}
+	if createdAt != nil {
+		user.CreatedAt = createdAt.UTC()
+	}
+	// Пароль, не менявшийся после создания пользователя, действует с момента создания
+	user.PasswordChangedAt = user.CreatedAt
+	if passwordChangedAt != nil {
+		user.PasswordChangedAt = passwordChangedAt.UTC()
+	}
+	return user, nil
+}
+
+// GetUser ищет пользователя по имени без учета регистра и формы Unicode.
+// Пользователи из username_collisions не находятся, пока их не переименуют.
+func (ps *PostgresStorage) GetUser(ctx context.Context, username string) (models.User, error) {
+	const op = usersOp + "GetUser"
+
+	query := "SELECT " + userColumns + " FROM users WHERE username_key = $1"
+	user, err := scanUser(ps.client.QueryRow(ctx, query, models.UsernameKey(username)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user, storage.ErrUserNotFound
+		}
+		return user, fmt.Errorf("%s: %w", op, err)
+	}
+	return user, nil
+}
+
+func (ps *PostgresStorage) GetUserByID(ctx context.Context, userID uint64) (models.User, error) {
+	const op = usersOp + "GetUserByID"
+
+	query := "SELECT " + userColumns + " FROM users WHERE id = $1"
+	user, err := scanUser(ps.client.QueryRow(ctx, query, userID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return user, storage.ErrUserNotFound
+		}
+		return user, fmt.Errorf("%s: %w", op, err)
+	}
+	return user, nil
+}
+
+// ListUsers возвращает страницу пользователей по фильтру и общее число найденных
+func (ps *PostgresStorage) ListUsers(ctx context.Context, filter models.UserFilter) ([]models.User, int, error) {
+	const op = usersOp + "ListUsers"
+
+	var conds []string
+	var args []any
+
+	placeholder := func() string { return "$" + strconv.Itoa(len(args)) }
+
+	if filter.Username != "" {
+		args = append(args, "%"+escapeLike(filter.Username)+"%")
+		conds = append(conds, "username LIKE "+placeholder())
+	}
+	if filter.RoleID != 0 {
+		args = append(args, filter.RoleID)
+		conds = append(conds, "role_id = "+placeholder())
+	}
+	if filter.Disabled != nil {
+		args = append(args, *filter.Disabled)
+		conds = append(conds, "is_disabled = "+placeholder())
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := ps.client.QueryRow(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	args = append(args, filter.Limit)
+	limit := placeholder()
+	args = append(args, filter.Offset)
+	offset := placeholder()
+
+	query := "SELECT " + userColumns + " FROM users" + where + " ORDER BY id LIMIT " + limit + " OFFSET " + offset
+	rows, err := ps.client.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return users, total, nil
+}
+
+// CreateUser создает пользователя с указанной ролью и возвращает его id
+func (ps *PostgresStorage) CreateUser(ctx context.Context, user models.User) (uint64, error) {
+	const op = usersOp + "CreateUser"
+
+	var id uint64
+	query := `
+		INSERT INTO users (username, username_key, pass_hash, role_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	err := ps.client.QueryRow(
+		ctx, query,
+		user.Username, models.UsernameKey(user.Username), string(user.PassHash), user.Role_id, time.Now().UTC(),
+	).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrUserExist)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+// UpdateUser изменяет имя и роль пользователя.
+// Переименование пользователя из username_collisions снимает конфликт имен.
+func (ps *PostgresStorage) UpdateUser(ctx context.Context, user models.User) error {
+	const op = usersOp + "UpdateUser"
+
+	tx, err := ps.client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE users SET
+			username_key = CASE WHEN username = $1 THEN username_key ELSE $2 END,
+			username = $1,
+			role_id = $3
+		WHERE id = $4
+	`
+	tag, err := tx.Exec(ctx, query, user.Username, models.UsernameKey(user.Username), user.Role_id, user.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrUserExist)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkUserAffected(tag); err != nil {
+		return err
+	}
+
+	query = `
+		DELETE FROM username_collisions
+		WHERE user_id = $1 AND EXISTS (SELECT 1 FROM users WHERE id = $1 AND username_key IS NOT NULL)
+	`
+	if _, err := tx.Exec(ctx, query, user.ID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SetUserDisabled включает или отключает учетную запись пользователя
+func (ps *PostgresStorage) SetUserDisabled(ctx context.Context, userID uint64, disabled bool) error {
+	const op = usersOp + "SetUserDisabled"
+
+	tag, err := ps.client.Exec(ctx, "UPDATE users SET is_disabled = $1 WHERE id = $2", disabled, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return checkUserAffected(tag)
+}
+
+// UnlockUser снимает блокировку входа пользователя
+func (ps *PostgresStorage) UnlockUser(ctx context.Context, userID uint64) error {
+	const op = usersOp + "UnlockUser"
+
+	tag, err := ps.client.Exec(ctx, "UPDATE users SET locked_until = NULL WHERE id = $1", userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return checkUserAffected(tag)
+}
+
+// UpdateUserPassword сохраняет новый хэш пароля пользователя.
+// Предыдущий хэш переносится в историю паролей, в ней остаются последние models.MaxPasswordHistory.
+func (ps *PostgresStorage) UpdateUserPassword(ctx context.Context, userID uint64, passHash []byte) error {
+	const op = usersOp + "UpdateUserPassword"
+
+	now := time.Now().UTC()
+
+	tx, err := ps.client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO password_history (user_id, pass_hash, created_at)
+		SELECT id, pass_hash, $1 FROM users WHERE id = $2
+	`
+	if _, err := tx.Exec(ctx, query, now, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	tag, err := tx.Exec(
+		ctx,
+		"UPDATE users SET pass_hash = $1, password_changed_at = $2 WHERE id = $3",
+		string(passHash), now, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkUserAffected(tag); err != nil {
+		return err
+	}
+
+	query = `
+		DELETE FROM password_history
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM password_history WHERE user_id = $1 ORDER BY id DESC LIMIT $2
+		)
+	`
+	if _, err := tx.Exec(ctx, query, userID, models.MaxPasswordHistory); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RehashUserPassword заменяет хэш того же пароля на пересозданный, не трогая историю паролей
+// и дату смены пароля. Если хэш уже изменился, ничего не делает.
+func (ps *PostgresStorage) RehashUserPassword(ctx context.Context, userID uint64, oldHash, newHash []byte) error {
+	const op = usersOp + "RehashUserPassword"
+
+	query := "UPDATE users SET pass_hash = $1 WHERE id = $2 AND pass_hash = $3"
+	if _, err := ps.client.Exec(ctx, query, string(newHash), userID, string(oldHash)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ListPasswordHistory возвращает хэши последних limit предыдущих паролей пользователя, начиная с новых
+func (ps *PostgresStorage) ListPasswordHistory(ctx context.Context, userID uint64, limit int) ([][]byte, error) {
+	const op = usersOp + "ListPasswordHistory"
+
+	query := "SELECT pass_hash FROM password_history WHERE user_id = $1 ORDER BY id DESC LIMIT $2"
+	rows, err := ps.client.Query(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	hashes := make([][]byte, 0, limit)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		hashes = append(hashes, []byte(hash))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return hashes, nil
+}
+
+// DeleteUser удаляет пользователя, зависимые записи удаляются каскадно
+func (ps *PostgresStorage) DeleteUser(ctx context.Context, userID uint64) error {
+	const op = usersOp + "DeleteUser"
+
+	tag, err := ps.client.Exec(ctx, "DELETE FROM users WHERE id = $1", userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return checkUserAffected(tag)
+}
+
+// DeleteUserRefreshTokens отзывает все refresh токены пользователя
+func (ps *PostgresStorage) DeleteUserRefreshTokens(ctx context.Context, userID uint64) error {
+	const op = usersOp + "DeleteUserRefreshTokens"
+
+	if _, err := ps.client.Exec(ctx, "DELETE FROM refresh_tokens WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func checkUserAffected(tag pgconn.CommandTag) error {
+	if tag.RowsAffected() == 0 {
+		return storage.ErrUserNotFound
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE (экранирующий символ по умолчанию — \)
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
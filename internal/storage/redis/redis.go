@@ -6,10 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/Grino777/sso/internal/config"
 	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/secretbox"
+	"github.com/Grino777/sso/internal/storage"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -21,23 +25,32 @@ var (
 const opRedis = "storage.redis."
 
 type RedisStorage struct {
-	mu      sync.RWMutex
-	cfg     config.RedisConfig
-	client  *redis.Client
+	mu     sync.RWMutex
+	cfg    config.RedisConfig
+	client *redis.Client
+	// Шифрование секретов приложений в кэше
+	secrets *secretbox.Box
 	logger  *slog.Logger
 	errChan chan<- error
 }
 
-func NewRedisStorage(log *slog.Logger, cfg config.RedisConfig, errChan chan error) *RedisStorage {
+func NewRedisStorage(log *slog.Logger, cfg config.RedisConfig, secrets *secretbox.Box, errChan chan error) *RedisStorage {
 	store := &RedisStorage{
-		cfg:    cfg,
-		logger: log,
+		cfg:     cfg,
+		secrets: secrets,
+		logger:  log,
 	}
 	return store
 }
 
 // -----------------------------------User Block-----------------------------------
 
+// userKey ключ пользователя в кэше. Имя приводится к models.UsernameKey,
+// чтобы варианты написания одного имени не попадали в разные записи.
+func userKey(appID uint32, username string) string {
+	return fmt.Sprintf("users:%d:%s", appID, models.UsernameKey(username))
+}
+
 func (rs *RedisStorage) SaveUser(
 	ctx context.Context,
 	user models.User,
@@ -51,8 +64,7 @@ func (rs *RedisStorage) SaveUser(
 			return models.User{}, err
 		}
 
-		resString := fmt.Sprintf("users:%d:%s", appID, user.Username)
-		err = rc.Set(ctx, resString, data, rs.cfg.TokenTTL).Err()
+		err = rc.Set(ctx, userKey(appID, user.Username), data, rs.cfg.TokenTTL).Err()
 		if err != nil {
 			return models.User{}, fmt.Errorf("%s: %w", op, err)
 		}
@@ -68,7 +80,7 @@ func (rs *RedisStorage) GetUser(
 ) (models.User, error) {
 	const op = opRedis + "GetUser"
 
-	key := fmt.Sprintf("users:%d:%s", appID, username)
+	key := userKey(appID, username)
 	result, err := withClient(ctx, rs, func(rc *redis.Client) (string, error) {
 		return rc.Get(ctx, key).Result()
 	})
@@ -87,13 +99,22 @@ func (rs *RedisStorage) GetUser(
 	return user, nil
 }
 
-// FIXME
-func (rs *RedisStorage) IsAdmin(
+func (rs *RedisStorage) DeleteUser(
 	ctx context.Context,
-	user models.User,
-	app models.App,
-) (bool, error) {
-	panic("implement me!")
+	username string,
+) error {
+	const op = opRedis + "DeleteUser"
+
+	pattern := "users:*:" + escapePattern(models.UsernameKey(username))
+	_, err := withClient(ctx, rs, func(rc *redis.Client) (struct{}, error) {
+		return struct{}{}, deleteByPattern(ctx, rc, pattern)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rs.logger.Debug("user removed from cache", "username", username)
+	return nil
 }
 
 // -----------------------------------End Block------------------------------------
@@ -121,6 +142,9 @@ func (rs *RedisStorage) GetApp(
 	if err := json.Unmarshal([]byte(result), &app); err != nil {
 		return models.App{}, fmt.Errorf("%s: failed to unmarshal user: %w", op, err)
 	}
+	if err := storage.OpenAppSecrets(rs.secrets, &app); err != nil {
+		return models.App{}, fmt.Errorf("%s: %w", op, err)
+	}
 
 	return app, nil
 }
@@ -131,8 +155,14 @@ func (rs *RedisStorage) SaveApp(
 ) error {
 	const op = opRedis + "SaveApp"
 
+	// Секреты в кэше хранятся так же зашифрованными, как в базе
+	app, err := storage.SealAppSecrets(rs.secrets, app)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
 	key := fmt.Sprintf("apps:%d", app.ID)
-	_, err := withClient(ctx, rs, func(rc *redis.Client) (models.App, error) {
+	_, err = withClient(ctx, rs, func(rc *redis.Client) (models.App, error) {
 		data, err := json.Marshal(app)
 		if err != nil {
 			return models.App{}, fmt.Errorf("%s: %w", op, err)
@@ -152,4 +182,402 @@ func (rs *RedisStorage) SaveApp(
 	return nil
 }
 
+func (rs *RedisStorage) DeleteApp(
+	ctx context.Context,
+	appID uint32,
+) error {
+	const op = opRedis + "DeleteApp"
+
+	key := fmt.Sprintf("apps:%d", appID)
+	_, err := withClient(ctx, rs, func(rc *redis.Client) (int64, error) {
+		return rc.Del(ctx, key).Result()
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rs.logger.Debug("app removed from cache", "appID", appID)
+	return nil
+}
+
+// -----------------------------------End Block------------------------------------
+
+// -----------------------------------Nonce Block----------------------------------
+
+func (rs *RedisStorage) SaveNonce(
+	ctx context.Context,
+	scope, nonce string,
+	ttl time.Duration,
+) (bool, error) {
+	const op = opRedis + "SaveNonce"
+
+	key := fmt.Sprintf("nonces:%s:%s", scope, nonce)
+	saved, err := withClient(ctx, rs, func(rc *redis.Client) (bool, error) {
+		return rc.SetNX(ctx, key, 1, ttl).Result()
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return saved, nil
+}
+
 // -----------------------------------End Block------------------------------------
+
+// -----------------------------------Lockout Block--------------------------------
+
+func lockoutKey(subject, name string) string {
+	return fmt.Sprintf("lockout:%s:%s", subject, name)
+}
+
+// loginFailuresScript увеличивает счетчик и задает окно, если у ключа нет срока хранения.
+// Обе команды выполняются атомарно, поэтому счетчик не может остаться без TTL.
+var loginFailuresScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if redis.call("PTTL", KEYS[1]) < 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+func (rs *RedisStorage) IncrLoginFailures(
+	ctx context.Context,
+	subject string,
+	window time.Duration,
+) (int64, error) {
+	const op = opRedis + "IncrLoginFailures"
+
+	key := lockoutKey(subject, "failures")
+	failures, err := withClient(ctx, rs, func(rc *redis.Client) (int64, error) {
+		return loginFailuresScript.Run(ctx, rc, []string{key}, window.Milliseconds()).Int64()
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return failures, nil
+}
+
+func (rs *RedisStorage) IncrLockoutStrikes(
+	ctx context.Context,
+	subject string,
+	ttl time.Duration,
+) (int64, error) {
+	const op = opRedis + "IncrLockoutStrikes"
+
+	key := lockoutKey(subject, "strikes")
+	strikes, err := withClient(ctx, rs, func(rc *redis.Client) (int64, error) {
+		pipe := rc.TxPipeline()
+		incr := pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, ttl)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return 0, err
+		}
+		return incr.Val(), nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return strikes, nil
+}
+
+func (rs *RedisStorage) SetLockout(
+	ctx context.Context,
+	subject string,
+	until time.Time,
+) error {
+	const op = opRedis + "SetLockout"
+
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+
+	_, err := withClient(ctx, rs, func(rc *redis.Client) (struct{}, error) {
+		pipe := rc.TxPipeline()
+		pipe.Set(ctx, lockoutKey(subject, "until"), until.Unix(), ttl)
+		pipe.Del(ctx, lockoutKey(subject, "failures"))
+		_, err := pipe.Exec(ctx)
+		return struct{}{}, err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (rs *RedisStorage) GetLockout(
+	ctx context.Context,
+	subject string,
+) (models.LockoutStatus, error) {
+	const op = opRedis + "GetLockout"
+
+	status, err := withClient(ctx, rs, func(rc *redis.Client) (models.LockoutStatus, error) {
+		pipe := rc.Pipeline()
+		failures := pipe.Get(ctx, lockoutKey(subject, "failures"))
+		strikes := pipe.Get(ctx, lockoutKey(subject, "strikes"))
+		until := pipe.Get(ctx, lockoutKey(subject, "until"))
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			return models.LockoutStatus{}, err
+		}
+
+		status := models.LockoutStatus{Subject: subject}
+		status.Failures, _ = failures.Int64()
+		status.Strikes, _ = strikes.Int64()
+		if ts, err := until.Int64(); err == nil {
+			status.LockedUntil = time.Unix(ts, 0).UTC()
+		}
+		return status, nil
+	})
+	if err != nil {
+		return models.LockoutStatus{}, fmt.Errorf("%s: %w", op, err)
+	}
+	return status, nil
+}
+
+func (rs *RedisStorage) ClearLockout(
+	ctx context.Context,
+	subject string,
+) error {
+	const op = opRedis + "ClearLockout"
+
+	_, err := withClient(ctx, rs, func(rc *redis.Client) (struct{}, error) {
+		return struct{}{}, rc.Del(ctx,
+			lockoutKey(subject, "failures"),
+			lockoutKey(subject, "strikes"),
+			lockoutKey(subject, "until"),
+		).Err()
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// -----------------------------------End Block------------------------------------
+
+// -----------------------------------Rate Limit Block-----------------------------
+
+// rateLimitScript берет токен из bucket (hash tokens/ts) атомарно для всех реплик.
+// Возвращает {1, 0}, если токен взят, иначе {0, мс до появления токена}.
+var rateLimitScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local data = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(data[1]) or burst
+local ts = tonumber(data[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, wait}
+`)
+
+func (rs *RedisStorage) AllowRequest(
+	ctx context.Context,
+	key string,
+	limit models.RateLimit,
+) (models.RateLimitResult, error) {
+	const op = opRedis + "AllowRequest"
+
+	if limit.Unlimited() {
+		return models.RateLimitResult{Allowed: true}, nil
+	}
+
+	result, err := withClient(ctx, rs, func(rc *redis.Client) ([]int64, error) {
+		return rateLimitScript.Run(ctx, rc, []string{"ratelimit:" + key},
+			limit.Rate, limit.Burst, time.Now().UnixMilli(),
+		).Int64Slice()
+	})
+	if err != nil {
+		return models.RateLimitResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(result) != 2 {
+		return models.RateLimitResult{}, fmt.Errorf("%s: unexpected script result %v", op, result)
+	}
+
+	return models.RateLimitResult{
+		Allowed:    result[0] == 1,
+		RetryAfter: time.Duration(result[1]) * time.Millisecond,
+	}, nil
+}
+
+// -----------------------------------End Block------------------------------------
+
+// -----------------------------------MFA Block------------------------------------
+
+func (rs *RedisStorage) SaveMFAChallenge(
+	ctx context.Context,
+	token string,
+	challenge models.MFAChallenge,
+	ttl time.Duration,
+) error {
+	const op = opRedis + "SaveMFAChallenge"
+
+	data, err := json.Marshal(challenge)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = withClient(ctx, rs, func(rc *redis.Client) (struct{}, error) {
+		return struct{}{}, rc.Set(ctx, "mfa:challenges:"+token, data, ttl).Err()
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (rs *RedisStorage) TakeMFAChallenge(
+	ctx context.Context,
+	token string,
+) (models.MFAChallenge, error) {
+	const op = opRedis + "TakeMFAChallenge"
+
+	result, err := withClient(ctx, rs, func(rc *redis.Client) (string, error) {
+		return rc.GetDel(ctx, "mfa:challenges:"+token).Result()
+	})
+	if err != nil {
+		if err == redis.Nil {
+			return models.MFAChallenge{}, fmt.Errorf("%s: %w", op, ErrCacheNotFound)
+		}
+		return models.MFAChallenge{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var challenge models.MFAChallenge
+	if err := json.Unmarshal([]byte(result), &challenge); err != nil {
+		return models.MFAChallenge{}, fmt.Errorf("%s: failed to unmarshal challenge: %w", op, err)
+	}
+	return challenge, nil
+}
+
+// -----------------------------------End Block------------------------------------
+
+// -----------------------------------WebAuthn Block-------------------------------
+
+func (rs *RedisStorage) SaveWebAuthnSession(
+	ctx context.Context,
+	challenge string,
+	session models.WebAuthnSession,
+	ttl time.Duration,
+) error {
+	const op = opRedis + "SaveWebAuthnSession"
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = withClient(ctx, rs, func(rc *redis.Client) (struct{}, error) {
+		return struct{}{}, rc.Set(ctx, "webauthn:sessions:"+challenge, data, ttl).Err()
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (rs *RedisStorage) TakeWebAuthnSession(
+	ctx context.Context,
+	challenge string,
+) (models.WebAuthnSession, error) {
+	const op = opRedis + "TakeWebAuthnSession"
+
+	result, err := withClient(ctx, rs, func(rc *redis.Client) (string, error) {
+		return rc.GetDel(ctx, "webauthn:sessions:"+challenge).Result()
+	})
+	if err != nil {
+		if err == redis.Nil {
+			return models.WebAuthnSession{}, fmt.Errorf("%s: %w", op, ErrCacheNotFound)
+		}
+		return models.WebAuthnSession{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var session models.WebAuthnSession
+	if err := json.Unmarshal([]byte(result), &session); err != nil {
+		return models.WebAuthnSession{}, fmt.Errorf("%s: failed to unmarshal session: %w", op, err)
+	}
+	return session, nil
+}
+
+// -----------------------------------End Block------------------------------------
+
+// -----------------------------------Login Code Block-----------------------------
+
+func (rs *RedisStorage) SaveLoginCode(
+	ctx context.Context,
+	key string,
+	code models.LoginCode,
+	ttl time.Duration,
+) error {
+	const op = opRedis + "SaveLoginCode"
+
+	data, err := json.Marshal(code)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = withClient(ctx, rs, func(rc *redis.Client) (struct{}, error) {
+		return struct{}{}, rc.Set(ctx, "passwordless:"+key, data, ttl).Err()
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (rs *RedisStorage) TakeLoginCode(
+	ctx context.Context,
+	key string,
+) (models.LoginCode, error) {
+	const op = opRedis + "TakeLoginCode"
+
+	result, err := withClient(ctx, rs, func(rc *redis.Client) (string, error) {
+		return rc.GetDel(ctx, "passwordless:"+key).Result()
+	})
+	if err != nil {
+		if err == redis.Nil {
+			return models.LoginCode{}, fmt.Errorf("%s: %w", op, ErrCacheNotFound)
+		}
+		return models.LoginCode{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var code models.LoginCode
+	if err := json.Unmarshal([]byte(result), &code); err != nil {
+		return models.LoginCode{}, fmt.Errorf("%s: failed to unmarshal login code: %w", op, err)
+	}
+	return code, nil
+}
+
+// -----------------------------------End Block------------------------------------
+
+// deleteByPattern удаляет все ключи, подходящие под шаблон
+func deleteByPattern(ctx context.Context, rc *redis.Client, pattern string) error {
+	iter := rc.Scan(ctx, 0, pattern, 100).Iterator()
+
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return rc.Del(ctx, keys...).Err()
+}
+
+// escapePattern экранирует спецсимволы glob-шаблона SCAN
+func escapePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`).Replace(s)
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/secretbox"
+)
+
+// SealAppSecrets возвращает копию приложения с зашифрованными секретами для сохранения
+func SealAppSecrets(box *secretbox.Box, app models.App) (models.App, error) {
+	var err error
+	if app.Secret, err = box.Seal(app.Secret); err != nil {
+		return app, err
+	}
+	if app.PreviousSecret, err = box.Seal(app.PreviousSecret); err != nil {
+		return app, err
+	}
+	return app, nil
+}
+
+// OpenAppSecrets расшифровывает секреты приложения, прочитанного из хранилища
+func OpenAppSecrets(box *secretbox.Box, app *models.App) error {
+	var err error
+	if app.Secret, err = box.Open(app.Secret); err != nil {
+		return err
+	}
+	if app.PreviousSecret, err = box.Open(app.PreviousSecret); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/secretbox"
+	"github.com/Grino777/sso/internal/storage"
+)
+
+const appsOp = sqliteOp + "apps."
+
+const appColumns = "id, name, secret, previous_secret, previous_secret_expires_at, created_at, membership_policy, require_mfa, " +
+	"access_token_ttl, refresh_token_ttl, idle_timeout, max_session_age, offline_access, offline_token_ttl"
+
+// scanApp читает приложение, выбранное по appColumns
+func scanApp(row rowScanner) (models.App, error) {
+	var app models.App
+	var previousSecret, previousExpiresAt, createdAt sql.NullString
+	var accessTTL, refreshTTL, idleTimeout, maxAge, offlineTTL int64
+
+	err := row.Scan(
+		&app.ID,
+		&app.Name,
+		&app.Secret,
+		&previousSecret,
+		&previousExpiresAt,
+		&createdAt,
+		&app.MembershipPolicy,
+		&app.RequireMFA,
+		&accessTTL,
+		&refreshTTL,
+		&idleTimeout,
+		&maxAge,
+		&app.OfflineAccess,
+		&offlineTTL,
+	)
+	if err != nil {
+		return app, err
+	}
+
+	app.Lifetimes = models.SessionLifetimes{
+		AccessTTL:   time.Duration(accessTTL) * time.Second,
+		RefreshTTL:  time.Duration(refreshTTL) * time.Second,
+		IdleTimeout: time.Duration(idleTimeout) * time.Second,
+		MaxAge:      time.Duration(maxAge) * time.Second,
+		OfflineTTL:  time.Duration(offlineTTL) * time.Second,
+	}
+
+	app.PreviousSecret = previousSecret.String
+	if previousExpiresAt.Valid && previousExpiresAt.String != "" {
+		if app.PreviousSecretExpiresAt, err = time.Parse(time.RFC3339, previousExpiresAt.String); err != nil {
+			return app, fmt.Errorf("failed to parse previous_secret_expires_at: %w", err)
+		}
+	}
+	if createdAt.Valid && createdAt.String != "" {
+		if app.CreatedAt, err = time.Parse(time.RFC3339, createdAt.String); err != nil {
+			return app, fmt.Errorf("failed to parse created_at: %w", err)
+		}
+	}
+	return app, nil
+}
+
+// ListApps возвращает все зарегистрированные приложения
+func (s *SQLiteStorage) ListApps(ctx context.Context) ([]models.App, error) {
+	const op = appsOp + "ListApps"
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+appColumns+" FROM apps ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var apps []models.App
+	for rows.Next() {
+		app, err := scanApp(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := storage.OpenAppSecrets(s.secrets, &app); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		apps = append(apps, app)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return apps, nil
+}
+
+// CreateApp сохраняет новое приложение и возвращает его id
+func (s *SQLiteStorage) CreateApp(
+	ctx context.Context,
+	app models.App,
+) (uint32, error) {
+	const op = appsOp + "CreateApp"
+
+	app, err := storage.SealAppSecrets(s.secrets, app)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := s.db.ExecContext(
+		ctx,
+		"INSERT INTO apps (name, secret, created_at, membership_policy) VALUES (?, ?, ?, ?)",
+		app.Name,
+		app.Secret,
+		app.CreatedAt.UTC().Format(time.RFC3339),
+		app.Policy(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return uint32(id), nil
+}
+
+// UpdateApp изменяет название, политику членства, требование MFA, сроки сеансов и offline доступ приложения
+func (s *SQLiteStorage) UpdateApp(
+	ctx context.Context,
+	app models.App,
+) error {
+	const op = appsOp + "UpdateApp"
+
+	query := `
+		UPDATE apps
+		SET name = ?, membership_policy = ?, require_mfa = ?,
+			access_token_ttl = ?, refresh_token_ttl = ?, idle_timeout = ?, max_session_age = ?,
+			offline_access = ?, offline_token_ttl = ?
+		WHERE id = ?
+	`
+	res, err := s.db.ExecContext(
+		ctx,
+		query,
+		app.Name,
+		app.Policy(),
+		app.RequireMFA,
+		int64(app.Lifetimes.AccessTTL/time.Second),
+		int64(app.Lifetimes.RefreshTTL/time.Second),
+		int64(app.Lifetimes.IdleTimeout/time.Second),
+		int64(app.Lifetimes.MaxAge/time.Second),
+		app.OfflineAccess,
+		int64(app.Lifetimes.OfflineTTL/time.Second),
+		app.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return checkAppAffected(op, res)
+}
+
+// UpdateAppSecret сохраняет новый секрет приложения вместе с предыдущим,
+// который продолжает приниматься до окончания периода перекрытия
+func (s *SQLiteStorage) UpdateAppSecret(
+	ctx context.Context,
+	app models.App,
+) error {
+	const op = appsOp + "UpdateAppSecret"
+
+	app, err := storage.SealAppSecrets(s.secrets, app)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var previous, expiresAt sql.NullString
+	if app.PreviousSecret != "" {
+		previous = sql.NullString{String: app.PreviousSecret, Valid: true}
+		expiresAt = sql.NullString{String: app.PreviousSecretExpiresAt.UTC().Format(time.RFC3339), Valid: true}
+	}
+
+	query := `
+		UPDATE apps
+		SET secret = ?, previous_secret = ?, previous_secret_expires_at = ?
+		WHERE id = ?
+	`
+	res, err := s.db.ExecContext(ctx, query, app.Secret, previous, expiresAt, app.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return checkAppAffected(op, res)
+}
+
+// DeleteApp удаляет приложение вместе с его токенами, ключами и сертификатами
+func (s *SQLiteStorage) DeleteApp(
+	ctx context.Context,
+	appID uint32,
+) error {
+	const op = appsOp + "DeleteApp"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		"DELETE FROM refresh_tokens WHERE app_id = ?",
+		"DELETE FROM user_apps WHERE app_id = ?",
+		"DELETE FROM app_encryption_keys WHERE app_id = ?",
+		"DELETE FROM app_certificates WHERE app_id = ?",
+	} {
+		if _, err := tx.ExecContext(ctx, query, appID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM apps WHERE id = ?", appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkAppAffected(op, res); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func checkAppAffected(op string, res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrAppNotFound
+	}
+	return nil
+}
+
+// sealPlaintextSecrets шифрует секреты приложений, сохраненные до включения шифрования
+func (s *SQLiteStorage) sealPlaintextSecrets(ctx context.Context) error {
+	const op = appsOp + "sealPlaintextSecrets"
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, secret, previous_secret FROM apps")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	var apps []models.App
+	for rows.Next() {
+		var app models.App
+		var previous sql.NullString
+		if err := rows.Scan(&app.ID, &app.Secret, &previous); err != nil {
+			rows.Close()
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		app.PreviousSecret = previous.String
+		if (app.Secret != "" && !secretbox.IsSealed(app.Secret)) ||
+			(app.PreviousSecret != "" && !secretbox.IsSealed(app.PreviousSecret)) {
+			apps = append(apps, app)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, app := range apps {
+		secret, previous := app.Secret, sql.NullString{String: app.PreviousSecret, Valid: app.PreviousSecret != ""}
+		if !secretbox.IsSealed(secret) {
+			if secret, err = s.secrets.Seal(secret); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		if previous.Valid && !secretbox.IsSealed(previous.String) {
+			if previous.String, err = s.secrets.Seal(previous.String); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		query := "UPDATE apps SET secret = ?, previous_secret = ? WHERE id = ?"
+		if _, err := s.db.ExecContext(ctx, query, secret, previous, app.ID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if len(apps) > 0 {
+		s.logger.Info("plaintext app secrets encrypted", slog.Int("apps", len(apps)))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package sqlite
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/config"
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/secretbox"
+)
+
+// testHasher хранит пароль открытым текстом, чтобы тесты не тратили время на argon2id
+type testHasher struct{}
+
+func (testHasher) Hash(password string) (string, error) { return "plain:" + password, nil }
+
+func (testHasher) Verify(hash []byte, password string) (bool, bool, error) {
+	return string(hash) == "plain:"+password, false, nil
+}
+
+// newTestStorage возвращает хранилище во временном файле со всеми миграциями
+func newTestStorage(t *testing.T, path string) *SQLiteStorage {
+	t.Helper()
+
+	box, err := secretbox.New("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := New("sqlite3", path, config.SuperUser{Username: "root", Password: "root-password"}, testHasher{}, box, log)
+	if err := s.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close(context.Background()) })
+	return s
+}
+
+// rawSecrets возвращает секреты приложения в том виде, в котором они лежат в базе
+func rawSecrets(t *testing.T, s *SQLiteStorage, appID uint32) (string, string) {
+	t.Helper()
+
+	var secret string
+	var previous *string
+	err := s.db.QueryRow("SELECT secret, previous_secret FROM apps WHERE id = ?", appID).Scan(&secret, &previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if previous == nil {
+		return secret, ""
+	}
+	return secret, *previous
+}
+
+func TestAppSecrets__Encrypted(t *testing.T) {
+	s := newTestStorage(t, filepath.Join(t.TempDir(), "sso.sqlite3"))
+	ctx := context.Background()
+
+	appID, err := s.CreateApp(ctx, models.App{Name: "app", Secret: "first-secret", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret, _ := rawSecrets(t, s, appID); !secretbox.IsSealed(secret) {
+		t.Fatalf("secret is stored as %q", secret)
+	}
+
+	app, err := s.GetApp(ctx, appID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.Secret != "first-secret" {
+		t.Fatalf("secret = %q, want first-secret", app.Secret)
+	}
+
+	app.PreviousSecret, app.PreviousSecretExpiresAt = app.Secret, time.Now().Add(time.Hour)
+	app.Secret = "second-secret"
+	if err := s.UpdateAppSecret(ctx, app); err != nil {
+		t.Fatal(err)
+	}
+	if secret, previous := rawSecrets(t, s, appID); !secretbox.IsSealed(secret) || !secretbox.IsSealed(previous) {
+		t.Fatalf("rotated secrets are stored as %q, %q", secret, previous)
+	}
+
+	apps, err := s.ListApps(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(apps) != 1 || apps[0].Secret != "second-secret" || apps[0].PreviousSecret != "first-secret" {
+		t.Fatalf("apps = %+v", apps)
+	}
+}
+
+func TestAppSecrets__PlaintextSealedOnConnect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sso.sqlite3")
+	s := newTestStorage(t, path)
+
+	// Секреты, сохраненные до шифрования, шифруются при следующем подключении
+	res, err := s.db.Exec("INSERT INTO apps (name, secret, previous_secret) VALUES ('legacy', 'plain-secret', 'plain-previous')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	s = newTestStorage(t, path)
+	if secret, previous := rawSecrets(t, s, uint32(id)); !secretbox.IsSealed(secret) || !secretbox.IsSealed(previous) {
+		t.Fatalf("legacy secrets are stored as %q, %q", secret, previous)
+	}
+	app, err := s.GetApp(context.Background(), uint32(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.Secret != "plain-secret" || app.PreviousSecret != "plain-previous" {
+		t.Fatalf("secrets = %q, %q", app.Secret, app.PreviousSecret)
+	}
+}
@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/storage"
+	"github.com/mattn/go-sqlite3"
+)
+
+// GetAppCertificates возвращает зарегистрированные клиентские сертификаты приложения
+func (s *SQLiteStorage) GetAppCertificates(
+	ctx context.Context,
+	appID uint32,
+) ([]models.AppCertificate, error) {
+	const op = sqliteOp + "GetAppCertificates"
+
+	query := `
+		SELECT app_id, thumbprint, subject, not_after, created_at
+		FROM app_certificates
+		WHERE app_id = ?
+		ORDER BY created_at
+	`
+	rows, err := s.db.QueryContext(ctx, query, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var certs []models.AppCertificate
+	for rows.Next() {
+		var cert models.AppCertificate
+		var notAfter, createdAt string
+
+		if err := rows.Scan(
+			&cert.AppID,
+			&cert.Thumbprint,
+			&cert.Subject,
+			&notAfter,
+			&createdAt,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if cert.NotAfter, err = time.Parse(time.RFC3339, notAfter); err != nil {
+			return nil, fmt.Errorf("%s: failed to parse not_after: %w", op, err)
+		}
+		if cert.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to parse created_at: %w", op, err)
+		}
+		certs = append(certs, cert)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return certs, nil
+}
+
+// SaveAppCertificate регистрирует клиентский сертификат приложения
+func (s *SQLiteStorage) SaveAppCertificate(
+	ctx context.Context,
+	cert models.AppCertificate,
+) error {
+	const op = sqliteOp + "SaveAppCertificate"
+
+	query := `
+		INSERT INTO app_certificates (thumbprint, app_id, subject, not_after, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		cert.Thumbprint,
+		cert.AppID,
+		cert.Subject,
+		cert.NotAfter.UTC().Format(time.RFC3339),
+		cert.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey {
+			return storage.ErrCertificateExist
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteAppCertificate удаляет клиентский сертификат приложения
+func (s *SQLiteStorage) DeleteAppCertificate(
+	ctx context.Context,
+	appID uint32,
+	thumbprint string,
+) error {
+	const op = sqliteOp + "DeleteAppCertificate"
+
+	query := "DELETE FROM app_certificates WHERE app_id = ? AND thumbprint = ?"
+	res, err := s.db.ExecContext(ctx, query, appID, thumbprint)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrCertificateNotFound
+	}
+	return nil
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 
+	"github.com/Grino777/sso/internal/lib/logger"
 	sUtils "github.com/Grino777/sso/internal/utils/storage/sqlite"
 	"github.com/Grino777/sso/migrations"
 	_ "github.com/mattn/go-sqlite3"
@@ -23,9 +25,13 @@ func (s *SQLiteStorage) Connect(ctx context.Context) error {
 	if err := migrations.Migrate(s.db, s.driverName); err != nil {
 		return err
 	}
-	if err := sUtils.CreateSuperUser(s.db, s.superuser.Username, s.superuser.Password); err != nil {
+	if err := s.sealPlaintextSecrets(ctx); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	if err := sUtils.CreateSuperUser(s.db, s.superuser.Username, s.superuser.Password, s.hasher); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	s.warnUsernameCollisions(ctx)
 
 	s.logger.Debug("database connection successfully")
 	return nil
@@ -45,3 +51,30 @@ func (s *SQLiteStorage) Close(ctx context.Context) error {
 
 	return nil
 }
+
+// warnUsernameCollisions сообщает о пользователях, чьи имена совпали с чужими после нормализации.
+// Такие пользователи не могут войти, пока их не переименуют.
+func (s *SQLiteStorage) warnUsernameCollisions(ctx context.Context) {
+	const op = sqliteOp + "warnUsernameCollisions"
+
+	rows, err := s.db.QueryContext(ctx, "SELECT user_id, username, conflicts_with FROM username_collisions ORDER BY user_id")
+	if err != nil {
+		s.logger.Error("failed to check username collisions", slog.String("op", op), logger.Error(err))
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID, conflictsWith uint64
+		var username string
+		if err := rows.Scan(&userID, &username, &conflictsWith); err != nil {
+			s.logger.Error("failed to check username collisions", slog.String("op", op), logger.Error(err))
+			return
+		}
+		s.logger.Warn("username collides with another user after normalization, the user cannot sign in until renamed",
+			slog.Uint64("user_id", userID),
+			slog.String("username", username),
+			slog.Uint64("conflicts_with", conflictsWith),
+		)
+	}
+}
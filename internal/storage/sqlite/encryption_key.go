@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/storage"
+)
+
+// GetAppEncryptionKey возвращает активный ключ шифрования токенов приложения
+func (s *SQLiteStorage) GetAppEncryptionKey(
+	ctx context.Context,
+	appID uint32,
+) (models.EncryptionKey, error) {
+	const op = sqliteOp + "GetAppEncryptionKey"
+
+	var key models.EncryptionKey
+	var createdAt string
+
+	query := `
+		SELECT id, app_id, algorithm, public_key, created_at
+		FROM app_encryption_keys
+		WHERE app_id = ? AND is_active = 1
+	`
+	err := s.db.QueryRowContext(ctx, query, appID).Scan(
+		&key.ID,
+		&key.AppID,
+		&key.Algorithm,
+		&key.PublicKey,
+		&createdAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return key, storage.ErrEncryptionKeyNotFound
+		}
+		return key, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key.CreatedAt, err = time.Parse(time.RFC3339, createdAt)
+	if err != nil {
+		return key, fmt.Errorf("%s: failed to parse created_at: %w", op, err)
+	}
+	return key, nil
+}
+
+// SaveAppEncryptionKey сохраняет новый ключ шифрования и деактивирует предыдущий
+func (s *SQLiteStorage) SaveAppEncryptionKey(
+	ctx context.Context,
+	key models.EncryptionKey,
+) error {
+	const op = sqliteOp + "SaveAppEncryptionKey"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE app_encryption_keys SET is_active = 0 WHERE app_id = ?"
+	if _, err := tx.ExecContext(ctx, query, key.AppID); err != nil {
+		return fmt.Errorf("%s: failed to deactivate previous key: %w", op, err)
+	}
+
+	query = `
+		INSERT INTO app_encryption_keys (id, app_id, algorithm, public_key, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err = tx.ExecContext(
+		ctx,
+		query,
+		key.ID,
+		key.AppID,
+		key.Algorithm,
+		key.PublicKey,
+		key.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: failed to save key: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteAppEncryptionKey отключает шифрование токенов для приложения
+func (s *SQLiteStorage) DeleteAppEncryptionKey(
+	ctx context.Context,
+	appID uint32,
+) error {
+	const op = sqliteOp + "DeleteAppEncryptionKey"
+
+	query := "UPDATE app_encryption_keys SET is_active = 0 WHERE app_id = ? AND is_active = 1"
+	res, err := s.db.ExecContext(ctx, query, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrEncryptionKeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+)
+
+const loginsOp = sqliteOp + "logins."
+
+// SaveLoginRecord сохраняет попытку входа в users_logs
+func (s *SQLiteStorage) SaveLoginRecord(
+	ctx context.Context,
+	record models.LoginRecord,
+) error {
+	const op = loginsOp + "SaveLoginRecord"
+
+	query := `
+		INSERT INTO users_logs (user_id, app_id, username, user_ip, user_agent, success, reason, loggined_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		record.UserID,
+		record.AppID,
+		record.Username,
+		record.IP,
+		record.UserAgent,
+		record.Success,
+		record.Reason,
+		record.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ListLoginRecords возвращает последние попытки входа пользователя, новые первыми
+func (s *SQLiteStorage) ListLoginRecords(
+	ctx context.Context,
+	userID uint64,
+	limit int,
+) ([]models.LoginRecord, error) {
+	const op = loginsOp + "ListLoginRecords"
+
+	query := `
+		SELECT id, user_id, app_id, username, user_ip, user_agent, success, reason, loggined_at
+		FROM users_logs
+		WHERE user_id = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var records []models.LoginRecord
+	for rows.Next() {
+		var record models.LoginRecord
+		var createdAt string
+
+		if err := rows.Scan(
+			&record.ID,
+			&record.UserID,
+			&record.AppID,
+			&record.Username,
+			&record.IP,
+			&record.UserAgent,
+			&record.Success,
+			&record.Reason,
+			&createdAt,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if record.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			return nil, fmt.Errorf("%s: failed to parse loggined_at: %w", op, err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return records, nil
+}
@@ -0,0 +1,185 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/storage"
+)
+
+const membersOp = sqliteOp + "members."
+
+const memberQuery = `
+	SELECT ua.user_id, ua.app_id, u.username, ua.is_blocked, ua.role_id, ua.created_at
+	FROM user_apps ua
+	JOIN users u ON u.id = ua.user_id
+`
+
+// scanMember читает запись, выбранную по memberQuery
+func scanMember(row rowScanner) (models.AppMember, error) {
+	var member models.AppMember
+	var roleID sql.NullInt64
+	var createdAt sql.NullString
+
+	err := row.Scan(
+		&member.UserID,
+		&member.AppID,
+		&member.Username,
+		&member.Blocked,
+		&roleID,
+		&createdAt,
+	)
+	if err != nil {
+		return member, err
+	}
+
+	member.RoleID = int(roleID.Int64)
+	if createdAt.Valid && createdAt.String != "" {
+		if member.CreatedAt, err = time.Parse(time.RFC3339, createdAt.String); err != nil {
+			return member, fmt.Errorf("failed to parse created_at: %w", err)
+		}
+	}
+	return member, nil
+}
+
+// GetAppMember возвращает членство пользователя в приложении
+func (s *SQLiteStorage) GetAppMember(
+	ctx context.Context,
+	userID uint64,
+	appID uint32,
+) (models.AppMember, error) {
+	const op = membersOp + "GetAppMember"
+
+	query := memberQuery + " WHERE ua.user_id = ? AND ua.app_id = ?"
+	member, err := scanMember(s.db.QueryRowContext(ctx, query, userID, appID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return member, storage.ErrMemberNotFound
+		}
+		return member, fmt.Errorf("%s: %w", op, err)
+	}
+	return member, nil
+}
+
+// ListAppMembers возвращает участников приложения
+func (s *SQLiteStorage) ListAppMembers(
+	ctx context.Context,
+	appID uint32,
+) ([]models.AppMember, error) {
+	const op = membersOp + "ListAppMembers"
+
+	rows, err := s.db.QueryContext(ctx, memberQuery+" WHERE ua.app_id = ? ORDER BY ua.user_id", appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var members []models.AppMember
+	for rows.Next() {
+		member, err := scanMember(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return members, nil
+}
+
+// AddAppMember добавляет пользователя в приложение
+func (s *SQLiteStorage) AddAppMember(
+	ctx context.Context,
+	userID uint64,
+	appID uint32,
+) error {
+	const op = membersOp + "AddAppMember"
+
+	query := "INSERT INTO user_apps (user_id, app_id, created_at) VALUES (?, ?, ?)"
+	_, err := s.db.ExecContext(ctx, query, userID, appID, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrMemberExist)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RemoveAppMember исключает пользователя из приложения и отзывает его refresh токен
+func (s *SQLiteStorage) RemoveAppMember(
+	ctx context.Context,
+	userID uint64,
+	appID uint32,
+) error {
+	const op = membersOp + "RemoveAppMember"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM user_apps WHERE user_id = ? AND app_id = ?", userID, appID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	} else if affected == 0 {
+		return storage.ErrMemberNotFound
+	}
+
+	query := "DELETE FROM refresh_tokens WHERE user_id = ? AND app_id = ?"
+	if _, err := tx.ExecContext(ctx, query, userID, appID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SetAppMemberBlocked блокирует или разблокирует пользователя в приложении.
+// Блокировка создает запись, если пользователь еще не участник, и отзывает его refresh токен.
+func (s *SQLiteStorage) SetAppMemberBlocked(
+	ctx context.Context,
+	userID uint64,
+	appID uint32,
+	blocked bool,
+) error {
+	const op = membersOp + "SetAppMemberBlocked"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO user_apps (user_id, app_id, is_blocked, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, app_id) DO UPDATE SET is_blocked = excluded.is_blocked
+	`
+	createdAt := time.Now().UTC().Format(time.RFC3339)
+	if _, err := tx.ExecContext(ctx, query, userID, appID, blocked, createdAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if blocked {
+		query := "DELETE FROM refresh_tokens WHERE user_id = ? AND app_id = ?"
+		if _, err := tx.ExecContext(ctx, query, userID, appID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/storage"
+)
+
+const mfaOp = sqliteOp + "mfa."
+
+// GetUserMFA возвращает настройки TOTP пользователя и число неиспользованных кодов восстановления
+func (s *SQLiteStorage) GetUserMFA(
+	ctx context.Context,
+	userID uint64,
+) (models.UserMFA, error) {
+	const op = mfaOp + "GetUserMFA"
+
+	query := `
+		SELECT m.user_id, m.totp_secret, m.enabled, m.last_used_step, m.created_at, m.enabled_at,
+			(SELECT COUNT(*) FROM mfa_recovery_codes c WHERE c.user_id = m.user_id AND c.used_at IS NULL)
+		FROM user_mfa m
+		WHERE m.user_id = ?
+	`
+	var mfa models.UserMFA
+	var createdAt string
+	var enabledAt sql.NullString
+
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(
+		&mfa.UserID,
+		&mfa.TOTPSecret,
+		&mfa.Enabled,
+		&mfa.LastUsedStep,
+		&createdAt,
+		&enabledAt,
+		&mfa.RecoveryCodesLeft,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return mfa, storage.ErrMFANotFound
+		}
+		return mfa, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if mfa.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+		return mfa, fmt.Errorf("%s: failed to parse created_at: %w", op, err)
+	}
+	if enabledAt.Valid && enabledAt.String != "" {
+		if mfa.EnabledAt, err = time.Parse(time.RFC3339, enabledAt.String); err != nil {
+			return mfa, fmt.Errorf("%s: failed to parse enabled_at: %w", op, err)
+		}
+	}
+	return mfa, nil
+}
+
+// SaveUserMFA сохраняет новый, еще не подтвержденный секрет TOTP.
+// Прежние настройки и коды восстановления пользователя удаляются.
+func (s *SQLiteStorage) SaveUserMFA(
+	ctx context.Context,
+	mfa models.UserMFA,
+) error {
+	const op = mfaOp + "SaveUserMFA"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = ?", mfa.UserID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `
+		INSERT INTO user_mfa (user_id, totp_secret, enabled, last_used_step, created_at, enabled_at)
+		VALUES (?, ?, 0, 0, ?, NULL)
+		ON CONFLICT (user_id) DO UPDATE
+		SET totp_secret = excluded.totp_secret, enabled = 0, last_used_step = 0,
+			created_at = excluded.created_at, enabled_at = NULL
+	`
+	if _, err := tx.ExecContext(ctx, query, mfa.UserID, mfa.TOTPSecret, mfa.CreatedAt.UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// EnableUserMFA включает TOTP, запоминает шаг подтверждающего кода
+// и сохраняет хэши кодов восстановления
+func (s *SQLiteStorage) EnableUserMFA(
+	ctx context.Context,
+	userID uint64,
+	step int64,
+	codeHashes []string,
+) error {
+	const op = mfaOp + "EnableUserMFA"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE user_mfa SET enabled = 1, last_used_step = ?, enabled_at = ? WHERE user_id = ?"
+	res, err := tx.ExecContext(ctx, query, step, time.Now().UTC().Format(time.RFC3339), userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkMFAAffected(op, res); err != nil {
+		return err
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// UseTOTPStep запоминает шаг принятого кода.
+// Возвращает false, если код этого или более позднего шага уже использовался.
+func (s *SQLiteStorage) UseTOTPStep(
+	ctx context.Context,
+	userID uint64,
+	step int64,
+) (bool, error) {
+	const op = mfaOp + "UseTOTPStep"
+
+	query := "UPDATE user_mfa SET last_used_step = ? WHERE user_id = ? AND enabled = 1 AND last_used_step < ?"
+	res, err := s.db.ExecContext(ctx, query, step, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return affected > 0, nil
+}
+
+// UseRecoveryCode помечает код восстановления использованным.
+// Возвращает false, если кода нет или он уже использован.
+func (s *SQLiteStorage) UseRecoveryCode(
+	ctx context.Context,
+	userID uint64,
+	codeHash string,
+) (bool, error) {
+	const op = mfaOp + "UseRecoveryCode"
+
+	query := "UPDATE mfa_recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL"
+	res, err := s.db.ExecContext(ctx, query, time.Now().UTC().Format(time.RFC3339), userID, codeHash)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return affected > 0, nil
+}
+
+// ReplaceRecoveryCodes заменяет коды восстановления пользователя новыми
+func (s *SQLiteStorage) ReplaceRecoveryCodes(
+	ctx context.Context,
+	userID uint64,
+	codeHashes []string,
+) error {
+	const op = mfaOp + "ReplaceRecoveryCodes"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteUserMFA отключает TOTP пользователя и удаляет коды восстановления
+func (s *SQLiteStorage) DeleteUserMFA(
+	ctx context.Context,
+	userID uint64,
+) error {
+	const op = mfaOp + "DeleteUserMFA"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM user_mfa WHERE user_id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkMFAAffected(op, res); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID uint64, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		query := "INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES (?, ?)"
+		if _, err := tx.ExecContext(ctx, query, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkMFAAffected(op string, res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrMFANotFound
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+)
+
+// enableTestMFA включает TOTP пользователю alice и возвращает его id
+func enableTestMFA(t *testing.T, s *SQLiteStorage, step int64, codeHashes []string) uint64 {
+	t.Helper()
+
+	ctx := context.Background()
+	if err := s.SaveUser(ctx, "alice", "plain:password1"); err != nil {
+		t.Fatal(err)
+	}
+	user, err := s.GetUser(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveUserMFA(ctx, models.UserMFA{UserID: user.ID, TOTPSecret: "secret", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EnableUserMFA(ctx, user.ID, step, codeHashes); err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
+
+func TestUseRecoveryCode__SingleUse(t *testing.T) {
+	s := newTestStorage(t, filepath.Join(t.TempDir(), "sso.sqlite3"))
+	ctx := context.Background()
+	userID := enableTestMFA(t, s, 100, []string{"hash-1", "hash-2"})
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{"first use", "hash-1", true},
+		{"second use", "hash-1", false},
+		{"unknown code", "hash-3", false},
+		{"other code", "hash-2", true},
+	}
+	for _, tt := range tests {
+		ok, err := s.UseRecoveryCode(ctx, userID, tt.hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.want {
+			t.Fatalf("%s: ok = %v, want %v", tt.name, ok, tt.want)
+		}
+	}
+
+	mfa, err := s.GetUserMFA(ctx, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mfa.RecoveryCodesLeft != 0 {
+		t.Fatalf("recovery codes left = %d, want 0", mfa.RecoveryCodesLeft)
+	}
+
+	// Новый набор кодов заменяет старый, использованные коды не возвращаются
+	if err := s.ReplaceRecoveryCodes(ctx, userID, []string{"hash-1", "hash-4"}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.UseRecoveryCode(ctx, userID, "hash-1"); err != nil || !ok {
+		t.Fatalf("code from the new set: ok = %v, err = %v", ok, err)
+	}
+	if ok, err := s.UseRecoveryCode(ctx, userID, "hash-2"); err != nil || ok {
+		t.Fatalf("code from the old set: ok = %v, err = %v", ok, err)
+	}
+}
+
+func TestUseTOTPStep__Replay(t *testing.T) {
+	s := newTestStorage(t, filepath.Join(t.TempDir(), "sso.sqlite3"))
+	ctx := context.Background()
+	userID := enableTestMFA(t, s, 100, nil)
+
+	// Шаг, которым подтверждено подключение, и более ранние шаги не принимаются
+	for _, step := range []int64{99, 100} {
+		if ok, err := s.UseTOTPStep(ctx, userID, step); err != nil || ok {
+			t.Fatalf("step %d: ok = %v, err = %v", step, ok, err)
+		}
+	}
+	if ok, err := s.UseTOTPStep(ctx, userID, 101); err != nil || !ok {
+		t.Fatalf("step 101: ok = %v, err = %v", ok, err)
+	}
+	if ok, err := s.UseTOTPStep(ctx, userID, 101); err != nil || ok {
+		t.Fatalf("step 101 reused: ok = %v, err = %v", ok, err)
+	}
+}
@@ -0,0 +1,155 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/storage"
+)
+
+const profilesOp = sqliteOp + "profiles."
+
+// GetProfile возвращает профиль пользователя
+func (s *SQLiteStorage) GetProfile(
+	ctx context.Context,
+	userID uint64,
+) (models.Profile, error) {
+	const op = profilesOp + "GetProfile"
+
+	query := `
+		SELECT user_id, email, email_verified, display_name, locale, attributes, updated_at
+		FROM user_profiles
+		WHERE user_id = ?
+	`
+	profile := models.Profile{UserID: userID}
+	var email sql.NullString
+	var attributes, updatedAt string
+
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(
+		&profile.UserID,
+		&email,
+		&profile.EmailVerified,
+		&profile.DisplayName,
+		&profile.Locale,
+		&attributes,
+		&updatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return profile, storage.ErrProfileNotFound
+		}
+		return profile, fmt.Errorf("%s: %w", op, err)
+	}
+
+	profile.Email = email.String
+	if err := json.Unmarshal([]byte(attributes), &profile.Attributes); err != nil {
+		return profile, fmt.Errorf("%s: failed to parse attributes: %w", op, err)
+	}
+	if profile.UpdatedAt, err = time.Parse(time.RFC3339, updatedAt); err != nil {
+		return profile, fmt.Errorf("%s: failed to parse updated_at: %w", op, err)
+	}
+	return profile, nil
+}
+
+// SaveProfile создает или заменяет профиль пользователя
+func (s *SQLiteStorage) SaveProfile(
+	ctx context.Context,
+	profile models.Profile,
+) error {
+	const op = profilesOp + "SaveProfile"
+
+	attributes := []byte("{}")
+	if profile.Attributes != nil {
+		data, err := json.Marshal(profile.Attributes)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		attributes = data
+	}
+
+	query := `
+		INSERT INTO user_profiles (user_id, email, email_verified, display_name, locale, attributes, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE
+		SET email = excluded.email,
+			email_verified = excluded.email_verified,
+			display_name = excluded.display_name,
+			locale = excluded.locale,
+			attributes = excluded.attributes,
+			updated_at = excluded.updated_at
+	`
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		profile.UserID,
+		sql.NullString{String: profile.Email, Valid: profile.Email != ""},
+		profile.EmailVerified,
+		profile.DisplayName,
+		profile.Locale,
+		string(attributes),
+		time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrEmailTaken)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// VerifyProfileEmail подтверждает email, если он не изменился с момента отправки письма
+func (s *SQLiteStorage) VerifyProfileEmail(
+	ctx context.Context,
+	userID uint64,
+	email string,
+) error {
+	const op = profilesOp + "VerifyProfileEmail"
+
+	query := "UPDATE user_profiles SET email_verified = 1, updated_at = ? WHERE user_id = ? AND email = ?"
+	res, err := s.db.ExecContext(ctx, query, time.Now().UTC().Format(time.RFC3339), userID, email)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrEmailTaken)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrProfileNotFound
+	}
+	return nil
+}
+
+// GetUserByEmail возвращает пользователя по подтвержденному email
+func (s *SQLiteStorage) GetUserByEmail(
+	ctx context.Context,
+	email string,
+) (models.User, error) {
+	const op = profilesOp + "GetUserByEmail"
+
+	query := `
+		SELECT u.id, u.username, u.pass_hash, u.role_id, u.is_disabled, u.locked_until, u.created_at,
+			u.password_changed_at
+		FROM users u
+		JOIN user_profiles p ON p.user_id = u.id
+		WHERE p.email = ? AND p.email_verified = 1
+	`
+	user, err := scanUser(s.db.QueryRowContext(ctx, query, email))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, storage.ErrUserNotFound
+		}
+		return user, fmt.Errorf("%s: %w", op, err)
+	}
+	return user, nil
+}
@@ -0,0 +1,359 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/storage"
+)
+
+const rolesOp = sqliteOp + "roles."
+
+// querier общий интерфейс *sql.DB и *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// ListRoles возвращает все роли с их правами
+func (s *SQLiteStorage) ListRoles(ctx context.Context) ([]models.Role, error) {
+	const op = rolesOp + "ListRoles"
+
+	roles, err := queryRoles(ctx, s.db, "SELECT id, name, description FROM roles ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return roles, nil
+}
+
+// GetRole возвращает роль с ее правами
+func (s *SQLiteStorage) GetRole(ctx context.Context, roleID int) (models.Role, error) {
+	const op = rolesOp + "GetRole"
+
+	roles, err := queryRoles(ctx, s.db, "SELECT id, name, description FROM roles WHERE id = ?", roleID)
+	if err != nil {
+		return models.Role{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(roles) == 0 {
+		return models.Role{}, storage.ErrRoleNotFound
+	}
+	return roles[0], nil
+}
+
+// CreateRole сохраняет роль вместе с набором прав и возвращает ее id
+func (s *SQLiteStorage) CreateRole(ctx context.Context, role models.Role) (int, error) {
+	const op = rolesOp + "CreateRole"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO roles (name, description) VALUES (?, ?)", role.Name, role.Description)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrRoleExist)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := insertRolePermissions(ctx, tx, int(id), role.Permissions); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int(id), nil
+}
+
+// SetRolePermissions заменяет набор прав роли
+func (s *SQLiteStorage) SetRolePermissions(
+	ctx context.Context,
+	roleID int,
+	permissions []string,
+) error {
+	const op = rolesOp + "SetRolePermissions"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM roles WHERE id = ?)", roleID).Scan(&exists); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return storage.ErrRoleNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE role_id = ?", roleID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := insertRolePermissions(ctx, tx, roleID, permissions); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteRole удаляет роль. Роль, назначенную пользователям глобально, удалить нельзя,
+// назначения в приложениях снимаются.
+func (s *SQLiteStorage) DeleteRole(ctx context.Context, roleID int) error {
+	const op = rolesOp + "DeleteRole"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var inUse bool
+	if err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE role_id = ?)", roleID).Scan(&inUse); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if inUse {
+		return storage.ErrRoleInUse
+	}
+
+	for _, query := range []string{
+		"UPDATE user_apps SET role_id = NULL WHERE role_id = ?",
+		"DELETE FROM role_permissions WHERE role_id = ?",
+	} {
+		if _, err := tx.ExecContext(ctx, query, roleID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM roles WHERE id = ?", roleID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	} else if affected == 0 {
+		return storage.ErrRoleNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ListPermissions возвращает все зарегистрированные права
+func (s *SQLiteStorage) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	const op = rolesOp + "ListPermissions"
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, description FROM permissions ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var permissions []models.Permission
+	for rows.Next() {
+		var permission models.Permission
+		if err := rows.Scan(&permission.ID, &permission.Name, &permission.Description); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		permissions = append(permissions, permission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return permissions, nil
+}
+
+// CreatePermission сохраняет право и возвращает его id
+func (s *SQLiteStorage) CreatePermission(
+	ctx context.Context,
+	permission models.Permission,
+) (int, error) {
+	const op = rolesOp + "CreatePermission"
+
+	res, err := s.db.ExecContext(
+		ctx,
+		"INSERT INTO permissions (name, description) VALUES (?, ?)",
+		permission.Name,
+		permission.Description,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrPermissionExist)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int(id), nil
+}
+
+// DeletePermission удаляет право и отзывает его у всех ролей
+func (s *SQLiteStorage) DeletePermission(ctx context.Context, permissionID int) error {
+	const op = rolesOp + "DeletePermission"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE permission_id = ?", permissionID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM permissions WHERE id = ?", permissionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	} else if affected == 0 {
+		return storage.ErrPermissionNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SetUserAppRole назначает пользователю роль в приложении, roleID = 0 снимает назначение.
+// Если пользователь не был участником приложения, он добавляется.
+func (s *SQLiteStorage) SetUserAppRole(
+	ctx context.Context,
+	userID uint64,
+	appID uint32,
+	roleID int,
+) error {
+	const op = rolesOp + "SetUserAppRole"
+
+	role := sql.NullInt64{Int64: int64(roleID), Valid: roleID != 0}
+
+	query := `
+		INSERT INTO user_apps (user_id, app_id, role_id, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, app_id) DO UPDATE SET role_id = excluded.role_id
+	`
+	createdAt := time.Now().UTC().Format(time.RFC3339)
+	if _, err := s.db.ExecContext(ctx, query, userID, appID, role, createdAt); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// GetUserRoles возвращает глобальную роль пользователя и его роль в приложении
+func (s *SQLiteStorage) GetUserRoles(
+	ctx context.Context,
+	userID uint64,
+	appID uint32,
+) ([]models.Role, error) {
+	const op = rolesOp + "GetUserRoles"
+
+	query := `
+		SELECT id, name, description FROM roles
+		WHERE id = (SELECT role_id FROM users WHERE id = ?)
+		   OR id = (SELECT role_id FROM user_apps WHERE user_id = ? AND app_id = ?)
+		ORDER BY id
+	`
+	roles, err := queryRoles(ctx, s.db, query, userID, userID, appID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return roles, nil
+}
+
+// queryRoles выбирает роли и загружает их права
+func queryRoles(ctx context.Context, q querier, query string, args ...any) ([]models.Role, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []models.Role
+	index := make(map[int]int)
+	for rows.Next() {
+		role := models.Role{Permissions: []string{}}
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description); err != nil {
+			return nil, err
+		}
+		index[role.ID] = len(roles)
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return roles, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(roles)), ", ")
+	ids := make([]any, 0, len(roles))
+	for _, role := range roles {
+		ids = append(ids, role.ID)
+	}
+
+	permRows, err := q.QueryContext(ctx, `
+		SELECT rp.role_id, p.name
+		FROM role_permissions rp
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE rp.role_id IN (`+placeholders+`)
+		ORDER BY p.name
+	`, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer permRows.Close()
+
+	for permRows.Next() {
+		var roleID int
+		var name string
+		if err := permRows.Scan(&roleID, &name); err != nil {
+			return nil, err
+		}
+		role := &roles[index[roleID]]
+		role.Permissions = append(role.Permissions, name)
+	}
+	return roles, permRows.Err()
+}
+
+// insertRolePermissions связывает роль с правами по их именам
+func insertRolePermissions(ctx context.Context, q querier, roleID int, permissions []string) error {
+	for _, name := range permissions {
+		var permissionID int
+		err := q.QueryRowContext(ctx, "SELECT id FROM permissions WHERE name = ?", name).Scan(&permissionID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("%w: %s", storage.ErrPermissionNotFound, name)
+			}
+			return err
+		}
+
+		query := "INSERT OR IGNORE INTO role_permissions (role_id, permission_id) VALUES (?, ?)"
+		if _, err := q.ExecContext(ctx, query, roleID, permissionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
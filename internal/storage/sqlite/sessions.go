@@ -0,0 +1,174 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/storage"
+)
+
+const sessionsOp = sqliteOp + "sessions."
+
+// ListSessions возвращает действующие сеансы пользователя, последние использованные первыми
+func (s *SQLiteStorage) ListSessions(
+	ctx context.Context,
+	filter models.SessionFilter,
+) ([]models.Session, error) {
+	const op = sessionsOp + "ListSessions"
+
+	query := `
+		SELECT session_id, user_id, app_id, ip, user_agent, device_name, created_at, last_used_at, expire_at, offline
+		FROM refresh_tokens
+		WHERE user_id = ? AND CAST(expire_at AS INTEGER) > ?
+	`
+	args := []any{filter.UserID, time.Now().UTC().Unix()}
+	if filter.SessionID != "" {
+		query += " AND session_id = ?"
+		args = append(args, filter.SessionID)
+	}
+	if filter.AppID != 0 {
+		query += " AND app_id = ?"
+		args = append(args, filter.AppID)
+	}
+	if filter.Offline != nil {
+		query += " AND offline = ?"
+		args = append(args, *filter.Offline)
+	}
+	query += " ORDER BY last_used_at DESC, id DESC"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		var session models.Session
+		var createdAt, lastUsedAt string
+		var expireAt int64
+
+		if err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.AppID,
+			&session.IP,
+			&session.UserAgent,
+			&session.DeviceName,
+			&createdAt,
+			&lastUsedAt,
+			&expireAt,
+			&session.Offline,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if session.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if session.LastUsedAt, err = time.Parse(time.RFC3339, lastUsedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		session.ExpiresAt = time.Unix(expireAt, 0).UTC()
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return sessions, nil
+}
+
+// DeleteSession отзывает сеанс пользователя вместе с его refresh токеном
+func (s *SQLiteStorage) DeleteSession(
+	ctx context.Context,
+	userID uint64,
+	sessionID string,
+) error {
+	const op = sessionsOp + "DeleteSession"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id = ? AND session_id = ?", userID, sessionID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrSessionNotFound
+	}
+	return nil
+}
+
+// DeleteUserSessions отзывает все сеансы пользователя, кроме сеанса except (пусто — все)
+func (s *SQLiteStorage) DeleteUserSessions(
+	ctx context.Context,
+	userID uint64,
+	except string,
+) (int64, error) {
+	const op = sessionsOp + "DeleteUserSessions"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id = ? AND session_id != ?", userID, except)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return affected, nil
+}
+
+// DeleteOfflineSessions отзывает offline сеансы пользователя во всех приложениях
+func (s *SQLiteStorage) DeleteOfflineSessions(
+	ctx context.Context,
+	userID uint64,
+) (int64, error) {
+	const op = sessionsOp + "DeleteOfflineSessions"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id = ? AND offline = 1", userID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return affected, nil
+}
+
+// TrimSessions оставляет keep последних созданных действующих сеансов пользователя в приложении.
+// Более старые и истекшие сеансы удаляются.
+func (s *SQLiteStorage) TrimSessions(
+	ctx context.Context,
+	userID uint64,
+	appID uint32,
+	keep int,
+) (int64, error) {
+	const op = sessionsOp + "TrimSessions"
+
+	query := `
+		DELETE FROM refresh_tokens
+		WHERE user_id = ? AND app_id = ? AND id NOT IN (
+			SELECT id FROM refresh_tokens
+			WHERE user_id = ? AND app_id = ? AND CAST(expire_at AS INTEGER) > ?
+			ORDER BY created_at DESC, id DESC
+			LIMIT ?
+		)
+	`
+	res, err := s.db.ExecContext(ctx, query, userID, appID, userID, appID, time.Now().UTC().Unix(), keep)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return affected, nil
+}
@@ -8,9 +8,12 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/Grino777/sso/internal/config"
 	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/passhash"
+	"github.com/Grino777/sso/internal/lib/secretbox"
 	"github.com/Grino777/sso/internal/storage"
 
 	"github.com/mattn/go-sqlite3"
@@ -27,19 +30,26 @@ type SQLiteStorage struct {
 	driverName string
 	localPath  string
 	superuser  config.SuperUser
-	logger     *slog.Logger
+	hasher     passhash.PasswordHasher
+	// Шифрование секретов приложений
+	secrets *secretbox.Box
+	logger  *slog.Logger
 }
 
 // Creates a new DB session and performs migrations
 func New(
 	driverName, localPath string,
 	superuser config.SuperUser,
+	hasher passhash.PasswordHasher,
+	secrets *secretbox.Box,
 	log *slog.Logger,
 ) *SQLiteStorage {
 	return &SQLiteStorage{
 		driverName: driverName,
 		localPath:  localPath,
 		superuser:  superuser,
+		hasher:     hasher,
+		secrets:    secrets,
 		logger:     log,
 	}
 }
@@ -50,12 +60,12 @@ func (s *SQLiteStorage) SaveUser(
 ) error {
 	const op = "storage.SaveUser"
 
-	stmt, err := s.db.Prepare("INSERT INTO users(username, pass_hash) VALUES(?, ?)")
+	stmt, err := s.db.Prepare("INSERT INTO users(username, username_key, pass_hash, created_at) VALUES(?, ?, ?, ?)")
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = stmt.ExecContext(ctx, username, passHash)
+	_, err = stmt.ExecContext(ctx, username, models.UsernameKey(username), passHash, time.Now().UTC().Format(time.RFC3339))
 	if err != nil {
 		var sqlErr sqlite3.Error
 
@@ -68,21 +78,33 @@ func (s *SQLiteStorage) SaveUser(
 	return nil
 }
 
+// GetUser ищет пользователя по имени без учета регистра и формы Unicode.
+// Пользователи из username_collisions не находятся, пока их не переименуют.
 func (s *SQLiteStorage) GetUser(
 	ctx context.Context,
 	username string,
 ) (models.User, error) {
 	const op = "storage.sqlite.GetUser"
 
-	user := models.User{}
+	query := "SELECT " + userColumns + " FROM users WHERE username_key = ?"
+	user, err := scanUser(s.db.QueryRowContext(ctx, query, models.UsernameKey(username)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return user, storage.ErrUserNotFound
+		}
+		return user, fmt.Errorf("%s: %w", op, err)
+	}
+	return user, nil
+}
 
-	query := "SELECT * FROM users WHERE username = ?"
-	err := s.db.QueryRowContext(ctx, query, username).Scan(
-		&user.ID,
-		&user.Username,
-		&user.PassHash,
-		&user.Role_id,
-	)
+func (s *SQLiteStorage) GetUserByID(
+	ctx context.Context,
+	userID uint64,
+) (models.User, error) {
+	const op = sqliteOp + "GetUserByID"
+
+	query := "SELECT " + userColumns + " FROM users WHERE id = ?"
+	user, err := scanUser(s.db.QueryRowContext(ctx, query, userID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return user, storage.ErrUserNotFound
@@ -98,29 +120,35 @@ func (s *SQLiteStorage) GetApp(
 ) (app models.App, err error) {
 	const op = "sqlite.GetApp"
 
-	app = models.App{}
-
-	query := "SELECT * FROM apps WHERE id = ?"
-	err = s.db.QueryRowContext(ctx, query, appID).Scan(
-		&app.ID,
-		&app.Name,
-		&app.Secret,
-	)
+	query := "SELECT " + appColumns + " FROM apps WHERE id = ?"
+	app, err = scanApp(s.db.QueryRowContext(ctx, query, appID))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return app, storage.ErrUserNotFound
+			return app, storage.ErrAppNotFound
 		}
 		return app, fmt.Errorf("%s: %w", op, err)
 	}
-	return app, nil
-}
+	if err := storage.OpenAppSecrets(s.secrets, &app); err != nil {
+		return app, fmt.Errorf("%s: %w", op, err)
+	}
 
-// FIXME
-func (s *SQLiteStorage) IsAdmin(
-	ctx context.Context,
-	username string,
-) (bool, error) {
-	panic("implement me")
+	key, err := s.GetAppEncryptionKey(ctx, appID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrEncryptionKeyNotFound) {
+			return app, fmt.Errorf("%s: %w", op, err)
+		}
+	} else {
+		app.EncryptionKey = &key
+	}
+
+	certs, err := s.GetAppCertificates(ctx, appID)
+	if err != nil {
+		return app, fmt.Errorf("%s: %w", op, err)
+	}
+	for _, cert := range certs {
+		app.ClientCerts = append(app.ClientCerts, cert.Thumbprint)
+	}
+	return app, nil
 }
 
 func (s *SQLiteStorage) DeleteRefreshToken(
@@ -139,29 +167,162 @@ func (s *SQLiteStorage) DeleteRefreshToken(
 	return nil
 }
 
+// SaveRefreshToken создает сеанс с refresh токеном
 func (s *SQLiteStorage) SaveRefreshToken(
 	ctx context.Context,
-	userID uint64,
-	appID uint32,
+	session models.Session,
 	token models.Token,
 ) error {
 	const op = "storage.sqlite.sqlite.SaveRefreshToken"
 
 	query := `
-		INSERT INTO refresh_tokens (user_id, app_id, r_token, expire_at)
-		VALUES (?, ?, ?, ?)
-		ON CONFLICT (user_id, app_id) DO UPDATE
-		SET r_token = excluded.r_token, expire_at = excluded.expire_at
+		INSERT INTO refresh_tokens (
+			session_id, user_id, app_id, r_token, expire_at, jkt, x5t_s256,
+			ip, user_agent, device_name, created_at, last_used_at, offline
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := s.db.ExecContext(ctx, query, userID, appID, token.Token, token.Expire_at)
+	jkt, x5t := tokenConfirmation(token)
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		session.ID,
+		session.UserID,
+		session.AppID,
+		token.Token,
+		token.Expire_at,
+		jkt,
+		x5t,
+		session.IP,
+		session.UserAgent,
+		session.DeviceName,
+		session.CreatedAt.UTC().Format(time.RFC3339),
+		session.LastUsedAt.UTC().Format(time.RFC3339),
+		session.Offline,
+	)
 	if err != nil {
 		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.Code == sqlite3.ErrConstraint {
 			if strings.Contains(sqliteErr.Error(), "refresh_tokens.r_token") {
 				return fmt.Errorf("%s: %w", op, ErrRefreshTokenExist)
 			}
 		}
-		return fmt.Errorf("failed to save or update refresh token: %w", err)
+		return fmt.Errorf("failed to save refresh token: %w", err)
 	}
 	return nil
 }
+
+// RotateRefreshToken заменяет refresh токен сеанса и обновляет данные о его использовании.
+// Если сеанс отозван или токен old уже заменен параллельным запросом, возвращается ErrTokenNotFound.
+func (s *SQLiteStorage) RotateRefreshToken(
+	ctx context.Context,
+	session models.Session,
+	old string,
+	token models.Token,
+) error {
+	const op = "storage.sqlite.sqlite.RotateRefreshToken"
+
+	query := `
+		UPDATE refresh_tokens
+		SET r_token = ?, expire_at = ?, jkt = ?, x5t_s256 = ?,
+			ip = ?, user_agent = ?, device_name = ?, last_used_at = ?
+		WHERE session_id = ? AND user_id = ? AND r_token = ?
+	`
+
+	jkt, x5t := tokenConfirmation(token)
+	res, err := s.db.ExecContext(
+		ctx,
+		query,
+		token.Token,
+		token.Expire_at,
+		jkt,
+		x5t,
+		session.IP,
+		session.UserAgent,
+		session.DeviceName,
+		session.LastUsedAt.UTC().Format(time.RFC3339),
+		session.ID,
+		session.UserID,
+		old,
+	)
+	if err != nil {
+		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.Code == sqlite3.ErrConstraint {
+			if strings.Contains(sqliteErr.Error(), "refresh_tokens.r_token") {
+				return fmt.Errorf("%s: %w", op, ErrRefreshTokenExist)
+			}
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrTokenNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) GetRefreshToken(
+	ctx context.Context,
+	token string,
+) (models.RefreshTokenInfo, error) {
+	const op = sqliteOp + "GetRefreshToken"
+
+	info := models.RefreshTokenInfo{}
+	var jkt, x5t sql.NullString
+	var createdAt, lastUsedAt string
+
+	query := `
+		SELECT session_id, user_id, app_id, r_token, expire_at, jkt, x5t_s256,
+			ip, user_agent, device_name, created_at, last_used_at, offline
+		FROM refresh_tokens
+		WHERE r_token = ?
+	`
+	err := s.db.QueryRowContext(ctx, query, token).Scan(
+		&info.Session.ID,
+		&info.UserID,
+		&info.AppID,
+		&info.Token.Token,
+		&info.Token.Expire_at,
+		&jkt,
+		&x5t,
+		&info.Session.IP,
+		&info.Session.UserAgent,
+		&info.Session.DeviceName,
+		&createdAt,
+		&lastUsedAt,
+		&info.Session.Offline,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return info, storage.ErrTokenNotFound
+		}
+		return info, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if jkt.Valid || x5t.Valid {
+		info.Token.Cnf = &models.Confirmation{JKT: jkt.String, X5TS256: x5t.String}
+	}
+
+	info.Session.UserID = info.UserID
+	info.Session.AppID = info.AppID
+	info.Session.ExpiresAt = time.Unix(info.Token.Expire_at, 0).UTC()
+	if info.Session.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+		return info, fmt.Errorf("%s: %w", op, err)
+	}
+	if info.Session.LastUsedAt, err = time.Parse(time.RFC3339, lastUsedAt); err != nil {
+		return info, fmt.Errorf("%s: %w", op, err)
+	}
+	return info, nil
+}
+
+// tokenConfirmation возвращает привязку токена к ключу клиента для сохранения в БД
+func tokenConfirmation(token models.Token) (jkt, x5t sql.NullString) {
+	if token.Cnf != nil {
+		jkt = sql.NullString{String: token.Cnf.JKT, Valid: token.Cnf.JKT != ""}
+		x5t = sql.NullString{String: token.Cnf.X5TS256, Valid: token.Cnf.X5TS256 != ""}
+	}
+	return jkt, x5t
+}
@@ -0,0 +1,395 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/storage"
+	"github.com/mattn/go-sqlite3"
+)
+
+const usersOp = sqliteOp + "users."
+
+const userColumns = "id, username, pass_hash, role_id, is_disabled, locked_until, created_at, password_changed_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanUser читает пользователя, выбранного по userColumns
+func scanUser(row rowScanner) (models.User, error) {
+	var user models.User
+	var lockedUntil, createdAt, passwordChangedAt sql.NullString
+
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.PassHash,
+		&user.Role_id,
+		&user.Disabled,
+		&lockedUntil,
+		&createdAt,
+		&passwordChangedAt,
+	)
+	if err != nil {
+		return user, err
+	}
+
+	if lockedUntil.Valid && lockedUntil.String != "" {
+		if user.LockedUntil, err = time.Parse(time.RFC3339, lockedUntil.String); err != nil {
+			return user, fmt.Errorf("failed to parse locked_until: %w", err)
+		}
+	}
+	if createdAt.Valid && createdAt.String != "" {
+		if user.CreatedAt, err = time.Parse(time.RFC3339, createdAt.String); err != nil {
+			return user, fmt.Errorf("failed to parse created_at: %w", err)
+		}
+	}
+	// Пароль, не менявшийся после создания пользователя, действует с момента создания
+	user.PasswordChangedAt = user.CreatedAt
+	if passwordChangedAt.Valid && passwordChangedAt.String != "" {
+		if user.PasswordChangedAt, err = time.Parse(time.RFC3339, passwordChangedAt.String); err != nil {
+			return user, fmt.Errorf("failed to parse password_changed_at: %w", err)
+		}
+	}
+	return user, nil
+}
+
+// ListUsers возвращает страницу пользователей по фильтру и общее число найденных
+func (s *SQLiteStorage) ListUsers(
+	ctx context.Context,
+	filter models.UserFilter,
+) ([]models.User, int, error) {
+	const op = usersOp + "ListUsers"
+
+	var conds []string
+	var args []any
+
+	if filter.Username != "" {
+		conds = append(conds, `username LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(filter.Username)+"%")
+	}
+	if filter.RoleID != 0 {
+		conds = append(conds, "role_id = ?")
+		args = append(args, filter.RoleID)
+	}
+	if filter.Disabled != nil {
+		conds = append(conds, "is_disabled = ?")
+		args = append(args, *filter.Disabled)
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := "SELECT " + userColumns + " FROM users" + where + " ORDER BY id LIMIT ? OFFSET ?"
+	rows, err := s.db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return users, total, nil
+}
+
+// CreateUser создает пользователя с указанной ролью и возвращает его id
+func (s *SQLiteStorage) CreateUser(
+	ctx context.Context,
+	user models.User,
+) (uint64, error) {
+	const op = usersOp + "CreateUser"
+
+	query := "INSERT INTO users (username, username_key, pass_hash, role_id, created_at) VALUES (?, ?, ?, ?, ?)"
+	res, err := s.db.ExecContext(
+		ctx,
+		query,
+		user.Username,
+		models.UsernameKey(user.Username),
+		string(user.PassHash),
+		user.Role_id,
+		time.Now().UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrUserExist)
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return uint64(id), nil
+}
+
+// UpdateUser изменяет имя и роль пользователя.
+// Переименование пользователя из username_collisions снимает конфликт имен.
+func (s *SQLiteStorage) UpdateUser(
+	ctx context.Context,
+	user models.User,
+) error {
+	const op = usersOp + "UpdateUser"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users SET
+			username_key = CASE WHEN username = ? THEN username_key ELSE ? END,
+			username = ?,
+			role_id = ?
+		WHERE id = ?
+	`
+	res, err := tx.ExecContext(ctx, query, user.Username, models.UsernameKey(user.Username), user.Username, user.Role_id, user.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%s: %w", op, storage.ErrUserExist)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkUserAffected(op, res); err != nil {
+		return err
+	}
+
+	query = `
+		DELETE FROM username_collisions
+		WHERE user_id = ? AND EXISTS (SELECT 1 FROM users WHERE id = ? AND username_key IS NOT NULL)
+	`
+	if _, err := tx.ExecContext(ctx, query, user.ID, user.ID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// SetUserDisabled включает или отключает учетную запись пользователя
+func (s *SQLiteStorage) SetUserDisabled(
+	ctx context.Context,
+	userID uint64,
+	disabled bool,
+) error {
+	const op = usersOp + "SetUserDisabled"
+
+	res, err := s.db.ExecContext(ctx, "UPDATE users SET is_disabled = ? WHERE id = ?", disabled, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return checkUserAffected(op, res)
+}
+
+// UnlockUser снимает блокировку входа пользователя
+func (s *SQLiteStorage) UnlockUser(
+	ctx context.Context,
+	userID uint64,
+) error {
+	const op = usersOp + "UnlockUser"
+
+	res, err := s.db.ExecContext(ctx, "UPDATE users SET locked_until = NULL WHERE id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return checkUserAffected(op, res)
+}
+
+// UpdateUserPassword сохраняет новый хэш пароля пользователя.
+// Предыдущий хэш переносится в историю паролей, в ней остаются последние models.MaxPasswordHistory.
+func (s *SQLiteStorage) UpdateUserPassword(
+	ctx context.Context,
+	userID uint64,
+	passHash []byte,
+) error {
+	const op = usersOp + "UpdateUserPassword"
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO password_history (user_id, pass_hash, created_at)
+		SELECT id, pass_hash, ? FROM users WHERE id = ?
+	`
+	if _, err := tx.ExecContext(ctx, query, now, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := tx.ExecContext(
+		ctx,
+		"UPDATE users SET pass_hash = ?, password_changed_at = ? WHERE id = ?",
+		string(passHash), now, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkUserAffected(op, res); err != nil {
+		return err
+	}
+
+	query = `
+		DELETE FROM password_history
+		WHERE user_id = ? AND id NOT IN (
+			SELECT id FROM password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?
+		)
+	`
+	if _, err := tx.ExecContext(ctx, query, userID, userID, models.MaxPasswordHistory); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// RehashUserPassword заменяет хэш того же пароля на пересозданный, не трогая историю паролей
+// и дату смены пароля. Если хэш уже изменился, ничего не делает.
+func (s *SQLiteStorage) RehashUserPassword(
+	ctx context.Context,
+	userID uint64,
+	oldHash, newHash []byte,
+) error {
+	const op = usersOp + "RehashUserPassword"
+
+	query := "UPDATE users SET pass_hash = ? WHERE id = ? AND pass_hash = ?"
+	if _, err := s.db.ExecContext(ctx, query, string(newHash), userID, string(oldHash)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ListPasswordHistory возвращает хэши последних limit предыдущих паролей пользователя, начиная с новых
+func (s *SQLiteStorage) ListPasswordHistory(
+	ctx context.Context,
+	userID uint64,
+	limit int,
+) ([][]byte, error) {
+	const op = usersOp + "ListPasswordHistory"
+
+	query := "SELECT pass_hash FROM password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?"
+	rows, err := s.db.QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	hashes := make([][]byte, 0, limit)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		hashes = append(hashes, []byte(hash))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return hashes, nil
+}
+
+// DeleteUser удаляет пользователя вместе с его refresh токенами и привязками к приложениям
+func (s *SQLiteStorage) DeleteUser(
+	ctx context.Context,
+	userID uint64,
+) error {
+	const op = usersOp + "DeleteUser"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	// Внешние ключи в SQLite по умолчанию не проверяются, поэтому удаляем зависимые записи явно
+	for _, query := range []string{
+		"DELETE FROM refresh_tokens WHERE user_id = ?",
+		"DELETE FROM user_apps WHERE user_id = ?",
+		"DELETE FROM mfa_recovery_codes WHERE user_id = ?",
+		"DELETE FROM user_mfa WHERE user_id = ?",
+		"DELETE FROM webauthn_credentials WHERE user_id = ?",
+		"DELETE FROM password_history WHERE user_id = ?",
+		"DELETE FROM username_collisions WHERE user_id = ?",
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM users WHERE id = ?", userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := checkUserAffected(op, res); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// DeleteUserRefreshTokens отзывает все refresh токены пользователя
+func (s *SQLiteStorage) DeleteUserRefreshTokens(
+	ctx context.Context,
+	userID uint64,
+) error {
+	const op = usersOp + "DeleteUserRefreshTokens"
+
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id = ?", userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func checkUserAffected(op string, res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrUserNotFound
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var sqlErr sqlite3.Error
+	return errors.As(err, &sqlErr) && sqlErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,185 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/storage"
+	"github.com/mattn/go-sqlite3"
+)
+
+const webauthnOp = sqliteOp + "webauthn."
+
+const webauthnColumns = `id, user_id, credential_id, public_key, algorithm, sign_count, aaguid,
+	attestation_format, transports, name, created_at, last_used_at`
+
+// SaveWebAuthnCredential сохраняет ключ пользователя и возвращает его id
+func (s *SQLiteStorage) SaveWebAuthnCredential(
+	ctx context.Context,
+	cred models.WebAuthnCredential,
+) (uint64, error) {
+	const op = webauthnOp + "SaveWebAuthnCredential"
+
+	query := `
+		INSERT INTO webauthn_credentials (user_id, credential_id, public_key, algorithm, sign_count,
+			aaguid, attestation_format, transports, name, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	res, err := s.db.ExecContext(
+		ctx,
+		query,
+		cred.UserID,
+		cred.CredentialID,
+		cred.PublicKey,
+		cred.Algorithm,
+		cred.SignCount,
+		cred.AAGUID,
+		cred.AttestationFormat,
+		strings.Join(cred.Transports, ","),
+		cred.Name,
+		cred.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return 0, storage.ErrCredentialExist
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return uint64(id), nil
+}
+
+// ListWebAuthnCredentials возвращает ключи пользователя в порядке регистрации
+func (s *SQLiteStorage) ListWebAuthnCredentials(
+	ctx context.Context,
+	userID uint64,
+) ([]models.WebAuthnCredential, error) {
+	const op = webauthnOp + "ListWebAuthnCredentials"
+
+	query := "SELECT " + webauthnColumns + " FROM webauthn_credentials WHERE user_id = ? ORDER BY id"
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	creds := make([]models.WebAuthnCredential, 0)
+	for rows.Next() {
+		cred, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		creds = append(creds, cred)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return creds, nil
+}
+
+// GetWebAuthnCredential возвращает ключ по идентификатору, выданному аутентификатором
+func (s *SQLiteStorage) GetWebAuthnCredential(
+	ctx context.Context,
+	credentialID []byte,
+) (models.WebAuthnCredential, error) {
+	const op = webauthnOp + "GetWebAuthnCredential"
+
+	query := "SELECT " + webauthnColumns + " FROM webauthn_credentials WHERE credential_id = ?"
+	cred, err := scanWebAuthnCredential(s.db.QueryRowContext(ctx, query, credentialID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return cred, storage.ErrCredentialNotFound
+		}
+		return cred, fmt.Errorf("%s: %w", op, err)
+	}
+	return cred, nil
+}
+
+// UpdateWebAuthnSignCount сохраняет счетчик подписей после успешного входа
+func (s *SQLiteStorage) UpdateWebAuthnSignCount(
+	ctx context.Context,
+	id uint64,
+	signCount uint32,
+) error {
+	const op = webauthnOp + "UpdateWebAuthnSignCount"
+
+	query := "UPDATE webauthn_credentials SET sign_count = ?, last_used_at = ? WHERE id = ?"
+	res, err := s.db.ExecContext(ctx, query, signCount, time.Now().UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return checkCredentialAffected(op, res)
+}
+
+// DeleteWebAuthnCredential удаляет ключ пользователя
+func (s *SQLiteStorage) DeleteWebAuthnCredential(
+	ctx context.Context,
+	userID uint64,
+	id uint64,
+) error {
+	const op = webauthnOp + "DeleteWebAuthnCredential"
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM webauthn_credentials WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return checkCredentialAffected(op, res)
+}
+
+func scanWebAuthnCredential(row rowScanner) (models.WebAuthnCredential, error) {
+	var cred models.WebAuthnCredential
+	var transports, createdAt string
+	var lastUsedAt sql.NullString
+
+	err := row.Scan(
+		&cred.ID,
+		&cred.UserID,
+		&cred.CredentialID,
+		&cred.PublicKey,
+		&cred.Algorithm,
+		&cred.SignCount,
+		&cred.AAGUID,
+		&cred.AttestationFormat,
+		&transports,
+		&cred.Name,
+		&createdAt,
+		&lastUsedAt,
+	)
+	if err != nil {
+		return cred, err
+	}
+
+	if transports != "" {
+		cred.Transports = strings.Split(transports, ",")
+	}
+	if cred.CreatedAt, err = time.Parse(time.RFC3339, createdAt); err != nil {
+		return cred, fmt.Errorf("failed to parse created_at: %w", err)
+	}
+	if lastUsedAt.Valid && lastUsedAt.String != "" {
+		if cred.LastUsedAt, err = time.Parse(time.RFC3339, lastUsedAt.String); err != nil {
+			return cred, fmt.Errorf("failed to parse last_used_at: %w", err)
+		}
+	}
+	return cred, nil
+}
+
+func checkCredentialAffected(op string, res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrCredentialNotFound
+	}
+	return nil
+}
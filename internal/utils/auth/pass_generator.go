@@ -1,17 +0,0 @@
-package auth
-
-import (
-	"fmt"
-
-	"golang.org/x/crypto/bcrypt"
-)
-
-func CreatePassHash(pass string) (string, error) {
-	const op = "utils.auth.createPassHash"
-
-	passHash, err := bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
-	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
-	}
-	return string(passHash), nil
-}
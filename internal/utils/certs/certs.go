@@ -1,15 +1,48 @@
 package certs
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"math/big"
+	"net"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"time"
 )
 
+// Типы ключей самоподписанного сертификата
+const (
+	KeyTypeRSA     = "rsa"
+	KeyTypeECDSA   = "ecdsa"
+	KeyTypeEd25519 = "ed25519"
+)
+
+const (
+	CertFileName = "cert.pem"
+	KeyFileName  = "key.pem"
+
+	rsaKeyBits     = 4096
+	renewThreshold = 7 * 24 * time.Hour
+)
+
+var ErrUnsupportedKeyType = errors.New("unsupported key type")
+
+// Options параметры генерации самоподписанного сертификата
+type Options struct {
+	Hosts    []string // DNS имена и IP адреса для SAN
+	KeyType  string
+	Validity time.Duration
+}
+
 func CheckCertsFolder(path string) error {
 	if _, err := os.Stat(path); err != nil {
 		if os.IsNotExist(err) {
@@ -32,32 +65,133 @@ func CheckCertsFolder(path string) error {
 	return nil
 }
 
-func CreateCertsFiles(certsDir string) error {
-	keyPath := filepath.Join(certsDir, "key.pem")
-	certPath := filepath.Join(certsDir, "cert.pem")
-
-	cmd := exec.Command(
-		"openssl",
-		"req",
-		"-x509",
-		"-newkey", "rsa:4096",
-		"-keyout", keyPath,
-		"-out", certPath,
-		"-days", "365",
-		"-nodes",
-		"-subj", "/CN=localhost",
-	)
-
-	output, err := cmd.CombinedOutput()
+// GenerateCertificate создает самоподписанный сертификат и ключ в формате PEM
+func GenerateCertificate(opts Options) (certPEM, keyPEM []byte, err error) {
+	priv, pub, err := generateKey(opts.KeyType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	hosts := opts.Hosts
+	if len(hosts) == 0 {
+		hosts = []string{"localhost"}
+	}
+	validity := opts.Validity
+	if validity <= 0 {
+		validity = 365 * 24 * time.Hour
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: hosts[0]},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	if _, ok := pub.(*rsa.PublicKey); ok {
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, pub, priv)
 	if err != nil {
-		return fmt.Errorf("failed to create certificate files: %w, output: %s", err, string(output))
+		return nil, nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// generateKey создает ключевую пару указанного типа
+func generateKey(keyType string) (crypto.Signer, crypto.PublicKey, error) {
+	switch keyType {
+	case KeyTypeRSA:
+		key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate rsa key: %w", err)
+		}
+		return key, key.Public(), nil
+	case "", KeyTypeECDSA:
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate ecdsa key: %w", err)
+		}
+		return key, key.Public(), nil
+	case KeyTypeEd25519:
+		pub, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate ed25519 key: %w", err)
+		}
+		return key, pub, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnsupportedKeyType, keyType)
+	}
+}
+
+// CreateCertsFiles генерирует самоподписанный сертификат и записывает его в certsDir.
+// Файлы заменяются атомарно, чтобы GetCertificate не прочитал их частично.
+func CreateCertsFiles(certsDir string, opts Options) error {
+	certPEM, keyPEM, err := GenerateCertificate(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(filepath.Join(certsDir, KeyFileName), keyPEM, 0600); err != nil {
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(certsDir, CertFileName), certPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write certificate file: %w", err)
 	}
 
 	return nil
 }
 
-func CheckCertificate(certsDir string) (bool, error) {
-	certPath := filepath.Join(certsDir, "cert.pem")
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// CheckCertificate проверяет сертификат в certsDir и создает его, если файла нет.
+// Возвращает true, если сертификат истек или скоро истечет и его нужно перевыпустить.
+func CheckCertificate(certsDir string, opts Options) (bool, error) {
+	certPath := filepath.Join(certsDir, CertFileName)
 
 	_, err := os.Stat(certPath)
 	if err != nil {
@@ -65,7 +199,7 @@ func CheckCertificate(certsDir string) (bool, error) {
 			return false, err
 
 		}
-		if err := CreateCertsFiles(certsDir); err != nil {
+		if err := CreateCertsFiles(certsDir, opts); err != nil {
 			return false, err
 		}
 	}
@@ -75,28 +209,40 @@ func CheckCertificate(certsDir string) (bool, error) {
 		return false, fmt.Errorf("failed to read certificate file %q: %w", certPath, err)
 	}
 
-	block, _ := pem.Decode(certData)
-	if block == nil || block.Type != "CERTIFICATE" {
-		return false, fmt.Errorf("failed to decode PEM block from %q", certPath)
+	cert, err := ParseCertificatePEM(certData)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse certificate %q: %w", certPath, err)
 	}
 
-	cert, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		return false, fmt.Errorf("failed to parse certificate: %w", err)
+	return needsRenewal(cert, time.Now()), nil
+}
+
+// EnsureCertificate гарантирует наличие действующего самоподписанного сертификата
+// в certsDir и возвращает пути к файлам сертификата и ключа
+func EnsureCertificate(certsDir string, opts Options) (certFile, keyFile string, err error) {
+	if err := CheckCertsFolder(certsDir); err != nil {
+		return "", "", fmt.Errorf("failed to checking certificate folder: %w", err)
 	}
 
-	now := time.Now()
-	if now.After(cert.NotAfter) {
-		if err := os.RemoveAll(certsDir); err != nil {
-			return true, fmt.Errorf("failed to remove certs folder %q: %w", certsDir, err)
+	expired, err := CheckCertificate(certsDir, opts)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to checking certificate: %w", err)
+	}
+	if expired {
+		if err := CreateCertsFiles(certsDir, opts); err != nil {
+			return "", "", fmt.Errorf("failed to creating certificate: %w", err)
 		}
-		return true, nil
 	}
 
-	nearExpiration := now.Add(7 * 24 * time.Hour)
-	if nearExpiration.After(cert.NotAfter) {
-		return true, nil
-	}
+	return filepath.Join(certsDir, CertFileName), filepath.Join(certsDir, KeyFileName), nil
+}
 
-	return false, nil
+// needsRenewal сообщает, истек ли сертификат или скоро истечет.
+// Для короткоживущих сертификатов порог — пятая часть срока действия.
+func needsRenewal(cert *x509.Certificate, now time.Time) bool {
+	threshold := renewThreshold
+	if lifetime := cert.NotAfter.Sub(cert.NotBefore) / 5; lifetime < threshold {
+		threshold = lifetime
+	}
+	return now.Add(threshold).After(cert.NotAfter)
 }
@@ -0,0 +1,77 @@
+package certs
+
+import (
+	"crypto/tls"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGenerateCertificate(t *testing.T) {
+	for _, keyType := range []string{KeyTypeRSA, KeyTypeECDSA, KeyTypeEd25519} {
+		t.Run(keyType, func(t *testing.T) {
+			certPEM, keyPEM, err := GenerateCertificate(Options{
+				Hosts:    []string{"sso.local", "127.0.0.1"},
+				KeyType:  keyType,
+				Validity: time.Hour,
+			})
+			if err != nil {
+				t.Fatalf("failed to generate certificate: %v", err)
+			}
+			if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+				t.Fatalf("invalid key pair: %v", err)
+			}
+
+			cert, err := ParseCertificatePEM(certPEM)
+			if err != nil {
+				t.Fatalf("failed to parse certificate: %v", err)
+			}
+			if err := cert.VerifyHostname("sso.local"); err != nil {
+				t.Errorf("dns san missing: %v", err)
+			}
+			if len(cert.IPAddresses) != 1 || !cert.IPAddresses[0].Equal(net.ParseIP("127.0.0.1")) {
+				t.Errorf("unexpected ip sans: %v", cert.IPAddresses)
+			}
+		})
+	}
+
+	if _, _, err := GenerateCertificate(Options{KeyType: "dsa"}); err == nil {
+		t.Error("expected error for unsupported key type")
+	}
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{Hosts: []string{"localhost"}, Validity: time.Hour}
+
+	r, err := NewSelfSignedReloader(dir, opts, 0)
+	if err != nil {
+		t.Fatalf("failed to create reloader: %v", err)
+	}
+
+	first, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("failed to get certificate: %v", err)
+	}
+
+	// Имитация ротации файлов внешним процессом
+	if err := CreateCertsFiles(dir, opts); err != nil {
+		t.Fatalf("failed to rotate certificate: %v", err)
+	}
+	future := time.Now().Add(time.Minute)
+	for _, name := range []string{CertFileName, KeyFileName} {
+		if err := os.Chtimes(filepath.Join(dir, name), future, future); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	second, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("failed to get certificate: %v", err)
+	}
+	if second.Leaf.SerialNumber.Cmp(first.Leaf.SerialNumber) == 0 {
+		t.Error("certificate was not reloaded after rotation")
+	}
+}
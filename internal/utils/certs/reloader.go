@@ -0,0 +1,149 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Reloader отдает TLS сертификат сервера и перечитывает файлы при их изменении,
+// что позволяет ротировать сертификаты без перезапуска сервера
+type Reloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	// Если задан certsDir, самоподписанный сертификат перевыпускается перед истечением
+	certsDir string
+	opts     Options
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	leaf      *x509.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// NewReloader загружает сертификат из файлов. Файлы проверяются
+// на изменения не чаще одного раза в interval.
+func NewReloader(certFile, keyFile string, interval time.Duration) (*Reloader, error) {
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewSelfSignedReloader создает (при необходимости) самоподписанный сертификат
+// в certsDir и перевыпускает его, когда срок действия подходит к концу
+func NewSelfSignedReloader(certsDir string, opts Options, interval time.Duration) (*Reloader, error) {
+	certFile, keyFile, err := EnsureCertificate(certsDir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := NewReloader(certFile, keyFile, interval)
+	if err != nil {
+		return nil, err
+	}
+	r.certsDir = certsDir
+	r.opts = opts
+	return r, nil
+}
+
+// GetCertificate реализует tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	cert, checkedAt := r.cert, r.checkedAt
+	r.mu.RUnlock()
+
+	if time.Since(checkedAt) < r.interval {
+		return cert, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) >= r.interval {
+		r.checkedAt = time.Now()
+		// При ошибке продолжаем отдавать ранее загруженный сертификат:
+		// файлы могут быть в процессе замены
+		_ = r.refresh()
+	}
+	return r.cert, nil
+}
+
+// refresh перевыпускает самоподписанный сертификат при необходимости
+// и перечитывает файлы, если они изменились. Вызывается под r.mu.
+func (r *Reloader) refresh() error {
+	if r.certsDir != "" && needsRenewal(r.leaf, time.Now()) {
+		if err := CreateCertsFiles(r.certsDir, r.opts); err != nil {
+			return err
+		}
+	}
+
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return err
+	}
+	if modTime.Equal(r.modTime) {
+		return nil
+	}
+
+	return r.load(modTime)
+}
+
+func (r *Reloader) reload() error {
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checkedAt = time.Now()
+	return r.load(modTime)
+}
+
+func (r *Reloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load key pair: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	cert.Leaf = leaf
+
+	r.cert = &cert
+	r.leaf = leaf
+	r.modTime = modTime
+	return nil
+}
+
+// filesModTime возвращает время последнего изменения файлов сертификата и ключа
+func (r *Reloader) filesModTime() (time.Time, error) {
+	var latest time.Time
+
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(filepath.Clean(path))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to stat %q: %w", path, err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
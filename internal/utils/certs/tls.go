@@ -0,0 +1,84 @@
+package certs
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+var (
+	ErrInvalidCertificate = errors.New("invalid certificate")
+)
+
+// Thumbprint возвращает SHA-256 отпечаток сертификата в формате x5t#S256 (RFC 8705)
+func Thumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ParseCertificatePEM разбирает сертификат в формате PEM
+func ParseCertificatePEM(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%w: failed to decode PEM block", ErrInvalidCertificate)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+	}
+	return cert, nil
+}
+
+// NewServerReloader возвращает Reloader для пользовательского сертификата,
+// а если certFile и keyFile не заданы — для самоподписанного сертификата в certsDir
+func NewServerReloader(
+	certFile, keyFile, certsDir string,
+	opts Options,
+	interval time.Duration,
+) (*Reloader, error) {
+	if certFile != "" && keyFile != "" {
+		return NewReloader(certFile, keyFile, interval)
+	}
+	return NewSelfSignedReloader(certsDir, opts, interval)
+}
+
+// NewServerTLSConfig создает TLS конфигурацию сервера с перезагружаемым сертификатом.
+// Если задан clientCAFile, клиентские сертификаты проверяются этим пулом CA.
+func NewServerTLSConfig(
+	reloader *Reloader,
+	clientCAFile string,
+	clientAuth tls.ClientAuthType,
+) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+		ClientAuth:     clientAuth,
+		MinVersion:     tls.VersionTLS12,
+	}
+
+	if clientCAFile == "" {
+		if clientAuth >= tls.VerifyClientCertIfGiven {
+			return nil, errors.New("client CA file is required to verify client certificates")
+		}
+		return tlsConfig, nil
+	}
+
+	caData, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file %q: %w", clientCAFile, err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caData) {
+		return nil, fmt.Errorf("no certificates found in client CA file %q", clientCAFile)
+	}
+	tlsConfig.ClientCAs = pool
+
+	return tlsConfig, nil
+}
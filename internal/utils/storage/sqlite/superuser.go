@@ -4,13 +4,15 @@ import (
 	"database/sql"
 	"fmt"
 
-	"golang.org/x/crypto/bcrypt"
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/Grino777/sso/internal/lib/passhash"
 )
 
 // Cоздает пользователя с ролью superadmin, если такой еще не существует.
 func CreateSuperUser(
 	db *sql.DB,
 	username, password string,
+	hasher passhash.PasswordHasher,
 ) error {
 	const op = "storage.sqlite.CreateSuperUser"
 
@@ -25,13 +27,13 @@ func CreateSuperUser(
 		return nil
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := hasher.Hash(password)
 	if err != nil {
 		return fmt.Errorf("%s: error creating password for superuser: %w", op, err)
 	}
 
-	query = "INSERT INTO users (username, pass_hash, role_id) VALUES (?, ?, 3)"
-	_, err = db.Exec(query, username, string(hashedPassword))
+	query = "INSERT INTO users (username, username_key, pass_hash, role_id) VALUES (?, ?, ?, 3)"
+	_, err = db.Exec(query, username, models.UsernameKey(username), hashedPassword)
 	if err != nil {
 		return fmt.Errorf("%s: error inserting superuser to DB: %w", op, err)
 	}
@@ -0,0 +1,79 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Grino777/sso/internal/domain/models"
+	"github.com/pressly/goose/v3"
+)
+
+// Заполняет users.username_key после миграции 20250629101245_add_username_key.
+// Ключ — имя пользователя в форме models.UsernameKey. Если несколько пользователей получают
+// одинаковый ключ, он остается за самым ранним из них, остальные записываются в username_collisions
+// с пустым ключом и не могут войти, пока администратор их не переименует.
+//
+// Миграция необратима: откат возвращает ErrIrreversible, и goose down не проходит
+// через эту версию. Вернуть базу к версии до username_key можно только из резервной копии.
+func init() {
+	goose.AddMigrationContext(upNormalizeUsernames, downNormalizeUsernames)
+}
+
+func upNormalizeUsernames(ctx context.Context, tx *sql.Tx) error {
+	const op = "migrations.normalizeUsernames"
+
+	rows, err := tx.QueryContext(ctx, "SELECT id, username FROM users ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	type user struct {
+		id       int64
+		username string
+	}
+	var users []user
+	for rows.Next() {
+		var u user
+		if err := rows.Scan(&u.id, &u.username); err != nil {
+			rows.Close()
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		users = append(users, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var detectedAt any = time.Now().UTC()
+	if dialect != "postgres" {
+		detectedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	owners := make(map[string]int64, len(users))
+	for _, u := range users {
+		key := models.UsernameKey(u.username)
+		if owner, ok := owners[key]; ok {
+			query := bind(dialect, `
+				INSERT INTO username_collisions (user_id, username, username_key, conflicts_with, detected_at)
+				VALUES (?, ?, ?, ?, ?)
+			`)
+			if _, err := tx.ExecContext(ctx, query, u.id, u.username, key, owner, detectedAt); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			continue
+		}
+		owners[key] = u.id
+
+		query := bind(dialect, "UPDATE users SET username_key = ? WHERE id = ?")
+		if _, err := tx.ExecContext(ctx, query, key, u.id); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return nil
+}
+
+func downNormalizeUsernames(context.Context, *sql.Tx) error {
+	return fmt.Errorf("migrations.downNormalizeUsernames: %w", ErrIrreversible)
+}
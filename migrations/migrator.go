@@ -3,7 +3,9 @@ package migrations
 import (
 	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/pressly/goose/v3"
 )
@@ -11,13 +13,21 @@ import (
 //go:embed */*.sql
 var embedMigrations embed.FS
 
-// Performs migrations
+// ErrIrreversible возвращает откат миграции, которую нельзя отменить
+var ErrIrreversible = errors.New("migration is irreversible")
+
+// dialect диалект текущего запуска миграций для миграций на Go (sqlite3, postgres)
+var dialect string
+
+// Performs migrations.
+// driverName задает и диалект goose, и каталог миграций (sqlite3, postgres).
 func Migrate(db *sql.DB, driverName string) error {
 	const op = "migrations.Migrate"
 
 	goose.SetBaseFS(embedMigrations)
+	dialect = driverName
 
-	if err := goose.SetDialect("sqlite3"); err != nil {
+	if err := goose.SetDialect(driverName); err != nil {
 		return fmt.Errorf("%s: failed to set dialect: %w", op, err)
 	}
 
@@ -26,3 +36,21 @@ func Migrate(db *sql.DB, driverName string) error {
 	}
 	return nil
 }
+
+// bind заменяет плейсхолдеры ? на $1, $2... для postgres
+func bind(driverName, query string) string {
+	if driverName != "postgres" {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
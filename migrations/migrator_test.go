@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pressly/goose/v3"
+)
+
+const (
+	addUsernameKeyVersion     = 20250629101245
+	normalizeUsernamesVersion = 20250629101530
+)
+
+func TestNormalizeUsernames(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "sso.sqlite3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	goose.SetBaseFS(embedMigrations)
+	dialect = "sqlite3"
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := goose.UpTo(db, "sqlite3", addUsernameKeyVersion); err != nil {
+		t.Fatal(err)
+	}
+	for _, username := range []string{"Alice", "alice", "bob"} {
+		if _, err := db.Exec("INSERT INTO users (username, pass_hash) VALUES (?, 'hash')", username); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Migrate(db, "sqlite3"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ключ остается за первым пользователем, второй записывается в username_collisions
+	var key sql.NullString
+	if err := db.QueryRow("SELECT username_key FROM users WHERE username = 'Alice'").Scan(&key); err != nil {
+		t.Fatal(err)
+	}
+	if key.String != "alice" {
+		t.Fatalf("username_key of Alice = %q, want alice", key.String)
+	}
+	if err := db.QueryRow("SELECT username_key FROM users WHERE username = 'alice'").Scan(&key); err != nil {
+		t.Fatal(err)
+	}
+	if key.Valid {
+		t.Fatalf("colliding user got username_key %q", key.String)
+	}
+	var collisions int
+	if err := db.QueryRow("SELECT COUNT(*) FROM username_collisions WHERE username = 'alice'").Scan(&collisions); err != nil {
+		t.Fatal(err)
+	}
+	if collisions != 1 {
+		t.Fatalf("collisions = %d, want 1", collisions)
+	}
+
+	// Откат через миграцию на Go не проходит молча
+	if err := goose.DownTo(db, "sqlite3", addUsernameKeyVersion); !errors.Is(err, ErrIrreversible) {
+		t.Fatalf("down: err = %v, want ErrIrreversible", err)
+	}
+	version, err := goose.GetDBVersion(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version < normalizeUsernamesVersion {
+		t.Fatalf("version after failed down = %d", version)
+	}
+}
@@ -0,0 +1,81 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE roles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) UNIQUE NOT NULL
+);
+
+INSERT INTO roles (name) VALUES ('user'), ('admin'), ('superadmin');
+
+CREATE TABLE users (
+    id BIGSERIAL PRIMARY KEY,
+    username VARCHAR(50) UNIQUE NOT NULL,
+    pass_hash VARCHAR(100) NOT NULL,
+    role_id INTEGER NOT NULL DEFAULT 1 REFERENCES roles (id)
+);
+
+CREATE TABLE apps (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL
+);
+
+CREATE TABLE user_apps (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    app_id INTEGER NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    is_blocked BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE refresh_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    app_id INTEGER NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    r_token TEXT NOT NULL,
+    expire_at BIGINT NOT NULL,
+    jkt VARCHAR(64),
+    x5t_s256 VARCHAR(64),
+    CONSTRAINT unique_user_app UNIQUE (user_id, app_id),
+    CONSTRAINT unique_r_token UNIQUE (r_token)
+);
+
+CREATE TABLE users_logs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    app_id INTEGER NOT NULL,
+    user_ip VARCHAR(50) DEFAULT 'unknown',
+    loggined_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE app_encryption_keys (
+    id VARCHAR(36) PRIMARY KEY,
+    app_id INTEGER NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    algorithm VARCHAR(20) NOT NULL,
+    public_key TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_app_encryption_keys_app_id ON app_encryption_keys (app_id, is_active);
+
+CREATE TABLE app_certificates (
+    thumbprint VARCHAR(64) PRIMARY KEY,
+    app_id INTEGER NOT NULL REFERENCES apps (id) ON DELETE CASCADE,
+    subject TEXT NOT NULL,
+    not_after TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_app_certificates_app_id ON app_certificates (app_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE app_certificates;
+DROP TABLE app_encryption_keys;
+DROP TABLE users_logs;
+DROP TABLE refresh_tokens;
+DROP TABLE user_apps;
+DROP TABLE apps;
+DROP TABLE users;
+DROP TABLE roles;
+-- +goose StatementEnd
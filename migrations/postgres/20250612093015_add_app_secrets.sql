@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE apps ADD COLUMN secret VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE apps ADD COLUMN previous_secret VARCHAR(100);
+ALTER TABLE apps ADD COLUMN previous_secret_expires_at TIMESTAMPTZ;
+ALTER TABLE apps ADD COLUMN created_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE apps DROP COLUMN created_at;
+ALTER TABLE apps DROP COLUMN previous_secret_expires_at;
+ALTER TABLE apps DROP COLUMN previous_secret;
+ALTER TABLE apps DROP COLUMN secret;
+-- +goose StatementEnd
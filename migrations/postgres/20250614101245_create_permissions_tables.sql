@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE roles ADD COLUMN description VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE TABLE permissions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+    role_id INTEGER NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    permission_id INTEGER NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+-- Роль пользователя в конкретном приложении (дополняет глобальную users.role_id)
+ALTER TABLE user_apps ADD COLUMN role_id INTEGER REFERENCES roles (id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX idx_user_apps_user_app ON user_apps (user_id, app_id);
+
+INSERT INTO permissions (name, description) VALUES ('admin', 'Administrative access to the application');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name IN ('admin', 'superadmin') AND p.name = 'admin';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_user_apps_user_app;
+ALTER TABLE user_apps DROP COLUMN role_id;
+DROP TABLE role_permissions;
+DROP TABLE permissions;
+ALTER TABLE roles DROP COLUMN description;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users_logs ADD COLUMN username VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE users_logs ADD COLUMN user_agent VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE users_logs ADD COLUMN success BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE users_logs ADD COLUMN reason VARCHAR(50) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_users_logs_user_id ON users_logs (user_id, loggined_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_users_logs_user_id;
+ALTER TABLE users_logs DROP COLUMN reason;
+ALTER TABLE users_logs DROP COLUMN success;
+ALTER TABLE users_logs DROP COLUMN user_agent;
+ALTER TABLE users_logs DROP COLUMN username;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_profiles (
+    user_id BIGINT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    email VARCHAR(254),
+    email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    display_name VARCHAR(128) NOT NULL DEFAULT '',
+    locale VARCHAR(35) NOT NULL DEFAULT '',
+    attributes JSONB NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- Подтвержденный email принадлежит только одному пользователю
+CREATE UNIQUE INDEX idx_user_profiles_verified_email ON user_profiles (email)
+WHERE email_verified;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_user_profiles_verified_email;
+DROP TABLE user_profiles;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN username_key VARCHAR(200);
+
+CREATE UNIQUE INDEX idx_users_username_key ON users (username_key);
+
+CREATE TABLE username_collisions (
+    user_id BIGINT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    username VARCHAR(50) NOT NULL,
+    username_key VARCHAR(200) NOT NULL,
+    conflicts_with BIGINT NOT NULL,
+    detected_at TIMESTAMPTZ NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE username_collisions;
+DROP INDEX idx_users_username_key;
+ALTER TABLE users DROP COLUMN username_key;
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens
+    ADD COLUMN session_id VARCHAR(32),
+    ADD COLUMN ip VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN device_name VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN last_used_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+UPDATE refresh_tokens SET session_id = md5(random()::text || id::text);
+
+ALTER TABLE refresh_tokens
+    ALTER COLUMN session_id SET NOT NULL,
+    ADD CONSTRAINT unique_session_id UNIQUE (session_id),
+    DROP CONSTRAINT unique_user_app;
+
+CREATE INDEX idx_refresh_tokens_user_app ON refresh_tokens (user_id, app_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_refresh_tokens_user_app;
+
+-- Из нескольких сеансов пользователя в приложении сохраняется последний использованный
+DELETE FROM refresh_tokens t
+USING refresh_tokens newer
+WHERE newer.user_id = t.user_id
+    AND newer.app_id = t.app_id
+    AND (newer.last_used_at, newer.id) > (t.last_used_at, t.id);
+
+ALTER TABLE refresh_tokens
+    ADD CONSTRAINT unique_user_app UNIQUE (user_id, app_id),
+    DROP CONSTRAINT unique_session_id,
+    DROP COLUMN session_id,
+    DROP COLUMN ip,
+    DROP COLUMN user_agent,
+    DROP COLUMN device_name,
+    DROP COLUMN created_at,
+    DROP COLUMN last_used_at;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE apps
+    ADD COLUMN access_token_ttl BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN refresh_token_ttl BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN idle_timeout BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN max_session_age BIGINT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE apps
+    DROP COLUMN max_session_age,
+    DROP COLUMN idle_timeout,
+    DROP COLUMN refresh_token_ttl,
+    DROP COLUMN access_token_ttl;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens ADD COLUMN offline BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE apps
+    ADD COLUMN offline_access BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN offline_token_ttl BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_refresh_tokens_offline ON refresh_tokens (user_id) WHERE offline;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_refresh_tokens_offline;
+ALTER TABLE apps
+    DROP COLUMN offline_token_ttl,
+    DROP COLUMN offline_access;
+ALTER TABLE refresh_tokens DROP COLUMN offline;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE apps ALTER COLUMN secret TYPE VARCHAR(255);
+ALTER TABLE apps ALTER COLUMN previous_secret TYPE VARCHAR(255);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE apps ALTER COLUMN previous_secret TYPE VARCHAR(100);
+ALTER TABLE apps ALTER COLUMN secret TYPE VARCHAR(100);
+-- +goose StatementEnd
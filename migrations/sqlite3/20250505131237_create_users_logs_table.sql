@@ -11,5 +11,5 @@ CREATE TABLE users_logs (
 
 -- +goose Down
 -- +goose StatementBegin
-DROP TABLE users_logins;
+DROP TABLE users_logs;
 -- +goose StatementEnd
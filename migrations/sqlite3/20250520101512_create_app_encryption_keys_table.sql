@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE app_encryption_keys (
+    id VARCHAR(36) PRIMARY KEY,
+    app_id INTEGER NOT NULL,
+    algorithm VARCHAR(20) NOT NULL,
+    public_key TEXT NOT NULL,
+    is_active INTEGER NOT NULL DEFAULT 1 CHECK (is_active in (0, 1)),
+    created_at VARCHAR(50) NOT NULL,
+    FOREIGN KEY (app_id) REFERENCES apps (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_app_encryption_keys_app_id ON app_encryption_keys (app_id, is_active);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE app_encryption_keys;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE app_certificates (
+    thumbprint VARCHAR(64) PRIMARY KEY,
+    app_id INTEGER NOT NULL,
+    subject TEXT NOT NULL,
+    not_after VARCHAR(50) NOT NULL,
+    created_at VARCHAR(50) NOT NULL,
+    FOREIGN KEY (app_id) REFERENCES apps (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_app_certificates_app_id ON app_certificates (app_id);
+
+ALTER TABLE refresh_tokens ADD COLUMN x5t_s256 VARCHAR(64);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens DROP COLUMN x5t_s256;
+
+DROP TABLE app_certificates;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE apps ADD COLUMN membership_policy VARCHAR(20) NOT NULL DEFAULT 'open';
+ALTER TABLE user_apps ADD COLUMN created_at VARCHAR(50);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_apps DROP COLUMN created_at;
+ALTER TABLE apps DROP COLUMN membership_policy;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE
+    user_mfa (
+        user_id INTEGER PRIMARY KEY,
+        totp_secret VARCHAR(64) NOT NULL,
+        enabled INTEGER NOT NULL DEFAULT 0,
+        last_used_step INTEGER NOT NULL DEFAULT 0,
+        created_at VARCHAR(50) NOT NULL,
+        enabled_at VARCHAR(50),
+        FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+    );
+
+CREATE TABLE
+    mfa_recovery_codes (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id INTEGER NOT NULL,
+        code_hash VARCHAR(64) NOT NULL,
+        used_at VARCHAR(50),
+        UNIQUE (user_id, code_hash),
+        FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+    );
+
+ALTER TABLE apps ADD COLUMN require_mfa INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE apps DROP COLUMN require_mfa;
+DROP TABLE mfa_recovery_codes;
+DROP TABLE user_mfa;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE
+    webauthn_credentials (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id INTEGER NOT NULL,
+        credential_id BLOB NOT NULL UNIQUE,
+        public_key BLOB NOT NULL,
+        algorithm INTEGER NOT NULL,
+        sign_count INTEGER NOT NULL DEFAULT 0,
+        aaguid BLOB,
+        attestation_format VARCHAR(32) NOT NULL,
+        transports VARCHAR(255) NOT NULL DEFAULT '',
+        name VARCHAR(64) NOT NULL DEFAULT '',
+        created_at VARCHAR(50) NOT NULL,
+        last_used_at VARCHAR(50),
+        FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+    );
+
+CREATE INDEX idx_webauthn_credentials_user_id ON webauthn_credentials (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_webauthn_credentials_user_id;
+DROP TABLE webauthn_credentials;
+-- +goose StatementEnd
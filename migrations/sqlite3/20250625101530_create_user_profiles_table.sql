@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE
+    user_profiles (
+        user_id INTEGER PRIMARY KEY,
+        email VARCHAR(254),
+        email_verified INTEGER NOT NULL DEFAULT 0,
+        display_name VARCHAR(128) NOT NULL DEFAULT '',
+        locale VARCHAR(35) NOT NULL DEFAULT '',
+        attributes TEXT NOT NULL DEFAULT '{}',
+        updated_at VARCHAR(50) NOT NULL,
+        FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+    );
+
+-- Подтвержденный email принадлежит только одному пользователю
+CREATE UNIQUE INDEX idx_user_profiles_verified_email ON user_profiles (email)
+WHERE
+    email_verified = 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_user_profiles_verified_email;
+DROP TABLE user_profiles;
+-- +goose StatementEnd
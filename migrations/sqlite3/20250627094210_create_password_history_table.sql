@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN password_changed_at VARCHAR(50);
+
+CREATE TABLE
+    password_history (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user_id INTEGER NOT NULL,
+        pass_hash VARCHAR(100) NOT NULL,
+        created_at VARCHAR(50) NOT NULL,
+        FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+    );
+
+CREATE INDEX idx_password_history_user_id ON password_history (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_password_history_user_id;
+DROP TABLE password_history;
+ALTER TABLE users DROP COLUMN password_changed_at;
+-- +goose StatementEnd
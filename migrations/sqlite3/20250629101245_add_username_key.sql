@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN username_key VARCHAR(200);
+
+CREATE UNIQUE INDEX idx_users_username_key ON users (username_key);
+
+CREATE TABLE
+    username_collisions (
+        user_id INTEGER PRIMARY KEY,
+        username VARCHAR(50) NOT NULL,
+        username_key VARCHAR(200) NOT NULL,
+        conflicts_with INTEGER NOT NULL,
+        detected_at VARCHAR(50) NOT NULL,
+        FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+    );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE username_collisions;
+DROP INDEX idx_users_username_key;
+ALTER TABLE users DROP COLUMN username_key;
+-- +goose StatementEnd
@@ -0,0 +1,63 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE refresh_tokens_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id VARCHAR(32) NOT NULL,
+    user_id INTEGER NOT NULL,
+    app_id INTEGER NOT NULL,
+    r_token TEXT NOT NULL,
+    expire_at VARCHAR(50) NOT NULL,
+    jkt VARCHAR(64),
+    x5t_s256 VARCHAR(64),
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    device_name VARCHAR(64) NOT NULL DEFAULT '',
+    created_at VARCHAR(50) NOT NULL,
+    last_used_at VARCHAR(50) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (app_id) REFERENCES apps (id) ON DELETE CASCADE,
+    CONSTRAINT unique_session_id UNIQUE (session_id),
+    CONSTRAINT unique_r_token UNIQUE (r_token)
+);
+
+INSERT INTO refresh_tokens_new (session_id, user_id, app_id, r_token, expire_at, jkt, x5t_s256, created_at, last_used_at)
+SELECT lower(hex(randomblob(16))), user_id, app_id, r_token, expire_at, jkt, x5t_s256,
+    strftime('%Y-%m-%dT%H:%M:%SZ', 'now'), strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+FROM refresh_tokens;
+
+DROP TABLE refresh_tokens;
+ALTER TABLE refresh_tokens_new RENAME TO refresh_tokens;
+
+CREATE INDEX idx_refresh_tokens_user_app ON refresh_tokens (user_id, app_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TABLE refresh_tokens_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    app_id INTEGER NOT NULL,
+    r_token TEXT NOT NULL,
+    expire_at VARCHAR(50) NOT NULL,
+    jkt VARCHAR(64),
+    x5t_s256 VARCHAR(64),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (app_id) REFERENCES apps (id) ON DELETE CASCADE,
+    CONSTRAINT unique_user_app UNIQUE (user_id, app_id),
+    CONSTRAINT unique_r_token UNIQUE (r_token)
+);
+
+-- Из нескольких сеансов пользователя в приложении сохраняется последний использованный
+INSERT INTO refresh_tokens_old (user_id, app_id, r_token, expire_at, jkt, x5t_s256)
+SELECT user_id, app_id, r_token, expire_at, jkt, x5t_s256
+FROM refresh_tokens t
+WHERE t.id = (
+    SELECT id FROM refresh_tokens
+    WHERE user_id = t.user_id AND app_id = t.app_id
+    ORDER BY last_used_at DESC, id DESC
+    LIMIT 1
+);
+
+DROP TABLE refresh_tokens;
+ALTER TABLE refresh_tokens_old RENAME TO refresh_tokens;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE refresh_tokens ADD COLUMN offline INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE apps ADD COLUMN offline_access INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE apps ADD COLUMN offline_token_ttl INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_refresh_tokens_offline ON refresh_tokens (user_id) WHERE offline = 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_refresh_tokens_offline;
+ALTER TABLE apps DROP COLUMN offline_token_ttl;
+ALTER TABLE apps DROP COLUMN offline_access;
+ALTER TABLE refresh_tokens DROP COLUMN offline;
+-- +goose StatementEnd